@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // generateEnvError creates a standard error message for missing environment variables.
@@ -16,49 +19,260 @@ func generateConfigError(name string) string {
 	return fmt.Sprintf("required config value not found: %s", name)
 }
 
+// ErrMissing is matched by errors for environment variables that are not set.
+var ErrMissing = errors.New("not set")
+
+// ErrInvalid is matched by errors for environment variables whose value
+// cannot be parsed as the requested type.
+var ErrInvalid = errors.New("invalid value")
+
+// EnvError describes an environment variable that could not be read. Use
+// errors.Is with ErrMissing or ErrInvalid to tell the cases apart; a
+// <Name>_FILE that cannot be read wraps the underlying file error instead.
+type EnvError struct {
+	Name  string
+	Value string // the offending value, empty when the variable is missing
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrMissing):
+		return generateEnvError(e.Name)
+	case e.Value != "":
+		return fmt.Sprintf("environment variable %s=%q: %v", e.Name, e.Value, e.Err)
+	default:
+		return fmt.Sprintf("environment variable %s: %v", e.Name, e.Err)
+	}
+}
+
+func (e *EnvError) Unwrap() error { return e.Err }
+
+// invalidEnv returns an EnvError for a value that failed to parse.
+func invalidEnv(name, value string, cause error) error {
+	if cause == nil {
+		return &EnvError{Name: name, Value: value, Err: ErrInvalid}
+	}
+	return &EnvError{Name: name, Value: value, Err: fmt.Errorf("%w: %v", ErrInvalid, cause)}
+}
+
+// must panics with err's message when err is not nil.
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err.Error())
+	}
+	return v
+}
+
+// fileSuffix marks a variable holding the path of a file with the value,
+// as used for Docker and Kubernetes secrets: DB_PASSWORD_FILE for DB_PASSWORD.
+const fileSuffix = "_FILE"
+
+// lookupEnv returns the value of the environment variable name or, when it
+// is unset, the contents of the file named by name+"_FILE" with a single
+// trailing newline removed. err is set when that file cannot be read.
+func lookupEnv(name string) (value string, ok bool, err error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	path, ok := os.LookupEnv(name + fileSuffix)
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, &EnvError{Name: name, Err: fmt.Errorf("cannot read %s%s file %s: %w", name, fileSuffix, path, err)}
+	}
+	value = string(data)
+	if v, found := strings.CutSuffix(value, "\r\n"); found {
+		return v, true, nil
+	}
+	return strings.TrimSuffix(value, "\n"), true, nil
+}
+
+// GetEnvE retrieves an environment variable by name. When the variable is
+// unset but <name>_FILE is set, the value is read from that file. The error
+// is an *EnvError matching ErrMissing when neither is set.
+func GetEnvE(name string) (string, error) {
+	value, ok, err := lookupEnv(name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", &EnvError{Name: name, Err: ErrMissing}
+	}
+	return value, nil
+}
+
 // GetEnv retrieves an environment variable by name and returns its string value.
-// It panics if the environment variable is not set.
+// When the variable is unset but <name>_FILE is set, the value is read from
+// that file. It panics if neither is set or the file cannot be read.
 func GetEnv(name string) string {
-	value, ok := os.LookupEnv(name)
-	if !ok {
-		panic(generateEnvError(name))
+	return must(GetEnvE(name))
+}
+
+// GetEnvIntE is GetEnvE for integers. A value that does not parse yields an
+// *EnvError matching ErrInvalid.
+func GetEnvIntE(name string) (int, error) {
+	value, err := GetEnvE(name)
+	if err != nil {
+		return 0, err
 	}
-	return value
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, invalidEnv(name, value, nil)
+	}
+	return result, nil
 }
 
 // GetEnvInt retrieves an environment variable by name and returns its integer value.
 // It panics if the environment variable is not set or cannot be parsed as an integer.
 func GetEnvInt(name string) int {
-	value := GetEnv(name)
-	result, err := strconv.Atoi(value)
+	return must(GetEnvIntE(name))
+}
+
+// GetEnvUintE is GetEnvE for unsigned integers.
+func GetEnvUintE(name string) (uint, error) {
+	value, err := GetEnvE(name)
 	if err != nil {
-		panic(generateEnvError(name))
+		return 0, err
 	}
-	return result
+	result, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, invalidEnv(name, value, nil)
+	}
+	return uint(result), nil
 }
 
 // GetEnvUint retrieves an environment variable by name and returns its unsigned integer value.
 // It panics if the environment variable is not set or cannot be parsed as an unsigned integer.
 func GetEnvUint(name string) uint {
-	value := GetEnv(name)
-	result, err := strconv.ParseUint(value, 10, 32)
+	return must(GetEnvUintE(name))
+}
+
+// GetEnvBoolE is GetEnvE for booleans, accepting the strconv.ParseBool forms.
+func GetEnvBoolE(name string) (bool, error) {
+	value, err := GetEnvE(name)
 	if err != nil {
-		panic(generateEnvError(name))
+		return false, err
 	}
-	return uint(result)
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidEnv(name, value, nil)
+	}
+	return result, nil
 }
 
 // GetEnvBool retrieves an environment variable by name and returns its boolean value.
 // It panics if the environment variable is not set or cannot be parsed as a boolean.
 func GetEnvBool(name string) bool {
-	value := GetEnv(name)
-	result, err := strconv.ParseBool(value)
+	return must(GetEnvBoolE(name))
+}
+
+// GetEnvSliceE is GetEnvE for comma-separated lists; see GetEnvSlice.
+func GetEnvSliceE(name string) ([]string, error) {
+	value, err := GetEnvE(name)
 	if err != nil {
-		panic(generateEnvError(name))
+		return nil, err
+	}
+	return splitList(value, ","), nil
+}
+
+// GetEnvSlice retrieves a comma-separated environment variable as a list.
+// Elements are trimmed and empty ones dropped, so "a, b,," yields [a b].
+// It panics if the environment variable is not set.
+func GetEnvSlice(name string) []string {
+	return GetEnvSliceSep(name, ",")
+}
+
+// GetEnvSliceSep is GetEnvSlice with a custom separator.
+func GetEnvSliceSep(name, sep string) []string {
+	return splitList(GetEnv(name), sep)
+}
+
+// GetEnvSliceOrDefault is GetEnvSlice returning def when the environment
+// variable is not set, or its _FILE cannot be read.
+func GetEnvSliceOrDefault(name string, def []string) []string {
+	value, ok, err := lookupEnv(name)
+	if !ok || err != nil {
+		return def
+	}
+	return splitList(value, ",")
+}
+
+// splitList splits value on sep, trimming elements and dropping empty ones.
+func splitList(value, sep string) []string {
+	result := []string{}
+	for _, part := range strings.Split(value, sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
 	}
 	return result
 }
 
+// GetEnvURLE is GetEnvE for absolute URLs; see GetEnvURL.
+func GetEnvURLE(name string) (*url.URL, error) {
+	value, err := GetEnvE(name)
+	if err != nil {
+		return nil, err
+	}
+	u, err := parseURL(value)
+	if err != nil {
+		return nil, invalidEnv(name, value, err)
+	}
+	return u, nil
+}
+
+// GetEnvURL retrieves an environment variable as an absolute URL. Surrounding
+// whitespace is ignored. It panics if the environment variable is not set or
+// is not a URL with both a scheme and a host.
+func GetEnvURL(name string) *url.URL {
+	return must(GetEnvURLE(name))
+}
+
+// GetEnvURLOrDefault is GetEnvURL returning def, parsed, when the environment
+// variable is not set or its _FILE cannot be read. It panics if def is not a
+// valid URL, whether or not it is used, so a bad default is caught on the
+// first call.
+func GetEnvURLOrDefault(name, def string) *url.URL {
+	defURL, err := parseURL(def)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default URL for environment variable %s: %q: %v", name, def, err))
+	}
+	u, err := GetEnvURLE(name)
+	if err != nil && !errors.Is(err, ErrInvalid) {
+		return defURL
+	}
+	return must(u, err)
+}
+
+// TrimTrailingSlash returns a copy of u without trailing slashes in its
+// path, so "https://api.example.com/v1/" joins cleanly with "/users".
+func TrimTrailingSlash(u *url.URL) *url.URL {
+	trimmed := *u
+	trimmed.Path = strings.TrimRight(u.Path, "/")
+	trimmed.RawPath = strings.TrimRight(u.RawPath, "/")
+	return &trimmed
+}
+
+// parseURL parses an absolute URL, requiring a scheme and a host.
+func parseURL(value string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &urlErr):
+		return nil, urlErr.Err
+	case err != nil:
+		return nil, err
+	case u.Scheme == "":
+		return nil, errors.New("missing scheme")
+	case u.Host == "":
+		return nil, errors.New("missing host")
+	}
+	return u, nil
+}
+
 // GetString returns a resolved application setting. It checks exact OS
 // environment variables first and then application.properties.
 func GetString(key string) string {
@@ -1,6 +1,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -251,3 +258,280 @@ func TestLookupHelpersFromApplicationProperties(t *testing.T) {
 		t.Fatalf("LookupBool() = (%v, %v), want (true, true)", got, ok)
 	}
 }
+
+func TestGetEnvSlice(t *testing.T) {
+	tests := []struct {
+		name      string
+		envValue  string
+		set       bool
+		sep       string
+		want      []string
+		wantPanic bool
+	}{
+		{name: "spaces are trimmed", envValue: " https://a.com , https://b.com ", set: true, want: []string{"https://a.com", "https://b.com"}},
+		{name: "trailing and empty segments dropped", envValue: "b1:9092,,b2:9092,", set: true, want: []string{"b1:9092", "b2:9092"}},
+		{name: "single element", envValue: "only", set: true, want: []string{"only"}},
+		{name: "empty string", envValue: "", set: true, want: []string{}},
+		{name: "custom separator", envValue: "a; b;c", set: true, sep: ";", want: []string{"a", "b", "c"}},
+		{name: "missing variable panics", wantPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const key = "TEST_SLICE"
+			if tt.set {
+				t.Setenv(key, tt.envValue)
+			}
+			get := func() []string {
+				if tt.sep != "" {
+					return GetEnvSliceSep(key, tt.sep)
+				}
+				return GetEnvSlice(key)
+			}
+
+			if tt.wantPanic {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Error("expected panic but did not panic")
+					}
+				}()
+				get()
+				return
+			}
+
+			if got := get(); !slices.Equal(got, tt.want) || got == nil {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvSliceOrDefault(t *testing.T) {
+	def := []string{"localhost:9092"}
+	if got := GetEnvSliceOrDefault("TEST_SLICE_DEFAULT_MISSING", def); !slices.Equal(got, def) {
+		t.Errorf("missing variable: got %q, want %q", got, def)
+	}
+
+	t.Setenv("TEST_SLICE_DEFAULT", "b1, b2")
+	if got := GetEnvSliceOrDefault("TEST_SLICE_DEFAULT", def); !slices.Equal(got, []string{"b1", "b2"}) {
+		t.Errorf("set variable: got %q", got)
+	}
+
+	t.Setenv("TEST_SLICE_DEFAULT", "")
+	if got := GetEnvSliceOrDefault("TEST_SLICE_DEFAULT", def); len(got) != 0 {
+		t.Errorf("empty variable should not fall back to the default, got %q", got)
+	}
+}
+
+func TestGetEnvURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		envValue  string
+		want      string
+		wantPanic string
+	}{
+		{name: "https URL", envValue: "https://api.example.com/v1", want: "https://api.example.com/v1"},
+		{name: "surrounding whitespace", envValue: "  http://localhost:8080\n", want: "http://localhost:8080"},
+		{name: "scheme-less value", envValue: "api.example.com", wantPanic: `TEST_URL="api.example.com": invalid value: missing scheme`},
+		{name: "missing host", envValue: "https://", wantPanic: "missing host"},
+		{name: "garbage", envValue: "://%zz", wantPanic: `"://%zz"`},
+		{name: "missing variable", wantPanic: "TEST_URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const key = "TEST_URL"
+			if tt.envValue != "" {
+				t.Setenv(key, tt.envValue)
+			}
+
+			if tt.wantPanic != "" {
+				defer func() {
+					r := recover()
+					if r == nil {
+						t.Fatal("expected panic but did not panic")
+					}
+					if msg := fmt.Sprint(r); !strings.Contains(msg, tt.wantPanic) {
+						t.Errorf("panic = %q, want it to contain %q", msg, tt.wantPanic)
+					}
+				}()
+				GetEnvURL(key)
+				return
+			}
+
+			if got := GetEnvURL(key).String(); got != tt.want {
+				t.Errorf("GetEnvURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEnvURLOrDefault(t *testing.T) {
+	if got := GetEnvURLOrDefault("TEST_URL_DEFAULT", "http://localhost:9000").String(); got != "http://localhost:9000" {
+		t.Errorf("default = %q", got)
+	}
+
+	t.Setenv("TEST_URL_DEFAULT", "https://prod.example.com")
+	if got := GetEnvURLOrDefault("TEST_URL_DEFAULT", "http://localhost:9000").String(); got != "https://prod.example.com" {
+		t.Errorf("set variable = %q", got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("an invalid default should panic even when the variable is set")
+		}
+	}()
+	GetEnvURLOrDefault("TEST_URL_DEFAULT", "localhost:9000")
+}
+
+func TestTrimTrailingSlash(t *testing.T) {
+	t.Setenv("TEST_URL_SLASH", "https://api.example.com/v1//")
+	u := GetEnvURL("TEST_URL_SLASH")
+	if got := TrimTrailingSlash(u).String(); got != "https://api.example.com/v1" {
+		t.Errorf("TrimTrailingSlash() = %q", got)
+	}
+	if u.Path != "/v1//" {
+		t.Errorf("TrimTrailingSlash must not modify its argument, path = %q", u.Path)
+	}
+}
+
+func TestGetEnvFromFile(t *testing.T) {
+	dir := t.TempDir()
+	writeSecret := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("reads the file when the variable is unset", func(t *testing.T) {
+		t.Setenv("TEST_SECRET_FILE", writeSecret("secret", "s3cr3t\n"))
+		if got := GetEnv("TEST_SECRET"); got != "s3cr3t" {
+			t.Errorf("GetEnv() = %q, want %q", got, "s3cr3t")
+		}
+	})
+
+	t.Run("only one trailing newline is trimmed", func(t *testing.T) {
+		t.Setenv("TEST_SECRET_FILE", writeSecret("multi", "line1\nline2\n\n"))
+		if got := GetEnv("TEST_SECRET"); got != "line1\nline2\n" {
+			t.Errorf("GetEnv() = %q", got)
+		}
+	})
+
+	t.Run("variable wins over file", func(t *testing.T) {
+		t.Setenv("TEST_SECRET", "from-env")
+		t.Setenv("TEST_SECRET_FILE", writeSecret("ignored", "from-file"))
+		if got := GetEnv("TEST_SECRET"); got != "from-env" {
+			t.Errorf("GetEnv() = %q, want %q", got, "from-env")
+		}
+	})
+
+	t.Run("typed getters use the file", func(t *testing.T) {
+		t.Setenv("TEST_SECRET_PORT_FILE", writeSecret("port", "5432\n"))
+		if got := GetEnvInt("TEST_SECRET_PORT"); got != 5432 {
+			t.Errorf("GetEnvInt() = %d, want 5432", got)
+		}
+	})
+
+	t.Run("neither set panics", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected panic but did not panic")
+			}
+		}()
+		GetEnv("TEST_SECRET_UNSET")
+	})
+
+	t.Run("unreadable file panics with the path", func(t *testing.T) {
+		missing := filepath.Join(dir, "missing")
+		t.Setenv("TEST_SECRET_FILE", missing)
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatal("expected panic but did not panic")
+			}
+			if !strings.Contains(fmt.Sprint(r), missing) {
+				t.Errorf("panic %q should mention %s", r, missing)
+			}
+		}()
+		GetEnv("TEST_SECRET")
+	})
+
+	t.Run("defaults on unset and unreadable", func(t *testing.T) {
+		def := []string{"def"}
+		if got := GetEnvSliceOrDefault("TEST_SECRET_LIST", def); !slices.Equal(got, def) {
+			t.Errorf("unset: got %q", got)
+		}
+		t.Setenv("TEST_SECRET_LIST_FILE", filepath.Join(dir, "missing"))
+		if got := GetEnvSliceOrDefault("TEST_SECRET_LIST", def); !slices.Equal(got, def) {
+			t.Errorf("unreadable: got %q", got)
+		}
+		t.Setenv("TEST_SECRET_LIST_FILE", writeSecret("list", "a,b\n"))
+		if got := GetEnvSliceOrDefault("TEST_SECRET_LIST", def); !slices.Equal(got, []string{"a", "b"}) {
+			t.Errorf("file: got %q", got)
+		}
+	})
+}
+
+func TestGetEnvErrors(t *testing.T) {
+	t.Setenv("TEST_E_BAD", "nope")
+	t.Setenv("TEST_E_INT", "8080")
+	t.Setenv("TEST_E_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	getters := map[string]func(string) error{
+		"GetEnvE":     func(n string) error { _, err := GetEnvE(n); return err },
+		"GetEnvIntE":  func(n string) error { _, err := GetEnvIntE(n); return err },
+		"GetEnvUintE": func(n string) error { _, err := GetEnvUintE(n); return err },
+		"GetEnvBoolE": func(n string) error { _, err := GetEnvBoolE(n); return err },
+		"GetEnvURLE":  func(n string) error { _, err := GetEnvURLE(n); return err },
+	}
+
+	for name, get := range getters {
+		t.Run(name, func(t *testing.T) {
+			err := get("TEST_E_MISSING")
+			if !errors.Is(err, ErrMissing) || errors.Is(err, ErrInvalid) {
+				t.Errorf("missing: err = %v, want ErrMissing", err)
+			}
+			var envErr *EnvError
+			if !errors.As(err, &envErr) || envErr.Name != "TEST_E_MISSING" {
+				t.Errorf("missing: err = %#v, want *EnvError naming the variable", err)
+			}
+
+			if err := get("TEST_E_SECRET"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("unreadable file: err = %v, want fs.ErrNotExist", err)
+			}
+
+			if name == "GetEnvE" {
+				return
+			}
+			err = get("TEST_E_BAD")
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("invalid: err = %v, want ErrInvalid", err)
+			}
+			if !errors.As(err, &envErr) || envErr.Value != "nope" {
+				t.Errorf("invalid: err = %#v, want the bad value", err)
+			}
+		})
+	}
+
+	if v, err := GetEnvIntE("TEST_E_INT"); err != nil || v != 8080 {
+		t.Errorf("GetEnvIntE() = %d, %v", v, err)
+	}
+	if v, err := GetEnvSliceE("TEST_E_INT"); err != nil || !slices.Equal(v, []string{"8080"}) {
+		t.Errorf("GetEnvSliceE() = %q, %v", v, err)
+	}
+
+	err := errors.Join(get(GetEnvIntE, "TEST_E_MISSING"), get(GetEnvIntE, "TEST_E_BAD"))
+	if !errors.Is(err, ErrMissing) || !errors.Is(err, ErrInvalid) {
+		t.Errorf("aggregated errors should match both kinds, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `TEST_E_BAD="nope": invalid value`) {
+		t.Errorf("message should show the bad value, got %q", err)
+	}
+}
+
+func get[T any](fn func(string) (T, error), name string) error {
+	_, err := fn(name)
+	return err
+}
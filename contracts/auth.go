@@ -1,10 +1,20 @@
 package contracts
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 // Guard is the contract for authentication/authorization middleware providers
 // (e.g. ss-keel-jwt, ss-keel-oauth).
 //
+// A Guard middleware rejects a request by returning an error, typically a
+// 401 or 403 KError, without writing the response itself. It accepts a
+// request by returning GuardNext(c) rather than calling c.Next() directly,
+// so composite guards such as core.AnyOf and core.AllOf can run it as a
+// check.
+//
 // Usage:
 //
 //	route.Use(jwtGuard.Middleware()).WithSecured("bearerAuth")
@@ -12,6 +22,36 @@ type Guard interface {
 	Middleware() fiber.Handler
 }
 
+// ErrGuardPassed is returned by GuardNext when the guard runs inside a
+// composite guard. Composite guards treat it as success.
+var ErrGuardPassed = errors.New("guard passed")
+
+// guardCheckLocal marks a request whose guards run as checks.
+const guardCheckLocal = "_keel_guard_check"
+
+// GuardNext ends an accepting Guard middleware: it calls c.Next(), or returns
+// ErrGuardPassed when the guard runs inside a composite guard.
+func GuardNext(c *fiber.Ctx) error {
+	if check, _ := c.Locals(guardCheckLocal).(bool); check {
+		return ErrGuardPassed
+	}
+	return c.Next()
+}
+
+// RunGuardCheck runs a Guard middleware as a check, without continuing the
+// handler chain. It returns nil when the guard accepts the request. Composite
+// guards use it; guards that call c.Next() directly cannot be composed.
+func RunGuardCheck(c *fiber.Ctx, h fiber.Handler) error {
+	prev := c.Locals(guardCheckLocal)
+	c.Locals(guardCheckLocal, true)
+	err := h(c)
+	c.Locals(guardCheckLocal, prev)
+	if errors.Is(err, ErrGuardPassed) {
+		return nil
+	}
+	return err
+}
+
 // TokenSigner signs a JWT for an authenticated user.
 // Implemented by ss-keel-jwt; any custom implementation also works.
 //
@@ -2,9 +2,14 @@ package contracts
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrCacheMiss is returned by Cache.Get when the key does not exist or has
+// expired. Callers test for it with errors.Is.
+var ErrCacheMiss = errors.New("cache: miss")
+
 // Cache is the contract for key-value caching backends (e.g. Redis).
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
@@ -0,0 +1,25 @@
+package contracts
+
+import "time"
+
+// Clock is the time source of framework components that measure or wait,
+// such as rate limiters, caches and schedulers. Tests replace it with a fake
+// (e.g. keeltest.FakeClock) to move time forward without sleeping.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// ClockAware is implemented by components that accept a Clock, e.g.
+// schedulers. The App hands them its clock when they are registered.
+type ClockAware interface {
+	SetClock(Clock)
+}
+
+// SystemClock is the real Clock, backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
@@ -6,11 +6,18 @@ import (
 )
 
 // RequestMetrics holds the data recorded for each HTTP request.
+// Path is the route pattern so parameterized routes form one series; it
+// used to be the raw request path, which is now RawPath.
 type RequestMetrics struct {
-	Method     string
-	Path       string
-	StatusCode int
-	Duration   time.Duration
+	Method       string
+	Path         string // matched route pattern, e.g. "/users/:id"; "unmatched" when no route handled the request
+	RawPath      string // request path as received, e.g. "/users/123"
+	StatusCode   int
+	Duration     time.Duration
+	ResponseSize int64  // bytes sent, after compression
+	RequestSize  int64  // request body bytes received
+	ErrorCode    string // code of the *KError returned by the handler, e.g. "NOT_FOUND"; empty otherwise
+	RequestID    string
 }
 
 // MetricsCollector is the contract for metrics backends
@@ -19,6 +26,67 @@ type MetricsCollector interface {
 	RecordRequest(m RequestMetrics)
 }
 
+// ClientRequestMetrics holds the data recorded for each outbound HTTP request
+// made through Keel's HTTP client.
+type ClientRequestMetrics struct {
+	Method     string
+	Host       string
+	StatusCode int           // status of the last attempt; 0 when no response was received
+	Duration   time.Duration // across all attempts, including backoff
+	Attempts   int
+	Err        error // transport error of the last attempt, if any
+}
+
+// ClientMetricsCollector is implemented by metrics collectors that also
+// record outbound HTTP requests.
+type ClientMetricsCollector interface {
+	RecordClientRequest(m ClientRequestMetrics)
+}
+
+// InFlightCollector is implemented by metrics collectors that also track how
+// many requests are being served. Keel calls RequestStarted before the
+// handler runs and RequestFinished once it returns.
+type InFlightCollector interface {
+	RequestStarted()
+	RequestFinished()
+}
+
+// ErrorCollector is implemented by metrics collectors that also count error
+// responses and recovered panics. Keel's error handler calls RecordError with
+// the error code and status of every error response, and the recovery
+// middleware calls RecordPanic with the route pattern whose handler panicked.
+type ErrorCollector interface {
+	RecordError(code string, status int)
+	RecordPanic(route string)
+}
+
+// HealthCollector is implemented by metrics collectors that record health
+// checker outcomes. The readiness handler calls RecordHealthCheck for every
+// checker it runs, with the time the check took.
+type HealthCollector interface {
+	RecordHealthCheck(name string, up bool, d time.Duration)
+}
+
+// JobCollector is implemented by metrics collectors that record scheduled
+// job runs. Jobs added with App.AddJob call RecordJobRun after every run.
+type JobCollector interface {
+	RecordJobRun(name string, success bool, d time.Duration)
+}
+
+// OutboxCollector is implemented by metrics collectors that record outbox
+// relay progress. The relay calls RecordOutboxRelay after every poll with
+// the messages published and failed in it, and the age of the oldest
+// message still pending.
+type OutboxCollector interface {
+	RecordOutboxRelay(published, failed int, lag time.Duration)
+}
+
+// AuditCollector is implemented by metrics collectors that count audit
+// events the audit sink failed to write.
+type AuditCollector interface {
+	RecordAuditFailure()
+}
+
 // Span represents a single unit of work in a distributed trace.
 type Span interface {
 	SetAttribute(key string, value any)
@@ -26,8 +94,85 @@ type Span interface {
 	End()
 }
 
+// SpanStatus is the outcome of the work a span covers.
+type SpanStatus int
+
+const (
+	SpanStatusUnset SpanStatus = iota
+	SpanStatusOK
+	SpanStatusError
+)
+
+// String returns the status name, e.g. "error".
+func (s SpanStatus) String() string {
+	switch s {
+	case SpanStatusOK:
+		return "ok"
+	case SpanStatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
+
+// StatusSpan is implemented by spans that can set their status explicitly.
+type StatusSpan interface {
+	SetStatus(code SpanStatus, description string)
+}
+
+// EventSpan is implemented by spans that record timestamped events, e.g.
+// "cache miss".
+type EventSpan interface {
+	AddEvent(name string, attrs map[string]any)
+}
+
+// AttributeBatchSpan is implemented by spans that set several attributes in
+// one call.
+type AttributeBatchSpan interface {
+	SetAttributes(attrs map[string]any)
+}
+
 // Tracer creates spans for distributed tracing
 // (e.g. ss-keel-tracing / OpenTelemetry).
 type Tracer interface {
 	Start(ctx context.Context, name string) (context.Context, Span)
 }
+
+// SpanIdentifier is implemented by spans that expose their W3C trace and
+// span IDs as lowercase hex. Keel uses them to correlate logs and response
+// headers with the trace.
+type SpanIdentifier interface {
+	TraceID() string
+	SpanID() string
+}
+
+// SpanNamer is implemented by spans that can be renamed after they start.
+// Keel's HTTP middleware starts the server span before routing and renames
+// it to "METHOD /route/pattern" once the route is known.
+type SpanNamer interface {
+	SetName(name string)
+}
+
+// TraceParent is the remote span context received in the W3C traceparent
+// and tracestate headers of an incoming request.
+type TraceParent struct {
+	TraceID    string // 32 lowercase hex characters
+	SpanID     string // 16 lowercase hex characters identifying the caller's span
+	Sampled    bool
+	TraceState string // raw tracestate header, if any
+}
+
+type traceParentKey struct{}
+
+// ContextWithTraceParent returns a copy of ctx carrying the remote span
+// context. Keel's tracing middleware stores it before starting the server
+// span so tracers can continue the caller's trace.
+func ContextWithTraceParent(ctx context.Context, tp TraceParent) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, tp)
+}
+
+// TraceParentFromContext returns the remote span context stored in ctx, if any.
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
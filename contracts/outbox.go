@@ -0,0 +1,35 @@
+package contracts
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxRecord is a message held in a transactional outbox until it is
+// published.
+type OutboxRecord struct {
+	ID        string
+	Message   Message
+	CreatedAt time.Time
+	Attempts  int    // failed publish attempts so far
+	LastError string // error of the last failed attempt
+}
+
+// OutboxTx stores outbox records within a database transaction, so a
+// message is kept only if the transaction commits. Database modules
+// (e.g. ss-keel-gorm, ss-keel-mongo) implement it over their transaction
+// type.
+type OutboxTx interface {
+	InsertOutbox(ctx context.Context, rec OutboxRecord) error
+}
+
+// OutboxStore is the contract for reading and settling outbox records.
+type OutboxStore interface {
+	// Pending returns up to limit unsent records, oldest first.
+	Pending(ctx context.Context, limit int) ([]OutboxRecord, error)
+	// MarkSent marks a record published. Marking a sent record again is
+	// not an error.
+	MarkSent(ctx context.Context, id string) error
+	// MarkFailed records a failed publish attempt; the record stays pending.
+	MarkFailed(ctx context.Context, id string, err error) error
+}
@@ -22,3 +22,15 @@ type Storage interface {
 	URL(ctx context.Context, key string, expiry time.Duration) (string, error)
 	Stat(ctx context.Context, key string) (*StorageObject, error)
 }
+
+// StorageListOptions controls one page of a storage listing.
+type StorageListOptions struct {
+	Limit int    // maximum objects returned; backends apply a default when <= 0
+	Token string // NextToken of the previous page; empty for the first page
+}
+
+// StorageListResult is one page of a storage listing.
+type StorageListResult struct {
+	Objects   []StorageObject
+	NextToken string // empty on the last page
+}
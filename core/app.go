@@ -2,6 +2,9 @@ package core
 
 import (
 	"context"
+	"net/http"
+	"reflect"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
@@ -14,12 +17,26 @@ type App struct {
 	config           KConfig
 	routes           []httpx.Route
 	logger           *logger.Logger
+	startHooks       []func(context.Context) error
 	shutdownHooks    []func(context.Context) error
+	abortHooks       []func()
+	initializers     []Initializer
+	shutdowners      []Shutdowner
 	scheduler        contracts.Scheduler
 	metricsCollector contracts.MetricsCollector
 	tracer           contracts.Tracer
 	translator       contracts.Translator
+	httpClient       *http.Client
+	audit            fiber.Handler
+	clock            Clock
+	cache            contracts.Cache
+	messageMW        []MessageMiddleware
 	healthCheckers   []contracts.HealthChecker
+	healthCache      healthCache
+	services         map[reflect.Type]any
+	servicesMu       sync.RWMutex
+	inflight         inflight
+	conns            connSet
 }
 
 // Logger returns the configured logger instance.
@@ -2,10 +2,15 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,26 +19,274 @@ import (
 )
 
 // Listen starts the HTTP server with graceful shutdown support.
+// It blocks until the server stops or SIGINT/SIGTERM is received.
 func (a *App) Listen() error {
-	if err := a.resolveListenPort(); err != nil {
+	return a.ListenWithContext(context.Background())
+}
+
+// ListenWithContext behaves like Listen but also shuts the server down
+// gracefully when ctx is canceled. It returns nil after a clean shutdown.
+func (a *App) ListenWithContext(ctx context.Context) error {
+	network, addr, err := a.listenAddress()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := buildTLSConfig(a.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	if a.config.Prefork {
+		if network != "tcp" {
+			return fmt.Errorf("prefork is only supported for TCP addresses, got %q", a.config.ListenAddr)
+		}
+		return a.run(ctx, func() error {
+			return a.servePrefork(addr, tlsConfig)
+		})
+	}
+
+	return a.run(ctx, func() error {
+		ln, err := listen(network, addr)
+		if err != nil {
+			return err
+		}
+		return a.fiber.Listener(a.wrapListener(ln, tlsConfig))
+	})
+}
+
+// Serve serves requests on a listener bound by the caller, such as one
+// inherited through systemd socket activation. It behaves like Listen
+// otherwise, and the listener is closed on shutdown.
+func (a *App) Serve(ln net.Listener) error {
+	return a.ServeWithContext(context.Background(), ln)
+}
+
+// ServeWithContext behaves like Serve but also shuts the server down
+// gracefully when ctx is canceled.
+func (a *App) ServeWithContext(ctx context.Context, ln net.Listener) error {
+	if a.config.Prefork {
+		return fmt.Errorf("prefork is not supported with a pre-bound listener")
+	}
+
+	tlsConfig, err := buildTLSConfig(a.config.TLS)
+	if err != nil {
+		return err
+	}
+
+	switch addr := ln.Addr().(type) {
+	case *net.TCPAddr:
+		a.config.Port = addr.Port
+	case *net.UnixAddr:
+		a.config.ListenAddr = unixAddrPrefix + addr.Name
+	}
+
+	return a.run(ctx, func() error {
+		return a.fiber.Listener(a.wrapListener(ln, tlsConfig))
+	})
+}
+
+// wrapListener adds TLS on top of ln when tlsConfig is not nil.
+func (a *App) wrapListener(ln net.Listener, tlsConfig *tls.Config) net.Listener {
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	return ln
+}
+
+// run executes the startup sequence shared by Listen and Serve, then blocks
+// in serve until shutdown.
+func (a *App) run(ctx context.Context, serve func() error) error {
+	if err := a.runStartHooks(ctx); err != nil {
+		a.abort()
+		return err
+	}
+
+	if err := a.initModules(ctx); err != nil {
+		a.abort()
 		return err
 	}
 
 	a.registerDocsRoutes()
 
-	a.printBanner()
+	// Under prefork every child runs this same sequence; the banner and the
+	// scheduler belong to the parent only so jobs are not run once per CPU.
+	if !a.isPreforkChild() {
+		a.printBanner()
+
+		if a.scheduler != nil {
+			a.scheduler.Start()
+		}
+	}
+
+	return a.serveWithGracefulShutdown(ctx, serve)
+}
+
+// isPreforkChild reports whether this process is a prefork worker.
+func (a *App) isPreforkChild() bool {
+	return a.config.Prefork && fiber.IsChild()
+}
+
+// servePrefork serves addr through Fiber's prefork launcher, which needs the
+// address rather than a listener. Under TLS, Fiber applies its own minimum
+// version of TLS 1.2.
+func (a *App) servePrefork(addr string, tlsConfig *tls.Config) error {
+	switch {
+	case tlsConfig == nil:
+		return a.fiber.Listen(addr)
+	case tlsConfig.ClientCAs != nil:
+		return a.fiber.ListenMutualTLSWithCertificate(addr, tlsConfig.Certificates[0], tlsConfig.ClientCAs)
+	default:
+		return a.fiber.ListenTLSWithCertificate(addr, tlsConfig.Certificates[0])
+	}
+}
+
+// onAbort registers fn to run when Listen or Serve fails before shutdown,
+// e.g. because a later start hook failed or the address is taken. Shutdown
+// hooks do not run then, so fn releases what a start hook set up.
+func (a *App) onAbort(fn func()) {
+	a.abortHooks = append(a.abortHooks, fn)
+}
+
+// abort runs the onAbort functions in reverse registration order.
+func (a *App) abort() {
+	for i := len(a.abortHooks) - 1; i >= 0; i-- {
+		a.abortHooks[i]()
+	}
+}
+
+// runStartHooks runs the OnStart hooks in registration order and stops at the
+// first failure.
+func (a *App) runStartHooks(ctx context.Context) error {
+	for i, hook := range a.startHooks {
+		start := time.Now()
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("start hook %d failed: %w", i+1, err)
+		}
+		a.logger.Info("Start hook %d completed in %dms", i+1, time.Since(start).Milliseconds())
+	}
+	return nil
+}
+
+// buildTLSConfig loads the certificates referenced by cfg. It returns nil
+// when TLS is not enabled so Listen falls back to plain HTTP.
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	if !cfg.enabled() {
+		return nil, nil
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("tls: both CertFile and KeyFile must be set")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: cannot load key pair (cert=%q, key=%q): %w", cfg.CertFile, cfg.KeyFile, err)
+	}
+
+	minVersion := cfg.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: cannot read client CA file %q: %w", cfg.ClientCAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls: no valid certificates found in client CA file %q", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+const unixAddrPrefix = "unix://"
+
+// listenAddress returns the network and address to bind. ListenAddr takes
+// precedence over Port; only Port falls forward to the next free port, and
+// not under prefork, where sibling processes share the port on purpose.
+func (a *App) listenAddress() (string, string, error) {
+	if a.config.ListenAddr == "" {
+		if a.config.Prefork {
+			return "tcp", a.config.hostPort(), nil
+		}
+		if err := a.resolveListenPort(); err != nil {
+			return "", "", err
+		}
+		return "tcp", a.config.hostPort(), nil
+	}
+
+	return parseListenAddr(a.config.ListenAddr)
+}
 
-	if a.scheduler != nil {
-		a.scheduler.Start()
+// parseListenAddr splits a ListenAddr value into a network and address.
+func parseListenAddr(listenAddr string) (string, string, error) {
+	if path, ok := strings.CutPrefix(listenAddr, unixAddrPrefix); ok {
+		if path == "" {
+			return "", "", fmt.Errorf("invalid listen address %q: missing socket path", listenAddr)
+		}
+		return "unix", path, nil
+	}
+
+	if _, _, err := net.SplitHostPort(listenAddr); err != nil {
+		return "", "", fmt.Errorf("invalid listen address %q: %w", listenAddr, err)
+	}
+	return "tcp", listenAddr, nil
+}
+
+// listen binds network/addr. A stale unix socket left by a previous run is
+// removed first.
+func listen(network, addr string) (net.Listener, error) {
+	if network == "unix" {
+		if err := removeStaleSocket(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	if network == "unix" {
+		if err := os.Chmod(addr, 0o660); err != nil {
+			_ = ln.Close()
+			return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+		}
 	}
 
-	return a.serveWithGracefulShutdown()
+	return ln, nil
+}
+
+// removeStaleSocket deletes path if it is a unix socket. Any other kind of
+// file is left untouched so a misconfigured path cannot destroy data.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat socket %q: %w", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("cannot listen on %q: file exists and is not a socket", path)
+	}
+	return os.Remove(path)
 }
 
 func (a *App) resolveListenPort() error {
 	const maxPortChecks = 100
 
-	selected, err := firstAvailablePort(a.config.Port, maxPortChecks)
+	selected, err := firstAvailablePort(a.config.bindHost(), a.config.Port, maxPortChecks)
 	if err != nil {
 		return err
 	}
@@ -44,7 +297,7 @@ func (a *App) resolveListenPort() error {
 	return nil
 }
 
-func firstAvailablePort(startPort, maxChecks int) (int, error) {
+func firstAvailablePort(host string, startPort, maxChecks int) (int, error) {
 	if startPort < 1 || startPort > 65535 {
 		return 0, fmt.Errorf("invalid listen port: %d", startPort)
 	}
@@ -54,7 +307,7 @@ func firstAvailablePort(startPort, maxChecks int) (int, error) {
 
 	port := startPort
 	for i := 0; i < maxChecks && port <= 65535; i++ {
-		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
 		if err == nil {
 			_ = ln.Close()
 			return port, nil
@@ -66,54 +319,135 @@ func firstAvailablePort(startPort, maxChecks int) (int, error) {
 }
 
 func (a *App) registerDocsRoutes() {
-	if !a.config.docsEnabled() {
+	enabled, reason := a.config.docsDecision()
+	if !enabled {
+		a.logger.Info("Docs: not served (%s)", reason)
 		return
 	}
 
-	spec := openapi.Build(toBuildInput(a.config, a.routes))
-	a.fiber.Get("/docs/openapi.json", func(c *fiber.Ctx) error {
+	var guard []fiber.Handler
+	if auth := a.config.Docs.BasicAuth; auth != nil {
+		guard = append(guard, basicAuth(auth.Username, auth.Password, "docs"))
+		reason += ", behind basic auth"
+	}
+
+	spec := a.OpenAPISpec()
+	a.fiber.Get("/docs/openapi.json", append(guard, func(c *fiber.Ctx) error {
 		return c.JSON(spec)
-	})
-	a.fiber.Get(a.config.Docs.Path, openapi.SwaggerUIHandler("/docs/openapi.json"))
-	a.logger.Info("Docs: http://localhost:%d%s", a.config.Port, a.config.Docs.Path)
+	})...)
+	ui := append([]fiber.Handler(nil), guard...)
+	if a.config.securityHeadersEnabled() {
+		ui = append(ui, docsContentSecurityPolicy(a.config.SecurityHeaders))
+	}
+	a.fiber.Get(a.config.Docs.Path, append(ui, openapi.SwaggerUIHandler("/docs/openapi.json"))...)
+	if a.config.ListenAddr != "" {
+		a.logger.Info("Docs: %s on %s (%s)", a.config.Docs.Path, a.config.ListenAddr, reason)
+		return
+	}
+	a.logger.Info("Docs: %s://%s%s (%s)", a.config.scheme(), a.config.docsHostPort(), a.config.Docs.Path, reason)
 }
 
-func (a *App) serveWithGracefulShutdown() error {
+func (a *App) serveWithGracefulShutdown(ctx context.Context, serve func() error) error {
 	errCh := make(chan error, 1)
 	go func() {
-		errCh <- a.fiber.Listen(fmt.Sprintf(":%d", a.config.Port))
+		errCh <- serve()
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
 
 	select {
 	case err := <-errCh:
+		if err != nil {
+			a.abort()
+		}
 		return err
 	case <-quit:
 		return a.shutdown()
+	case <-ctx.Done():
+		return a.shutdown()
 	}
 }
 
+// shutdown stops the server using the configured ShutdownTimeout.
 func (a *App) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.ShutdownTimeout)
+	defer cancel()
+
+	return a.Shutdown(ctx)
+}
+
+// Shutdown gracefully stops the server: it stops accepting connections and
+// waits for in-flight requests until ctx is done. Requests still running
+// then have their c.UserContext() cancelled, and Shutdown waits up to
+// ShutdownHookTimeout for their handlers to return before force-closing
+// every connection left, idle or not. Finally it runs the shutdown hooks in
+// order, each bounded by ShutdownHookTimeout. Drain and hook errors are
+// joined in the result.
+func (a *App) Shutdown(ctx context.Context) error {
 	a.logger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Hooks still get their full budget when draining used up ctx.
+	hookCtx := context.WithoutCancel(ctx)
 
-	for _, hook := range a.shutdownHooks {
-		if err := hook(ctx); err != nil {
+	var errs []error
+	if err := a.fiber.ShutdownWithContext(ctx); err != nil {
+		a.inflight.cancel()
+		waitCtx, cancel := context.WithTimeout(hookCtx, a.config.ShutdownHookTimeout)
+		if n := a.inflight.wait(waitCtx); n > 0 {
+			a.logger.Warn("%d request(s) still running after drain timeout", n)
+		}
+		cancel()
+		if n := a.conns.closeAll(); n > 0 {
+			a.logger.Warn("Force-closed %d connection(s) after drain timeout", n)
+		}
+		errs = append(errs, fmt.Errorf("drain in-flight requests: %w", err))
+	}
+
+	for i, hook := range a.shutdownHooks {
+		if err := a.runShutdownHook(hookCtx, i+1, hook); err != nil {
 			a.logger.Warn("Shutdown hook error: %s", err.Error())
+			errs = append(errs, err)
 		}
 	}
 
-	return a.fiber.ShutdownWithContext(ctx)
+	return errors.Join(errs...)
+}
+
+// runShutdownHook runs hook with its own timeout. A hook that ignores its
+// context is abandoned once the timeout passes so shutdown can proceed.
+func (a *App) runShutdownHook(ctx context.Context, n int, hook func(context.Context) error) error {
+	timeout := a.config.ShutdownHookTimeout
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- hook(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("shutdown hook %d: %w", n, err)
+		}
+		return nil
+	case <-ctx.Done():
+		a.logger.Warn("Shutdown hook %d exceeded %s", n, timeout)
+		return fmt.Errorf("shutdown hook %d: %w", n, ctx.Err())
+	}
 }
 
-// printBanner prints the Keel service banner with service name, port and environment.
+// printBanner prints the Keel service banner with service name, address and environment.
 func (a *App) printBanner() {
+	addr := fmt.Sprintf("Port     : %d", a.config.Port)
+	if a.config.Host != "" {
+		addr = fmt.Sprintf("Address  : %s", a.config.hostPort())
+	}
+	if a.config.ListenAddr != "" {
+		addr = fmt.Sprintf("Address  : %s", a.config.ListenAddr)
+	}
 	fmt.Printf(
-		"\n  ⚓  K E E L\n  ──────────────────────────────\n  Service  : %s\n  Port     : %d\n  Env      : %s\n  ──────────────────────────────\n\n",
-		a.config.ServiceName, a.config.Port, a.config.Env,
+		"\n  ⚓  K E E L\n  ──────────────────────────────\n  Service  : %s\n  %s\n  Env      : %s\n  ──────────────────────────────\n\n",
+		a.config.ServiceName, addr, a.config.Env,
 	)
 }
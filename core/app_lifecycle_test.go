@@ -1,11 +1,31 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
 func TestRegisterDocsRoutes(t *testing.T) {
@@ -55,6 +75,42 @@ func TestRegisterDocsRoutes(t *testing.T) {
 			t.Fatalf("openapi status = %d, want %d", resp.StatusCode, http.StatusNotFound)
 		}
 	})
+
+	t.Run("registers docs routes in production when explicitly enabled", func(t *testing.T) {
+		enabled := true
+		var buf bytes.Buffer
+		app := New(KConfig{
+			DisableHealth: true,
+			Env:           "production",
+			Docs:          DocsConfig{Enabled: &enabled},
+		})
+		app.logger = app.logger.WithWriter(&buf)
+
+		app.registerDocsRoutes()
+
+		resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/docs/openapi.json", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("openapi status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if !strings.Contains(buf.String(), "enabled by Docs.Enabled") {
+			t.Errorf("log should explain why docs are served, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("logs why docs are not served", func(t *testing.T) {
+		var buf bytes.Buffer
+		app := New(KConfig{DisableHealth: true, Env: "production"})
+		app.logger = app.logger.WithWriter(&buf)
+
+		app.registerDocsRoutes()
+
+		if !strings.Contains(buf.String(), "Docs: not served (disabled in production") {
+			t.Errorf("log should explain why docs are not served, got:\n%s", buf.String())
+		}
+	})
 }
 
 func TestShutdownRunsHooks(t *testing.T) {
@@ -82,9 +138,10 @@ func TestShutdownRunsHooks(t *testing.T) {
 func TestListenReturnsErrorOnInvalidPort(t *testing.T) {
 	app := New(KConfig{
 		DisableHealth: true,
-		Port:          -1,
 		Env:           "production",
 	})
+	// New rejects this port; force it to exercise Listen's own check.
+	app.config.Port = -1
 	s := &schedulerSpy{}
 	app.RegisterScheduler(s)
 
@@ -124,7 +181,7 @@ func TestResolveListenPortWhenBusy(t *testing.T) {
 
 func TestFirstAvailablePort(t *testing.T) {
 	t.Run("returns error for invalid start port", func(t *testing.T) {
-		_, err := firstAvailablePort(-1, 10)
+		_, err := firstAvailablePort("", -1, 10)
 		if err == nil {
 			t.Fatal("expected error for invalid start port")
 		}
@@ -138,9 +195,795 @@ func TestFirstAvailablePort(t *testing.T) {
 		defer ln.Close()
 
 		busyPort := ln.Addr().(*net.TCPAddr).Port
-		_, err = firstAvailablePort(busyPort, 1)
+		_, err = firstAvailablePort("", busyPort, 1)
 		if err == nil {
 			t.Fatal("expected error when no port is available in scan window")
 		}
 	})
 }
+
+// freePort returns a TCP port that is free at the time of the call.
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// writeSelfSignedCert writes a self-signed certificate for localhost into dir
+// and returns the cert and key file paths.
+func writeSelfSignedCert(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestListenTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+	port := freePort(t)
+
+	app := New(KConfig{
+		Port: port,
+		Env:  "production",
+		TLS:  TLSConfig{CertFile: certFile, KeyFile: keyFile},
+	})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Listen() }()
+
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		resp, err = client.Get(fmt.Sprintf("https://127.0.0.1:%d/health", port))
+		if err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp.TLS == nil {
+		t.Fatal("response was not served over TLS")
+	}
+
+	if err := app.Fiber().Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("Listen() returned %v", err)
+	}
+}
+
+func TestListenTLSInvalidConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir)
+
+	tests := []struct {
+		name string
+		cfg  TLSConfig
+	}{
+		{"missing key file", TLSConfig{CertFile: certFile}},
+		{"nonexistent cert path", TLSConfig{CertFile: filepath.Join(dir, "nope.pem"), KeyFile: keyFile}},
+		{"nonexistent client CA", TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: filepath.Join(dir, "ca.pem")}},
+		{"client CA without certificates", TLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{
+				DisableHealth: true,
+				Port:          freePort(t),
+				Env:           "production",
+			})
+			app.config.TLS = tt.cfg
+			s := &schedulerSpy{}
+			app.RegisterScheduler(s)
+
+			if err := app.Listen(); err == nil {
+				t.Fatal("Listen() should return error for invalid TLS config")
+			}
+			if s.started {
+				t.Fatal("scheduler Start() should not be called when TLS config is invalid")
+			}
+		})
+	}
+}
+
+func TestBuildTLSConfigMutualTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	cfg, err := buildTLSConfig(TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: certFile,
+		MinVersion:   tls.VersionTLS13,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("ClientAuth = %v, want RequireAndVerifyClientCert", cfg.ClientAuth)
+	}
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("MinVersion = %x, want %x", cfg.MinVersion, tls.VersionTLS13)
+	}
+}
+
+// waitForServer polls url until the server answers or the attempts run out.
+func waitForServer(t *testing.T, url string) {
+	t.Helper()
+	client := &http.Client{Timeout: time.Second}
+	for i := 0; i < 50; i++ {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("server at %s did not become ready", url)
+}
+
+func TestListenWithContextStopsOnCancel(t *testing.T) {
+	port := freePort(t)
+	app := New(KConfig{Port: port, Env: "production"})
+
+	var order []string
+	app.OnShutdown(func(context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	app.OnShutdown(func(context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.ListenWithContext(ctx) }()
+
+	waitForServer(t, fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("ListenWithContext() returned %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenWithContext() did not return after cancel")
+	}
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("shutdown hooks order = %v, want [first second]", order)
+	}
+}
+
+func TestShutdownStopsListen(t *testing.T) {
+	port := freePort(t)
+	app := New(KConfig{Port: port, Env: "production"})
+
+	called := false
+	app.OnShutdown(func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Listen() }()
+
+	waitForServer(t, fmt.Sprintf("http://127.0.0.1:%d/health", port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() returned %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Listen() returned %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen() did not return after Shutdown")
+	}
+	if !called {
+		t.Fatal("Shutdown() should run shutdown hooks")
+	}
+}
+
+func TestOnStartHooksRunInOrder(t *testing.T) {
+	port := freePort(t)
+	app := New(KConfig{Port: port, Env: "production"})
+
+	var buf bytes.Buffer
+	app.logger = app.logger.WithWriter(&buf)
+
+	var order []string
+	app.OnStart(func(context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	app.OnStart(func(context.Context) error {
+		order = append(order, "second")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.ListenWithContext(ctx) }()
+
+	waitForServer(t, fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("ListenWithContext() returned %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Fatalf("start hooks order = %v, want [first second]", order)
+	}
+	out := buf.String()
+	for _, want := range []string{"Start hook 1 completed in", "Start hook 2 completed in"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestOnStartHookFailureAbortsListen(t *testing.T) {
+	app := New(KConfig{
+		DisableHealth: true,
+		Port:          freePort(t),
+		Env:           "production",
+	})
+	s := &schedulerSpy{}
+	app.RegisterScheduler(s)
+
+	hookErr := errors.New("migrations pending")
+	secondCalled := false
+	app.OnStart(func(context.Context) error { return hookErr })
+	app.OnStart(func(context.Context) error {
+		secondCalled = true
+		return nil
+	})
+
+	err := app.Listen()
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("Listen() error = %v, want %v", err, hookErr)
+	}
+	if secondCalled {
+		t.Fatal("hooks after a failing hook should not run")
+	}
+	if s.started {
+		t.Fatal("scheduler Start() should not be called when a start hook fails")
+	}
+}
+
+func TestListenOnUnixSocket(t *testing.T) {
+	// Keep the path short: unix socket paths are limited to ~104 bytes.
+	dir, err := os.MkdirTemp("", "keel")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "app.sock")
+
+	// A stale socket from a previous run must not prevent startup.
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	app := New(KConfig{ListenAddr: "unix://" + sock, Env: "production"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.ListenWithContext(ctx) }()
+
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+			},
+		},
+	}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://unix/health"); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o660 {
+		t.Errorf("socket permissions = %o, want 660", perm)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("ListenWithContext() returned %v", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("socket file should be removed on shutdown, stat err = %v", err)
+	}
+}
+
+func TestListenRefusesToReplaceRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	app := New(KConfig{ListenAddr: "unix://" + path, Env: "production"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := app.ListenWithContext(ctx); err == nil {
+		t.Fatal("ListenWithContext() should fail when the socket path is a regular file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "data" {
+		t.Error("regular file should be left untouched")
+	}
+}
+
+func TestParseListenAddr(t *testing.T) {
+	tests := []struct {
+		name        string
+		addr        string
+		wantNetwork string
+		wantAddr    string
+		wantErr     bool
+	}{
+		{"host and port", "127.0.0.1:8080", "tcp", "127.0.0.1:8080", false},
+		{"unix socket", "unix:///tmp/app.sock", "unix", "/tmp/app.sock", false},
+		{"empty socket path", "unix://", "", "", true},
+		{"missing port", "localhost", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, addr, err := parseListenAddr(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListenAddr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if network != tt.wantNetwork || addr != tt.wantAddr {
+				t.Errorf("parseListenAddr() = (%q, %q), want (%q, %q)", network, addr, tt.wantNetwork, tt.wantAddr)
+			}
+		})
+	}
+}
+
+func TestServeOnPreboundListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	app := New(KConfig{Env: "production"})
+	called := false
+	app.OnShutdown(func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.ServeWithContext(ctx, ln) }()
+
+	waitForServer(t, fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	if app.config.Port != port {
+		t.Errorf("config.Port = %d, want %d", app.config.Port, port)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("ServeWithContext() returned %v", err)
+	}
+	if !called {
+		t.Error("shutdown hooks should run when serving a pre-bound listener")
+	}
+}
+
+func TestPreforkConfig(t *testing.T) {
+	app := New(KConfig{Prefork: true})
+	if !app.Fiber().Config().Prefork {
+		t.Error("fiber Prefork should be enabled")
+	}
+
+	if _, err := NewE(KConfig{Prefork: true, ListenAddr: "unix:///tmp/keel.sock"}); err == nil {
+		t.Error("NewE() should reject prefork on a unix socket")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := New(KConfig{Prefork: true}).Serve(ln); err == nil {
+		t.Error("Serve() should reject prefork on a pre-bound listener")
+	}
+}
+
+func TestPreforkSchedulerStartsOnlyInParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		child       bool
+		wantStarted bool
+	}{
+		{"parent", false, true},
+		{"child", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.child {
+				t.Setenv("FIBER_PREFORK_CHILD", "1")
+			}
+
+			app := New(KConfig{Prefork: true})
+			s := &schedulerSpy{}
+			app.RegisterScheduler(s)
+
+			if err := app.run(context.Background(), func() error { return nil }); err != nil {
+				t.Fatalf("run() returned %v", err)
+			}
+			if s.started != tt.wantStarted {
+				t.Errorf("scheduler started = %v, want %v", s.started, tt.wantStarted)
+			}
+		})
+	}
+}
+
+func TestListenBindsConfiguredHost(t *testing.T) {
+	port := freePort(t)
+	app := New(KConfig{Host: "127.0.0.1", Port: port, Env: "production"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.ListenWithContext(ctx) }()
+
+	waitForServer(t, fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("ListenWithContext() returned %v", err)
+	}
+}
+
+func TestDocsBasicAuth(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{
+		DisableHealth: true,
+		Env:           "development",
+		Docs:          DocsConfig{BasicAuth: &DocsBasicAuth{Username: "admin", Password: "s3cret-pass"}},
+	})
+	app.logger = app.logger.WithWriter(&buf)
+	app.registerDocsRoutes()
+
+	tests := []struct {
+		name       string
+		user, pass string
+		setAuth    bool
+		wantStatus int
+	}{
+		{"missing credentials", "", "", false, http.StatusUnauthorized},
+		{"wrong password", "admin", "nope", true, http.StatusUnauthorized},
+		{"wrong username", "root", "s3cret-pass", true, http.StatusUnauthorized},
+		{"correct credentials", "admin", "s3cret-pass", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		for _, path := range []string{"/docs", "/docs/openapi.json"} {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				req := httptest.NewRequest("GET", path, nil)
+				if tt.setAuth {
+					req.SetBasicAuth(tt.user, tt.pass)
+				}
+				resp, err := app.Fiber().Test(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != tt.wantStatus {
+					t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+				if tt.wantStatus == http.StatusUnauthorized {
+					if got := resp.Header.Get("WWW-Authenticate"); !strings.HasPrefix(got, `Basic realm="docs"`) {
+						t.Errorf("WWW-Authenticate = %q, want Basic challenge", got)
+					}
+				}
+			})
+		}
+	}
+
+	if strings.Contains(buf.String(), "s3cret-pass") {
+		t.Errorf("credentials leaked into logs:\n%s", buf.String())
+	}
+}
+
+func TestParseBasicAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		user, pass string
+		ok         bool
+	}{
+		{"valid", "Basic YWRtaW46cGFzcw==", "admin", "pass", true},
+		{"lowercase scheme", "basic YWRtaW46cGFzcw==", "admin", "pass", true},
+		{"password with colon", "Basic YTpiOmM=", "a", "b:c", true},
+		{"bearer scheme", "Bearer token", "", "", false},
+		{"invalid base64", "Basic !!!", "", "", false},
+		{"missing colon", "Basic YWRtaW4=", "admin", "", false},
+		{"empty", "", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, pass, ok := parseBasicAuth(tt.header)
+			if user != tt.user || pass != tt.pass || ok != tt.ok {
+				t.Errorf("parseBasicAuth(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.header, user, pass, ok, tt.user, tt.pass, tt.ok)
+			}
+		})
+	}
+}
+
+// startSlowServer serves a /slow route that sleeps for delay and returns the
+// app and its base URL.
+// startBlockingServer serves handler on /slow and returns the app, its URL
+// and a channel closed once the handler has been entered.
+func startBlockingServer(t *testing.T, cfg KConfig, handler fiber.Handler, opts ...Option) (*App, string, <-chan struct{}) {
+	t.Helper()
+	cfg.Port = freePort(t)
+	cfg.Env = "production"
+	app := NewWithOptions(append([]Option{WithConfig(cfg)}, opts...)...)
+	started := make(chan struct{})
+	var once sync.Once
+	app.Fiber().Get("/slow", func(c *fiber.Ctx) error {
+		once.Do(func() { close(started) })
+		return handler(c)
+	})
+
+	go func() { _ = app.Listen() }()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
+	waitForServer(t, url+"/health")
+	return app, url, started
+}
+
+// waitForListenerClosed waits until addr refuses connections, i.e. until
+// shutdown has begun.
+func waitForListenerClosed(t *testing.T, addr string) {
+	t.Helper()
+	for i := 0; i < 250; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return
+		}
+		conn.Close()
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("%s still accepts connections", addr)
+}
+
+type slowResult struct {
+	status int
+	err    error
+}
+
+// getSlow requests /slow on a connection of its own, so no idle pooled
+// connection keeps the server from draining.
+func getSlow(url string) <-chan slowResult {
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resCh := make(chan slowResult, 1)
+	go func() {
+		resp, err := client.Get(url + "/slow")
+		if err != nil {
+			resCh <- slowResult{err: err}
+			return
+		}
+		resp.Body.Close()
+		resCh <- slowResult{status: resp.StatusCode}
+	}()
+	return resCh
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	release := make(chan struct{})
+	app, url, started := startBlockingServer(t, KConfig{}, func(c *fiber.Ctx) error {
+		<-release
+		return c.SendString("done")
+	})
+
+	resCh := getSlow(url)
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- app.Shutdown(context.Background()) }()
+	addr := strings.TrimPrefix(url, "http://")
+	waitForListenerClosed(t, addr)
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown() returned %v while a request was in flight", err)
+	default:
+	}
+	close(release)
+
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Shutdown() returned %v", err)
+	}
+	if res := <-resCh; res.err != nil || res.status != http.StatusOK {
+		t.Fatalf("in-flight request = (%d, %v), want 200", res.status, res.err)
+	}
+	if conn, err := net.Dial("tcp", addr); err == nil {
+		conn.Close()
+		t.Error("server should not accept new connections after shutdown")
+	}
+}
+
+func TestShutdownCancelsRequestsBeyondTimeout(t *testing.T) {
+	cancelled := make(chan struct{})
+	app, url, started := startBlockingServer(t, KConfig{}, func(c *fiber.Ctx) error {
+		<-c.UserContext().Done()
+		close(cancelled)
+		return c.UserContext().Err()
+	})
+
+	resCh := getSlow(url)
+	<-started
+
+	hookRan := false
+	app.OnShutdown(func(context.Context) error {
+		select {
+		case <-cancelled:
+			hookRan = true
+		default:
+			t.Error("shutdown hooks should run after cancelled handlers return")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := app.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() = %v, want deadline exceeded", err)
+	}
+	if !hookRan {
+		t.Error("shutdown hooks should run after a drain timeout")
+	}
+	if res := <-resCh; res.err == nil && res.status == http.StatusOK {
+		t.Error("request beyond the drain timeout should be cut off")
+	}
+}
+
+func TestShutdownForceClosesConnectionsBeyondTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	release := make(chan struct{})
+	app, url, started := startBlockingServer(t, KConfig{ShutdownHookTimeout: 50 * time.Millisecond}, func(c *fiber.Ctx) error {
+		<-release // ignores the cancelled context
+		return c.SendString("done")
+	}, WithLogger(logger.NewLogger(true).WithWriter(&buf)))
+	defer close(release)
+
+	resCh := getSlow(url)
+	<-started
+	idle, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := app.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() = %v, want deadline exceeded", err)
+	}
+	for _, want := range []string{"1 request(s) still running", "Force-closed 2 connection(s)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("log = %q, want %q", buf.String(), want)
+		}
+	}
+
+	select {
+	case res := <-resCh:
+		if res.err == nil {
+			t.Errorf("request = %d, want its connection closed", res.status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request still open after Shutdown returned")
+	}
+	idle.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint
+	if _, err := idle.Read(make([]byte, 1)); !errors.Is(err, io.EOF) {
+		t.Errorf("idle connection read = %v, want EOF", err)
+	}
+}
+
+func TestShutdownHookTimeoutAndErrors(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true, ShutdownHookTimeout: 50 * time.Millisecond})
+	app.logger = app.logger.WithWriter(&buf)
+
+	hookErr := errors.New("flush failed")
+	app.OnShutdown(func(context.Context) error { return hookErr })
+	app.OnShutdown(func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	lastRan := false
+	app.OnShutdown(func(context.Context) error {
+		lastRan = true
+		return nil
+	})
+
+	err := app.Shutdown(context.Background())
+	if !errors.Is(err, hookErr) {
+		t.Errorf("Shutdown() = %v, want it to wrap the hook error", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() = %v, want it to report the hook timeout", err)
+	}
+	if !lastRan {
+		t.Error("hooks after a slow one should still run")
+	}
+	if !strings.Contains(buf.String(), "Shutdown hook 2 exceeded 50ms") {
+		t.Errorf("log should report the slow hook, got:\n%s", buf.String())
+	}
+}
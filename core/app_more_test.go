@@ -1,21 +1,28 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
 type schedulerSpy struct {
 	started bool
 	stopped bool
+	jobs    []contracts.Job
 }
 
-func (s *schedulerSpy) Add(_ contracts.Job) error { return nil }
-func (s *schedulerSpy) Start()                    { s.started = true }
-func (s *schedulerSpy) Stop(_ context.Context)    { s.stopped = true }
+func (s *schedulerSpy) Add(job contracts.Job) error { s.jobs = append(s.jobs, job); return nil }
+func (s *schedulerSpy) Start()                      { s.started = true }
+func (s *schedulerSpy) Stop(_ context.Context)      { s.stopped = true }
 
 type moduleSpy struct {
 	registered bool
@@ -32,6 +39,20 @@ func TestLoggerGetter(t *testing.T) {
 	}
 }
 
+func TestSetLoggerRoutesRequestLogs(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.SetLogger(logger.FromSlog(slog.NewTextHandler(&buf, nil)))
+	app.Fiber().Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/ping", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `msg="HTTP GET /ping"`) {
+		t.Errorf("request log should go through the slog handler, got:\n%s", buf.String())
+	}
+}
+
 func TestRegisterSchedulerAddsShutdownHook(t *testing.T) {
 	app := New(KConfig{DisableHealth: true})
 	s := &schedulerSpy{}
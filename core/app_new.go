@@ -2,79 +2,218 @@ package core
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
 	"github.com/slice-soft/ss-keel-core/logger"
 )
 
 // New creates a new App instance with the given configuration.
+// It panics if the configuration is invalid; use NewE to handle the error.
 func New(cfg KConfig) *App {
+	app, err := NewE(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return app
+}
+
+// NewE creates a new App instance, returning an error if cfg fails Validate.
+func NewE(cfg KConfig) (*App, error) {
+	return newApp(options{config: cfg})
+}
+
+func newApp(o options) (*App, error) {
+	cfg := o.config
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	cfg = applyDefaults(cfg)
-	log := logger.NewLogger(cfg.isProduction())
+	log := o.logger
+	if log == nil {
+		log = logger.NewLogger(cfg.isProduction())
+		log.RedactKeys(cfg.Logging.redactKeys()...)
+	}
+
+	if !knownEnv(cfg.Env) {
+		log.Warn("Unknown environment %q; expected one of %s", cfg.Env, strings.Join(knownEnvs, ", "))
+	}
 
 	app := &App{
 		config: cfg,
 		logger: log,
 		tracer: noopTracer{},
+		clock:  contracts.SystemClock,
 	}
 
-	app.fiber = app.buildFiber()
+	app.httpClient = HTTPClient(app)
+	app.fiber = app.buildFiber(o.fiberConfig...)
+
+	if cfg.isProduction() && !cfg.CORS.Disabled && cfg.CORS.permissive() {
+		log.Warn("CORS allows every origin; set KConfig.CORS.AllowOrigins to restrict it")
+	}
 
 	if !cfg.DisableHealth {
 		app.registerHealth()
 	}
 
-	return app
+	if cfg.Metrics.Enabled {
+		app.registerMetrics()
+	}
+
+	return app, nil
 }
 
-func (a *App) buildFiber() *fiber.App {
-	f := fiber.New(fiber.Config{
+// buildFiber creates the Fiber app and installs the default middleware stack.
+// customize functions may adjust the Fiber config before it is created.
+func (a *App) buildFiber(customize ...func(*fiber.Config)) *fiber.App {
+	server := a.config.Server
+	fiberConfig := fiber.Config{
 		DisableStartupMessage: true,
 		ErrorHandler:          a.errorHandler(),
-	})
+		ReadTimeout:           unlimitedDuration(server.ReadTimeout),
+		WriteTimeout:          unlimitedDuration(server.WriteTimeout),
+		IdleTimeout:           unlimitedDuration(server.IdleTimeout),
+		BodyLimit:             unlimitedInt(server.BodyLimit),
+		Concurrency:           unlimitedInt(server.Concurrency),
+		Prefork:               a.config.Prefork,
+	}
+	for _, fn := range customize {
+		fn(&fiberConfig)
+	}
+	f := fiber.New(fiberConfig)
+	f.Server().ConnState = a.conns.track
 
-	f.Use(requestid.New())
+	f.Use(a.inflightMiddleware())
+	f.Use(a.requestIDMiddleware())
 	f.Use(a.keelLogger())
-	f.Use(recover.New())
-	f.Use(cors.New())
-	f.Use(a.translatorMiddleware())
+	f.Use(a.tracingMiddleware())
+	if !a.config.Compression.Disabled {
+		f.Use(compressionMiddleware(a.config.Compression.Level))
+	}
+	f.Use(recover.New(recover.Config{
+		// The stack trace hook is the only callback Fiber offers on a
+		// recovered panic; it counts the panic instead of printing a trace.
+		EnableStackTrace:  true,
+		StackTraceHandler: a.recordPanic,
+	}))
+	if !a.config.CORS.Disabled {
+		f.Use(cors.New(a.config.CORS.toFiber()))
+	}
+	if a.config.securityHeadersEnabled() {
+		f.Use(securityHeaders(a.config.SecurityHeaders, a.config.isProduction() || a.config.TLS.enabled()))
+	}
+	f.Use(a.localsMiddleware())
+	f.Use(a.auditMiddleware())
+	if a.config.LogBodies.Enabled && !a.config.isProduction() {
+		cfg := a.config.LogBodies
+		cfg.RedactKeys = append(append([]string(nil), a.config.Logging.RedactKeys...), cfg.RedactKeys...)
+		f.Use(bodyLogger(cfg, func() *logger.Logger { return a.logger }))
+	}
 
 	return f
 }
 
+// logHTTPError logs server errors at ERROR and client errors at WARN,
+// attributed to the error handler that called it.
+func (a *App) logHTTPError(status int, msg string) {
+	log := a.logger.WithCallerSkip(1)
+	if status >= fiber.StatusInternalServerError {
+		log.Error("HTTP Error [%d]: %s", status, msg)
+		return
+	}
+	log.Warn("HTTP Error [%d]: %s", status, msg)
+}
+
 func (a *App) errorHandler() fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
+		rid := httpx.RequestID(c)
+
 		var ke *KError
 		if errors.As(err, &ke) {
-			a.logger.Warn("HTTP Error [%d]: %s", ke.StatusCode, ke.Message)
-			return c.Status(ke.StatusCode).JSON(fiber.Map{
+			a.logHTTPError(ke.StatusCode, ke.Message)
+			a.recordError(ke.Code, ke.StatusCode)
+			c.Set("X-Error-Code", ke.Code)
+			return c.Status(ke.StatusCode).JSON(withTraceID(c, fiber.Map{
 				"status_code": ke.StatusCode,
 				"code":        ke.Code,
 				"message":     ke.Message,
-			})
+				"request_id":  rid,
+			}))
 		}
 
 		code := fiber.StatusInternalServerError
-		if e, ok := err.(*fiber.Error); ok {
-			code = e.Code
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			code = fe.Code
 		}
-		a.logger.Warn("HTTP Error [%d]: %s", code, err.Error())
-		return c.Status(code).JSON(fiber.Map{
+		a.logHTTPError(code, err.Error())
+		errCode := errorCodeFromStatus(code)
+		a.recordError(errCode, code)
+		c.Set("X-Error-Code", errCode)
+		body := fiber.Map{
 			"status_code": code,
+			"code":        errCode,
 			"message":     err.Error(),
-		})
+			"request_id":  rid,
+		}
+		var ve *httpx.ValidationError
+		if errors.As(err, &ve) {
+			body["errors"] = ve.Errors
+		}
+		return c.Status(code).JSON(withTraceID(c, body))
+	}
+}
+
+// recordError counts an error response when the metrics collector supports it.
+func (a *App) recordError(code string, status int) {
+	if ec, ok := a.metricsCollector.(contracts.ErrorCollector); ok {
+		ec.RecordError(code, status)
+	}
+}
+
+// recordPanic counts a panic recovered from a route handler when the metrics
+// collector supports it.
+func (a *App) recordPanic(c *fiber.Ctx, _ any) {
+	if ec, ok := a.metricsCollector.(contracts.ErrorCollector); ok {
+		ec.RecordPanic(c.Route().Path)
+	}
+}
+
+// withTraceID adds the request's trace ID to an error body when tracing is
+// on, so clients can quote it in bug reports.
+func withTraceID(c *fiber.Ctx, body fiber.Map) fiber.Map {
+	if tid := traceID(c); tid != "" {
+		body["trace_id"] = tid
+	}
+	return body
+}
+
+// errorCodeFromStatus derives an error code such as "NOT_FOUND" from an HTTP
+// status for errors that are not *KError.
+func errorCodeFromStatus(status int) string {
+	text := utils.StatusMessage(status)
+	if text == "" {
+		return "ERROR"
 	}
+	return strings.ToUpper(strings.ReplaceAll(text, " ", "_"))
 }
 
-func (a *App) translatorMiddleware() fiber.Handler {
-	// Inject translator into locals so Ctx.T() can access it.
+// localsMiddleware injects the app services httpx.Ctx helpers need: the
+// translator for Ctx.T() and the HTTP client for Ctx.HTTPClient().
+func (a *App) localsMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		if a.translator != nil {
 			c.Locals("_keel_translator", a.translator)
 		}
+		c.Locals("_keel_http_client", a.httpClient)
+		c.Locals("_keel_clock", a.clock)
 		return c.Next()
 	}
 }
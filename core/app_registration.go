@@ -2,32 +2,58 @@ package core
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
-// Use registers a module into the app.
+// Use registers a module into the app. Modules that implement Initializer or
+// Shutdowner are also hooked into the app lifecycle.
 func (a *App) Use(m contracts.Module[*App]) {
 	m.Register(a)
+	a.trackModule(m)
 }
 
 // RegisterController registers all routes from a controller into the app.
 func (a *App) RegisterController(c contracts.Controller[httpx.Route]) {
 	for _, route := range c.Routes() {
 		a.routes = append(a.routes, route)
-		handlers := append(append([]fiber.Handler{}, route.Middlewares()...), httpx.WrapHandler(route.Handler()))
-		a.fiber.Add(route.Method(), route.Path(), handlers...)
+		a.fiber.Add(route.Method(), route.Path(), routeHandlers(route)...)
 		a.logger.Debug("Route registered: [%s] %s", route.Method(), route.Path())
 	}
 }
 
+// routeHandlers returns the fiber handler chain of route: its middlewares,
+// the role check of WithRoles, then the handler.
+func routeHandlers(route httpx.Route) []fiber.Handler {
+	handlers := append([]fiber.Handler{}, route.Middlewares()...)
+	if roles := route.Roles(); len(roles) > 0 {
+		handlers = append(handlers, RequireRoles(roles...))
+	}
+	return append(handlers, httpx.WrapHandler(route.Handler()))
+}
+
+// OnStart registers a hook that is called by Listen before the server starts
+// accepting traffic. Hooks run in registration order; if one returns an error,
+// Listen aborts and returns it.
+func (a *App) OnStart(fn func(context.Context) error) {
+	a.startHooks = append(a.startHooks, fn)
+}
+
 // OnShutdown registers a hook that is called during graceful shutdown.
 func (a *App) OnShutdown(fn func(context.Context) error) {
 	a.shutdownHooks = append(a.shutdownHooks, fn)
 }
 
+// SetLogger replaces the app logger, including for request logs. Use
+// logger.FromSlog to log through an existing slog pipeline.
+func (a *App) SetLogger(l *logger.Logger) {
+	a.logger = l
+}
+
 // SetMetricsCollector sets the metrics collector.
 func (a *App) SetMetricsCollector(mc contracts.MetricsCollector) {
 	a.metricsCollector = mc
@@ -49,11 +75,37 @@ func (a *App) SetTranslator(t contracts.Translator) {
 }
 
 // RegisterScheduler registers a scheduler that will be started in Listen()
-// and stopped on shutdown.
+// and stopped on shutdown. Add jobs with AddJob to have their runs logged and
+// measured.
 func (a *App) RegisterScheduler(s contracts.Scheduler) {
 	a.scheduler = s
+	if ca, ok := s.(contracts.ClockAware); ok {
+		ca.SetClock(a.clock)
+	}
 	a.OnShutdown(func(ctx context.Context) error {
 		s.Stop(ctx)
 		return nil
 	})
 }
+
+// AddJob adds job to the registered scheduler. Each run is timed and
+// reported to the metrics collector when it implements
+// contracts.JobCollector; failed runs are also logged.
+func (a *App) AddJob(job contracts.Job) error {
+	if a.scheduler == nil {
+		return fmt.Errorf("cannot add job %q: no scheduler registered", job.Name)
+	}
+	handler := job.Handler
+	job.Handler = func(ctx context.Context) error {
+		start := a.clock.Now()
+		err := handler(ctx)
+		if jc, ok := a.metricsCollector.(contracts.JobCollector); ok {
+			jc.RecordJobRun(job.Name, err == nil, a.clock.Now().Sub(start))
+		}
+		if err != nil {
+			a.logger.ErrorCtx(ctx, "Job %s failed: %v", job.Name, err)
+		}
+		return err
+	}
+	return a.scheduler.Add(job)
+}
@@ -1,10 +1,14 @@
 package core
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
@@ -241,3 +245,106 @@ func TestDocsRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestCORS(t *testing.T) {
+	preflight := func(app *App, origin string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest("OPTIONS", "/health", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		resp, err := app.Fiber().Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("zero config allows every origin", func(t *testing.T) {
+		app := New(KConfig{})
+		resp := preflight(app, "https://anywhere.example.com")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+		}
+	})
+
+	t.Run("allowed origin is echoed", func(t *testing.T) {
+		app := New(KConfig{CORS: CORSConfig{
+			AllowOrigins:     []string{"https://app.example.com"},
+			AllowMethods:     []string{"GET", "POST"},
+			AllowCredentials: true,
+			MaxAge:           time.Hour,
+		}})
+		resp := preflight(app, "https://app.example.com")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want https://app.example.com", got)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Methods"); got != "GET,POST" {
+			t.Errorf("Access-Control-Allow-Methods = %q, want GET,POST", got)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+		}
+		if got := resp.Header.Get("Access-Control-Max-Age"); got != "3600" {
+			t.Errorf("Access-Control-Max-Age = %q, want 3600", got)
+		}
+	})
+
+	t.Run("disallowed origin gets no allow header", func(t *testing.T) {
+		app := New(KConfig{CORS: CORSConfig{AllowOrigins: []string{"https://app.example.com"}}})
+		resp := preflight(app, "https://evil.example.com")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want empty", got)
+		}
+	})
+
+	t.Run("disabled installs no middleware", func(t *testing.T) {
+		app := New(KConfig{CORS: CORSConfig{Disabled: true}})
+		resp := preflight(app, "https://app.example.com")
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want empty", got)
+		}
+	})
+}
+
+func TestBodyLimitRejectsOversizedBody(t *testing.T) {
+	port := freePort(t)
+	app := New(KConfig{
+		DisableHealth: true,
+		Port:          port,
+		Env:           "production",
+		Server:        ServerConfig{BodyLimit: 16},
+	})
+	app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+		return []httpx.Route{
+			httpx.POST("/upload", func(c *httpx.Ctx) error { return c.NoContent() }),
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.ListenWithContext(ctx)
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/upload", port)
+	waitForServer(t, url)
+
+	resp, err := http.Post(url, "text/plain", strings.NewReader("tiny"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("small body StatusCode = %v, want 204", resp.StatusCode)
+	}
+
+	resp, err = http.Post(url, "text/plain", strings.NewReader(strings.Repeat("x", 1024)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large body StatusCode = %v, want 413", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Error-Code"); got != "REQUEST_ENTITY_TOO_LARGE" {
+		t.Errorf("X-Error-Code = %q, want REQUEST_ENTITY_TOO_LARGE", got)
+	}
+}
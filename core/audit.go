@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// DefaultAuditRedactKeys are redacted from audit body snapshots in addition
+// to DefaultRedactKeys and the keys passed to WithAuditBody.
+var DefaultAuditRedactKeys = []string{"card_number", "cvv"}
+
+// AuditEvent records a state-changing request.
+type AuditEvent struct {
+	Time      time.Time         `json:"time"`
+	User      any               `json:"user,omitempty"` // as stored with Ctx.SetUser; nil for anonymous requests
+	Method    string            `json:"method"`
+	Route     string            `json:"route,omitempty"` // route pattern; empty when no route matched
+	Path      string            `json:"path"`
+	Params    map[string]string `json:"params,omitempty"`
+	Status    int               `json:"status"`
+	RequestID string            `json:"request_id,omitempty"`
+	Body      json.RawMessage   `json:"body,omitempty"` // redacted JSON request body, with WithAuditBody
+}
+
+// AuditSink writes audit events, e.g. to an append-only table or a queue.
+// It runs before the response is sent, so slow sinks should buffer.
+type AuditSink func(ctx context.Context, e AuditEvent) error
+
+// AuditOption configures AuditLog.
+type AuditOption func(*auditConfig)
+
+type auditConfig struct {
+	body    bool
+	redact  []string // lower-cased
+	onError func(ctx context.Context, e AuditEvent, err error)
+}
+
+// WithAuditBody adds a snapshot of JSON request bodies to audit events. The
+// values of DefaultRedactKeys, DefaultAuditRedactKeys and redact are replaced
+// at any depth, matching keys case-insensitively.
+func WithAuditBody(redact ...string) AuditOption {
+	return func(c *auditConfig) {
+		c.body = true
+		c.redact = append(c.redact, lowerAll(redact)...)
+	}
+}
+
+// AuditLog returns a middleware passing an AuditEvent to sink for every
+// POST, PUT, PATCH and DELETE request once it has been handled. A failing
+// sink never fails the request; the error is logged instead. App.SetAuditSink
+// installs it app-wide and also counts failures in the metrics collector.
+func AuditLog(sink AuditSink, opts ...AuditOption) fiber.Handler {
+	log := logger.NewLogger(false)
+	return auditLog(sink, func(ctx context.Context, e AuditEvent, err error) {
+		log.ErrorCtx(ctx, "Audit sink failed for [%s] %s: %v", e.Method, e.Path, err)
+	}, opts...)
+}
+
+func auditLog(sink AuditSink, onError func(context.Context, AuditEvent, error), opts ...AuditOption) fiber.Handler {
+	cfg := auditConfig{
+		redact:  lowerAll(append(append([]string(nil), DefaultRedactKeys...), DefaultAuditRedactKeys...)),
+		onError: onError,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(c *fiber.Ctx) error {
+		if !isStateChanging(c.Method()) {
+			return c.Next()
+		}
+		e := AuditEvent{
+			Time:   ClockFrom(c).Now(),
+			Method: strings.Clone(c.Method()),
+			Path:   strings.Clone(c.Path()),
+		}
+		if cfg.body {
+			e.Body = redactJSON(c.Body(), cfg.redact)
+		}
+
+		err := c.Next()
+
+		ctx := &httpx.Ctx{Ctx: c}
+		e.User = ctx.User()
+		e.RequestID = ctx.RequestID()
+		e.Status = resolveStatus(c, err)
+		if route, ok := matchedRoute(c, err); ok {
+			e.Route = strings.Clone(route)
+			for k, v := range c.AllParams() {
+				if e.Params == nil {
+					e.Params = make(map[string]string)
+				}
+				e.Params[k] = strings.Clone(v)
+			}
+		}
+		if serr := sink(c.UserContext(), e); serr != nil {
+			cfg.onError(c.UserContext(), e, serr)
+		}
+		return err
+	}
+}
+
+// SetAuditSink records an AuditEvent for every state-changing request, as
+// AuditLog does. Sink failures are logged and counted when the metrics
+// collector implements contracts.AuditCollector.
+func (a *App) SetAuditSink(sink AuditSink, opts ...AuditOption) {
+	a.audit = auditLog(sink, func(ctx context.Context, e AuditEvent, err error) {
+		a.logger.ErrorCtx(ctx, "Audit sink failed for [%s] %s: %v", e.Method, e.Path, err)
+		if ac, ok := a.metricsCollector.(contracts.AuditCollector); ok {
+			ac.RecordAuditFailure()
+		}
+	}, opts...)
+}
+
+// auditMiddleware runs the handler installed by SetAuditSink, if any.
+func (a *App) auditMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if a.audit == nil {
+			return c.Next()
+		}
+		return a.audit(c)
+	}
+}
+
+func isStateChanging(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// redactJSON returns body with the values of keys replaced by
+// logger.Redacted at any depth, or nil when body is not JSON.
+func redactJSON(body []byte, keys []string) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+	out, err := json.Marshal(redactValue(v, keys))
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+func redactValue(v any, keys []string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if slices.Contains(keys, strings.ToLower(k)) {
+				t[k] = logger.Redacted
+			} else {
+				t[k] = redactValue(val, keys)
+			}
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redactValue(val, keys)
+		}
+	}
+	return v
+}
+
+func lowerAll(keys []string) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = strings.ToLower(k)
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+type auditFailureCollector struct {
+	mockMetricsCollector
+	failures int
+}
+
+func (a *auditFailureCollector) RecordAuditFailure() { a.failures++ }
+
+type orderDTO struct {
+	Item string `json:"item"`
+}
+
+// newAuditApp serves /orders/:id routes behind a stand-in guard storing
+// "alice" and records audit events into the returned slice.
+func newAuditApp(sinkErr error, opts ...AuditOption) (*App, *[]AuditEvent) {
+	app := New(KConfig{DisableHealth: true})
+	var events []AuditEvent
+	app.SetAuditSink(func(_ context.Context, e AuditEvent) error {
+		events = append(events, e)
+		return sinkErr
+	}, opts...)
+	app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+		return []httpx.Route{
+			httpx.GET("/orders/:id", func(c *httpx.Ctx) error { return c.OK(nil) }),
+			httpx.PUT("/orders/:id", func(c *httpx.Ctx) error {
+				var body orderDTO
+				if err := c.ParseBody(&body); err != nil {
+					return err
+				}
+				return c.OK(body)
+			}).Use(asUser("alice")),
+			httpx.DELETE("/orders/:id", func(c *httpx.Ctx) error { return NotFound("order not found") }),
+		}
+	}))
+	return app, &events
+}
+
+func TestAuditLog(t *testing.T) {
+	app, events := newAuditApp(nil, WithAuditBody("Note"))
+
+	body := `{"item":"book","password":"p4ss","payment":{"card_number":"4111111111111111","CVV":"123"},"note":"gift"}`
+	req := httptest.NewRequest("PUT", "/orders/42", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	app.Fiber().Test(httptest.NewRequest("GET", "/orders/42", nil))    //nolint
+	app.Fiber().Test(httptest.NewRequest("DELETE", "/orders/43", nil)) //nolint
+
+	if len(*events) != 2 {
+		t.Fatalf("recorded %d events, want 2 (GET is not audited)", len(*events))
+	}
+	put, del := (*events)[0], (*events)[1]
+	if put.Method != "PUT" || put.Route != "/orders/:id" || put.Path != "/orders/42" ||
+		put.Params["id"] != "42" || put.Status != 200 || put.User != "alice" || put.RequestID != resp.Header.Get("X-Request-ID") || put.Time.IsZero() {
+		t.Errorf("PUT event = %+v", put)
+	}
+	var snapshot map[string]any
+	if err := json.Unmarshal(put.Body, &snapshot); err != nil {
+		t.Fatalf("body snapshot %s: %v", put.Body, err)
+	}
+	payment, _ := snapshot["payment"].(map[string]any)
+	if snapshot["item"] != "book" || snapshot["password"] != "[REDACTED]" || snapshot["note"] != "[REDACTED]" ||
+		payment["card_number"] != "[REDACTED]" || payment["CVV"] != "[REDACTED]" {
+		t.Errorf("body snapshot = %s", put.Body)
+	}
+	if del.Status != 404 || del.User != nil || del.Params["id"] != "43" || del.Body != nil {
+		t.Errorf("DELETE event = %+v", del)
+	}
+}
+
+func TestAuditLogSinkFailure(t *testing.T) {
+	app, events := newAuditApp(errors.New("disk full"))
+	var buf bytes.Buffer
+	app.logger = app.logger.WithWriter(&buf)
+	mc := &auditFailureCollector{}
+	app.SetMetricsCollector(mc)
+
+	req := httptest.NewRequest("PUT", "/orders/42", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("status = %d, want 200 despite the sink failure", resp.StatusCode)
+	}
+	if len(*events) != 1 || (*events)[0].Body != nil {
+		t.Errorf("events = %+v, want one without a body snapshot", *events)
+	}
+	if mc.failures != 1 {
+		t.Errorf("audit failures = %d, want 1", mc.failures)
+	}
+	if !strings.Contains(buf.String(), "Audit sink failed for [PUT] /orders/42: disk full") {
+		t.Errorf("logs = %s", buf.String())
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// DefaultBodyLogMaxBytes caps each body logged by BodyLogger.
+const DefaultBodyLogMaxBytes = 4096
+
+// BodyLogConfig configures BodyLogger.
+type BodyLogConfig struct {
+	Enabled    bool           // KConfig.LogBodies only: install BodyLogger outside production
+	MaxBytes   int            // logged bytes per body, after pretty-printing; defaults to DefaultBodyLogMaxBytes
+	RedactKeys []string       // JSON keys redacted at any depth, in addition to DefaultRedactKeys
+	Logger     *logger.Logger // defaults to a development logger; KConfig.LogBodies uses the app logger
+}
+
+// BodyLogger returns a debugging middleware that logs request and response
+// bodies at DEBUG. JSON bodies are redacted and pretty-printed, bodies over
+// MaxBytes are truncated, and binary or streamed bodies are left out. The
+// request body stays readable by handlers.
+//
+// Bodies can hold personal data; enable it temporarily, e.g. in staging
+// through KConfig.LogBodies.
+func BodyLogger(cfg BodyLogConfig) fiber.Handler {
+	log := cfg.Logger
+	if log == nil {
+		log = logger.NewLogger(false)
+	}
+	return bodyLogger(cfg, func() *logger.Logger { return log })
+}
+
+// bodyLogger builds the middleware, logging through the logger returned by
+// log so the app logger can be replaced after New.
+func bodyLogger(cfg BodyLogConfig, log func() *logger.Logger) fiber.Handler {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultBodyLogMaxBytes
+	}
+	redact := lowerAll(append(append([]string(nil), DefaultRedactKeys...), cfg.RedactKeys...))
+	return func(c *fiber.Ctx) error {
+		if body := c.Body(); len(body) > 0 {
+			log().DebugCtx(c.UserContext(), "Request body [%s] %s:\n%s", c.Method(), c.Path(),
+				formatBody(body, c.Get(fiber.HeaderContentType), redact, cfg.MaxBytes))
+		}
+
+		err := c.Next()
+
+		resp := c.Response()
+		switch {
+		case resp.IsBodyStream():
+			log().DebugCtx(c.UserContext(), "Response body [%d] %s: streamed, not logged", resp.StatusCode(), c.Path())
+		case len(resp.Body()) > 0:
+			log().DebugCtx(c.UserContext(), "Response body [%d] %s:\n%s", resp.StatusCode(), c.Path(),
+				formatBody(resp.Body(), string(resp.Header.ContentType()), redact, cfg.MaxBytes))
+		}
+		return err
+	}
+}
+
+// formatBody renders a body for the log.
+func formatBody(body []byte, contentType string, redact []string, maxBytes int) string {
+	if !isTextual(contentType) {
+		return fmt.Sprintf("<%d bytes of %s>", len(body), contentType)
+	}
+	text := string(body)
+	if redacted := redactJSON(body, redact); redacted != nil {
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, redacted, "", "  ") == nil {
+			text = pretty.String()
+		}
+	}
+	if len(text) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		return fmt.Sprintf("%s… (truncated, %d bytes total)", text[:cut], len(text))
+	}
+	return text
+}
+
+// isTextual reports whether a content type is worth logging as text.
+func isTextual(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	if ct == "" || strings.HasPrefix(ct, "text/") {
+		return true
+	}
+	for _, sub := range []string{"json", "xml", "x-www-form-urlencoded", "javascript"} {
+		if strings.Contains(ct, sub) {
+			return true
+		}
+	}
+	return false
+}
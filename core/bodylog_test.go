@@ -0,0 +1,106 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+type bodyLogDTO struct {
+	Name     string `json:"name" validate:"required"`
+	Password string `json:"password"`
+	Pin      string `json:"pin"`
+}
+
+func newBodyLogApp(cfg KConfig) (*App, *bytes.Buffer) {
+	cfg.DisableHealth = true
+	app := New(cfg)
+	var buf bytes.Buffer
+	app.logger = app.logger.WithWriter(&buf)
+	app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+		return []httpx.Route{
+			httpx.POST("/users", func(c *httpx.Ctx) error {
+				var in bodyLogDTO
+				if err := c.ParseBody(&in); err != nil {
+					return err
+				}
+				return c.Created(fiber.Map{"name": in.Name, "password": in.Password})
+			}),
+			httpx.GET("/file", func(c *httpx.Ctx) error {
+				c.Set(fiber.HeaderContentType, "application/octet-stream")
+				return c.Send([]byte{0x00, 0x01, 0x02})
+			}),
+			httpx.GET("/stream", func(c *httpx.Ctx) error {
+				return c.SendStream(strings.NewReader("chunk"))
+			}),
+		}
+	}))
+	return app, &buf
+}
+
+func TestBodyLogger(t *testing.T) {
+	app, logs := newBodyLogApp(KConfig{Env: "development", LogBodies: BodyLogConfig{Enabled: true, RedactKeys: []string{"PIN"}}})
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"ana","password":"hunter2","pin":"1234"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 201 || !strings.Contains(string(body), `"name":"ana"`) {
+		t.Fatalf("response = %d %s; ParseBody must still see the body", resp.StatusCode, body)
+	}
+	app.Fiber().Test(httptest.NewRequest("GET", "/file", nil))   //nolint
+	app.Fiber().Test(httptest.NewRequest("GET", "/stream", nil)) //nolint
+
+	out := logs.String()
+	for _, want := range []string{
+		"Request body [POST] /users:\n{\n  \"name\": \"ana\",\n  \"password\": \"[REDACTED]\",\n  \"pin\": \"[REDACTED]\"\n}",
+		"Response body [201] /users:\n{\n  \"name\": \"ana\",\n  \"password\": \"[REDACTED]\"\n}",
+		"Response body [200] /file:\n<3 bytes of application/octet-stream>",
+		"Response body [200] /stream: streamed, not logged",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logs missing %q\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, "1234") {
+		t.Errorf("secret logged:\n%s", out)
+	}
+}
+
+func TestBodyLoggerTruncates(t *testing.T) {
+	app, logs := newBodyLogApp(KConfig{Env: "development", LogBodies: BodyLogConfig{Enabled: true, MaxBytes: 10}})
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`name=`+strings.Repeat("x", 40)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if _, err := app.Fiber().Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Request body [POST] /users:\nname=xxxxx… (truncated, 45 bytes total)"; !strings.Contains(logs.String(), want) {
+		t.Errorf("logs missing %q\n%s", want, logs.String())
+	}
+}
+
+func TestBodyLoggerOffInProduction(t *testing.T) {
+	app, logs := newBodyLogApp(KConfig{Env: "production", LogBodies: BodyLogConfig{Enabled: true}})
+	// A development logger, so only the missing middleware keeps bodies out.
+	app.logger = logger.NewLogger(false).WithWriter(logs)
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"ana"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := app.Fiber().Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(logs.String(), "Request body") {
+		t.Errorf("bodies logged in production:\n%s", logs.String())
+	}
+}
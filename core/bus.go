@@ -0,0 +1,315 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// DefaultBusBufferSize is the queue length of each MemoryBus subscriber.
+const DefaultBusBufferSize = 64
+
+// DefaultBusMaxRequeues is how many times MemoryBus redelivers a message
+// nacked with requeue before dropping it.
+const DefaultBusMaxRequeues = 10
+
+// ErrBusClosed is returned by MemoryBus methods called after Close.
+var ErrBusClosed = errors.New("bus: closed")
+
+// BusOverflow decides what Publish does when a subscriber queue is full.
+type BusOverflow int
+
+const (
+	// BusOverflowBlock makes Publish wait for room, or for its ctx to end.
+	BusOverflowBlock BusOverflow = iota
+	// BusOverflowDrop drops the message for that subscriber and counts it
+	// in MemoryBus.Dropped.
+	BusOverflowDrop
+)
+
+// MemoryBusOption configures NewMemoryBus.
+type MemoryBusOption func(*MemoryBus)
+
+// WithBusBufferSize sets the queue length of each subscriber,
+// DefaultBusBufferSize by default.
+func WithBusBufferSize(n int) MemoryBusOption {
+	return func(b *MemoryBus) { b.bufferSize = n }
+}
+
+// WithBusOverflow sets the policy for full subscriber queues,
+// BusOverflowBlock by default.
+func WithBusOverflow(policy BusOverflow) MemoryBusOption {
+	return func(b *MemoryBus) { b.overflow = policy }
+}
+
+// WithBusRetries makes a failing handler run up to n more times, right
+// away, before the error is logged.
+func WithBusRetries(n int) MemoryBusOption {
+	return func(b *MemoryBus) { b.retries = n }
+}
+
+// WithBusMaxRequeues sets how many times a message nacked with requeue is
+// delivered again before it is dropped, DefaultBusMaxRequeues by default.
+func WithBusMaxRequeues(n int) MemoryBusOption {
+	return func(b *MemoryBus) { b.maxRequeues = n }
+}
+
+// WithBusLogger sets the logger for handler failures.
+func WithBusLogger(l *logger.Logger) MemoryBusOption {
+	return func(b *MemoryBus) { b.logger = l }
+}
+
+// MemoryBus is an in-process message broker implementing
+// contracts.Publisher and contracts.Subscriber, for local development,
+// demos and integration tests.
+//
+// Every subscriber of a topic receives each message published to it,
+// in publish order, through its own buffered queue consumed by one
+// goroutine. Messages published to a topic without subscribers are
+// discarded. Handler errors and panics are logged.
+type MemoryBus struct {
+	bufferSize  int
+	overflow    BusOverflow
+	retries     int
+	maxRequeues int
+	logger      *logger.Logger
+
+	mu         sync.RWMutex
+	subs       map[string][]*busSubscriber
+	closed     bool
+	publishing sync.WaitGroup // Publish calls in progress
+	consumers  sync.WaitGroup
+	dropped    atomic.Int64
+}
+
+type busSubscriber struct {
+	topic   string
+	ctx     context.Context
+	handler contracts.MessageHandler
+	queue   chan contracts.Message
+	done    chan struct{} // closed when the consumer goroutine exits
+
+	requeues int // of the message being delivered; used by the consumer only
+
+	mu       sync.Mutex
+	requeued []requeuedMessage // nacked with requeue, delivered before the queue
+	closed   bool              // set by Close; requeued messages are dropped
+}
+
+type requeuedMessage struct {
+	msg      contracts.Message
+	requeues int
+}
+
+var (
+	_ contracts.Publisher  = (*MemoryBus)(nil)
+	_ contracts.Subscriber = (*MemoryBus)(nil)
+)
+
+// NewMemoryBus returns a MemoryBus without subscribers.
+func NewMemoryBus(opts ...MemoryBusOption) *MemoryBus {
+	b := &MemoryBus{
+		bufferSize:  DefaultBusBufferSize,
+		maxRequeues: DefaultBusMaxRequeues,
+		subs:        make(map[string][]*busSubscriber),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.logger == nil {
+		b.logger = logger.NewLogger(false)
+	}
+	return b
+}
+
+// Publish implements contracts.Publisher, queueing msg for every subscriber
+// of msg.Topic. With BusOverflowBlock it returns ctx.Err() if ctx ends while
+// waiting for room in a queue.
+func (b *MemoryBus) Publish(ctx context.Context, msg contracts.Message) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBusClosed
+	}
+	b.publishing.Add(1)
+	subs := b.subs[msg.Topic]
+	b.mu.RUnlock()
+	defer b.publishing.Done()
+
+	for _, s := range subs {
+		m := msg
+		m.Headers = maps.Clone(msg.Headers)
+		if b.overflow == BusOverflowDrop {
+			select {
+			case s.queue <- m:
+			case <-s.done:
+			default:
+				b.dropped.Add(1)
+			}
+			continue
+		}
+		select {
+		case s.queue <- m:
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe implements contracts.Subscriber. It may be called before or
+// after messages are published to topic; the handler receives those
+// published from then on, until ctx ends or the bus is closed.
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string, handler contracts.MessageHandler) error {
+	return b.subscribe(ctx, topic, func(*busSubscriber) contracts.MessageHandler { return handler })
+}
+
+// subscribe adds a subscriber whose handler is built by newHandler.
+func (b *MemoryBus) subscribe(ctx context.Context, topic string, newHandler func(*busSubscriber) contracts.MessageHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+	s := &busSubscriber{
+		topic: topic,
+		ctx:   ctx,
+		queue: make(chan contracts.Message, max(b.bufferSize, 0)),
+		done:  make(chan struct{}),
+	}
+	s.handler = newHandler(s)
+	b.subs[topic] = append(slices.Clone(b.subs[topic]), s)
+	b.consumers.Add(1)
+	go b.consume(s)
+	return nil
+}
+
+// Close implements contracts.Publisher and contracts.Subscriber. It stops
+// accepting messages, waits for Publish calls in progress, then for every
+// subscriber to handle the messages already queued. Messages nacked with
+// requeue are no longer redelivered.
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	b.publishing.Wait()
+	b.mu.Lock()
+	for _, subs := range b.subs {
+		for _, s := range subs {
+			s.close()
+		}
+	}
+	b.subs = make(map[string][]*busSubscriber)
+	b.mu.Unlock()
+	b.consumers.Wait()
+	return nil
+}
+
+// Dropped returns how many deliveries BusOverflowDrop has discarded.
+func (b *MemoryBus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// consume delivers queued messages to s until its queue is closed by Close
+// or its ctx ends.
+func (b *MemoryBus) consume(s *busSubscriber) {
+	defer b.consumers.Done()
+	defer close(s.done)
+	for {
+		if r, ok := s.nextRequeued(); ok {
+			if s.ctx.Err() != nil {
+				b.unsubscribe(s)
+				return
+			}
+			s.requeues = r.requeues
+			b.deliver(s, r.msg)
+			continue
+		}
+		select {
+		case msg, ok := <-s.queue:
+			if !ok {
+				return
+			}
+			s.requeues = 0
+			b.deliver(s, msg)
+		case <-s.ctx.Done():
+			b.unsubscribe(s)
+			return
+		}
+	}
+}
+
+func (b *MemoryBus) deliver(s *busSubscriber, msg contracts.Message) {
+	var err error
+	for attempt := 0; attempt <= b.retries; attempt++ {
+		if err = safeHandle(s.ctx, s.handler, msg); err == nil {
+			return
+		}
+	}
+	b.logger.ErrorCtx(s.ctx, "Message handler for topic %s failed after %d attempt(s): %v", s.topic, b.retries+1, err)
+}
+
+// requeue schedules msg, requeued requeues times before, for redelivery.
+// It reports false once the bus is closed.
+func (s *busSubscriber) requeue(msg contracts.Message, requeues int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.requeued = append(s.requeued, requeuedMessage{msg: msg, requeues: requeues + 1})
+	return true
+}
+
+func (s *busSubscriber) nextRequeued() (requeuedMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requeued) == 0 {
+		return requeuedMessage{}, false
+	}
+	r := s.requeued[0]
+	s.requeued = s.requeued[1:]
+	return r, true
+}
+
+// close drops the requeued messages and closes the queue, so the consumer
+// returns once the queued messages are handled.
+func (s *busSubscriber) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.requeued = nil
+	s.mu.Unlock()
+	close(s.queue)
+}
+
+func (b *MemoryBus) unsubscribe(s *busSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subs := b.subs[s.topic]
+	if i := slices.Index(subs, s); i >= 0 {
+		b.subs[s.topic] = slices.Delete(slices.Clone(subs), i, i+1)
+	}
+}
+
+// safeHandle runs handler, turning a panic into an error.
+func safeHandle(ctx context.Context, handler contracts.MessageHandler, msg contracts.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("message handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, msg)
+}
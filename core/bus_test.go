@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// collector records the payloads a handler receives.
+type collector struct {
+	mu   sync.Mutex
+	got  []string
+	wait sync.WaitGroup
+}
+
+func newCollector(n int) *collector {
+	c := &collector{}
+	c.wait.Add(n)
+	return c
+}
+
+func (c *collector) handle(_ context.Context, msg contracts.Message) error {
+	c.mu.Lock()
+	c.got = append(c.got, string(msg.Payload))
+	c.mu.Unlock()
+	c.wait.Done()
+	return nil
+}
+
+func (c *collector) payloads() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.got...)
+}
+
+func publishN(t *testing.T, b *MemoryBus, topic string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := b.Publish(context.Background(), contracts.Message{Topic: topic, Payload: []byte(fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMemoryBusFanOutAndOrder(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemoryBus(WithBusBufferSize(4))
+	defer b.Close()
+
+	first, second, other := newCollector(100), newCollector(100), newCollector(0)
+	b.Subscribe(ctx, "orders", first.handle)  //nolint
+	b.Subscribe(ctx, "orders", second.handle) //nolint
+	b.Subscribe(ctx, "users", other.handle)   //nolint
+	publishN(t, b, "orders", 100)
+	first.wait.Wait()
+	second.wait.Wait()
+
+	want := make([]string, 100)
+	for i := range want {
+		want[i] = fmt.Sprint(i)
+	}
+	for name, c := range map[string]*collector{"first": first, "second": second} {
+		if got := c.payloads(); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s subscriber got %v, want messages in publish order", name, got)
+		}
+	}
+	if got := other.payloads(); len(got) != 0 {
+		t.Errorf("subscriber of another topic got %v", got)
+	}
+
+	late := newCollector(1)
+	b.Subscribe(ctx, "orders", late.handle) //nolint
+	publishN(t, b, "orders", 1)
+	late.wait.Wait()
+	if got := late.payloads(); len(got) != 1 || got[0] != "0" {
+		t.Errorf("late subscriber got %v, want only the message published after it subscribed", got)
+	}
+}
+
+func TestMemoryBusOverflow(t *testing.T) {
+	release := make(chan struct{})
+	blocking := func(context.Context, contracts.Message) error { <-release; return nil }
+
+	t.Run("drop", func(t *testing.T) {
+		b := NewMemoryBus(WithBusBufferSize(2), WithBusOverflow(BusOverflowDrop))
+		b.Subscribe(context.Background(), "t", blocking) //nolint
+		// One message is held by the handler and two fill the queue; allow
+		// for the consumer not having picked up the first one yet.
+		publishN(t, b, "t", 10)
+		if d := b.Dropped(); d < 7 || d > 8 {
+			t.Errorf("Dropped = %d, want 7 or 8", d)
+		}
+		close(release)
+		b.Close()
+	})
+
+	t.Run("block", func(t *testing.T) {
+		b := NewMemoryBus(WithBusBufferSize(1))
+		hold := make(chan struct{})
+		b.Subscribe(context.Background(), "t", func(context.Context, contracts.Message) error { <-hold; return nil }) //nolint
+		publishN(t, b, "t", 2)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := b.Publish(ctx, contracts.Message{Topic: "t"}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Publish on a full queue err = %v, want DeadlineExceeded", err)
+		}
+		close(hold)
+		if err := b.Publish(context.Background(), contracts.Message{Topic: "t"}); err != nil {
+			t.Errorf("Publish after room was made: %v", err)
+		}
+		b.Close()
+	})
+}
+
+func TestMemoryBusHandlerErrors(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewMemoryBus(WithBusRetries(2), WithBusLogger(logger.NewLogger(false).WithWriter(&buf)))
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	b.Subscribe(context.Background(), "t", func(_ context.Context, msg contracts.Message) error { //nolint
+		mu.Lock()
+		attempts[string(msg.Payload)]++
+		n := attempts[string(msg.Payload)]
+		mu.Unlock()
+		switch string(msg.Payload) {
+		case "flaky":
+			if n < 3 {
+				return errors.New("db blip")
+			}
+			return nil
+		case "panics":
+			panic("boom")
+		}
+		return errors.New("always fails")
+	})
+	for _, p := range []string{"flaky", "broken", "panics"} {
+		b.Publish(context.Background(), contracts.Message{Topic: "t", Payload: []byte(p)}) //nolint
+	}
+	b.Close()
+
+	if attempts["flaky"] != 3 || attempts["broken"] != 3 || attempts["panics"] != 3 {
+		t.Errorf("attempts = %v, want 3 each", attempts)
+	}
+	logs := buf.String()
+	if strings.Contains(logs, "db blip") {
+		t.Errorf("a handler that succeeded on retry was logged:\n%s", logs)
+	}
+	for _, want := range []string{"topic t failed after 3 attempt(s): always fails", "message handler panicked: boom"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestMemoryBusClose(t *testing.T) {
+	b := NewMemoryBus(WithBusBufferSize(100))
+	var mu sync.Mutex
+	handled := 0
+	b.Subscribe(context.Background(), "t", func(context.Context, contracts.Message) error { //nolint
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				b.Publish(context.Background(), contracts.Message{Topic: "t"}) //nolint
+			}
+		}()
+	}
+	wg.Wait()
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if handled != 40 {
+		t.Errorf("handled %d messages before Close returned, want all 40 queued", handled)
+	}
+	if err := b.Publish(context.Background(), contracts.Message{Topic: "t"}); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Publish after Close err = %v", err)
+	}
+	if err := b.Subscribe(context.Background(), "t", nil); !errors.Is(err, ErrBusClosed) {
+		t.Errorf("Subscribe after Close err = %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestMemoryBusUnsubscribeOnCancel(t *testing.T) {
+	b := NewMemoryBus(WithBusBufferSize(0))
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newCollector(1)
+	b.Subscribe(ctx, "t", c.handle) //nolint
+	publishN(t, b, "t", 1)
+	c.wait.Wait()
+	cancel()
+	for {
+		b.mu.RLock()
+		n := len(b.subs["t"])
+		b.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// With an unbuffered queue, Publish would block forever on a
+	// subscriber that is gone.
+	for i := 0; i < 3; i++ {
+		publishN(t, b, "t", 1)
+	}
+	if got := c.payloads(); len(got) != 1 {
+		t.Errorf("cancelled subscriber got %v", got)
+	}
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultCacheJanitorInterval is how often a MemoryCache removes expired
+// entries in the background.
+const DefaultCacheJanitorInterval = time.Minute
+
+// MemoryCache is an in-process contracts.Cache with per-key TTLs and an
+// optional entry limit, evicting the least recently used entries first.
+// Expired entries are dropped when read and by a background janitor; Close
+// stops the janitor. It is safe for concurrent use.
+type MemoryCache struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	lru        *list.List // front is the most recently used
+	maxEntries int
+	clock      Clock
+
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time // zero means no expiry
+}
+
+var (
+	_ contracts.Cache      = (*MemoryCache)(nil)
+	_ BatchCache           = (*MemoryCache)(nil)
+	_ PrefixInvalidator    = (*MemoryCache)(nil)
+	_ contracts.ClockAware = (*MemoryCache)(nil)
+	_ io.Closer            = (*MemoryCache)(nil)
+)
+
+// MemoryCacheOption configures NewMemoryCache.
+type MemoryCacheOption func(*MemoryCache)
+
+// WithCacheMaxEntries bounds the number of entries; when full, Set evicts
+// the least recently read or written entry. n <= 0 means no limit.
+func WithCacheMaxEntries(n int) MemoryCacheOption {
+	return func(c *MemoryCache) { c.maxEntries = n }
+}
+
+// WithCacheJanitorInterval sets how often expired entries are removed in the
+// background, DefaultCacheJanitorInterval by default. d <= 0 disables the
+// janitor; expired entries are then only dropped when read.
+func WithCacheJanitorInterval(d time.Duration) MemoryCacheOption {
+	return func(c *MemoryCache) { c.interval = d }
+}
+
+// NewMemoryCache returns an empty MemoryCache, a zero-dependency Cache for
+// small services and local development. Register it with App.RegisterCache
+// to have the janitor stopped on shutdown.
+//
+//	cache := core.NewMemoryCache(core.WithCacheMaxEntries(10_000))
+//	app.RegisterCache(cache)
+func NewMemoryCache(opts ...MemoryCacheOption) *MemoryCache {
+	c := &MemoryCache{
+		items:    make(map[string]*list.Element),
+		lru:      list.New(),
+		clock:    contracts.SystemClock,
+		interval: DefaultCacheJanitorInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.interval > 0 {
+		go c.janitor()
+	} else {
+		close(c.done)
+	}
+	return c
+}
+
+// Get implements contracts.Cache. It returns contracts.ErrCacheMiss for
+// missing and expired keys.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.live(key)
+	if !ok {
+		return nil, contracts.ErrCacheMiss
+	}
+	c.lru.MoveToFront(el)
+	return cloneBytes(el.Value.(*memoryEntry).value), nil
+}
+
+// Set implements contracts.Cache. A ttl of zero or less never expires.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl)
+	return nil
+}
+
+// set stores an entry; c.mu must be held.
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryEntry{key: key, value: cloneBytes(value)}
+	if ttl > 0 {
+		entry.expires = c.clock.Now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.lru.PushFront(entry)
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Delete implements contracts.Cache. Deleting a missing key is not an error.
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	return nil
+}
+
+// Exists implements contracts.Cache. It reports false for expired keys and,
+// unlike Get, does not count as a use for eviction.
+func (c *MemoryCache) Exists(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.live(key)
+	return ok, nil
+}
+
+// Len returns the number of entries held, including expired ones the
+// janitor has not removed yet.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// SetClock implements contracts.ClockAware; TTLs are measured with clock.
+func (c *MemoryCache) SetClock(clock Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
+// Close stops the janitor. The cache stays usable; it is safe to call Close
+// more than once.
+func (c *MemoryCache) Close() error {
+	c.stopOnce.Do(func() { close(c.stop) })
+	<-c.done
+	return nil
+}
+
+// live returns the unexpired entry for key, dropping it when expired; c.mu
+// must be held.
+func (c *MemoryCache) live(key string) (*list.Element, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	if c.expired(el.Value.(*memoryEntry), c.clock.Now()) {
+		c.remove(el)
+		return nil, false
+	}
+	return el, true
+}
+
+func (c *MemoryCache) expired(e *memoryEntry, now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
+
+func (c *MemoryCache) janitor() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+// deleteExpired removes every expired entry.
+func (c *MemoryCache) deleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	for _, el := range c.items {
+		if c.expired(el.Value.(*memoryEntry), now) {
+			c.remove(el)
+		}
+	}
+}
+
+// RegisterCache makes cache the App cache, returned by Cache. A cache
+// implementing io.Closer, such as MemoryCache, is closed on shutdown, and
+// one implementing contracts.ClockAware receives the App clock.
+func (a *App) RegisterCache(cache contracts.Cache) {
+	a.cache = cache
+	if ca, ok := cache.(contracts.ClockAware); ok {
+		ca.SetClock(a.clock)
+	}
+	if closer, ok := cache.(io.Closer); ok {
+		a.OnShutdown(func(context.Context) error {
+			return closer.Close()
+		})
+	}
+}
+
+// Cache returns the cache registered with RegisterCache, or nil.
+func (a *App) Cache() contracts.Cache {
+	return a.cache
+}
+
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
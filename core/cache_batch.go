@@ -0,0 +1,109 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// BatchCache is implemented by caches that read and write several keys in
+// one round trip, such as MemoryCache, and can report the remaining TTL of
+// a key. Use CacheMGet and CacheMSet to batch when the cache supports it
+// and fall back to one call per key otherwise.
+type BatchCache interface {
+	// MGet returns the values of the keys that exist; missing and expired
+	// keys are absent from the map.
+	MGet(ctx context.Context, keys []string) (map[string][]byte, error)
+	// MSet stores every entry with the same ttl; ttl <= 0 never expires.
+	MSet(ctx context.Context, entries map[string][]byte, ttl time.Duration) error
+	// TTL returns the time left before key expires. ok is false when the
+	// key does not exist; a zero ttl with ok true means no expiry.
+	TTL(ctx context.Context, key string) (ttl time.Duration, ok bool, err error)
+}
+
+// CacheMGet reads keys from c with one MGet when c is a BatchCache, and
+// with MGetFallback otherwise.
+func CacheMGet(ctx context.Context, c contracts.Cache, keys []string) (map[string][]byte, error) {
+	if bc, ok := c.(BatchCache); ok {
+		return bc.MGet(ctx, keys)
+	}
+	return MGetFallback(ctx, c, keys)
+}
+
+// CacheMSet stores entries in c with one MSet when c is a BatchCache, and
+// with MSetFallback otherwise.
+func CacheMSet(ctx context.Context, c contracts.Cache, entries map[string][]byte, ttl time.Duration) error {
+	if bc, ok := c.(BatchCache); ok {
+		return bc.MSet(ctx, entries, ttl)
+	}
+	return MSetFallback(ctx, c, entries, ttl)
+}
+
+// MGetFallback implements BatchCache.MGet over a plain Cache, with one Get
+// per key. It stops at the first error other than contracts.ErrCacheMiss.
+func MGetFallback(ctx context.Context, c contracts.Cache, keys []string) (map[string][]byte, error) {
+	out := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		v, err := c.Get(ctx, key)
+		if errors.Is(err, contracts.ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// MSetFallback implements BatchCache.MSet over a plain Cache, with one Set
+// per entry. It stops at the first error.
+func MSetFallback(ctx context.Context, c contracts.Cache, entries map[string][]byte, ttl time.Duration) error {
+	for key, v := range entries {
+		if err := c.Set(ctx, key, v, ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MGet implements BatchCache.
+func (c *MemoryCache) MGet(_ context.Context, keys []string) (map[string][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if el, ok := c.live(key); ok {
+			c.lru.MoveToFront(el)
+			out[key] = cloneBytes(el.Value.(*memoryEntry).value)
+		}
+	}
+	return out, nil
+}
+
+// MSet implements BatchCache.
+func (c *MemoryCache) MSet(_ context.Context, entries map[string][]byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, v := range entries {
+		c.set(key, v, ttl)
+	}
+	return nil
+}
+
+// TTL implements BatchCache.
+func (c *MemoryCache) TTL(_ context.Context, key string) (time.Duration, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.live(key)
+	if !ok {
+		return 0, false, nil
+	}
+	expires := el.Value.(*memoryEntry).expires
+	if expires.IsZero() {
+		return 0, true, nil
+	}
+	return expires.Sub(c.clock.Now()), true, nil
+}
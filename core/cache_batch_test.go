@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheBatch(t *testing.T) {
+	ctx := context.Background()
+	entries := map[string][]byte{}
+	for i := 0; i < 50; i++ {
+		entries[fmt.Sprintf("user:%d", i)] = []byte(fmt.Sprint(i))
+	}
+	keys := []string{"user:0", "user:7", "user:49", "user:50", "other"}
+
+	native, clock := newTestMemoryCache(t)
+	plain := &mapCache{}
+	if err := CacheMSet(ctx, native, entries, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := CacheMSet(ctx, plain, entries, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	fromNative, err := CacheMGet(ctx, native, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPlain, err := CacheMGet(ctx, plain, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFallback, err := MGetFallback(ctx, native, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{"user:0": []byte("0"), "user:7": []byte("7"), "user:49": []byte("49")}
+	for name, got := range map[string]map[string][]byte{"native": fromNative, "plain": fromPlain, "fallback": fromFallback} {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s MGet = %q, want %q", name, got, want)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	if got, _ := native.MGet(ctx, keys); len(got) != 0 {
+		t.Errorf("MGet after TTL = %q, want none", got)
+	}
+
+	failing := &failingCache{err: errors.New("connection refused")}
+	if _, err := CacheMGet(ctx, failing, keys); !errors.Is(err, failing.err) {
+		t.Errorf("fallback MGet err = %v", err)
+	}
+	if err := CacheMSet(ctx, failing, entries, 0); !errors.Is(err, failing.err) {
+		t.Errorf("fallback MSet err = %v", err)
+	}
+}
+
+func TestMemoryCacheTTLIntrospection(t *testing.T) {
+	ctx := context.Background()
+	c, clock := newTestMemoryCache(t)
+	c.Set(ctx, "session", nil, time.Minute) //nolint
+	c.Set(ctx, "config", nil, 0)            //nolint
+
+	clock.Advance(20 * time.Second)
+	tests := []struct {
+		key    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"session", 40 * time.Second, true},
+		{"config", 0, true},
+		{"missing", 0, false},
+	}
+	for _, tt := range tests {
+		ttl, ok, err := c.TTL(ctx, tt.key)
+		if ttl != tt.want || ok != tt.wantOK || err != nil {
+			t.Errorf("TTL(%s) = %v, %v, %v; want %v, %v", tt.key, ttl, ok, err, tt.want, tt.wantOK)
+		}
+	}
+
+	clock.Advance(40 * time.Second)
+	if _, ok, _ := c.TTL(ctx, "session"); ok {
+		t.Error("TTL reports an expired key")
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// CacheGetOrSetOption configures CacheGetOrSet.
+type CacheGetOrSetOption func(*getOrSetConfig)
+
+type getOrSetConfig struct {
+	negativeTTL time.Duration
+}
+
+// WithCacheNegativeTTL makes CacheGetOrSet remember a compute error for d,
+// returning it to callers without computing again. The error is kept in
+// process memory, not in the cache.
+func WithCacheNegativeTTL(d time.Duration) CacheGetOrSetOption {
+	return func(c *getOrSetConfig) { c.negativeTTL = d }
+}
+
+// CacheGetOrSet returns the value cached under key, decoded as JSON.
+// On a miss it runs compute, stores the result for ttl and returns it.
+// Concurrent callers missing the same key in this process share a single
+// compute run; they wait until the value is stored.
+//
+// Compute errors are returned and not cached, unless WithCacheNegativeTTL
+// is set. A stored value that fails to decode, or a failing cache read,
+// counts as a miss. When storing fails, the computed value is returned
+// together with the error.
+//
+// Compute gets a context detached from the caller's cancellation. A caller
+// whose ctx ends stops waiting with ctx.Err(), while the compute run
+// continues for the others.
+func CacheGetOrSet[T any](ctx context.Context, c contracts.Cache, key string, ttl time.Duration,
+	compute func(ctx context.Context) (T, error), opts ...CacheGetOrSetOption) (T, error) {
+	var zero T
+	if v, ok, err := CacheGetJSON[T](ctx, c, key); ok && err == nil {
+		return v, nil
+	}
+	var cfg getOrSetConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	fk := newFlightKey[T](c, key)
+	cacheFlights.mu.Lock()
+	if failed, ok := cacheFlights.failed[fk]; ok {
+		if contracts.SystemClock.Now().Before(failed.expires) {
+			cacheFlights.mu.Unlock()
+			return zero, failed.err
+		}
+		delete(cacheFlights.failed, fk)
+	}
+	f, running := cacheFlights.running[fk]
+	if !running {
+		f = &cacheFlight{done: make(chan struct{})}
+		cacheFlights.running[fk] = f
+		go f.run(context.WithoutCancel(ctx), fk, cfg, func(ctx context.Context) error {
+			v, err := compute(ctx)
+			if err != nil {
+				return err
+			}
+			f.value, f.computed = v, true
+			return CacheSetJSON(ctx, c, key, v, ttl)
+		})
+	}
+	cacheFlights.mu.Unlock()
+
+	select {
+	case <-f.done:
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+	v, _ := f.value.(T) // nil for failed computations
+	return v, f.err
+}
+
+// cacheFlights tracks the running and recently failed computations of
+// CacheGetOrSet.
+var cacheFlights = struct {
+	mu      sync.Mutex
+	running map[flightKey]*cacheFlight
+	failed  map[flightKey]failedFlight
+}{
+	running: make(map[flightKey]*cacheFlight),
+	failed:  make(map[flightKey]failedFlight),
+}
+
+// flightKey identifies a computation by cache, key and value type, so
+// unrelated caches or types sharing a key do not share results.
+type flightKey struct {
+	cache any
+	key   string
+	typ   reflect.Type
+}
+
+func newFlightKey[T any](c contracts.Cache, key string) flightKey {
+	var cache any = c
+	if !reflect.TypeOf(c).Comparable() {
+		cache = reflect.TypeOf(c)
+	}
+	return flightKey{cache: cache, key: key, typ: reflect.TypeFor[T]()}
+}
+
+type cacheFlight struct {
+	done     chan struct{}
+	computed bool
+	value    any // the computed T
+	err      error
+}
+
+type failedFlight struct {
+	err     error
+	expires time.Time
+}
+
+// run computes the value, then releases the waiters.
+func (f *cacheFlight) run(ctx context.Context, fk flightKey, cfg getOrSetConfig, compute func(context.Context) error) {
+	defer close(f.done)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				f.err = fmt.Errorf("cache: compute %q panicked: %v", fk.key, r)
+			}
+		}()
+		f.err = compute(ctx)
+	}()
+
+	cacheFlights.mu.Lock()
+	defer cacheFlights.mu.Unlock()
+	delete(cacheFlights.running, fk)
+	if !f.computed && cfg.negativeTTL > 0 {
+		cacheFlights.failed[fk] = failedFlight{err: f.err, expires: contracts.SystemClock.Now().Add(cfg.negativeTTL)}
+	}
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheGetOrSetSingleFlight(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestMemoryCache(t)
+	var runs atomic.Int32
+	release := make(chan struct{})
+	compute := func(context.Context) (cachedUser, error) {
+		runs.Add(1)
+		<-release
+		return cachedUser{ID: "1", Name: "Ada"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]cachedUser, 50)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u, err := CacheGetOrSet(ctx, c, "user:1", time.Minute, compute)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = u
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("compute ran %d times, want 1", n)
+	}
+	for i, u := range results {
+		if u.Name != "Ada" {
+			t.Fatalf("caller %d got %+v", i, u)
+		}
+	}
+	if u, ok, _ := CacheGetJSON[cachedUser](ctx, c, "user:1"); !ok || u.Name != "Ada" {
+		t.Errorf("value not stored: %+v, %v", u, ok)
+	}
+	if _, err := CacheGetOrSet(ctx, c, "user:1", time.Minute, compute); err != nil || runs.Load() != 1 {
+		t.Errorf("hit recomputed: runs %d err %v", runs.Load(), err)
+	}
+}
+
+func TestCacheGetOrSetErrors(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestMemoryCache(t)
+	errDown := errors.New("db down")
+	var runs int
+	failing := func(context.Context) (int, error) { runs++; return 0, errDown }
+
+	for i := 0; i < 2; i++ {
+		if _, err := CacheGetOrSet(ctx, c, "n", time.Minute, failing); !errors.Is(err, errDown) {
+			t.Fatalf("err = %v, want errDown", err)
+		}
+	}
+	if runs != 2 {
+		t.Errorf("compute ran %d times, want 2: errors are not cached by default", runs)
+	}
+	if ok, _ := c.Exists(ctx, "n"); ok {
+		t.Error("failed compute stored a value")
+	}
+
+	runs = 0
+	for i := 0; i < 3; i++ {
+		if _, err := CacheGetOrSet(ctx, c, "neg", time.Minute, failing, WithCacheNegativeTTL(time.Hour)); !errors.Is(err, errDown) {
+			t.Fatalf("err = %v, want errDown", err)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("compute ran %d times with a negative TTL, want 1", runs)
+	}
+
+	_, err := CacheGetOrSet(ctx, c, "p", time.Minute, func(context.Context) (int, error) { panic("boom") })
+	if err == nil || err.Error() != `cache: compute "p" panicked: boom` {
+		t.Errorf("panic err = %v", err)
+	}
+
+	c.Set(ctx, "corrupt", []byte("{"), 0) //nolint
+	if n, err := CacheGetOrSet(ctx, c, "corrupt", time.Minute, func(context.Context) (int, error) { return 7, nil }); n != 7 || err != nil {
+		t.Errorf("corrupted entry: got %d, %v; want recomputed 7", n, err)
+	}
+}
+
+func TestCacheGetOrSetWaiterCancel(t *testing.T) {
+	c, _ := newTestMemoryCache(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var computeErr error
+	done := make(chan struct{})
+	compute := func(ctx context.Context) (string, error) {
+		close(started)
+		<-release
+		computeErr = ctx.Err()
+		close(done)
+		return "v", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := CacheGetOrSet(ctx, c, "k", time.Minute, compute)
+		errc <- err
+	}()
+	<-started
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("waiter err = %v, want context.Canceled", err)
+	}
+
+	close(release)
+	<-done
+	if computeErr != nil {
+		t.Errorf("compute ctx err = %v after the caller was cancelled", computeErr)
+	}
+	if v, err := CacheGetOrSet(context.Background(), c, "k", time.Minute, compute); v != "v" || err != nil {
+		t.Errorf("CacheGetOrSet = %q, %v; want the value stored by the detached compute", v, err)
+	}
+}
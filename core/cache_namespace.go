@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ErrPrefixInvalidationUnsupported is returned by InvalidatePrefix on a
+// namespaced cache whose underlying cache is not a PrefixInvalidator.
+var ErrPrefixInvalidationUnsupported = errors.New("cache: prefix invalidation not supported")
+
+// PrefixInvalidator is implemented by caches that can delete every key
+// starting with a prefix, such as MemoryCache. Redis adapters can implement
+// it with SCAN MATCH prefix* and DEL. Check for it with a type assertion:
+//
+//	if inv, ok := cache.(core.PrefixInvalidator); ok {
+//		err = inv.InvalidatePrefix(ctx, "users:")
+//	}
+type PrefixInvalidator interface {
+	InvalidatePrefix(ctx context.Context, prefix string) error
+}
+
+// NamespacedCache returns a Cache storing every key of c under prefix, so
+// modules sharing a backend cannot collide. Include a separator in prefix,
+// e.g. "billing:". Namespaces nest: NamespacedCache(NamespacedCache(c,
+// "a:"), "b:") stores "k" as "a:b:k". The result is a PrefixInvalidator;
+// InvalidatePrefix(ctx, "") clears the whole namespace.
+func NamespacedCache(c contracts.Cache, prefix string) contracts.Cache {
+	if ns, ok := c.(*namespacedCache); ok {
+		return &namespacedCache{cache: ns.cache, prefix: ns.prefix + prefix}
+	}
+	return &namespacedCache{cache: c, prefix: prefix}
+}
+
+type namespacedCache struct {
+	cache  contracts.Cache
+	prefix string
+}
+
+func (n *namespacedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return n.cache.Get(ctx, n.prefix+key)
+}
+
+func (n *namespacedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return n.cache.Set(ctx, n.prefix+key, value, ttl)
+}
+
+func (n *namespacedCache) Delete(ctx context.Context, key string) error {
+	return n.cache.Delete(ctx, n.prefix+key)
+}
+
+func (n *namespacedCache) Exists(ctx context.Context, key string) (bool, error) {
+	return n.cache.Exists(ctx, n.prefix+key)
+}
+
+// InvalidatePrefix implements PrefixInvalidator within the namespace.
+func (n *namespacedCache) InvalidatePrefix(ctx context.Context, prefix string) error {
+	inv, ok := n.cache.(PrefixInvalidator)
+	if !ok {
+		return ErrPrefixInvalidationUnsupported
+	}
+	return inv.InvalidatePrefix(ctx, n.prefix+prefix)
+}
+
+// InvalidatePrefix implements PrefixInvalidator.
+func (c *MemoryCache) InvalidatePrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNamespacedCache(t *testing.T) {
+	ctx := context.Background()
+	base, _ := newTestMemoryCache(t)
+	billing := NamespacedCache(base, "billing:")
+	users := NamespacedCache(base, "users:")
+
+	billing.Set(ctx, "user:1", []byte("invoice"), 0) //nolint
+	users.Set(ctx, "user:1", []byte("profile"), 0)   //nolint
+
+	if v, _ := billing.Get(ctx, "user:1"); string(v) != "invoice" {
+		t.Errorf("billing user:1 = %q", v)
+	}
+	if v, _ := users.Get(ctx, "user:1"); string(v) != "profile" {
+		t.Errorf("users user:1 = %q", v)
+	}
+	if ok, _ := base.Exists(ctx, "billing:user:1"); !ok {
+		t.Error(`key not stored as "billing:user:1"`)
+	}
+	users.Delete(ctx, "user:1") //nolint
+	if ok, _ := billing.Exists(ctx, "user:1"); !ok {
+		t.Error("deleting in one namespace removed the other's key")
+	}
+
+	nested := NamespacedCache(billing, "eu:")
+	nested.Set(ctx, "k", nil, 0) //nolint
+	if ok, _ := base.Exists(ctx, "billing:eu:k"); !ok {
+		t.Error(`nested key not stored as "billing:eu:k"`)
+	}
+}
+
+func TestInvalidatePrefix(t *testing.T) {
+	ctx := context.Background()
+	base, _ := newTestMemoryCache(t)
+	billing := NamespacedCache(base, "billing:")
+	users := NamespacedCache(base, "users:")
+	for _, k := range []string{"user:1", "user:2", "plan:1"} {
+		billing.Set(ctx, k, nil, 0) //nolint
+		users.Set(ctx, k, nil, 0)   //nolint
+	}
+
+	if err := billing.(PrefixInvalidator).InvalidatePrefix(ctx, "user:"); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]bool{
+		"billing:user:1": false, "billing:user:2": false, "billing:plan:1": true,
+		"users:user:1": true, "users:user:2": true, "users:plan:1": true,
+	} {
+		if ok, _ := base.Exists(ctx, k); ok != want {
+			t.Errorf("Exists(%s) = %v, want %v", k, ok, want)
+		}
+	}
+
+	if err := users.(PrefixInvalidator).InvalidatePrefix(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := base.Len(); n != 1 {
+		t.Errorf("Len = %d after clearing the users namespace, want 1", n)
+	}
+
+	plain := NamespacedCache(&mapCache{}, "x:")
+	if err := plain.(PrefixInvalidator).InvalidatePrefix(ctx, ""); !errors.Is(err, ErrPrefixInvalidationUnsupported) {
+		t.Errorf("err = %v, want ErrPrefixInvalidationUnsupported", err)
+	}
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+func newTestMemoryCache(t *testing.T, opts ...MemoryCacheOption) (*MemoryCache, *keeltest.FakeClock) {
+	t.Helper()
+	clock := keeltest.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewMemoryCache(append([]MemoryCacheOption{WithCacheJanitorInterval(0)}, opts...)...)
+	c.SetClock(clock)
+	t.Cleanup(func() { c.Close() })
+	return c, clock
+}
+
+func TestMemoryCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	c, clock := newTestMemoryCache(t)
+
+	value := []byte("v1")
+	c.Set(ctx, "session", value, time.Minute) //nolint
+	c.Set(ctx, "config", []byte("c"), 0)      //nolint
+	value[0] = 'X'
+
+	got, err := c.Get(ctx, "session")
+	if err != nil || string(got) != "v1" {
+		t.Fatalf("Get = %q, %v; want v1 unaffected by caller mutation", got, err)
+	}
+	got[0] = 'Y'
+	if again, _ := c.Get(ctx, "session"); string(again) != "v1" {
+		t.Fatalf("Get = %q after mutating a previous result", again)
+	}
+
+	clock.Advance(59 * time.Second)
+	if ok, _ := c.Exists(ctx, "session"); !ok {
+		t.Error("Exists(session) = false before expiry")
+	}
+	clock.Advance(time.Second)
+	if _, err := c.Get(ctx, "session"); !errors.Is(err, contracts.ErrCacheMiss) {
+		t.Errorf("Get after TTL err = %v, want ErrCacheMiss", err)
+	}
+	if ok, _ := c.Exists(ctx, "session"); ok {
+		t.Error("Exists(session) = true after expiry")
+	}
+	clock.Advance(24 * time.Hour)
+	if ok, _ := c.Exists(ctx, "config"); !ok {
+		t.Error("entry without TTL expired")
+	}
+
+	c.Delete(ctx, "config")  //nolint
+	c.Delete(ctx, "missing") //nolint
+	if ok, _ := c.Exists(ctx, "config"); ok {
+		t.Error("Exists(config) = true after Delete")
+	}
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, contracts.ErrCacheMiss) {
+		t.Errorf("Get(missing) err = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestMemoryCache(t, WithCacheMaxEntries(3))
+
+	for _, k := range []string{"a", "b", "c"} {
+		c.Set(ctx, k, []byte(k), 0) //nolint
+	}
+	c.Get(ctx, "a")              //nolint // a is now the most recently used
+	c.Exists(ctx, "b")           //nolint // Exists does not count as a use
+	c.Set(ctx, "d", nil, 0)      //nolint // evicts b
+	c.Set(ctx, "c", []byte{}, 0) //nolint // overwriting refreshes c
+	c.Set(ctx, "e", nil, 0)      //nolint // evicts a
+
+	for k, want := range map[string]bool{"a": false, "b": false, "c": true, "d": true, "e": true} {
+		if ok, _ := c.Exists(ctx, k); ok != want {
+			t.Errorf("Exists(%s) = %v, want %v", k, ok, want)
+		}
+	}
+	if n := c.Len(); n != 3 {
+		t.Errorf("Len = %d, want 3", n)
+	}
+}
+
+func TestMemoryCacheJanitor(t *testing.T) {
+	ctx := context.Background()
+	clock := keeltest.NewFakeClock(time.Unix(0, 0))
+	c := NewMemoryCache(WithCacheJanitorInterval(time.Millisecond))
+	c.SetClock(clock)
+
+	c.Set(ctx, "short", nil, time.Second) //nolint
+	c.Set(ctx, "long", nil, time.Hour)    //nolint
+	clock.Advance(time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := c.Len(); n != 1 {
+		t.Fatalf("Len = %d, want the janitor to drop the expired entry", n)
+	}
+
+	c.Close()
+	c.Close()
+	select {
+	case <-c.done:
+	default:
+		t.Error("janitor still running after Close")
+	}
+}
+
+func TestMemoryCacheConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(WithCacheMaxEntries(50), WithCacheJanitorInterval(time.Millisecond))
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("k%d", (g*i)%100)
+				c.Set(ctx, key, []byte(key), time.Millisecond) //nolint
+				c.Get(ctx, key)                                //nolint
+				c.Exists(ctx, key)                             //nolint
+				if i%10 == 0 {
+					c.Delete(ctx, key) //nolint
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Len(); n > 50 {
+		t.Errorf("Len = %d, want at most 50", n)
+	}
+}
+
+func TestRegisterCache(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	clock := keeltest.NewFakeClock(time.Unix(0, 0))
+	app.SetClock(clock)
+	c := NewMemoryCache()
+	app.RegisterCache(c)
+
+	if app.Cache() != c {
+		t.Fatal("Cache() did not return the registered cache")
+	}
+	if c.clock != clock {
+		t.Error("cache did not receive the App clock")
+	}
+	if len(app.shutdownHooks) != 1 {
+		t.Fatalf("shutdownHooks len = %d, want 1", len(app.shutdownHooks))
+	}
+	if err := app.shutdownHooks[0](context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c.done:
+	default:
+		t.Error("janitor still running after shutdown")
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// CacheGetJSON reads key from c and decodes it as JSON into a T. A missing
+// or expired key returns ok false and a nil error; a backend failure or a
+// stored value that does not decode into T returns the error.
+func CacheGetJSON[T any](ctx context.Context, c contracts.Cache, key string) (value T, ok bool, err error) {
+	b, err := c.Get(ctx, key)
+	if errors.Is(err, contracts.ErrCacheMiss) {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, err
+	}
+	if err := json.Unmarshal(b, &value); err != nil {
+		var zero T
+		return zero, false, fmt.Errorf("cache: decode %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// CacheSetJSON stores value in c under key, encoded as JSON. A ttl of zero
+// or less never expires.
+func CacheSetJSON[T any](ctx context.Context, c contracts.Cache, key string, value T, ttl time.Duration) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("cache: encode %q: %w", key, err)
+	}
+	return c.Set(ctx, key, b, ttl)
+}
+
+// TypedCache stores values of type T as JSON under a key prefix, with a
+// default TTL:
+//
+//	users := core.NewTypedCache[User](cache, "users:", 10*time.Minute)
+//	u, ok, err := users.Get(ctx, id)
+type TypedCache[T any] struct {
+	cache  contracts.Cache
+	prefix string
+	ttl    time.Duration
+}
+
+// NewTypedCache returns a TypedCache storing entries in c under
+// prefix+id, expiring after ttl (never when ttl <= 0).
+func NewTypedCache[T any](c contracts.Cache, prefix string, ttl time.Duration) *TypedCache[T] {
+	return &TypedCache[T]{cache: c, prefix: prefix, ttl: ttl}
+}
+
+// Key returns the cache key of id.
+func (t *TypedCache[T]) Key(id string) string {
+	return t.prefix + id
+}
+
+// Get returns the value stored for id; see CacheGetJSON.
+func (t *TypedCache[T]) Get(ctx context.Context, id string) (T, bool, error) {
+	return CacheGetJSON[T](ctx, t.cache, t.Key(id))
+}
+
+// Set stores value for id with the default TTL.
+func (t *TypedCache[T]) Set(ctx context.Context, id string, value T) error {
+	return CacheSetJSON(ctx, t.cache, t.Key(id), value, t.ttl)
+}
+
+// SetTTL stores value for id, expiring after ttl instead of the default.
+func (t *TypedCache[T]) SetTTL(ctx context.Context, id string, value T, ttl time.Duration) error {
+	return CacheSetJSON(ctx, t.cache, t.Key(id), value, ttl)
+}
+
+// Delete removes the value stored for id.
+func (t *TypedCache[T]) Delete(ctx context.Context, id string) error {
+	return t.cache.Delete(ctx, t.Key(id))
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type cachedUser struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+func TestCacheJSON(t *testing.T) {
+	ctx := context.Background()
+	c, clock := newTestMemoryCache(t)
+	want := cachedUser{ID: "1", Name: "Ada", Roles: []string{"admin"}}
+
+	if err := CacheSetJSON(ctx, c, "user:1", want, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := CacheGetJSON[cachedUser](ctx, c, "user:1")
+	if err != nil || !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("CacheGetJSON = %+v, %v, %v; want %+v", got, ok, err, want)
+	}
+
+	clock.Advance(time.Minute)
+	if got, ok, err := CacheGetJSON[cachedUser](ctx, c, "user:1"); ok || err != nil || !reflect.DeepEqual(got, cachedUser{}) {
+		t.Errorf("expired: CacheGetJSON = %+v, %v, %v; want a miss", got, ok, err)
+	}
+
+	c.Set(ctx, "user:2", []byte(`{"id":`), 0) //nolint
+	if got, ok, err := CacheGetJSON[cachedUser](ctx, c, "user:2"); ok || err == nil || !reflect.DeepEqual(got, cachedUser{}) {
+		t.Errorf("corrupted: CacheGetJSON = %+v, %v, %v; want an error", got, ok, err)
+	}
+
+	failing := &failingCache{err: errors.New("connection refused")}
+	if _, ok, err := CacheGetJSON[cachedUser](ctx, failing, "user:1"); ok || !errors.Is(err, failing.err) {
+		t.Errorf("backend failure: ok %v err %v", ok, err)
+	}
+	if err := CacheSetJSON(ctx, c, "bad", make(chan int), 0); err == nil {
+		t.Error("CacheSetJSON of an unencodable value succeeded")
+	}
+}
+
+func TestTypedCache(t *testing.T) {
+	ctx := context.Background()
+	c, clock := newTestMemoryCache(t)
+	users := NewTypedCache[cachedUser](c, "users:", time.Minute)
+
+	ada := cachedUser{ID: "1", Name: "Ada"}
+	if err := users.Set(ctx, "1", ada); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.SetTTL(ctx, "2", cachedUser{ID: "2"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Exists(ctx, "users:1"); !ok {
+		t.Error(`entry not stored under "users:1"`)
+	}
+	if got, ok, err := users.Get(ctx, "1"); !ok || err != nil || got.Name != "Ada" {
+		t.Errorf("Get(1) = %+v, %v, %v", got, ok, err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, ok, _ := users.Get(ctx, "1"); ok {
+		t.Error("Get(1) hit after the default TTL")
+	}
+	if _, ok, _ := users.Get(ctx, "2"); !ok {
+		t.Error("Get(2) missed before its own TTL")
+	}
+	users.Delete(ctx, "2") //nolint
+	if _, ok, _ := users.Get(ctx, "2"); ok {
+		t.Error("Get(2) hit after Delete")
+	}
+}
+
+// failingCache fails every operation with err.
+type failingCache struct{ err error }
+
+func (f *failingCache) Get(context.Context, string) ([]byte, error) { return nil, f.err }
+func (f *failingCache) Set(context.Context, string, []byte, time.Duration) error {
+	return f.err
+}
+func (f *failingCache) Delete(context.Context, string) error         { return f.err }
+func (f *failingCache) Exists(context.Context, string) (bool, error) { return false, f.err }
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// Clock is the time source of an App; see contracts.Clock.
+type Clock = contracts.Clock
+
+// SetClock replaces the time source used by the request timer, health
+// checks, job timing, audit events and, unless configured with their own,
+// rate limiters. A registered scheduler or cache implementing
+// contracts.ClockAware receives it too. nil restores the system clock. Log
+// timestamps keep using real time.
+func (a *App) SetClock(clock Clock) {
+	if clock == nil {
+		clock = contracts.SystemClock
+	}
+	a.clock = clock
+	for _, dep := range []any{a.scheduler, a.cache} {
+		if ca, ok := dep.(contracts.ClockAware); ok {
+			ca.SetClock(clock)
+		}
+	}
+}
+
+// Clock returns the App time source (never nil).
+func (a *App) Clock() Clock {
+	return a.clock
+}
+
+// ClockFrom returns the clock of the App serving c, or the system clock
+// outside an App.
+func ClockFrom(c *fiber.Ctx) Clock {
+	if clock, ok := c.Locals("_keel_clock").(Clock); ok {
+		return clock
+	}
+	return contracts.SystemClock
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+// clockAwareScheduler records the clock handed to it.
+type clockAwareScheduler struct {
+	schedulerSpy
+	clock contracts.Clock
+}
+
+func (s *clockAwareScheduler) SetClock(c contracts.Clock) { s.clock = c }
+
+func TestAppClock(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	if app.Clock() != contracts.SystemClock {
+		t.Fatalf("default clock = %T, want the system clock", app.Clock())
+	}
+
+	clock := keeltest.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	sched := &clockAwareScheduler{}
+	app.RegisterScheduler(sched)
+	app.SetClock(clock)
+	if sched.clock != clock {
+		t.Error("scheduler did not receive the clock from SetClock")
+	}
+	app.Fiber().Get("/now", func(c *fiber.Ctx) error {
+		return c.SendString(ClockFrom(c).Now().Format(time.RFC3339))
+	})
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/now", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != "2026-01-01T00:00:00Z" {
+		t.Errorf("ClockFrom(c).Now() = %s", body)
+	}
+
+	app.SetClock(nil)
+	if app.Clock() != contracts.SystemClock {
+		t.Errorf("SetClock(nil) left %T", app.Clock())
+	}
+
+	other := &clockAwareScheduler{}
+	app.SetClock(clock)
+	app.RegisterScheduler(other)
+	if other.clock != clock {
+		t.Error("scheduler registered after SetClock did not receive the clock")
+	}
+}
+
+func TestHealthCacheUsesClock(t *testing.T) {
+	clock := keeltest.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	app := New(KConfig{DisableHealth: true, HealthCacheTTL: time.Minute})
+	app.SetClock(clock)
+	calls := 0
+	app.RegisterHealthChecker(HealthCheckerFunc("db", func(context.Context) error {
+		calls++
+		return nil
+	}))
+
+	ctx := context.Background()
+	app.healthReport(ctx)
+	clock.Advance(59 * time.Second)
+	app.healthReport(ctx)
+	if calls != 1 {
+		t.Fatalf("checks run = %d within the TTL, want 1", calls)
+	}
+	clock.Advance(time.Second)
+	app.healthReport(ctx)
+	if calls != 2 {
+		t.Errorf("checks run = %d after the TTL, want 2", calls)
+	}
+}
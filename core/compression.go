@@ -0,0 +1,61 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// compressionMiddleware compresses response bodies with brotli, gzip or
+// deflate depending on the client's Accept-Encoding header. Responses that are
+// already encoded or whose content type is an archive or media format are
+// left untouched.
+func compressionMiddleware(level CompressionLevel) fiber.Handler {
+	brotliLevel, otherLevel := fasthttp.CompressBrotliDefaultCompression, fasthttp.CompressDefaultCompression
+	switch level {
+	case CompressionBestSpeed:
+		brotliLevel, otherLevel = fasthttp.CompressBrotliBestSpeed, fasthttp.CompressBestSpeed
+	case CompressionBestCompression:
+		brotliLevel, otherLevel = fasthttp.CompressBrotliBestCompression, fasthttp.CompressBestCompression
+	}
+	compress := fasthttp.CompressHandlerBrotliLevel(func(*fasthttp.RequestCtx) {}, brotliLevel, otherLevel)
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if len(c.Response().Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+			return nil
+		}
+		if alreadyCompressed(string(c.Response().Header.ContentType())) {
+			return nil
+		}
+		compress(c.Context())
+		return nil
+	}
+}
+
+// alreadyCompressed reports whether a content type is a format that gains
+// nothing from another round of compression.
+func alreadyCompressed(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	ct = strings.TrimSpace(ct)
+
+	switch {
+	case strings.HasPrefix(ct, "image/") && ct != "image/svg+xml",
+		strings.HasPrefix(ct, "video/"),
+		strings.HasPrefix(ct, "audio/"):
+		return true
+	}
+	switch ct {
+	case "application/zip", "application/gzip", "application/x-gzip",
+		"application/x-bzip2", "application/x-xz", "application/x-7z-compressed",
+		"application/x-rar-compressed", "application/zstd", "application/pdf":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+func newCompressionTestApp(cfg KConfig) (*App, *mockMetricsCollector) {
+	cfg.DisableHealth = true
+	app := New(cfg)
+	mc := &mockMetricsCollector{}
+	app.SetMetricsCollector(mc)
+	payload := strings.Repeat("keel ", 500)
+	app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+		return []httpx.Route{
+			httpx.GET("/list", func(c *httpx.Ctx) error {
+				return c.OK(map[string]string{"data": payload})
+			}),
+			httpx.GET("/archive", func(c *httpx.Ctx) error {
+				c.Set("Content-Type", "application/zip")
+				return c.SendString(payload)
+			}),
+		}
+	}))
+	return app, mc
+}
+
+func TestCompression(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            KConfig
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"gzip when accepted", KConfig{}, "/list", "gzip", "gzip"},
+		{"identity without Accept-Encoding", KConfig{}, "/list", "", ""},
+		{"already compressed content type skipped", KConfig{}, "/archive", "gzip", ""},
+		{"disabled", KConfig{Compression: CompressionConfig{Disabled: true}}, "/list", "gzip", ""},
+		{"best speed level", KConfig{Compression: CompressionConfig{Level: CompressionBestSpeed}}, "/list", "gzip", "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, mc := newCompressionTestApp(tt.cfg)
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("StatusCode = %v, want 200", resp.StatusCode)
+			}
+			if got := resp.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mc.lastMetrics.ResponseSize != int64(len(body)) {
+				t.Errorf("ResponseSize = %d, want %d (bytes on the wire)", mc.lastMetrics.ResponseSize, len(body))
+			}
+		})
+	}
+}
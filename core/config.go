@@ -1,24 +1,192 @@
 package core
 
+import (
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
 type KConfig struct {
-	DisableHealth bool
-	Port          int    `keel:"server.port,required"`
-	ServiceName   string `keel:"app.name,required"`
-	Env           string `keel:"app.env,required"`
-	Docs          DocsConfig
+	DisableHealth       bool
+	HealthPaths         HealthPathsConfig
+	HealthCheckTimeout  time.Duration // bounds each HealthChecker.Check call; defaults to 2s
+	HealthCacheTTL      time.Duration // caches the readiness result; 0 disables caching
+	SimpleHealthChecks  bool          // report checks as flat "UP"/"DOWN: <err>" strings
+	Port                int           `keel:"server.port,required"`
+	Host                string        `keel:"server.host"`        // interface to bind; empty binds all interfaces
+	ListenAddr          string        `keel:"server.listen-addr"` // "host:port" or "unix:///path/to.sock"; overrides Port
+	Prefork             bool          `keel:"server.prefork"`     // spawn one child process per CPU sharing the port (TCP only)
+	ShutdownTimeout     time.Duration // how long in-flight requests may drain on shutdown; defaults to 10s
+	ShutdownHookTimeout time.Duration // per OnShutdown hook budget; defaults to 5s
+	ServiceName         string        `keel:"app.name,required"`
+	Env                 string        `keel:"app.env,required"`
+	Docs                DocsConfig
+	TLS                 TLSConfig
+	CORS                CORSConfig
+	Server              ServerConfig
+	Compression         CompressionConfig
+	RequestID           RequestIDConfig
+	Metrics             MetricsConfig
+	Tracing             TracingConfig
+	SecurityHeaders     SecurityHeadersConfig
+	Logging             LoggingConfig
+	AccessLog           AccessLogConfig
+	LogBodies           BodyLogConfig // request/response body logging, never installed in production
+}
+
+// DefaultRedactKeys are always redacted from log fields and access-log query
+// strings, in addition to LoggingConfig.RedactKeys.
+var DefaultRedactKeys = []string{"authorization", "password", "token", "access_token", "api_key", "secret"}
+
+// AccessLogConfig controls the per-request access log.
+type AccessLogConfig struct {
+	Disabled  bool     // stop writing access-log entries; metrics are still recorded
+	SkipPaths []string // exact request paths not logged, e.g. health probes
+	// Sampling, when set, samples 4xx lines per status, method and route so a
+	// client stuck in an error loop cannot flood the logs.
+	Sampling *logger.SamplingConfig
+}
+
+// skips reports whether requests to path are left out of the access log.
+func (c AccessLogConfig) skips(path string) bool {
+	if c.Disabled {
+		return true
+	}
+	for _, p := range c.SkipPaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// LoggingConfig controls what the app logger and access log may write.
+type LoggingConfig struct {
+	RedactKeys []string // extra field names and query parameters to redact, matched case-insensitively
+}
+
+// redactKeys returns the default and configured redact keys.
+func (c LoggingConfig) redactKeys() []string {
+	return append(append([]string(nil), DefaultRedactKeys...), c.RedactKeys...)
+}
+
+// redacts reports whether key must be redacted, ignoring case.
+func (c LoggingConfig) redacts(key string) bool {
+	for _, k := range c.redactKeys() {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// MetricsConfig enables the built-in Prometheus collector and its endpoint.
+type MetricsConfig struct {
+	Enabled bool
+	Path    string    // defaults to "/metrics"
+	Buckets []float64 // latency histogram buckets in seconds; defaults to DefaultMetricsBuckets
+}
+
+// TracingConfig controls the HTTP tracing middleware. Tracing turns on by
+// itself once SetTracer installs a tracer; Enabled forces it on with the noop
+// tracer so trace IDs are still propagated, logged and echoed to clients.
+type TracingConfig struct {
+	Enabled bool
+}
+
+// RequestIDConfig configures how each request is assigned its ID.
+type RequestIDConfig struct {
+	Header string // defaults to "X-Request-ID"
+	// AcceptIncoming reuses the ID sent by the client or gateway in Header.
+	// Values longer than 128 characters or containing characters outside
+	// [A-Za-z0-9-_.:] are discarded and a new ID is generated instead.
+	AcceptIncoming bool
+	Generator      func() string // defaults to a UUID generator
+}
+
+// CompressionLevel selects the trade-off between speed and response size.
+type CompressionLevel int
+
+const (
+	CompressionDefault CompressionLevel = iota
+	CompressionBestSpeed
+	CompressionBestCompression
+)
+
+// CompressionConfig configures response compression. It is enabled by default.
+type CompressionConfig struct {
+	Disabled bool
+	Level    CompressionLevel
+}
+
+// ServerConfig tunes the underlying HTTP server. Zero values are replaced by
+// production-safe defaults in applyDefaults; -1 means unlimited.
+type ServerConfig struct {
+	ReadTimeout  time.Duration // defaults to 30s
+	WriteTimeout time.Duration // defaults to 30s
+	IdleTimeout  time.Duration // defaults to 120s
+	// BodyLimit is the maximum request body size in bytes. Defaults to 4MB.
+	// Larger bodies are rejected before reaching handlers and answered by the
+	// App error handler with 413 and X-Error-Code: REQUEST_ENTITY_TOO_LARGE.
+	BodyLimit   int
+	Concurrency int // maximum concurrent connections; defaults to 256 * 1024
+}
+
+// CORSConfig configures the CORS middleware installed by New.
+// The zero value allows every origin; set AllowOrigins to restrict it.
+type CORSConfig struct {
+	Disabled         bool
+	AllowOrigins     []string // e.g. "https://app.example.com"; empty allows every origin
+	AllowMethods     []string // defaults to GET, POST, HEAD, PUT, DELETE, PATCH
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration // preflight cache duration; negative disables caching
+}
+
+// TLSConfig enables HTTPS in Listen when CertFile or KeyFile is set.
+// Setting ClientCAFile additionally requires and verifies client
+// certificates (mutual TLS).
+type TLSConfig struct {
+	CertFile     string `keel:"server.tls.cert-file"`
+	KeyFile      string `keel:"server.tls.key-file"`
+	ClientCAFile string `keel:"server.tls.client-ca-file"`
+	MinVersion   uint16 // defaults to tls.VersionTLS12
+}
+
+// HealthPathsConfig sets the paths of the built-in health endpoints.
+type HealthPathsConfig struct {
+	Health string // alias of Ready, kept for backwards compatibility; defaults to "/health"
+	Live   string // defaults to "/health/live"
+	Ready  string // defaults to "/health/ready"
 }
 
 type DocsConfig struct {
-	Path        string `keel:"docs.path,required"`
-	Title       string `keel:"docs.title,required"`
-	Version     string `keel:"docs.version,required"`
-	Description string `keel:"docs.description"`
+	Path        string         `keel:"docs.path,required"`
+	Title       string         `keel:"docs.title,required"`
+	Version     string         `keel:"docs.version,required"`
+	Description string         `keel:"docs.description"`
+	Enabled     *bool          // nil serves docs outside production; true/false overrides the environment
+	BasicAuth   *DocsBasicAuth // when set, docs require these HTTP Basic credentials
 	Contact     *DocsContact
 	License     *DocsLicense
 	Servers     []string // format: "https://api.example.com - Description"
 	Tags        []DocsTag
 }
 
+// DocsBasicAuth is the shared credential protecting the docs endpoints.
+type DocsBasicAuth struct {
+	Username string
+	Password string
+}
+
 type DocsContact struct {
 	Name  string
 	URL   string
@@ -46,6 +214,48 @@ func applyDefaults(cfg KConfig) KConfig {
 	if cfg.ServiceName == "" {
 		cfg.ServiceName = "Keel App"
 	}
+	if cfg.HealthPaths.Health == "" {
+		cfg.HealthPaths.Health = "/health"
+	}
+	if cfg.HealthPaths.Live == "" {
+		cfg.HealthPaths.Live = "/health/live"
+	}
+	if cfg.HealthPaths.Ready == "" {
+		cfg.HealthPaths.Ready = "/health/ready"
+	}
+	if cfg.HealthCheckTimeout <= 0 {
+		cfg.HealthCheckTimeout = 2 * time.Second
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 10 * time.Second
+	}
+	if cfg.ShutdownHookTimeout <= 0 {
+		cfg.ShutdownHookTimeout = 5 * time.Second
+	}
+	if cfg.Server.ReadTimeout == 0 {
+		cfg.Server.ReadTimeout = 30 * time.Second
+	}
+	if cfg.Server.WriteTimeout == 0 {
+		cfg.Server.WriteTimeout = 30 * time.Second
+	}
+	if cfg.Server.IdleTimeout == 0 {
+		cfg.Server.IdleTimeout = 120 * time.Second
+	}
+	if cfg.Server.BodyLimit == 0 {
+		cfg.Server.BodyLimit = 4 * 1024 * 1024
+	}
+	if cfg.Server.Concurrency == 0 {
+		cfg.Server.Concurrency = 256 * 1024
+	}
+	if cfg.RequestID.Header == "" {
+		cfg.RequestID.Header = fiber.HeaderXRequestID
+	}
+	if cfg.RequestID.Generator == nil {
+		cfg.RequestID.Generator = utils.UUID
+	}
+	if cfg.Metrics.Path == "" {
+		cfg.Metrics.Path = "/metrics"
+	}
 	if cfg.Docs.Path == "" {
 		cfg.Docs.Path = "/docs"
 	}
@@ -61,5 +271,100 @@ func applyDefaults(cfg KConfig) KConfig {
 // isProduction returns true if the environment is production.
 func (c KConfig) isProduction() bool { return c.Env == "production" }
 
+// enabled returns true if TLS has been configured.
+func (t TLSConfig) enabled() bool { return t.CertFile != "" || t.KeyFile != "" }
+
+// permissive returns true if every origin is allowed.
+func (c CORSConfig) permissive() bool {
+	if len(c.AllowOrigins) == 0 {
+		return true
+	}
+	for _, o := range c.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// toFiber maps CORSConfig onto the Fiber CORS middleware config.
+func (c CORSConfig) toFiber() cors.Config {
+	maxAge := int(c.MaxAge / time.Second)
+	if c.MaxAge < 0 {
+		maxAge = -1
+	}
+	return cors.Config{
+		AllowOrigins:     strings.Join(c.AllowOrigins, ","),
+		AllowMethods:     strings.Join(c.AllowMethods, ","),
+		AllowHeaders:     strings.Join(c.AllowHeaders, ","),
+		ExposeHeaders:    strings.Join(c.ExposeHeaders, ","),
+		AllowCredentials: c.AllowCredentials,
+		MaxAge:           maxAge,
+	}
+}
+
+// unlimitedDuration maps the -1 "unlimited" marker to 0, which the server
+// treats as no timeout.
+func unlimitedDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// unlimitedInt maps the -1 "unlimited" marker to math.MaxInt.
+func unlimitedInt(n int) int {
+	if n < 0 {
+		return math.MaxInt
+	}
+	return n
+}
+
+// scheme returns "https" when TLS is enabled and "http" otherwise.
+func (c KConfig) scheme() string {
+	if c.TLS.enabled() {
+		return "https"
+	}
+	return "http"
+}
+
+// bindHost returns Host without the brackets an IPv6 literal may carry.
+func (c KConfig) bindHost() string {
+	return strings.TrimSuffix(strings.TrimPrefix(c.Host, "["), "]")
+}
+
+// hostPort returns the TCP address to bind, e.g. ":3000", "127.0.0.1:3000"
+// or "[::1]:3000".
+func (c KConfig) hostPort() string {
+	return net.JoinHostPort(c.bindHost(), strconv.Itoa(c.Port))
+}
+
+// docsHostPort returns the host:port shown in log lines, using localhost
+// when binding all interfaces.
+func (c KConfig) docsHostPort() string {
+	host := c.bindHost()
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.Port))
+}
+
 // docsEnabled returns true if API documentation should be generated.
-func (c KConfig) docsEnabled() bool { return !c.isProduction() }
+func (c KConfig) docsEnabled() bool {
+	enabled, _ := c.docsDecision()
+	return enabled
+}
+
+// docsDecision reports whether docs are served and the reason, for logging.
+func (c KConfig) docsDecision() (bool, string) {
+	switch {
+	case c.Docs.Enabled != nil && *c.Docs.Enabled:
+		return true, "enabled by Docs.Enabled"
+	case c.Docs.Enabled != nil:
+		return false, "disabled by Docs.Enabled"
+	case c.isProduction():
+		return false, "disabled in production; set Docs.Enabled to serve them"
+	default:
+		return true, "enabled outside production"
+	}
+}
@@ -1,6 +1,11 @@
 package core
 
-import "testing"
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestApplyDefaults(t *testing.T) {
 	tests := []struct {
@@ -105,6 +110,62 @@ func TestApplyDefaults(t *testing.T) {
 	}
 }
 
+func TestApplyDefaultsHealth(t *testing.T) {
+	got := applyDefaults(KConfig{HealthPaths: HealthPathsConfig{Live: "/livez"}})
+	if got.HealthPaths.Health != "/health" {
+		t.Errorf("HealthPaths.Health = %v, want /health", got.HealthPaths.Health)
+	}
+	if got.HealthPaths.Live != "/livez" {
+		t.Errorf("HealthPaths.Live = %v, want /livez", got.HealthPaths.Live)
+	}
+	if got.HealthPaths.Ready != "/health/ready" {
+		t.Errorf("HealthPaths.Ready = %v, want /health/ready", got.HealthPaths.Ready)
+	}
+	if got.HealthCheckTimeout != 2*time.Second {
+		t.Errorf("HealthCheckTimeout = %v, want 2s", got.HealthCheckTimeout)
+	}
+	if got.HealthCacheTTL != 0 {
+		t.Errorf("HealthCacheTTL = %v, want 0", got.HealthCacheTTL)
+	}
+}
+
+func TestApplyDefaultsServer(t *testing.T) {
+	t.Run("zero values use defaults", func(t *testing.T) {
+		got := applyDefaults(KConfig{}).Server
+		if got.ReadTimeout != 30*time.Second {
+			t.Errorf("ReadTimeout = %v, want 30s", got.ReadTimeout)
+		}
+		if got.WriteTimeout != 30*time.Second {
+			t.Errorf("WriteTimeout = %v, want 30s", got.WriteTimeout)
+		}
+		if got.IdleTimeout != 120*time.Second {
+			t.Errorf("IdleTimeout = %v, want 120s", got.IdleTimeout)
+		}
+		if got.BodyLimit != 4*1024*1024 {
+			t.Errorf("BodyLimit = %v, want 4MB", got.BodyLimit)
+		}
+		if got.Concurrency != 256*1024 {
+			t.Errorf("Concurrency = %v, want %v", got.Concurrency, 256*1024)
+		}
+	})
+
+	t.Run("unlimited marker is preserved", func(t *testing.T) {
+		got := applyDefaults(KConfig{Server: ServerConfig{ReadTimeout: -1, BodyLimit: -1}}).Server
+		if got.ReadTimeout != -1 {
+			t.Errorf("ReadTimeout = %v, want -1", got.ReadTimeout)
+		}
+		if got.BodyLimit != -1 {
+			t.Errorf("BodyLimit = %v, want -1", got.BodyLimit)
+		}
+		if unlimitedDuration(got.ReadTimeout) != 0 {
+			t.Errorf("unlimitedDuration(-1) = %v, want 0", unlimitedDuration(got.ReadTimeout))
+		}
+		if unlimitedInt(got.BodyLimit) != math.MaxInt {
+			t.Errorf("unlimitedInt(-1) = %v, want math.MaxInt", unlimitedInt(got.BodyLimit))
+		}
+	})
+}
+
 func TestIsProduction(t *testing.T) {
 	tests := []struct {
 		name string
@@ -143,6 +204,7 @@ func TestIsProduction(t *testing.T) {
 }
 
 func TestDocsEnabled(t *testing.T) {
+	enabled, disabled := true, false
 	tests := []struct {
 		name string
 		cfg  KConfig
@@ -168,6 +230,26 @@ func TestDocsEnabled(t *testing.T) {
 			cfg:  KConfig{Env: ""},
 			want: true,
 		},
+		{
+			name: "explicitly enabled in production",
+			cfg:  KConfig{Env: "production", Docs: DocsConfig{Enabled: &enabled}},
+			want: true,
+		},
+		{
+			name: "explicitly disabled in production",
+			cfg:  KConfig{Env: "production", Docs: DocsConfig{Enabled: &disabled}},
+			want: false,
+		},
+		{
+			name: "explicitly enabled in development",
+			cfg:  KConfig{Env: "development", Docs: DocsConfig{Enabled: &enabled}},
+			want: true,
+		},
+		{
+			name: "explicitly disabled in development",
+			cfg:  KConfig{Env: "development", Docs: DocsConfig{Enabled: &disabled}},
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -178,3 +260,118 @@ func TestDocsEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     KConfig
+		wantErr string
+	}{
+		{"zero config is valid", KConfig{}, ""},
+		{"port too high", KConfig{Port: 70000}, "port 70000 is out of range"},
+		{"negative port", KConfig{Port: -1}, "port -1 is out of range"},
+		{"valid listen addr", KConfig{ListenAddr: "127.0.0.1:8080"}, ""},
+		{"listen addr without port", KConfig{ListenAddr: "localhost"}, "invalid listen address"},
+		{"unix addr without path", KConfig{ListenAddr: "unix://"}, "missing socket path"},
+		{"docs path without slash", KConfig{Docs: DocsConfig{Path: "docs"}}, `Docs.Path "docs"`},
+		{"health path without slash", KConfig{HealthPaths: HealthPathsConfig{Live: "live"}}, `HealthPaths.Live "live"`},
+		{"metrics path without slash", KConfig{Metrics: MetricsConfig{Path: "metrics"}}, `Metrics.Path "metrics"`},
+		{"metrics path collides with health", KConfig{Metrics: MetricsConfig{Enabled: true, Path: "/health"}}, "conflicts with HealthPaths.Health"},
+		{"metrics path collision ignored when health disabled", KConfig{DisableHealth: true, Metrics: MetricsConfig{Enabled: true, Path: "/health"}}, ""},
+		{"tls cert without key", KConfig{TLS: TLSConfig{CertFile: "cert.pem"}}, "both CertFile and KeyFile"},
+		{"prefork on unix socket", KConfig{Prefork: true, ListenAddr: "unix:///tmp/app.sock"}, "Prefork is only supported for TCP"},
+		{"credentials with wildcard origin", KConfig{CORS: CORSConfig{AllowCredentials: true}}, "CORS.AllowCredentials"},
+		{"credentials with explicit origin", KConfig{CORS: CORSConfig{AllowCredentials: true, AllowOrigins: []string{"https://app.example.com"}}}, ""},
+		{"credentials with cors disabled", KConfig{CORS: CORSConfig{Disabled: true, AllowCredentials: true}}, ""},
+		{"unknown env is only a warning", KConfig{Env: "prod"}, ""},
+		{"docs basic auth without password", KConfig{Docs: DocsConfig{BasicAuth: &DocsBasicAuth{Username: "admin"}}}, "Docs.BasicAuth requires"},
+		{"host with listen addr", KConfig{Host: "127.0.0.1", ListenAddr: "127.0.0.1:8080"}, "Host cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	err := KConfig{Port: 70000, Docs: DocsConfig{Path: "docs"}}.Validate()
+	if err == nil {
+		t.Fatal("Validate() should fail")
+	}
+	for _, want := range []string{"port 70000", "Docs.Path"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestNewE(t *testing.T) {
+	if _, err := NewE(KConfig{Port: 70000}); err == nil {
+		t.Error("NewE() should return an error for an invalid config")
+	}
+
+	app, err := NewE(KConfig{DisableHealth: true})
+	if err != nil || app == nil {
+		t.Fatalf("NewE() = (%v, %v), want app and nil error", app, err)
+	}
+}
+
+func TestNewPanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("New() should panic for an invalid config")
+		}
+	}()
+	New(KConfig{Port: 70000})
+}
+
+func TestKnownEnv(t *testing.T) {
+	for _, env := range []string{"development", "test", "staging", "production"} {
+		if !knownEnv(env) {
+			t.Errorf("%q should be a known env", env)
+		}
+	}
+	if knownEnv("prod") {
+		t.Error(`"prod" should not be a known env`)
+	}
+}
+
+func TestHostPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		wantBind string
+		wantDocs string
+	}{
+		{"empty host binds all interfaces", "", ":3000", "localhost:3000"},
+		{"ipv4", "127.0.0.1", "127.0.0.1:3000", "127.0.0.1:3000"},
+		{"ipv4 wildcard", "0.0.0.0", "0.0.0.0:3000", "localhost:3000"},
+		{"ipv6", "::1", "[::1]:3000", "[::1]:3000"},
+		{"bracketed ipv6", "[::1]", "[::1]:3000", "[::1]:3000"},
+		{"ipv6 wildcard", "::", "[::]:3000", "localhost:3000"},
+		{"hostname", "internal.local", "internal.local:3000", "internal.local:3000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := KConfig{Host: tt.host, Port: 3000}
+			if got := cfg.hostPort(); got != tt.wantBind {
+				t.Errorf("hostPort() = %q, want %q", got, tt.wantBind)
+			}
+			if got := cfg.docsHostPort(); got != tt.wantDocs {
+				t.Errorf("docsHostPort() = %q, want %q", got, tt.wantDocs)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// knownEnvs are the environments Keel recognizes. Other values are accepted,
+// but New logs a warning since they are usually typos.
+var knownEnvs = []string{"development", "test", "staging", "production"}
+
+// Validate reports every problem in the configuration after defaults are
+// applied, joined into a single error. The rules are:
+//
+//   - Port must be between 1 and 65535.
+//   - ListenAddr, when set, must be "host:port" or "unix:///path", and
+//     cannot be combined with Host.
+//   - Docs.Path, Metrics.Path and the health paths must start with "/".
+//   - Metrics.Path must not collide with a health path.
+//   - TLS needs both CertFile and KeyFile.
+//   - Docs.BasicAuth needs both Username and Password.
+//   - Prefork only works with TCP addresses.
+//   - CORS.AllowCredentials cannot be combined with a wildcard origin.
+func (c KConfig) Validate() error {
+	c = applyDefaults(c)
+	var errs []error
+
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is out of range 1-65535", c.Port))
+	}
+
+	network := "tcp"
+	if c.ListenAddr != "" {
+		var err error
+		if network, _, err = parseListenAddr(c.ListenAddr); err != nil {
+			errs = append(errs, err)
+		}
+		if c.Host != "" {
+			errs = append(errs, errors.New("Host cannot be combined with ListenAddr"))
+		}
+	}
+
+	paths := []struct{ name, value string }{
+		{"Docs.Path", c.Docs.Path},
+		{"HealthPaths.Health", c.HealthPaths.Health},
+		{"HealthPaths.Live", c.HealthPaths.Live},
+		{"HealthPaths.Ready", c.HealthPaths.Ready},
+		{"Metrics.Path", c.Metrics.Path},
+	}
+	for _, p := range paths {
+		if !strings.HasPrefix(p.value, "/") {
+			errs = append(errs, fmt.Errorf("%s %q must start with \"/\"", p.name, p.value))
+		}
+	}
+
+	if c.Metrics.Enabled && !c.DisableHealth {
+		for _, p := range paths[1:4] {
+			if c.Metrics.Path == p.value {
+				errs = append(errs, fmt.Errorf("Metrics.Path %q conflicts with %s", c.Metrics.Path, p.name))
+			}
+		}
+	}
+
+	if c.TLS.enabled() && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("TLS requires both CertFile and KeyFile"))
+	}
+
+	if c.Docs.BasicAuth != nil && (c.Docs.BasicAuth.Username == "" || c.Docs.BasicAuth.Password == "") {
+		errs = append(errs, errors.New("Docs.BasicAuth requires both Username and Password"))
+	}
+
+	if c.Prefork && network != "tcp" {
+		errs = append(errs, fmt.Errorf("Prefork is only supported for TCP addresses, got %q", c.ListenAddr))
+	}
+
+	if !c.CORS.Disabled && c.CORS.AllowCredentials && c.CORS.permissive() {
+		errs = append(errs, errors.New("CORS.AllowCredentials cannot be used when every origin is allowed"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
+
+// knownEnv returns true if env is one of knownEnvs.
+func knownEnv(env string) bool {
+	for _, e := range knownEnvs {
+		if env == e {
+			return true
+		}
+	}
+	return false
+}
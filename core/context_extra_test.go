@@ -3,12 +3,15 @@ package core
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/validation"
 )
 
 // — SetUser / UserAs —
@@ -236,17 +239,61 @@ func TestCtxT(t *testing.T) {
 	})
 }
 
+func TestParseBodyLocalizedErrors(t *testing.T) {
+	type signupDTO struct {
+		Name     string `json:"name" validate:"required"`
+		Password string `json:"password" validate:"min=8"`
+		Email    string `json:"email" validate:"email"`
+	}
+
+	app := NewTestAppWith(KConfig{}, httpx.POST("/signup", func(c *httpx.Ctx) error {
+		var in signupDTO
+		if err := c.ParseBody(&in); err != nil {
+			return err
+		}
+		return c.NoContent()
+	}))
+	app.SetTranslator(&mockTranslator{})
+
+	resp := app.NewRequest("POST", "/signup").
+		Header("Accept-Language", "es,en;q=0.8").
+		JSON(`{"password":"short","email":"x"}`).
+		Send()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422; body %s", resp.StatusCode, resp.Body)
+	}
+	body, err := JSONAs[struct {
+		Errors []validation.FieldError `json:"errors"`
+	}](resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"name":     "campo obligatorio",
+		"password": "mínimo 8 caracteres",
+		"email":    "must be a valid email",
+	}
+	if len(body.Errors) != len(want) {
+		t.Fatalf("got %v, want %d errors", body.Errors, len(want))
+	}
+	for _, e := range body.Errors {
+		if e.Message != want[e.Field] {
+			t.Errorf("%s: message = %q, want %q", e.Field, e.Message, want[e.Field])
+		}
+	}
+}
+
 // mockTranslator is a simple test double that supports en/es.
 type mockTranslator struct{}
 
-func (m *mockTranslator) T(locale, key string, _ ...any) string {
+func (m *mockTranslator) T(locale, key string, args ...any) string {
 	translations := map[string]map[string]string{
 		"en": {"hello": "hello"},
-		"es": {"hello": "hola"},
+		"es": {"hello": "hola", "validation.required": "campo obligatorio", "validation.min": "mínimo %s caracteres"},
 	}
 	if loc, ok := translations[locale]; ok {
 		if val, ok := loc[key]; ok {
-			return val
+			return fmt.Sprintf(val, args...)
 		}
 	}
 	return key
@@ -283,6 +330,88 @@ func TestMetricsCollector(t *testing.T) {
 			t.Errorf("StatusCode = %v, want 200", mc.lastMetrics.StatusCode)
 		}
 	})
+
+	t.Run("Path is the route pattern", func(t *testing.T) {
+		mc := &mockMetricsCollector{}
+		keelApp := New(KConfig{DisableHealth: true})
+		keelApp.SetMetricsCollector(mc)
+		keelApp.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+			return []httpx.Route{
+				httpx.GET("/users/:id", func(c *httpx.Ctx) error { return c.OK(nil) }),
+			}
+		}))
+
+		var paths, rawPaths []string
+		for _, id := range []string{"123", "456"} {
+			keelApp.Fiber().Test(httptest.NewRequest("GET", "/users/"+id, nil)) //nolint
+			paths = append(paths, mc.lastMetrics.Path)
+			rawPaths = append(rawPaths, mc.lastMetrics.RawPath)
+		}
+
+		if paths[0] != "/users/:id" || paths[1] != "/users/:id" {
+			t.Errorf("Path = %v, want /users/:id for both requests", paths)
+		}
+		if rawPaths[0] != "/users/123" || rawPaths[1] != "/users/456" {
+			t.Errorf("RawPath = %v, want the request paths", rawPaths)
+		}
+	})
+
+	t.Run("sizes and error code", func(t *testing.T) {
+		mc := &mockMetricsCollector{}
+		keelApp := New(KConfig{DisableHealth: true})
+		keelApp.SetMetricsCollector(mc)
+		keelApp.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+			return []httpx.Route{
+				httpx.POST("/echo", func(c *httpx.Ctx) error { return c.Send(c.Body()) }),
+				httpx.GET("/missing", func(c *httpx.Ctx) error { return NotFound("no such thing") }),
+				httpx.GET("/boom", func(c *httpx.Ctx) error { return Internal("boom", nil) }),
+			}
+		}))
+
+		keelApp.Fiber().Test(httptest.NewRequest("POST", "/echo", strings.NewReader("0123456789"))) //nolint
+		if mc.lastMetrics.RequestSize != 10 || mc.lastMetrics.ResponseSize != 10 {
+			t.Errorf("RequestSize = %d, ResponseSize = %d, want 10 and 10", mc.lastMetrics.RequestSize, mc.lastMetrics.ResponseSize)
+		}
+		if mc.lastMetrics.ErrorCode != "" {
+			t.Errorf("ErrorCode = %q, want empty for a successful request", mc.lastMetrics.ErrorCode)
+		}
+
+		resp, err := keelApp.Fiber().Test(httptest.NewRequest("GET", "/missing", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body) //nolint
+		if mc.lastMetrics.ErrorCode != "NOT_FOUND" || mc.lastMetrics.StatusCode != 404 {
+			t.Errorf("got ErrorCode %q status %d, want NOT_FOUND and 404", mc.lastMetrics.ErrorCode, mc.lastMetrics.StatusCode)
+		}
+		if len(body) == 0 || mc.lastMetrics.ResponseSize != int64(len(body)) {
+			t.Errorf("ResponseSize = %d, want the %d bytes of the JSON error", mc.lastMetrics.ResponseSize, len(body))
+		}
+
+		resp, err = keelApp.Fiber().Test(httptest.NewRequest("GET", "/boom", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ = io.ReadAll(resp.Body) //nolint
+		if mc.lastMetrics.StatusCode != 500 || len(body) == 0 || mc.lastMetrics.ResponseSize != int64(len(body)) {
+			t.Errorf("status %d ResponseSize = %d, want 500 and the %d bytes of the JSON error", mc.lastMetrics.StatusCode, mc.lastMetrics.ResponseSize, len(body))
+		}
+	})
+
+	t.Run("unmatched requests share one path", func(t *testing.T) {
+		mc := &mockMetricsCollector{}
+		keelApp := New(KConfig{DisableHealth: true})
+		keelApp.SetMetricsCollector(mc)
+
+		keelApp.Fiber().Test(httptest.NewRequest("GET", "/wp-admin.php", nil)) //nolint
+
+		if mc.lastMetrics.Path != "unmatched" || mc.lastMetrics.RawPath != "/wp-admin.php" {
+			t.Errorf("got Path %q RawPath %q, want unmatched and /wp-admin.php", mc.lastMetrics.Path, mc.lastMetrics.RawPath)
+		}
+		if mc.lastMetrics.StatusCode != 404 {
+			t.Errorf("StatusCode = %v, want 404", mc.lastMetrics.StatusCode)
+		}
+	})
 }
 
 type mockMetricsCollector struct {
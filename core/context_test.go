@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/validation"
 )
 
 // newTestApp creates a minimal Fiber app for testing Ctx methods.
@@ -235,3 +237,76 @@ func TestParseBody(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBodyErrorResponse(t *testing.T) {
+	type testDTO struct {
+		Name string `json:"name" validate:"required"`
+	}
+	app := NewTestAppWith(KConfig{}, httpx.POST("/test", func(ctx *httpx.Ctx) error {
+		var dto testDTO
+		if err := ctx.ParseBody(&dto); err != nil {
+			return err
+		}
+		return ctx.OK(dto)
+	}))
+
+	type errorBody struct {
+		StatusCode int                     `json:"status_code"`
+		Code       string                  `json:"code"`
+		Message    string                  `json:"message"`
+		RequestID  string                  `json:"request_id"`
+		Errors     []validation.FieldError `json:"errors"`
+	}
+
+	resp := app.POST("/test", `{}`)
+	body, err := JSONAs[errorBody](resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnprocessableEntity || body.Code != "UNPROCESSABLE_ENTITY" || body.Message != "validation error" || body.RequestID == "" {
+		t.Errorf("422 response = %d %+v", resp.StatusCode, body)
+	}
+	if len(body.Errors) != 1 || body.Errors[0].Field != "name" {
+		t.Errorf("errors = %+v, want one for name", body.Errors)
+	}
+	if got := resp.Header.Get("X-Error-Code"); got != "UNPROCESSABLE_ENTITY" {
+		t.Errorf("X-Error-Code = %q", got)
+	}
+
+	resp = app.POST("/test", `{"name":`)
+	body, err = JSONAs[errorBody](resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusBadRequest || body.Code != "BAD_REQUEST" || body.Message != "invalid request body" || body.Errors != nil {
+		t.Errorf("400 response = %d %+v", resp.StatusCode, body)
+	}
+}
+
+func TestParsePatchBodyErrorResponse(t *testing.T) {
+	type patchDTO struct {
+		Name *string `json:"name" validate:"required"`
+	}
+	app := NewTestAppWith(KConfig{}, httpx.PATCH("/users/:id", func(ctx *httpx.Ctx) error {
+		var dto patchDTO
+		if err := ctx.ParsePatchBody(&dto); err != nil {
+			return err
+		}
+		return ctx.NoContent()
+	}))
+
+	if resp := app.PATCH("/users/1", `{}`); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("empty patch status = %d, want 204", resp.StatusCode)
+	}
+	resp := app.PATCH("/users/1", `{"name":""}`)
+	body, err := JSONAs[struct {
+		Errors []validation.FieldError `json:"errors"`
+	}](resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []validation.FieldError{{Field: "name", Message: "this field is required"}}
+	if resp.StatusCode != http.StatusUnprocessableEntity || !slices.Equal(body.Errors, want) {
+		t.Errorf("response = %d %+v, want 422 with %v", resp.StatusCode, body.Errors, want)
+	}
+}
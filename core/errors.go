@@ -49,3 +49,13 @@ func BadRequest(msg string) *KError {
 func Internal(msg string, cause error) *KError {
 	return &KError{Code: "INTERNAL_ERROR", StatusCode: 500, Message: msg, Cause: cause}
 }
+
+// TooManyRequests creates a 429 KError.
+func TooManyRequests(msg string) *KError {
+	return &KError{Code: "TOO_MANY_REQUESTS", StatusCode: 429, Message: msg}
+}
+
+// GatewayTimeout creates a 504 KError.
+func GatewayTimeout(msg string) *KError {
+	return &KError{Code: "GATEWAY_TIMEOUT", StatusCode: 504, Message: msg}
+}
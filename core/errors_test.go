@@ -1,11 +1,14 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
 )
@@ -166,3 +169,103 @@ func TestKErrorHTTPHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestErrorResponseIncludesRequestID(t *testing.T) {
+	type dto struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"KError 404", "GET", "", 404, "NOT_FOUND"},
+		{"validation 422", "POST", `{}`, 422, "UNPROCESSABLE_ENTITY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{DisableHealth: true})
+			app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+				return []httpx.Route{
+					httpx.GET("/items/:id", func(c *httpx.Ctx) error {
+						return NotFound("item not found")
+					}),
+					httpx.POST("/items/:id", func(c *httpx.Ctx) error {
+						var in dto
+						if err := c.ParseBody(&in); err != nil {
+							return err
+						}
+						return c.OK(in)
+					}),
+				}
+			}))
+
+			req := httptest.NewRequest(tt.method, "/items/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("StatusCode = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("X-Error-Code"); got != tt.wantCode {
+				t.Errorf("X-Error-Code = %q, want %q", got, tt.wantCode)
+			}
+			rid := resp.Header.Get("X-Request-ID")
+			if rid == "" {
+				t.Fatal("X-Request-ID header should be set")
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["request_id"] != rid {
+				t.Errorf("request_id = %v, want %v", body["request_id"], rid)
+			}
+			if body["code"] != tt.wantCode {
+				t.Errorf("code = %v, want %v", body["code"], tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorHandlerLogLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantLevel string
+	}{
+		{"client error logs warn", NotFound("missing"), "[WARN]"},
+		{"server KError logs error", Internal("db down", nil), "[ERROR]"},
+		{"plain error logs error", errors.New("boom"), "[ERROR]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			app := New(KConfig{DisableHealth: true})
+			app.logger = app.logger.WithWriter(&buf)
+			app.Fiber().Get("/fail", func(c *fiber.Ctx) error { return tt.err })
+
+			if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/fail", nil)); err != nil {
+				t.Fatal(err)
+			}
+
+			var line string
+			for _, l := range strings.Split(buf.String(), "\n") {
+				if strings.Contains(l, "HTTP Error") {
+					line = l
+				}
+			}
+			if !strings.Contains(line, tt.wantLevel) {
+				t.Errorf("HTTP Error log = %q, want level %s", line, tt.wantLevel)
+			}
+		})
+	}
+}
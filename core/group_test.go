@@ -168,8 +168,11 @@ func TestGroupHealthCheckers(t *testing.T) {
 		if !ok {
 			t.Fatal("checks should be present")
 		}
-		if checks["db"] != "UP" || checks["cache"] != "UP" {
-			t.Errorf("checks = %v", checks)
+		for _, name := range []string{"db", "cache"} {
+			check, ok := checks[name].(map[string]any)
+			if !ok || check["status"] != "UP" {
+				t.Errorf("checks[%s] = %v, want status UP", name, checks[name])
+			}
 		}
 	})
 
@@ -192,6 +195,11 @@ func TestGroupHealthCheckers(t *testing.T) {
 		if body["status"] != "DOWN" {
 			t.Errorf("status = %v, want DOWN", body["status"])
 		}
+		checks, _ := body["checks"].(map[string]any)
+		redis, ok := checks["redis"].(map[string]any)
+		if !ok || redis["status"] != "DOWN" || redis["error"] != "connection refused" {
+			t.Errorf("checks[redis] = %v, want DOWN with error", checks["redis"])
+		}
 	})
 }
 
@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/openapi"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BasicUser is the user stored by BasicAuthGuard; read it with
+// httpx.UserAs[core.BasicUser].
+type BasicUser struct {
+	Username string
+}
+
+// BasicAuthGuard returns a Guard checking HTTP Basic credentials against
+// users, which maps usernames to passwords. Values that look like bcrypt
+// hashes ("$2a$", "$2b$" or "$2y$" prefix) are verified as such; others are
+// compared in constant time. Rejected requests get a 401 KError and a
+// WWW-Authenticate challenge for realm.
+//
+// Usage:
+//
+//	route.Use(core.BasicAuthGuard(users, "admin").Middleware()).WithSecured("basicAuth")
+func BasicAuthGuard(users map[string]string, realm string) contracts.Guard {
+	return basicAuthGuard{
+		users:     users,
+		challenge: fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, realm),
+	}
+}
+
+type basicAuthGuard struct {
+	users     map[string]string
+	challenge string
+}
+
+// Middleware implements contracts.Guard.
+func (g basicAuthGuard) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, pass, ok := parseBasicAuth(c.Get(fiber.HeaderAuthorization))
+		if !ok || !g.verify(user, pass) {
+			c.Set(fiber.HeaderWWWAuthenticate, g.challenge)
+			return Unauthorized("invalid credentials")
+		}
+		(&httpx.Ctx{Ctx: c}).SetUser(BasicUser{Username: user})
+		return contracts.GuardNext(c)
+	}
+}
+
+// verify reports whether pass is the password of user. Unknown users are
+// still compared against a placeholder so response timing does not reveal
+// which usernames exist.
+func (g basicAuthGuard) verify(user, pass string) bool {
+	want, known := g.users[user]
+	if !known {
+		want = "\x00"
+	}
+	var match bool
+	if isBcryptHash(want) {
+		match = bcrypt.CompareHashAndPassword([]byte(want), []byte(pass)) == nil
+	} else {
+		match = subtle.ConstantTimeCompare([]byte(want), []byte(pass)) == 1
+	}
+	return known && match
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+// APIKeyConfig configures APIKeyGuard.
+type APIKeyConfig struct {
+	Header string // defaults to "X-API-Key"
+	// Query, when set, names a query parameter read when the header is
+	// absent. Pick a name redacted from access logs, such as the default
+	// "api_key", so keys are never logged.
+	Query string
+	// Lookup returns the principal owning key, stored with Ctx.SetUser, or a
+	// nil principal when the key is unknown. Errors fail the request with a
+	// 500. StaticAPIKeys builds one from a map.
+	Lookup func(ctx context.Context, key string) (any, error)
+	// SchemeName is the OpenAPI security scheme the guard defines when
+	// added with Route.UseGuard, documented for routes declaring
+	// WithSecured(SchemeName). Defaults to "apiKey".
+	SchemeName string
+}
+
+// APIKeyGuard returns a Guard authenticating requests by API key. A missing
+// or unknown key gets a 401 KError; the key itself never appears in
+// responses or logs.
+//
+// Usage:
+//
+//	guard := core.APIKeyGuard(core.APIKeyConfig{Lookup: core.StaticAPIKeys(keys)})
+//	route.UseGuard(guard).WithSecured("apiKey")
+func APIKeyGuard(cfg APIKeyConfig) contracts.Guard {
+	if cfg.Header == "" {
+		cfg.Header = "X-API-Key"
+	}
+	if cfg.SchemeName == "" {
+		cfg.SchemeName = "apiKey"
+	}
+	return apiKeyGuard{cfg: cfg}
+}
+
+type apiKeyGuard struct {
+	cfg APIKeyConfig
+}
+
+// Middleware implements contracts.Guard.
+func (g apiKeyGuard) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(g.cfg.Header)
+		if key == "" && g.cfg.Query != "" {
+			key = c.Query(g.cfg.Query)
+		}
+		if key == "" {
+			return Unauthorized("missing API key")
+		}
+		principal, err := g.cfg.Lookup(c.UserContext(), key)
+		if err != nil {
+			return Internal("API key lookup failed", err)
+		}
+		if principal == nil {
+			return Unauthorized("invalid API key")
+		}
+		(&httpx.Ctx{Ctx: c}).SetUser(principal)
+		return contracts.GuardNext(c)
+	}
+}
+
+func (g apiKeyGuard) securitySchemes() map[string]openapi.SecurityScheme {
+	return map[string]openapi.SecurityScheme{
+		g.cfg.SchemeName: {Type: "apiKey", In: "header", Name: g.cfg.Header},
+	}
+}
+
+// StaticAPIKeys returns an APIKeyConfig.Lookup over a fixed map of keys to
+// principals. Every key is compared in constant time.
+func StaticAPIKeys(keys map[string]any) func(context.Context, string) (any, error) {
+	return func(_ context.Context, key string) (any, error) {
+		var found any
+		for k, principal := range keys {
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				found = principal
+			}
+		}
+		return found, nil
+	}
+}
+
+// AnyOf returns a Guard accepting a request when any of guards accepts it.
+// Guards run in order until one passes; when all reject, the last
+// rejection is returned. Each guard must follow the contracts.Guard
+// pass/fail contract.
+//
+// Usage:
+//
+//	route.Use(core.AnyOf(jwtGuard, apiKeyGuard).Middleware())
+func AnyOf(guards ...contracts.Guard) contracts.Guard {
+	return anyOfGuard{guards}
+}
+
+// AllOf returns a Guard accepting a request only when all guards accept
+// it, in order. The first rejection is returned; users stored by earlier
+// guards stay set, so a later guard can inspect them.
+func AllOf(guards ...contracts.Guard) contracts.Guard {
+	return allOfGuard{guards}
+}
+
+// guardSet is the guards combined by AnyOf or AllOf.
+type guardSet []contracts.Guard
+
+func (gs guardSet) handlers() []fiber.Handler {
+	handlers := make([]fiber.Handler, len(gs))
+	for i, g := range gs {
+		handlers[i] = g.Middleware()
+	}
+	return handlers
+}
+
+func (gs guardSet) securitySchemes() map[string]openapi.SecurityScheme {
+	return guardSchemes(gs)
+}
+
+type anyOfGuard struct{ guardSet }
+
+// Middleware implements contracts.Guard.
+func (g anyOfGuard) Middleware() fiber.Handler {
+	handlers := g.handlers()
+	return func(c *fiber.Ctx) error {
+		var err error = Unauthorized("unauthorized")
+		for _, h := range handlers {
+			// A challenge from a rejecting guard must not outlive its attempt.
+			c.Response().Header.Del(fiber.HeaderWWWAuthenticate)
+			if err = contracts.RunGuardCheck(c, h); err == nil {
+				return contracts.GuardNext(c)
+			}
+		}
+		return err
+	}
+}
+
+type allOfGuard struct{ guardSet }
+
+// Middleware implements contracts.Guard.
+func (g allOfGuard) Middleware() fiber.Handler {
+	handlers := g.handlers()
+	return func(c *fiber.Ctx) error {
+		for _, h := range handlers {
+			if err := contracts.RunGuardCheck(c, h); err != nil {
+				return err
+			}
+		}
+		return contracts.GuardNext(c)
+	}
+}
+
+// securitySchemer is implemented by guards defining the OpenAPI security
+// schemes they check, keyed by the name routes pass to WithSecured.
+type securitySchemer interface {
+	securitySchemes() map[string]openapi.SecurityScheme
+}
+
+// guardSchemes returns the schemes defined by guards.
+func guardSchemes(guards []contracts.Guard) map[string]openapi.SecurityScheme {
+	schemes := make(map[string]openapi.SecurityScheme)
+	for _, g := range guards {
+		if s, ok := g.(securitySchemer); ok {
+			maps.Copy(schemes, s.securitySchemes())
+		}
+	}
+	return schemes
+}
+
+// routeSecuritySchemes returns the schemes defined by the guards added to
+// routes with UseGuard, so each app documents only its own guards.
+func routeSecuritySchemes(routes []httpx.Route) map[string]openapi.SecurityScheme {
+	schemes := make(map[string]openapi.SecurityScheme)
+	for _, r := range routes {
+		maps.Copy(schemes, guardSchemes(r.Guards()))
+	}
+	return schemes
+}
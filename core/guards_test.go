@@ -0,0 +1,218 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/openapi"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func basicHeader(user, pass string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+}
+
+func TestBasicAuthGuard(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hashed-secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard := BasicAuthGuard(map[string]string{
+		"alice": "secret",
+		"bob":   string(hash),
+	}, "admin")
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantUser   string
+	}{
+		{"good credentials", basicHeader("alice", "secret"), 200, "alice"},
+		{"bcrypt credentials", basicHeader("bob", "hashed-secret"), 200, "bob"},
+		{"bad password", basicHeader("alice", "wrong"), 401, ""},
+		{"bcrypt hash as password", basicHeader("bob", string(hash)), 401, ""},
+		{"unknown user", basicHeader("mallory", "secret"), 401, ""},
+		{"missing header", "", 401, ""},
+		{"bearer header", "Bearer abc", 401, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{DisableHealth: true})
+			var stored BasicUser
+			app.Fiber().Get("/private", guard.Middleware(), httpx.WrapHandler(func(c *httpx.Ctx) error {
+				stored, _ = httpx.UserAs[BasicUser](c)
+				return c.SendString("ok")
+			}))
+
+			req := httptest.NewRequest("GET", "/private", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if stored.Username != tt.wantUser {
+				t.Errorf("stored user = %q, want %q", stored.Username, tt.wantUser)
+			}
+			if tt.wantStatus == fiber.StatusUnauthorized {
+				if got := resp.Header.Get("WWW-Authenticate"); got != `Basic realm="admin", charset="UTF-8"` {
+					t.Errorf("WWW-Authenticate = %q", got)
+				}
+			}
+		})
+	}
+}
+
+// guardedApp serves /private behind guard and echoes the stored user.
+func guardedApp(guard contracts.Guard) *App {
+	app := New(KConfig{DisableHealth: true})
+	app.Fiber().Get("/private", guard.Middleware(), httpx.WrapHandler(func(c *httpx.Ctx) error {
+		return c.JSON(c.User())
+	}))
+	return app
+}
+
+func TestAPIKeyGuard(t *testing.T) {
+	guard := APIKeyGuard(APIKeyConfig{
+		Query:  "api_key",
+		Lookup: StaticAPIKeys(map[string]any{"k-123": "billing-service"}),
+	})
+	failing := APIKeyGuard(APIKeyConfig{
+		Lookup: func(context.Context, string) (any, error) { return nil, errors.New("db down") },
+	})
+
+	tests := []struct {
+		name       string
+		guard      contracts.Guard
+		target     string
+		header     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"header hit", guard, "/private", "k-123", 200, `"billing-service"`},
+		{"query fallback", guard, "/private?api_key=k-123", "", 200, `"billing-service"`},
+		{"miss", guard, "/private", "k-999", 401, "invalid API key"},
+		{"missing", guard, "/private", "", 401, "missing API key"},
+		{"lookup error", failing, "/private", "k-123", 500, "API key lookup failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			app := guardedApp(tt.guard)
+			app.logger = app.logger.WithWriter(&buf)
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("response = %d %s, want %d containing %s", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+			if strings.Contains(string(body), "k-") || strings.Contains(buf.String(), "k-") {
+				t.Errorf("API key leaked:\nbody: %s\nlogs: %s", body, buf.String())
+			}
+		})
+	}
+}
+
+func TestAPIKeyGuardSecurityScheme(t *testing.T) {
+	guard := APIKeyGuard(APIKeyConfig{Header: "X-Service-Key", SchemeName: "serviceKey", Lookup: StaticAPIKeys(nil)})
+	handler := func(c *httpx.Ctx) error { return nil }
+	want := openapi.SecurityScheme{Type: "apiKey", In: "header", Name: "X-Service-Key"}
+
+	guarded := NewTestAppWith(KConfig{}, httpx.GET("/jobs", handler).UseGuard(guard).WithSecured("serviceKey"))
+	if got := guarded.OpenAPISpec().Components.SecuritySchemes["serviceKey"]; got != want {
+		t.Errorf("serviceKey scheme = %+v, want %+v", got, want)
+	}
+
+	composed := NewTestAppWith(KConfig{}, httpx.GET("/jobs", handler).UseGuard(AnyOf(BasicAuthGuard(nil, "jobs"), guard)).WithSecured("serviceKey"))
+	if got := composed.OpenAPISpec().Components.SecuritySchemes["serviceKey"]; got != want {
+		t.Errorf("serviceKey scheme through AnyOf = %+v, want %+v", got, want)
+	}
+
+	// Another app declaring the scheme without the guard is not affected.
+	other := NewTestAppWith(KConfig{}, httpx.GET("/jobs", handler).WithSecured("serviceKey"))
+	if got := other.OpenAPISpec().Components.SecuritySchemes["serviceKey"]; got.Name != "X-API-Key" {
+		t.Errorf("serviceKey scheme of an app without the guard = %+v, want the inferred one", got)
+	}
+}
+
+func TestRouteUseGuard(t *testing.T) {
+	guard := APIKeyGuard(APIKeyConfig{Lookup: StaticAPIKeys(map[string]any{"k-123": "billing-service"})})
+	app := NewTestAppWith(KConfig{}, httpx.GET("/private", func(c *httpx.Ctx) error {
+		return c.JSON(c.User())
+	}).UseGuard(guard))
+
+	if resp := app.GET("/private", nil); resp.StatusCode != 401 {
+		t.Errorf("without key status = %d, want 401", resp.StatusCode)
+	}
+	resp := app.NewRequest("GET", "/private").Header("X-API-Key", "k-123").Send()
+	if resp.StatusCode != 200 || string(resp.Body) != `"billing-service"` {
+		t.Errorf("with key = %d %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestGuardComposition(t *testing.T) {
+	basic := BasicAuthGuard(map[string]string{"alice": "secret"}, "admin")
+	apiKey := APIKeyGuard(APIKeyConfig{Lookup: StaticAPIKeys(map[string]any{"k-123": "billing-service"})})
+
+	tests := []struct {
+		name       string
+		guard      contracts.Guard
+		basic      string
+		key        string
+		wantStatus int
+		wantBody   string
+	}{
+		{"any: first passes", AnyOf(basic, apiKey), basicHeader("alice", "secret"), "", 200, `{"Username":"alice"}`},
+		{"any: second passes", AnyOf(basic, apiKey), "", "k-123", 200, `"billing-service"`},
+		{"any: none pass", AnyOf(basic, apiKey), basicHeader("alice", "wrong"), "k-999", 401, "invalid API key"},
+		{"all: both pass", AllOf(basic, apiKey), basicHeader("alice", "secret"), "k-123", 200, `"billing-service"`},
+		{"all: first fails", AllOf(basic, apiKey), basicHeader("alice", "wrong"), "k-123", 401, "invalid credentials"},
+		{"all: second fails", AllOf(basic, apiKey), basicHeader("alice", "secret"), "", 401, "missing API key"},
+		{"nested", AllOf(AnyOf(apiKey, basic), basic), basicHeader("alice", "secret"), "", 200, `{"Username":"alice"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := guardedApp(tt.guard)
+
+			req := httptest.NewRequest("GET", "/private", nil)
+			if tt.basic != "" {
+				req.Header.Set("Authorization", tt.basic)
+			}
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("response = %d %s, want %d containing %s", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+			if tt.wantStatus == 200 && resp.Header.Get("WWW-Authenticate") != "" {
+				t.Errorf("WWW-Authenticate = %q on success", resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
@@ -12,65 +15,221 @@ func (a *App) RegisterHealthChecker(h contracts.HealthChecker) {
 	a.healthCheckers = append(a.healthCheckers, h)
 }
 
-// healthResponse is the response for the /health endpoint.
+// HealthCheckerFunc adapts a plain function into a contracts.HealthChecker.
+//
+//	app.RegisterHealthChecker(core.HealthCheckerFunc("db", db.PingContext))
+func HealthCheckerFunc(name string, fn func(ctx context.Context) error) contracts.HealthChecker {
+	return healthCheckerFunc{name: name, fn: fn}
+}
+
+type healthCheckerFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (h healthCheckerFunc) Name() string                    { return h.name }
+func (h healthCheckerFunc) Check(ctx context.Context) error { return h.fn(ctx) }
+
+// healthCheckResult is the outcome of a single health checker.
+type healthCheckResult struct {
+	Status     string `json:"status"           doc:"Check status"                example:"UP"`
+	Error      string `json:"error,omitempty"  doc:"Error reported when DOWN"`
+	DurationMS int64  `json:"duration_ms"      doc:"Check duration in milliseconds"  example:"12"`
+}
+
+// healthResponse is the response for the health endpoints.
 type healthResponse struct {
+	Status  string                       `json:"status"   doc:"Overall service status"  example:"UP"`
+	Service string                       `json:"service"  doc:"Service name"            example:"My API"`
+	Version string                       `json:"version"  doc:"Service version"         example:"1.0.0"`
+	Checks  map[string]healthCheckResult `json:"checks,omitempty" doc:"Per-dependency check results"`
+}
+
+// simpleHealthResponse is the flat /health format used when
+// KConfig.SimpleHealthChecks is enabled.
+type simpleHealthResponse struct {
 	Status  string            `json:"status"   doc:"Overall service status"  example:"UP"`
 	Service string            `json:"service"  doc:"Service name"            example:"My API"`
 	Version string            `json:"version"  doc:"Service version"         example:"1.0.0"`
 	Checks  map[string]string `json:"checks,omitempty" doc:"Per-dependency check results"`
 }
 
-// registerHealth adds the /health route to both Fiber and the OpenAPI spec.
-// It is called automatically in New() unless DisableHealth is set to true.
+// registerHealth adds the liveness, readiness and /health routes to both Fiber
+// and the OpenAPI spec. It is called automatically in New() unless
+// DisableHealth is set to true.
 func (a *App) registerHealth() {
+	paths := a.config.HealthPaths
+	readyResponse := httpx.WithResponse[healthResponse](200)
+	downResponse := httpx.WithResponse[healthResponse](503)
+	if a.config.SimpleHealthChecks {
+		readyResponse = httpx.WithResponse[simpleHealthResponse](200)
+		downResponse = httpx.WithResponse[simpleHealthResponse](503)
+	}
+	downResponse.Description = "A health check failed; the body reports status DOWN"
+
 	a.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
 		return []httpx.Route{
-			httpx.GET("/health", func(c *httpx.Ctx) error {
-				status := "UP"
-				checks := make(map[string]string)
-
-				if len(a.healthCheckers) > 0 {
-					var mu sync.Mutex
-					var wg sync.WaitGroup
-					ctx := c.Context()
-
-					for _, hc := range a.healthCheckers {
-						hc := hc
-						wg.Add(1)
-						go func() {
-							defer wg.Done()
-							result := "UP"
-							if err := hc.Check(ctx); err != nil {
-								result = "DOWN: " + err.Error()
-								mu.Lock()
-								status = "DOWN"
-								mu.Unlock()
-							}
-							mu.Lock()
-							checks[hc.Name()] = result
-							mu.Unlock()
-						}()
-					}
-					wg.Wait()
-				}
-
-				resp := healthResponse{
-					Status:  status,
-					Service: a.config.ServiceName,
-					Version: a.config.Docs.Version,
-				}
-				if len(checks) > 0 {
-					resp.Checks = checks
-				}
-
-				if status == "DOWN" {
-					return c.Status(503).JSON(resp)
-				}
-				return c.OK(resp)
-			}).
+			httpx.GET(paths.Live, a.livenessHandler).
 				WithResponse(httpx.WithResponse[healthResponse](200)).
 				Tag("system").
-				Describe("Health check", "Returns the current status of the service"),
+				Describe("Liveness probe", "Reports whether the process is up without running dependency checks"),
+			httpx.GET(paths.Ready, a.readinessHandler).
+				WithResponse(readyResponse).
+				WithExtraResponse(downResponse).
+				Tag("system").
+				Describe("Readiness probe", "Runs the registered health checkers and reports whether the service can serve traffic"),
+			httpx.GET(paths.Health, a.readinessHandler).
+				WithResponse(readyResponse).
+				WithExtraResponse(downResponse).
+				Tag("system").
+				Describe("Health check", "Returns the current status of the service. Alias of the readiness probe"),
 		}
 	}))
 }
+
+// livenessHandler always reports UP; it never touches dependencies so a
+// failing dependency does not cause the process to be restarted.
+func (a *App) livenessHandler(c *httpx.Ctx) error {
+	return c.OK(healthResponse{
+		Status:  "UP",
+		Service: a.config.ServiceName,
+		Version: a.config.Docs.Version,
+	})
+}
+
+// readinessHandler runs every registered health checker and responds 503 if
+// any of them fails.
+func (a *App) readinessHandler(c *httpx.Ctx) error {
+	report := a.healthReport(c.UserContext())
+
+	code := 200
+	if report.status == "DOWN" {
+		code = 503
+	}
+
+	if a.config.SimpleHealthChecks {
+		resp := simpleHealthResponse{
+			Status:  report.status,
+			Service: a.config.ServiceName,
+			Version: a.config.Docs.Version,
+		}
+		if len(report.checks) > 0 {
+			resp.Checks = make(map[string]string, len(report.checks))
+			for name, result := range report.checks {
+				flat := result.Status
+				if result.Error != "" {
+					flat += ": " + result.Error
+				}
+				resp.Checks[name] = flat
+			}
+		}
+		return c.Status(code).JSON(resp)
+	}
+
+	resp := healthResponse{
+		Status:  report.status,
+		Service: a.config.ServiceName,
+		Version: a.config.Docs.Version,
+	}
+	if len(report.checks) > 0 {
+		resp.Checks = report.checks
+	}
+	return c.Status(code).JSON(resp)
+}
+
+// healthReport is the aggregate result of running all health checkers.
+type healthReport struct {
+	status string
+	checks map[string]healthCheckResult
+}
+
+// healthCache holds the last aggregate report while HealthCacheTTL is active.
+type healthCache struct {
+	mu      sync.Mutex
+	report  healthReport
+	expires time.Time
+}
+
+// healthReport returns the cached report when still fresh, otherwise runs the
+// checkers and refreshes the cache.
+func (a *App) healthReport(ctx context.Context) healthReport {
+	ttl := a.config.HealthCacheTTL
+	if ttl <= 0 {
+		return a.runHealthCheckers(ctx)
+	}
+
+	a.healthCache.mu.Lock()
+	defer a.healthCache.mu.Unlock()
+
+	if a.clock.Now().Before(a.healthCache.expires) {
+		return a.healthCache.report
+	}
+	report := a.runHealthCheckers(ctx)
+	a.healthCache.report = report
+	a.healthCache.expires = a.clock.Now().Add(ttl)
+	return report
+}
+
+// runHealthCheckers runs every checker concurrently, each bounded by
+// HealthCheckTimeout.
+func (a *App) runHealthCheckers(ctx context.Context) healthReport {
+	report := healthReport{
+		status: "UP",
+		checks: make(map[string]healthCheckResult),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, hc := range a.healthCheckers {
+		hc := hc
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := a.clock.Now()
+			result := healthCheckResult{Status: "UP"}
+			if err := checkWithTimeout(ctx, hc, a.config.HealthCheckTimeout); err != nil {
+				result.Status = "DOWN"
+				result.Error = err.Error()
+			}
+			elapsed := a.clock.Now().Sub(start)
+			result.DurationMS = elapsed.Milliseconds()
+			a.recordHealthCheck(hc.Name(), result.Status == "UP", elapsed)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Status == "DOWN" {
+				report.status = "DOWN"
+			}
+			report.checks[hc.Name()] = result
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// recordHealthCheck reports a checker outcome when the metrics collector
+// supports it.
+func (a *App) recordHealthCheck(name string, up bool, d time.Duration) {
+	if hc, ok := a.metricsCollector.(contracts.HealthCollector); ok {
+		hc.RecordHealthCheck(name, up, d)
+	}
+}
+
+// checkWithTimeout runs hc.Check and gives up once timeout expires. A checker
+// that ignores its context keeps running in the background, but the probe
+// response is no longer held up by it.
+func checkWithTimeout(ctx context.Context, hc contracts.HealthChecker, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- hc.Check(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New("timeout")
+	}
+}
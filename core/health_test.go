@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLivenessAndReadiness(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"liveness ignores failing checker", "/health/live", http.StatusOK, "UP"},
+		{"readiness reports failing checker", "/health/ready", http.StatusServiceUnavailable, "DOWN"},
+		{"health aliases readiness", "/health", http.StatusServiceUnavailable, "DOWN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{ServiceName: "Test"})
+			app.RegisterHealthChecker(&mockHealthChecker{name: "redis", err: errors.New("connection refused")})
+
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("StatusCode = %v, want %v", resp.StatusCode, tt.wantCode)
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["status"] != tt.wantBody {
+				t.Errorf("status = %v, want %v", body["status"], tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHealthPathsConfig(t *testing.T) {
+	app := New(KConfig{HealthPaths: HealthPathsConfig{
+		Health: "/status",
+		Live:   "/livez",
+		Ready:  "/readyz",
+	}})
+
+	for _, path := range []string{"/status", "/livez", "/readyz"} {
+		resp, err := app.Fiber().Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s StatusCode = %v, want 200", path, resp.StatusCode)
+		}
+	}
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/health", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("/health StatusCode = %v, want 404 when renamed", resp.StatusCode)
+	}
+}
+
+func TestHealthRoutesInOpenAPI(t *testing.T) {
+	app := New(KConfig{})
+
+	paths := map[string]bool{}
+	for _, r := range app.routes {
+		paths[r.Path()] = true
+	}
+	for _, want := range []string{"/health", "/health/live", "/health/ready"} {
+		if !paths[want] {
+			t.Errorf("route %s not registered for OpenAPI", want)
+		}
+	}
+
+	spec := app.OpenAPISpec()
+	for path, want503 := range map[string]bool{"/health": true, "/health/ready": true, "/health/live": false} {
+		op := spec.Paths[path].(map[string]any)["get"].(map[string]any)
+		down, ok := op["responses"].(map[string]any)["503"].(map[string]any)
+		if ok != want503 {
+			t.Errorf("%s documents 503: %v, want %v", path, ok, want503)
+			continue
+		}
+		if ok && down["content"] == nil {
+			t.Errorf("%s 503 response has no body schema: %v", path, down)
+		}
+	}
+}
+
+// slowHealthChecker blocks for delay unless its context is canceled first.
+type slowHealthChecker struct {
+	name  string
+	delay time.Duration
+}
+
+func (s *slowHealthChecker) Name() string { return s.name }
+func (s *slowHealthChecker) Check(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// countingHealthChecker counts how many times Check is called.
+type countingHealthChecker struct {
+	calls atomic.Int32
+}
+
+func (c *countingHealthChecker) Name() string { return "counting" }
+func (c *countingHealthChecker) Check(context.Context) error {
+	c.calls.Add(1)
+	return nil
+}
+
+func TestHealthCheckerTimeout(t *testing.T) {
+	app := New(KConfig{HealthCheckTimeout: 50 * time.Millisecond})
+	app.RegisterHealthChecker(&slowHealthChecker{name: "db", delay: time.Second})
+	app.RegisterHealthChecker(&mockHealthChecker{name: "cache"})
+
+	start := time.Now()
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/health", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("health response took %v, want it bounded by the checker timeout", elapsed)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("StatusCode = %v, want 503", resp.StatusCode)
+	}
+
+	var body healthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if db := body.Checks["db"]; db.Status != "DOWN" || db.Error != "timeout" {
+		t.Errorf("checks[db] = %+v, want DOWN with timeout error", db)
+	}
+	if cache := body.Checks["cache"]; cache.Status != "UP" {
+		t.Errorf("checks[cache] = %+v, want UP", cache)
+	}
+	if db := body.Checks["db"]; db.DurationMS < 50 {
+		t.Errorf("checks[db].duration_ms = %d, want >= 50", db.DurationMS)
+	}
+}
+
+func TestHealthCacheTTL(t *testing.T) {
+	t.Run("cached result short-circuits second call", func(t *testing.T) {
+		app := New(KConfig{HealthCacheTTL: time.Minute})
+		hc := &countingHealthChecker{}
+		app.RegisterHealthChecker(hc)
+
+		for i := 0; i < 2; i++ {
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/health/ready", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("StatusCode = %v, want 200", resp.StatusCode)
+			}
+		}
+		if got := hc.calls.Load(); got != 1 {
+			t.Fatalf("Check() called %d times, want 1", got)
+		}
+	})
+
+	t.Run("cache disabled runs checkers every time", func(t *testing.T) {
+		app := New(KConfig{})
+		hc := &countingHealthChecker{}
+		app.RegisterHealthChecker(hc)
+
+		for i := 0; i < 2; i++ {
+			if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/health/ready", nil)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := hc.calls.Load(); got != 2 {
+			t.Fatalf("Check() called %d times, want 2", got)
+		}
+	})
+}
+
+func TestHealthCheckerFunc(t *testing.T) {
+	called := false
+	hc := HealthCheckerFunc("db", func(context.Context) error {
+		called = true
+		return errors.New("unreachable")
+	})
+
+	if hc.Name() != "db" {
+		t.Errorf("Name() = %q, want db", hc.Name())
+	}
+	if err := hc.Check(context.Background()); err == nil || err.Error() != "unreachable" {
+		t.Errorf("Check() = %v, want unreachable", err)
+	}
+	if !called {
+		t.Error("Check() should call the wrapped function")
+	}
+}
+
+func TestHealthCheckFormats(t *testing.T) {
+	t.Run("structured checks by default", func(t *testing.T) {
+		app := New(KConfig{})
+		app.RegisterHealthChecker(HealthCheckerFunc("db", func(context.Context) error { return nil }))
+		app.RegisterHealthChecker(HealthCheckerFunc("redis", func(context.Context) error {
+			return errors.New("connection refused")
+		}))
+
+		resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/health", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		checks, ok := body["checks"].(map[string]any)
+		if !ok {
+			t.Fatalf("checks = %v, want object", body["checks"])
+		}
+		redis, ok := checks["redis"].(map[string]any)
+		if !ok {
+			t.Fatalf("checks.redis = %v, want object", checks["redis"])
+		}
+		if redis["status"] != "DOWN" || redis["error"] != "connection refused" {
+			t.Errorf("checks.redis = %v", redis)
+		}
+		if _, ok := redis["duration_ms"].(float64); !ok {
+			t.Errorf("checks.redis.duration_ms = %v, want number", redis["duration_ms"])
+		}
+		db, _ := checks["db"].(map[string]any)
+		if db["status"] != "UP" {
+			t.Errorf("checks.db = %v", db)
+		}
+		if _, hasErr := db["error"]; hasErr {
+			t.Errorf("checks.db should omit error when UP: %v", db)
+		}
+	})
+
+	t.Run("flat checks with SimpleHealthChecks", func(t *testing.T) {
+		app := New(KConfig{SimpleHealthChecks: true})
+		app.RegisterHealthChecker(HealthCheckerFunc("db", func(context.Context) error { return nil }))
+		app.RegisterHealthChecker(HealthCheckerFunc("redis", func(context.Context) error {
+			return errors.New("connection refused")
+		}))
+
+		resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/health", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		checks, _ := body["checks"].(map[string]any)
+		if checks["db"] != "UP" {
+			t.Errorf("checks.db = %v, want UP", checks["db"])
+		}
+		if checks["redis"] != "DOWN: connection refused" {
+			t.Errorf("checks.redis = %v, want %q", checks["redis"], "DOWN: connection refused")
+		}
+	})
+}
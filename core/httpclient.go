@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultHTTPClientTimeout bounds each call made with an HTTPClient,
+// retries included, unless WithClientTimeout says otherwise.
+const DefaultHTTPClientTimeout = 10 * time.Second
+
+// HTTPClientOption configures a client built with HTTPClient.
+type HTTPClientOption func(*httpClientOptions)
+
+type httpClientOptions struct {
+	timeout   time.Duration
+	retries   int
+	backoff   time.Duration
+	transport http.RoundTripper
+}
+
+// WithClientTimeout sets the overall timeout of each call; 0 disables it.
+func WithClientTimeout(d time.Duration) HTTPClientOption {
+	return func(o *httpClientOptions) { o.timeout = d }
+}
+
+// WithClientRetries retries idempotent requests up to n times when the
+// transport fails or the server answers 502, 503 or 504. The wait before
+// retry i is backoff * 2^i.
+func WithClientRetries(n int, backoff time.Duration) HTTPClientOption {
+	return func(o *httpClientOptions) { o.retries, o.backoff = n, backoff }
+}
+
+// WithClientTransport sets the transport that sends the requests. Defaults to
+// http.DefaultTransport.
+func WithClientTransport(rt http.RoundTripper) HTTPClientOption {
+	return func(o *httpClientOptions) { o.transport = rt }
+}
+
+// HTTPClient returns an *http.Client for service-to-service calls. Each
+// request gets a client span from the app tracer and a traceparent header
+// continuing the trace found in the request context, and is reported to the
+// metrics collector when it implements contracts.ClientMetricsCollector.
+// Pass the incoming request's context with http.NewRequestWithContext, or
+// use httpx.Ctx.HTTPClient which binds it automatically.
+func HTTPClient(app *App, opts ...HTTPClientOption) *http.Client {
+	o := httpClientOptions{timeout: DefaultHTTPClientTimeout, transport: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &http.Client{
+		Timeout: o.timeout,
+		Transport: &clientTransport{
+			app:     app,
+			next:    o.transport,
+			retries: o.retries,
+			backoff: o.backoff,
+		},
+	}
+}
+
+// clientTransport instruments outbound requests. The tracer and collector are
+// read per request because they may be set after the client is built.
+type clientTransport struct {
+	app     *App
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.app.tracer.Start(req.Context(), "HTTP "+req.Method)
+	req = req.Clone(ctx)
+	injectTraceParent(ctx, span, req.Header)
+
+	start := time.Now()
+	resp, attempts, err := t.send(req)
+	duration := time.Since(start)
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	SpanSetAttributes(span, map[string]any{
+		"http.method":      req.Method,
+		"http.url":         req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+		"server.address":   req.URL.Hostname(),
+		"http.status_code": status,
+		"http.attempts":    attempts,
+	})
+	if err != nil || status >= http.StatusInternalServerError {
+		spanErr := err
+		if spanErr == nil {
+			spanErr = fmt.Errorf("HTTP %d", status)
+		}
+		span.RecordError(spanErr)
+		SpanSetStatus(span, contracts.SpanStatusError, spanErr.Error())
+	}
+	span.End()
+
+	if mc, ok := t.app.metricsCollector.(contracts.ClientMetricsCollector); ok {
+		mc.RecordClientRequest(contracts.ClientRequestMetrics{
+			Method:     req.Method,
+			Host:       req.URL.Host,
+			StatusCode: status,
+			Duration:   duration,
+			Attempts:   attempts,
+			Err:        err,
+		})
+	}
+	return resp, err
+}
+
+// send performs the request, retrying it while retryable allows.
+func (t *clientTransport) send(req *http.Request) (resp *http.Response, attempts int, err error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, attempt, err
+			}
+		}
+		resp, err = t.next.RoundTrip(req)
+		if attempt >= t.retries || !retryable(req, resp, err) {
+			return resp, attempt + 1, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(t.backoff << attempt)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, attempt + 1, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether a failed attempt may be sent again: the method
+// must be idempotent, the body replayable, and the failure transient.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+	default:
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// injectTraceParent sets the traceparent and tracestate headers for the
+// outbound request unless the caller already set traceparent.
+func injectTraceParent(ctx context.Context, span contracts.Span, h http.Header) {
+	if h.Get(headerTraceParent) != "" {
+		return
+	}
+	traceParent, traceState := traceHeaders(ctx, span)
+	if traceParent == "" {
+		return
+	}
+	h.Set(headerTraceParent, traceParent)
+	if traceState != "" {
+		h.Set(headerTraceState, traceState)
+	}
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// clientMetricsRecorder records outbound request metrics.
+type clientMetricsRecorder struct {
+	mockMetricsCollector
+	client []contracts.ClientRequestMetrics
+}
+
+func (r *clientMetricsRecorder) RecordClientRequest(m contracts.ClientRequestMetrics) {
+	r.client = append(r.client, m)
+}
+
+// flakyServer answers 503 to the first failures requests and 200 afterwards.
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, r.Header.Get("traceparent"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestHTTPClientPropagatesTraceParent(t *testing.T) {
+	srv, _ := flakyServer(t, 0)
+	tracer := &recordingTracer{}
+	collector := &clientMetricsRecorder{}
+	app := New(KConfig{DisableHealth: true})
+	app.SetTracer(tracer)
+	app.SetMetricsCollector(collector)
+
+	resp, err := HTTPClient(app).Get(srv.URL + "/users?id=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if want := "00-" + testTraceID + "-" + testSpanID + "-01"; string(body) != want {
+		t.Errorf("traceparent = %q, want %q", body, want)
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("spans started = %d, want 1", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "HTTP GET" || span.ended != 1 {
+		t.Errorf("span name = %q, ended = %d", span.name, span.ended)
+	}
+	if span.attrs["http.status_code"] != 200 || span.attrs["http.url"] != srv.URL+"/users" {
+		t.Errorf("span attributes = %v", span.attrs)
+	}
+	if len(collector.client) != 1 || collector.client[0].StatusCode != 200 || collector.client[0].Attempts != 1 {
+		t.Errorf("client metrics = %+v", collector.client)
+	}
+}
+
+func TestHTTPClientWithoutTrace(t *testing.T) {
+	srv, _ := flakyServer(t, 0)
+	client := HTTPClient(New(KConfig{DisableHealth: true}))
+	if client.Timeout != DefaultHTTPClientTimeout {
+		t.Errorf("Timeout = %v, want %v", client.Timeout, DefaultHTTPClientTimeout)
+	}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if len(body) != 0 {
+		t.Errorf("traceparent = %q, want none without a trace", body)
+	}
+}
+
+func TestHTTPClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		failures   int32
+		retries    int
+		wantStatus int
+		wantHits   int32
+	}{
+		{"GET recovers", http.MethodGet, 2, 2, 200, 3},
+		{"GET gives up", http.MethodGet, 5, 2, 503, 3},
+		{"PUT with body recovers", http.MethodPut, 1, 2, 200, 2},
+		{"POST is not retried", http.MethodPost, 1, 2, 503, 1},
+		{"no retries by default", http.MethodGet, 1, 0, 503, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := flakyServer(t, tt.failures)
+			client := HTTPClient(New(KConfig{DisableHealth: true}), WithClientRetries(tt.retries, time.Millisecond))
+
+			req, err := http.NewRequest(tt.method, srv.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("server hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestHTTPClientRetryStopsWhenContextEnds(t *testing.T) {
+	srv, hits := flakyServer(t, 10)
+	client := HTTPClient(New(KConfig{DisableHealth: true}), WithClientRetries(5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("Do() error = nil, want the context error")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hits = %d, want 1", got)
+	}
+}
+
+func TestCtxHTTPClientBindsRequestContext(t *testing.T) {
+	srv, _ := flakyServer(t, 0)
+	app := New(KConfig{DisableHealth: true, Tracing: TracingConfig{Enabled: true}})
+	var sent string
+	app.Fiber().Get("/proxy", httpx.WrapHandler(func(c *httpx.Ctx) error {
+		resp, err := c.HTTPClient().Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		sent = string(body)
+		return c.SendStatus(fiber.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest("GET", "/proxy", nil)
+	req.Header.Set("traceparent", "00-"+testTraceID+"-b7ad6b7169203331-01")
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	tp, ok := parseTraceParent(sent)
+	if !ok || tp.TraceID != testTraceID {
+		t.Errorf("outbound traceparent = %q, want trace %s", sent, testTraceID)
+	}
+	if tp.SpanID == "b7ad6b7169203331" {
+		t.Error("outbound traceparent reuses the caller's span ID instead of the server span")
+	}
+}
@@ -1,6 +1,12 @@
 package httpx
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/validation"
@@ -19,26 +25,78 @@ func WrapHandler(h func(*Ctx) error) fiber.Handler {
 }
 
 // ParseBody parses and validates the request body.
-// Returns 400 if JSON is invalid, 422 if validation fails.
+// Returns a 400 *fiber.Error if JSON is invalid and a *ValidationError if
+// validation fails. Validation messages are translated to the request
+// language when the app has a translator.
 func (c *Ctx) ParseBody(dst any) error {
 	if err := c.Ctx.BodyParser(dst); err != nil {
-		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"status_code": 400,
-			"message":     "invalid request body",
-		})
-		return fiber.ErrBadRequest
+		return errInvalidBody
+	}
+	return validationFailed(validation.ValidateWithLocale(dst, c.Lang(), c.translator()))
+}
+
+// ParsePatchBody parses a JSON body for a partial update and validates only
+// the fields it provides; see validation.ValidatePartial. Decode dst with
+// pointer fields so absent ones stay nil. Returns a 400 *fiber.Error if the
+// JSON is invalid or has fields dst does not declare, and a *ValidationError
+// if validation fails.
+func (c *Ctx) ParsePatchBody(dst any) error {
+	dec := json.NewDecoder(bytes.NewReader(c.Body()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return errInvalidBody
 	}
+	return validationFailed(validation.ValidatePartialWithLocale(dst, c.Lang(), c.translator()))
+}
+
+// errInvalidBody is returned for unparsable input.
+var errInvalidBody = fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+
+// ValidationError is returned by ParseBody and ParsePatchBody when the body
+// fails validation. The App error handler renders it as a 422 response
+// listing Errors; it unwraps to fiber.ErrUnprocessableEntity, so plain Fiber
+// apps answer 422 as well.
+type ValidationError struct {
+	Errors []validation.FieldError
+}
 
-	if errs := validation.Validate(dst); len(errs) > 0 {
-		c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"status_code": 422,
-			"message":     "validation error",
-			"errors":      errs,
-		})
-		return fiber.ErrUnprocessableEntity
+func (e *ValidationError) Error() string { return "validation error" }
+
+func (e *ValidationError) Unwrap() error { return fiber.ErrUnprocessableEntity }
+
+// validationFailed returns a *ValidationError for errs, if there are any.
+func validationFailed(errs []validation.FieldError) error {
+	if len(errs) == 0 {
+		return nil
 	}
+	return &ValidationError{Errors: errs}
+}
+
+// RequestID returns the ID assigned to the current request by the requestid
+// middleware. Returns "" when no ID has been assigned.
+func (c *Ctx) RequestID() string {
+	return RequestID(c.Ctx)
+}
+
+// RequestID returns the ID the requestid middleware assigned to the request
+// of c, or "", for code handling a plain *fiber.Ctx.
+func RequestID(c *fiber.Ctx) string {
+	rid, _ := c.Locals("requestid").(string)
+	return rid
+}
+
+// TraceID returns the trace ID of the current request, for correlating logs
+// and error reports with the trace. Returns "" when tracing is off.
+func (c *Ctx) TraceID() string {
+	id, _ := c.Locals("traceid").(string)
+	return id
+}
 
-	return nil
+// SpanID returns the ID of the server span wrapping the current request.
+// Returns "" when tracing is off.
+func (c *Ctx) SpanID() string {
+	id, _ := c.Locals("spanid").(string)
+	return id
 }
 
 // SetUser stores the authenticated user in Fiber locals for later retrieval.
@@ -75,13 +133,61 @@ func (c *Ctx) Lang() string {
 // T translates a key using a translator stored in locals.
 // Returns the key unchanged if no translator is registered.
 func (c *Ctx) T(key string, args ...any) string {
-	t, ok := c.Locals("_keel_translator").(contracts.Translator)
-	if !ok || t == nil {
+	t := c.translator()
+	if t == nil {
 		return key
 	}
 	return t.T(c.Lang(), key, args...)
 }
 
+// translator returns the app translator stored in locals, or nil.
+func (c *Ctx) translator() contracts.Translator {
+	t, _ := c.Locals("_keel_translator").(contracts.Translator)
+	return t
+}
+
+// HTTPClient returns the app's outbound HTTP client bound to this request.
+// Requests sent with it see the values of the request context, such as the
+// trace, on top of their own, so plain client.Get calls continue the trace;
+// their deadline and cancellation stay their own. Outside a Keel app it
+// returns a plain client with a 10s timeout.
+func (c *Ctx) HTTPClient() *http.Client {
+	base, _ := c.Locals("_keel_http_client").(*http.Client)
+	if base == nil {
+		base = &http.Client{Timeout: 10 * time.Second}
+	}
+	next := base.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	bound := *base
+	bound.Transport = boundTransport{values: c.UserContext(), next: next}
+	return &bound
+}
+
+// boundTransport adds the values of a request context to outbound requests.
+type boundTransport struct {
+	values context.Context
+	next   http.RoundTripper
+}
+
+func (t boundTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(valuesContext{Context: req.Context(), values: t.values}))
+}
+
+// valuesContext looks values up in Context first, then in values.
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valuesContext) Value(key any) any {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.values.Value(key)
+}
+
 // OK responds with HTTP 200 and a JSON body.
 func (c *Ctx) OK(data any) error {
 	return c.Status(fiber.StatusOK).JSON(data)
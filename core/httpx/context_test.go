@@ -3,6 +3,7 @@ package httpx
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -280,3 +281,89 @@ func TestResponseHelpers(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBodyErrors(t *testing.T) {
+	type dto struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	var errs []error
+	app := newHTTPXTestApp("POST", "/body", func(c *Ctx) error {
+		var in dto
+		err := c.ParseBody(&in)
+		errs = append(errs, err)
+		return err
+	})
+	for _, body := range []string{`{"name":`, `{}`} {
+		req := httptest.NewRequest("POST", "/body", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var fe *fiber.Error
+	if !errors.As(errs[0], &fe) || fe.Code != http.StatusBadRequest || fe.Message != "invalid request body" {
+		t.Errorf("invalid JSON err = %v, want a 400 *fiber.Error", errs[0])
+	}
+	var ve *ValidationError
+	if !errors.As(errs[1], &ve) || len(ve.Errors) != 1 || ve.Errors[0].Field != "name" {
+		t.Fatalf("validation err = %#v, want a *ValidationError for name", errs[1])
+	}
+	if !errors.Is(errs[1], fiber.ErrUnprocessableEntity) {
+		t.Error("ValidationError does not unwrap to fiber.ErrUnprocessableEntity")
+	}
+}
+
+func TestParsePatchBody(t *testing.T) {
+	type patchDTO struct {
+		Name  *string `json:"name" validate:"required,min=2"`
+		Email *string `json:"email" validate:"required,email"`
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantBody  string
+		wantField string
+	}{
+		{"empty object", `{}`, http.StatusOK, `{"name":null,"email":null}`, ""},
+		{"valid subset", `{"name":"Ana"}`, http.StatusOK, `{"name":"Ana","email":null}`, ""},
+		{"invalid provided field", `{"email":"nope"}`, http.StatusUnprocessableEntity, "", "email"},
+		{"unknown field", `{"nickname":"x"}`, http.StatusBadRequest, "invalid request body", ""},
+		{"malformed json", `{"name":`, http.StatusBadRequest, "invalid request body", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parseErr error
+			app := newHTTPXTestApp("PATCH", "/users/1", func(c *Ctx) error {
+				var in patchDTO
+				if parseErr = c.ParsePatchBody(&in); parseErr != nil {
+					return parseErr
+				}
+				return c.OK(in)
+			})
+
+			req := httptest.NewRequest("PATCH", "/users/1", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			var buf bytes.Buffer
+			buf.ReadFrom(resp.Body) //nolint
+			if !bytes.Contains(buf.Bytes(), []byte(tt.wantBody)) {
+				t.Errorf("body = %s, want it to contain %s", buf.String(), tt.wantBody)
+			}
+			var ve *ValidationError
+			if tt.wantField != "" && (!errors.As(parseErr, &ve) || ve.Errors[0].Field != tt.wantField) {
+				t.Errorf("err = %#v, want a *ValidationError for %s", parseErr, tt.wantField)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package httpx
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
 
 // QueryParamMeta documents a query string parameter in OpenAPI.
 type QueryParamMeta struct {
@@ -16,13 +19,16 @@ type Route struct {
 	path        string
 	handler     func(*Ctx) error
 	middlewares []fiber.Handler
+	guards      []contracts.Guard
 
 	summary     string
 	description string
 	tags        []string
 	secured     []string
+	roles       []string
 	body        *BodyMeta
 	response    *ResponseMeta
+	extra       []*ResponseMeta
 	queryParams []QueryParamMeta
 	deprecated  bool
 }
@@ -37,6 +43,9 @@ type BodyMeta struct {
 type ResponseMeta struct {
 	Type       any
 	StatusCode int
+	// Description is shown in OpenAPI; responses added with
+	// WithExtraResponse default to the status text.
+	Description string
 }
 
 // Method returns the HTTP method of the route.
@@ -51,6 +60,9 @@ func (r Route) Handler() func(*Ctx) error { return r.handler }
 // Middlewares returns the middleware handlers.
 func (r Route) Middlewares() []fiber.Handler { return r.middlewares }
 
+// Guards returns the guards added with UseGuard.
+func (r Route) Guards() []contracts.Guard { return r.guards }
+
 // Summary returns the OpenAPI summary.
 func (r Route) Summary() string { return r.summary }
 
@@ -63,12 +75,18 @@ func (r Route) Tags() []string { return r.tags }
 // Secured returns the list of security schemes required.
 func (r Route) Secured() []string { return r.secured }
 
+// Roles returns the roles required by WithRoles.
+func (r Route) Roles() []string { return r.roles }
+
 // Body returns the request body metadata.
 func (r Route) Body() *BodyMeta { return r.body }
 
 // Response returns the response metadata.
 func (r Route) Response() *ResponseMeta { return r.response }
 
+// ExtraResponses returns the responses added by WithExtraResponse.
+func (r Route) ExtraResponses() []*ResponseMeta { return r.extra }
+
 // QueryParams returns the query parameter definitions.
 func (r Route) QueryParams() []QueryParamMeta { return r.queryParams }
 
@@ -99,6 +117,14 @@ func (r Route) WithResponse(res *ResponseMeta) Route {
 	return r
 }
 
+// WithExtraResponse documents another response of the route besides the
+// one set by WithResponse, such as a 503 with its own body. It replaces the
+// automatic error response with the same status code.
+func (r Route) WithExtraResponse(res *ResponseMeta) Route {
+	r.extra = append(r.extra, res)
+	return r
+}
+
 // Tag adds an OpenAPI tag to classify the route.
 func (r Route) Tag(tag string) Route {
 	r.tags = append(r.tags, tag)
@@ -120,12 +146,31 @@ func (r Route) WithSecured(schemes ...string) Route {
 	return r
 }
 
+// WithRoles requires the authenticated user to hold every role. The app
+// checks them with core.RequireRoles after the route middlewares, so add the
+// authenticating guard with Use, and documents them in OpenAPI.
+func (r Route) WithRoles(roles ...string) Route {
+	r.roles = append(r.roles, roles...)
+	return r
+}
+
 // Use adds execution middlewares to the route.
 func (r Route) Use(middlewares ...fiber.Handler) Route {
 	r.middlewares = append(r.middlewares, middlewares...)
 	return r
 }
 
+// UseGuard adds the middlewares of guards to the route, like Use, and keeps
+// the guards so the app documents the OpenAPI security schemes they define,
+// such as the one of core.APIKeyGuard.
+func (r Route) UseGuard(guards ...contracts.Guard) Route {
+	for _, g := range guards {
+		r.middlewares = append(r.middlewares, g.Middleware())
+	}
+	r.guards = append(r.guards, guards...)
+	return r
+}
+
 // PrependMiddlewares prepends middlewares before existing route middlewares.
 func (r Route) PrependMiddlewares(middlewares ...fiber.Handler) Route {
 	r.middlewares = append(append([]fiber.Handler{}, middlewares...), r.middlewares...)
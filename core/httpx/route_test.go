@@ -50,6 +50,7 @@ func TestBuilderMetadata(t *testing.T) {
 	route := POST("/users", func(c *Ctx) error { return c.SendStatus(http.StatusCreated) }).
 		WithBody(WithBody[req]()).
 		WithResponse(WithResponse[res](http.StatusCreated)).
+		WithExtraResponse(WithResponse[res](http.StatusAccepted)).
 		Tag("users").
 		Tag("admin").
 		Describe("Create user", "Creates a user").
@@ -63,6 +64,9 @@ func TestBuilderMetadata(t *testing.T) {
 	if route.Response() == nil || route.Response().StatusCode != http.StatusCreated {
 		t.Fatal("Response() not configured correctly")
 	}
+	if extra := route.ExtraResponses(); len(extra) != 1 || extra[0].StatusCode != http.StatusAccepted {
+		t.Fatalf("ExtraResponses() = %v", extra)
+	}
 	if route.Summary() != "Create user" || route.Description() != "Creates a user" {
 		t.Fatalf("Describe() not applied: summary=%q description=%q", route.Summary(), route.Description())
 	}
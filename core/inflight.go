@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// inflight counts the requests being handled so shutdown can cancel the
+// ones still running after the drain timeout and wait for their handlers to
+// return.
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // closed when n drops to zero, set by wait
+	ctx  context.Context
+	stop context.CancelFunc
+}
+
+// begin records a request and returns the context cancelled by cancel.
+func (r *inflight) begin() context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+	r.n++
+	return r.ctx
+}
+
+func (r *inflight) end() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.n--
+	if r.n == 0 && r.idle != nil {
+		close(r.idle)
+		r.idle = nil
+	}
+}
+
+// cancel cancels the contexts of the requests in flight and of later ones.
+func (r *inflight) cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.init()
+	r.stop()
+}
+
+func (r *inflight) init() {
+	if r.ctx == nil {
+		r.ctx, r.stop = context.WithCancel(context.Background())
+	}
+}
+
+// wait blocks until no request is in flight or ctx is done, and returns how
+// many requests are still running.
+func (r *inflight) wait(ctx context.Context) int {
+	r.mu.Lock()
+	if r.n == 0 {
+		r.mu.Unlock()
+		return 0
+	}
+	if r.idle == nil {
+		r.idle = make(chan struct{})
+	}
+	idle := r.idle
+	r.mu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.n
+	}
+}
+
+// inflightMiddleware records each request in a.inflight and derives its
+// c.UserContext() from a context that shutdown cancels after the drain
+// timeout, so handlers honouring it stop early.
+func (a *App) inflightMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		shutdown := a.inflight.begin()
+		defer a.inflight.end()
+
+		ctx, cancel := context.WithCancel(c.UserContext())
+		defer cancel()
+		stop := context.AfterFunc(shutdown, cancel)
+		defer stop()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// connSet tracks the open connections of the server, through its ConnState
+// hook, so shutdown can force-close the ones left after the drain timeout.
+type connSet struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// track implements fasthttp.Server.ConnState.
+func (s *connSet) track(c net.Conn, state fasthttp.ConnState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch state {
+	case fasthttp.StateNew:
+		if s.conns == nil {
+			s.conns = make(map[net.Conn]struct{})
+		}
+		s.conns[c] = struct{}{}
+	case fasthttp.StateClosed, fasthttp.StateHijacked:
+		delete(s.conns, c)
+	}
+}
+
+// closeAll force-closes the open connections and returns how many there
+// were. Each is shut down in both directions rather than closed: clients see
+// it end at once, and fasthttp closes it when its next read or write fails,
+// whereas closing it under a running handler makes fasthttp panic when the
+// handler returns.
+func (s *connSet) closeAll() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		if tc, ok := c.(interface{ NetConn() net.Conn }); ok {
+			c = tc.NetConn()
+		}
+		if sc, ok := c.(interface {
+			CloseRead() error
+			CloseWrite() error
+		}); ok {
+			_ = sc.CloseRead()
+			_ = sc.CloseWrite()
+			continue
+		}
+		// Other connections can only have their pending I/O interrupted.
+		_ = c.SetDeadline(time.Now())
+	}
+	return len(s.conns)
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// JWT algorithms supported by JWTGuard.
+const (
+	JWTHS256 = "HS256"
+	JWTRS256 = "RS256"
+)
+
+// JWTConfig configures JWTGuard.
+type JWTConfig struct {
+	Algorithm string         // JWTHS256 (default) or JWTRS256; tokens signed with another algorithm are rejected
+	Secret    []byte         // HS256 key
+	PublicKey *rsa.PublicKey // RS256 key
+	// KeyFunc, when set, picks the verification key from the token header,
+	// e.g. by "kid", instead of Secret/PublicKey. It returns []byte for
+	// HS256 or *rsa.PublicKey for RS256.
+	KeyFunc  func(header map[string]any) (any, error)
+	Issuer   string        // required "iss" when set
+	Audience string        // required in "aud" when set
+	Leeway   time.Duration // clock skew tolerated on exp and nbf
+	Cookie   string        // cookie read when there is no Authorization header
+	// ClaimsFactory returns a pointer the claims are decoded into and stored
+	// with Ctx.SetUser, e.g. func() any { return &MyClaims{} }. Defaults to
+	// storing the claims as map[string]any, with numbers as json.Number.
+	ClaimsFactory func() any
+}
+
+// JWTGuard returns a Guard verifying bearer JWTs from the Authorization
+// header, or cfg.Cookie. Failures get a 401 KError whose message tells
+// expired, not yet valid, malformed and wrongly signed tokens apart, and
+// names a failed issuer or audience check. exp and nbf are checked against
+// the App clock, so App.SetClock with a fake clock controls expiry in tests.
+//
+// Usage:
+//
+//	route.Use(core.JWTGuard(core.JWTConfig{Secret: key}).Middleware()).WithSecured("bearerAuth")
+func JWTGuard(cfg JWTConfig) contracts.Guard {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = JWTHS256
+	}
+	return jwtGuard{cfg: cfg}
+}
+
+type jwtGuard struct {
+	cfg JWTConfig
+}
+
+// JWT verification failures, reported as the 401 message.
+var (
+	errTokenMissing      = errors.New("missing bearer token")
+	errTokenMalformed    = errors.New("malformed token")
+	errTokenSignature    = errors.New("invalid token signature")
+	errTokenExpired      = errors.New("token expired")
+	errTokenNotYetValid  = errors.New("token not yet valid")
+	errTokenBadIssuer    = errors.New("invalid token issuer")
+	errTokenBadAudience  = errors.New("invalid token audience")
+	errTokenUnsupported  = errors.New("unsupported token algorithm")
+	errTokenKeyNotUsable = errors.New("JWT verification key not usable") // server misconfiguration
+)
+
+// Middleware implements contracts.Guard.
+func (g jwtGuard) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := g.verify(g.token(c), ClockFrom(c).Now())
+		if errors.Is(err, errTokenKeyNotUsable) {
+			return Internal(err.Error(), nil)
+		}
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return Unauthorized(err.Error())
+		}
+		(&httpx.Ctx{Ctx: c}).SetUser(claims)
+		return contracts.GuardNext(c)
+	}
+}
+
+// token returns the bearer token of the request, or "".
+func (g jwtGuard) token(c *fiber.Ctx) string {
+	const prefix = "Bearer "
+	if h := c.Get(fiber.HeaderAuthorization); len(h) > len(prefix) && strings.EqualFold(h[:len(prefix)], prefix) {
+		return strings.TrimSpace(h[len(prefix):])
+	}
+	if g.cfg.Cookie != "" {
+		return c.Cookies(g.cfg.Cookie)
+	}
+	return ""
+}
+
+// verify checks the token signature and registered claims and returns the
+// claims to store. exp and nbf are checked against now.
+func (g jwtGuard) verify(token string, now time.Time) (any, error) {
+	if token == "" {
+		return nil, errTokenMissing
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errTokenMalformed
+	}
+	var header map[string]any
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errTokenMalformed
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errTokenMalformed
+	}
+	var claims map[string]any
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, errTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errTokenMalformed
+	}
+
+	if alg, _ := header["alg"].(string); alg != g.cfg.Algorithm {
+		return nil, errTokenUnsupported
+	}
+	if err := g.verifySignature(header, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+	if err := g.validateClaims(claims, now); err != nil {
+		return nil, err
+	}
+
+	if g.cfg.ClaimsFactory == nil {
+		return claims, nil
+	}
+	typed := g.cfg.ClaimsFactory()
+	if err := json.Unmarshal(payload, typed); err != nil {
+		return nil, errTokenMalformed
+	}
+	return typed, nil
+}
+
+func (g jwtGuard) verifySignature(header map[string]any, signed string, signature []byte) error {
+	var key any
+	switch {
+	case g.cfg.KeyFunc != nil:
+		k, err := g.cfg.KeyFunc(header)
+		if err != nil {
+			return errTokenSignature
+		}
+		key = k
+	case g.cfg.Algorithm == JWTRS256:
+		key = g.cfg.PublicKey
+	default:
+		key = g.cfg.Secret
+	}
+
+	switch g.cfg.Algorithm {
+	case JWTHS256:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return errTokenKeyNotUsable
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errTokenSignature
+		}
+	case JWTRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || pub == nil {
+			return errTokenKeyNotUsable
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return errTokenSignature
+		}
+	default:
+		return errTokenUnsupported
+	}
+	return nil
+}
+
+// validateClaims checks exp, nbf, iss and aud.
+func (g jwtGuard) validateClaims(claims map[string]any, now time.Time) error {
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return err
+	} else if ok && now.After(exp.Add(g.cfg.Leeway)) {
+		return errTokenExpired
+	}
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(g.cfg.Leeway).Before(nbf) {
+		return errTokenNotYetValid
+	}
+	if g.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != g.cfg.Issuer {
+			return errTokenBadIssuer
+		}
+	}
+	if g.cfg.Audience != "" && !slices.Contains(audiences(claims["aud"]), g.cfg.Audience) {
+		return errTokenBadAudience
+	}
+	return nil
+}
+
+// maxNumericDate is the last second of year 9999; later NumericDate claims
+// are rejected as malformed rather than overflowing time.Time arithmetic.
+const maxNumericDate = 253402300799
+
+// numericDate reads a NumericDate claim. ok is false when it is absent.
+func numericDate(claims map[string]any, name string) (t time.Time, ok bool, err error) {
+	v, present := claims[name]
+	if !present {
+		return time.Time{}, false, nil
+	}
+	n, isNumber := v.(json.Number)
+	if !isNumber {
+		return time.Time{}, false, errTokenMalformed
+	}
+	secs, err := n.Float64()
+	if err != nil || secs < 0 || secs > maxNumericDate {
+		return time.Time{}, false, errTokenMalformed
+	}
+	whole, frac := math.Modf(secs)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), true, nil
+}
+
+// audiences returns the "aud" claim, which may be a string or an array.
+func audiences(v any) []string {
+	switch aud := v.(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		out := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON token segment into v.
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+var jwtSecret = []byte("test-secret")
+
+// signJWT builds a token signed with key: []byte for HS256, *rsa.PrivateKey
+// for RS256.
+func signJWT(t *testing.T, alg string, key any, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]any{"alg": alg, "typ": "JWT"}) + "." + enc(claims)
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+type testClaims struct {
+	Sub   string   `json:"sub"`
+	Roles []string `json:"roles"`
+}
+
+func TestJWTGuard(t *testing.T) {
+	now := time.Now()
+	valid := map[string]any{
+		"sub": "user-1",
+		"iss": "keel",
+		"aud": []string{"api", "admin"},
+		"exp": now.Add(time.Hour).Unix(),
+		"nbf": now.Add(-time.Minute).Unix(),
+	}
+	with := func(k string, v any) map[string]any {
+		c := map[string]any{}
+		for key, val := range valid {
+			c[key] = val
+		}
+		c[k] = v
+		return c
+	}
+	cfg := JWTConfig{Secret: jwtSecret, Issuer: "keel", Audience: "api"}
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantMsg    string
+	}{
+		{"valid", "Bearer " + signJWT(t, "HS256", jwtSecret, valid), 200, "user-1"},
+		{"expired", "Bearer " + signJWT(t, "HS256", jwtSecret, with("exp", now.Add(-time.Minute).Unix())), 401, "token expired"},
+		{"not yet valid", "Bearer " + signJWT(t, "HS256", jwtSecret, with("nbf", now.Add(time.Hour).Unix())), 401, "token not yet valid"},
+		{"wrong signature", "Bearer " + signJWT(t, "HS256", []byte("other"), valid), 401, "invalid token signature"},
+		{"malformed", "Bearer not.a-token", 401, "malformed token"},
+		{"two segments", "Bearer abc.def", 401, "malformed token"},
+		{"alg none", "Bearer " + strings.TrimSuffix(signJWT(t, "none", []byte("x"), valid), "x"), 401, "unsupported token algorithm"},
+		{"exp overflowing int64 nanoseconds", "Bearer " + signJWT(t, "HS256", jwtSecret, with("exp", 1e19)), 401, "malformed token"},
+		{"exp after year 9999", "Bearer " + signJWT(t, "HS256", jwtSecret, with("exp", int64(maxNumericDate+1))), 401, "malformed token"},
+		{"negative nbf", "Bearer " + signJWT(t, "HS256", jwtSecret, with("nbf", -1)), 401, "malformed token"},
+		{"fractional exp", "Bearer " + signJWT(t, "HS256", jwtSecret, with("exp", float64(now.Add(time.Hour).Unix())+0.5)), 200, "user-1"},
+		{"wrong issuer", "Bearer " + signJWT(t, "HS256", jwtSecret, with("iss", "other")), 401, "invalid token issuer"},
+		{"wrong audience", "Bearer " + signJWT(t, "HS256", jwtSecret, with("aud", "web")), 401, "invalid token audience"},
+		{"missing", "", 401, "missing bearer token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := guardedApp(JWTGuard(cfg))
+			req := httptest.NewRequest("GET", "/private", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantMsg) {
+				t.Errorf("response = %d %s, want %d containing %q", resp.StatusCode, body, tt.wantStatus, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestJWTGuardUsesAppClock(t *testing.T) {
+	start := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := keeltest.NewFakeClock(start)
+	app := guardedApp(JWTGuard(JWTConfig{Secret: jwtSecret}))
+	app.SetClock(clock)
+	token := "Bearer " + signJWT(t, "HS256", jwtSecret, map[string]any{
+		"sub": "user-1",
+		"nbf": start.Add(-time.Minute).Unix(),
+		"exp": start.Add(time.Hour).Unix(),
+	})
+
+	status := func() int {
+		req := httptest.NewRequest("GET", "/private", nil)
+		req.Header.Set("Authorization", token)
+		resp, err := app.Fiber().Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	if got := status(); got != 200 {
+		t.Fatalf("status before expiry = %d, want 200", got)
+	}
+	clock.Advance(2 * time.Hour)
+	if got := status(); got != 401 {
+		t.Errorf("status after expiry = %d, want 401", got)
+	}
+}
+
+func TestJWTGuardRS256TypedClaimsAndCookie(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard := JWTGuard(JWTConfig{
+		Algorithm:     JWTRS256,
+		KeyFunc:       func(map[string]any) (any, error) { return &key.PublicKey, nil },
+		Cookie:        "session",
+		ClaimsFactory: func() any { return &testClaims{} },
+	})
+	app := New(KConfig{DisableHealth: true})
+	var got *testClaims
+	app.Fiber().Get("/private", guard.Middleware(), httpx.WrapHandler(func(c *httpx.Ctx) error {
+		got, _ = httpx.UserAs[*testClaims](c)
+		return c.SendString("ok")
+	}))
+
+	token := signJWT(t, "RS256", key, map[string]any{"sub": "user-2", "roles": []string{"admin"}})
+	req := httptest.NewRequest("GET", "/private", nil)
+	req.Header.Set("Cookie", "session="+token)
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got == nil || got.Sub != "user-2" || len(got.Roles) != 1 || got.Roles[0] != "admin" {
+		t.Errorf("claims = %+v", got)
+	}
+
+	// An HS256 token signed with the public key must not pass an RS256 guard.
+	forged := signJWT(t, "HS256", key.PublicKey.N.Bytes(), map[string]any{"sub": "mallory"})
+	req = httptest.NewRequest("GET", "/private", nil)
+	req.Header.Set("Authorization", "Bearer "+forged)
+	if resp, _ := app.Fiber().Test(req); resp.StatusCode != 401 {
+		t.Errorf("algorithm confusion: status = %d, want 401", resp.StatusCode)
+	}
+}
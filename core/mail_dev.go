@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// devMailerPreviewLines is how many body lines DevMailer logs.
+const devMailerPreviewLines = 3
+
+// DevMailer is a contracts.Mailer for development and CI that never sends
+// anything: it logs a summary of each mail, or writes it as an .eml file
+// that mail clients open, attachments included.
+type DevMailer struct {
+	logger *logger.Logger
+	dir    string
+}
+
+var _ contracts.Mailer = (*DevMailer)(nil)
+
+// DevMailerOption configures NewDevMailer.
+type DevMailerOption func(*DevMailer)
+
+// WithDevMailerLogger sets the logger mails are reported to, typically
+// app.Logger(). Defaults to a development logger.
+func WithDevMailerLogger(l *logger.Logger) DevMailerOption {
+	return func(m *DevMailer) { m.logger = l }
+}
+
+// WithDevMailerDir switches to file mode: each mail is written to dir, which
+// is created when missing, as <timestamp>-<id>.eml, and only its path is
+// logged.
+func WithDevMailerDir(dir string) DevMailerOption {
+	return func(m *DevMailer) { m.dir = dir }
+}
+
+// NewDevMailer returns a DevMailer, in log mode unless WithDevMailerDir is
+// given.
+//
+//	mailer := core.NewDevMailer(core.WithDevMailerLogger(app.Logger()), core.WithDevMailerDir("tmp/mail"))
+func NewDevMailer(opts ...DevMailerOption) *DevMailer {
+	m := &DevMailer{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.logger == nil {
+		m.logger = logger.NewLogger(false)
+	}
+	return m
+}
+
+// Send implements contracts.Mailer.
+func (m *DevMailer) Send(ctx context.Context, mail contracts.Mail) error {
+	if _, err := mailRecipients(mail); err != nil {
+		return err
+	}
+	if m.dir == "" {
+		m.logger.InfoCtx(ctx, "Mail to %s: %q (%d attachment(s))\n%s",
+			strings.Join(mail.To, ", "), mail.Subject, len(mail.Attachments), mailPreview(mail))
+		return nil
+	}
+
+	data, err := buildMIMEMessage(mail, time.Now())
+	if err != nil {
+		return err
+	}
+	path, err := m.writeEML(data)
+	if err != nil {
+		return fmt.Errorf("mail: write %s: %w", m.dir, err)
+	}
+	m.logger.InfoCtx(ctx, "Mail to %s: %q written to %s", strings.Join(mail.To, ", "), mail.Subject, path)
+	return nil
+}
+
+func (m *DevMailer) writeEML(data []byte) (string, error) {
+	if err := os.MkdirAll(m.dir, DefaultStorageDirMode); err != nil {
+		return "", err
+	}
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + randomHex(4) + ".eml"
+	path := filepath.Join(m.dir, name)
+	return path, os.WriteFile(path, data, DefaultStorageFileMode)
+}
+
+// mailPreview returns the first lines of the text body of mail, or of its
+// HTML body stripped of markup.
+func mailPreview(mail contracts.Mail) string {
+	body := mail.TextBody
+	if body == "" {
+		body = htmlToText(mail.HTMLBody)
+	}
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	if len(lines) > devMailerPreviewLines {
+		lines = append(lines[:devMailerPreviewLines], "…")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RecordingMailer is a contracts.Mailer keeping sent mail in memory, for
+// tests. It is safe for concurrent use.
+type RecordingMailer struct {
+	mu   sync.Mutex
+	sent []contracts.Mail
+}
+
+var _ contracts.Mailer = (*RecordingMailer)(nil)
+
+// NewRecordingMailer returns an empty RecordingMailer.
+func NewRecordingMailer() *RecordingMailer {
+	return &RecordingMailer{}
+}
+
+// Send implements contracts.Mailer, recording mail.
+func (m *RecordingMailer) Send(_ context.Context, mail contracts.Mail) error {
+	mail.To = slices.Clone(mail.To)
+	mail.CC = slices.Clone(mail.CC)
+	mail.BCC = slices.Clone(mail.BCC)
+	mail.Attachments = slices.Clone(mail.Attachments)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+// Sent returns the mail sent so far, in order.
+func (m *RecordingMailer) Sent() []contracts.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.sent)
+}
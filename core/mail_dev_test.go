@@ -0,0 +1,88 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+func TestDevMailerLogMode(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewDevMailer(WithDevMailerLogger(logger.NewLogger(false).WithWriter(&buf)))
+
+	err := m.Send(context.Background(), contracts.Mail{
+		From:     "no-reply@example.com",
+		To:       []string{"ana@example.com"},
+		Subject:  "Reset your password",
+		HTMLBody: "<p>Hi Ana</p><p>Line 2</p><p>Line 3</p><p>Line 4</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"ana@example.com", `"Reset your password"`, "Hi Ana\nLine 2\nLine 3\n…"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log %q does not contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "Line 4") {
+		t.Errorf("log %q contains more than the first lines", out)
+	}
+
+	if err := m.Send(context.Background(), contracts.Mail{From: "no-reply@example.com"}); err == nil {
+		t.Error("Send without recipients succeeded")
+	}
+}
+
+func TestDevMailerFileMode(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	var buf bytes.Buffer
+	m := NewDevMailer(WithDevMailerDir(dir), WithDevMailerLogger(logger.NewLogger(false).WithWriter(&buf)))
+
+	for range 2 {
+		if err := m.Send(context.Background(), testMail()); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("eml files = %v, %v; want 2", files, err)
+	}
+	if !strings.Contains(buf.String(), files[0]) && !strings.Contains(buf.String(), files[1]) {
+		t.Errorf("log %q does not name the written file", buf.String())
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertTestMailMIME(t, data)
+}
+
+func TestRecordingMailer(t *testing.T) {
+	m := NewRecordingMailer()
+	to := []string{"ana@example.com"}
+	for _, subject := range []string{"first", "second"} {
+		if err := m.Send(context.Background(), contracts.Mail{To: to, Subject: subject}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	to[0] = "changed@example.com"
+
+	sent := m.Sent()
+	if len(sent) != 2 || sent[0].Subject != "first" || sent[1].Subject != "second" {
+		t.Fatalf("Sent() = %+v", sent)
+	}
+	if sent[0].To[0] != "ana@example.com" {
+		t.Errorf("recorded To = %v, want the value at send time", sent[0].To)
+	}
+	sent[0].Subject = "mutated"
+	if m.Sent()[0].Subject != "first" {
+		t.Error("Sent() exposes the recorder's slice")
+	}
+}
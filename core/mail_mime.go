@@ -0,0 +1,216 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// mailAddresses parses addrs, keeping display names.
+func mailAddresses(field string, addrs []string) ([]*mail.Address, error) {
+	parsed := make([]*mail.Address, 0, len(addrs))
+	for _, a := range addrs {
+		addr, err := mail.ParseAddress(a)
+		if err != nil {
+			return nil, fmt.Errorf("mail: invalid %s address %q: %w", field, a, err)
+		}
+		parsed = append(parsed, addr)
+	}
+	return parsed, nil
+}
+
+// mailRecipients returns the bare addresses of every To, CC and BCC
+// recipient of m.
+func mailRecipients(m contracts.Mail) ([]string, error) {
+	var rcpts []string
+	for _, field := range []struct {
+		name  string
+		addrs []string
+	}{{"To", m.To}, {"Cc", m.CC}, {"Bcc", m.BCC}} {
+		addrs, err := mailAddresses(field.name, field.addrs)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			rcpts = append(rcpts, a.Address)
+		}
+	}
+	if len(rcpts) == 0 {
+		return nil, fmt.Errorf("mail: no recipients")
+	}
+	return rcpts, nil
+}
+
+// buildMIMEMessage renders m as an RFC 5322 message. An HTML and a text body
+// form a multipart/alternative part; attachments wrap the body in
+// multipart/mixed and are base64-encoded. Non-ASCII subjects and display
+// names are encoded as RFC 2047 words. BCC recipients are left out.
+func buildMIMEMessage(m contracts.Mail, date time.Time) ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid From address %q: %w", m.From, err)
+	}
+	var buf bytes.Buffer
+	writeHeader := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	writeHeader("From", from.String())
+	for _, field := range []struct {
+		name  string
+		addrs []string
+	}{{"To", m.To}, {"Cc", m.CC}} {
+		if len(field.addrs) == 0 {
+			continue
+		}
+		addrs, err := mailAddresses(field.name, field.addrs)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]string, len(addrs))
+		for i, a := range addrs {
+			list[i] = a.String()
+		}
+		writeHeader(field.name, strings.Join(list, ", "))
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader("Date", date.Format(time.RFC1123Z))
+	writeHeader("Message-ID", fmt.Sprintf("<%s@%s>", randomHex(16), from.Address[strings.LastIndex(from.Address, "@")+1:]))
+	writeHeader("MIME-Version", "1.0")
+
+	if len(m.Attachments) == 0 {
+		if err := writeMailBody(&buf, m, nil); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixed.Boundary()}))
+	buf.WriteString("\r\n")
+	if err := writeMailBody(&buf, m, mixed); err != nil {
+		return nil, err
+	}
+	for _, a := range m.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(path.Ext(a.Filename))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(w, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeMailBody writes the body of m as a part of parent, or as the rest of
+// the message in buf when parent is nil.
+func writeMailBody(buf *bytes.Buffer, m contracts.Mail, parent *multipart.Writer) error {
+	alternative := m.HTMLBody != "" && m.TextBody != ""
+	var boundary string
+	var header textproto.MIMEHeader
+	switch {
+	case alternative:
+		boundary = multipart.NewWriter(io.Discard).Boundary()
+		header = textproto.MIMEHeader{
+			"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": boundary})},
+		}
+	case m.HTMLBody != "":
+		header = textPartHeader("text/html")
+	default:
+		header = textPartHeader("text/plain")
+	}
+
+	var w io.Writer = buf
+	if parent != nil {
+		var err error
+		if w, err = parent.CreatePart(header); err != nil {
+			return err
+		}
+	} else {
+		for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if v := header.Get(key); v != "" {
+				fmt.Fprintf(buf, "%s: %s\r\n", key, v)
+			}
+		}
+		buf.WriteString("\r\n")
+	}
+
+	if !alternative {
+		body := m.TextBody
+		if m.HTMLBody != "" {
+			body = m.HTMLBody
+		}
+		return writeQuotedPrintable(w, body)
+	}
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.TextBody},
+		{"text/html", m.HTMLBody},
+	} {
+		pw, err := mw.CreatePart(textPartHeader(part.contentType))
+		if err != nil {
+			return err
+		}
+		if err := writeQuotedPrintable(pw, part.body); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func textPartHeader(contentType string) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, body); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64Lines writes data base64-encoded in lines of 76 characters, as
+// RFC 2045 requires.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultMailQueueMaxAttachmentBytes caps the total attachment size of mail
+// sent through a QueuedMailer. Base64 grows it by a third on the bus, which
+// keeps a message under the 1 MB default of common brokers.
+const DefaultMailQueueMaxAttachmentBytes = 512 << 10
+
+// ErrMailTooLarge is returned by QueuedMailer.Send for mail whose
+// attachments exceed the configured limit.
+var ErrMailTooLarge = errors.New("mail: attachments too large to queue")
+
+// QueuedMailer is a contracts.Mailer publishing mail to a topic instead of
+// sending it, so request handlers return without waiting for the mail
+// server. A worker delivers it with MailConsumer.
+type QueuedMailer struct {
+	publisher          contracts.Publisher
+	topic              string
+	maxAttachmentBytes int
+}
+
+var _ contracts.Mailer = (*QueuedMailer)(nil)
+
+// QueuedMailerOption configures NewQueuedMailer.
+type QueuedMailerOption func(*QueuedMailer)
+
+// WithMailQueueMaxAttachmentBytes sets the total attachment size Send
+// accepts, DefaultMailQueueMaxAttachmentBytes by default; 0 or less removes
+// the limit. Larger files are better stored in a Storage and linked.
+func WithMailQueueMaxAttachmentBytes(n int) QueuedMailerOption {
+	return func(m *QueuedMailer) { m.maxAttachmentBytes = n }
+}
+
+// NewQueuedMailer returns a QueuedMailer publishing to topic on p.
+//
+//	mailer := core.NewQueuedMailer(bus, "mail.outgoing")
+//
+//	// in the worker
+//	err := core.MailConsumer(ctx, bus, core.NewSMTPMailer(cfg), "mail.outgoing", core.RetryConfig{
+//		MaxAttempts: 5,
+//		OnExhausted: core.DeadLetter(bus, core.WithDeadLetterLogger(app.Logger())),
+//	})
+func NewQueuedMailer(p contracts.Publisher, topic string, opts ...QueuedMailerOption) *QueuedMailer {
+	m := &QueuedMailer{publisher: p, topic: topic, maxAttachmentBytes: DefaultMailQueueMaxAttachmentBytes}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Send implements contracts.Mailer. It validates the addresses and the
+// attachment size, then publishes the mail as JSON with PublishJSON; a nil
+// error means the mail was queued, not delivered.
+func (m *QueuedMailer) Send(ctx context.Context, mail contracts.Mail) error {
+	if _, err := mailRecipients(mail); err != nil {
+		return err
+	}
+	if _, err := mailAddresses("From", []string{mail.From}); err != nil {
+		return err
+	}
+	if m.maxAttachmentBytes > 0 {
+		size := 0
+		for _, a := range mail.Attachments {
+			size += len(a.Data)
+		}
+		if size > m.maxAttachmentBytes {
+			return fmt.Errorf("%w: %d bytes of attachments, limit is %d", ErrMailTooLarge, size, m.maxAttachmentBytes)
+		}
+	}
+	return PublishJSON(ctx, m.publisher, m.topic, "", newQueuedMail(mail))
+}
+
+// MailConsumer subscribes to topic on s and sends each mail queued by a
+// QueuedMailer with m, running it through WithRetry with retry. A payload
+// that does not decode is not retried and goes straight to
+// retry.OnExhausted, e.g. DeadLetter.
+func MailConsumer(ctx context.Context, s contracts.Subscriber, m contracts.Mailer, topic string, retry RetryConfig) error {
+	send := func(ctx context.Context, msg contracts.Message) error {
+		var queued queuedMail
+		if err := json.Unmarshal(msg.Payload, &queued); err != nil {
+			return &MessageDecodeError{Topic: msg.Topic, Err: err}
+		}
+		return m.Send(ctx, queued.mail())
+	}
+	return s.Subscribe(ctx, topic, WithRetry(send, retry))
+}
+
+// queuedMail is the JSON form of a contracts.Mail on the bus. Attachment
+// data is base64-encoded by encoding/json.
+type queuedMail struct {
+	From        string                 `json:"from"`
+	To          []string               `json:"to,omitempty"`
+	CC          []string               `json:"cc,omitempty"`
+	BCC         []string               `json:"bcc,omitempty"`
+	Subject     string                 `json:"subject"`
+	HTMLBody    string                 `json:"html_body,omitempty"`
+	TextBody    string                 `json:"text_body,omitempty"`
+	Attachments []queuedMailAttachment `json:"attachments,omitempty"`
+}
+
+type queuedMailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Data        []byte `json:"data"`
+}
+
+func newQueuedMail(m contracts.Mail) queuedMail {
+	q := queuedMail{
+		From:     m.From,
+		To:       m.To,
+		CC:       m.CC,
+		BCC:      m.BCC,
+		Subject:  m.Subject,
+		HTMLBody: m.HTMLBody,
+		TextBody: m.TextBody,
+	}
+	for _, a := range m.Attachments {
+		q.Attachments = append(q.Attachments, queuedMailAttachment(a))
+	}
+	return q
+}
+
+func (q queuedMail) mail() contracts.Mail {
+	m := contracts.Mail{
+		From:     q.From,
+		To:       q.To,
+		CC:       q.CC,
+		BCC:      q.BCC,
+		Subject:  q.Subject,
+		HTMLBody: q.HTMLBody,
+		TextBody: q.TextBody,
+	}
+	for _, a := range q.Attachments {
+		m.Attachments = append(m.Attachments, contracts.MailAttachment(a))
+	}
+	return m
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+// flakyMailer fails the first failures sends, then records like next.
+type flakyMailer struct {
+	failures int
+	calls    int
+	next     *RecordingMailer
+}
+
+func (m *flakyMailer) Send(ctx context.Context, mail contracts.Mail) error {
+	m.calls++
+	if m.calls <= m.failures {
+		return errors.New("451 try again later")
+	}
+	return m.next.Send(ctx, mail)
+}
+
+func TestQueuedMailerEndToEnd(t *testing.T) {
+	ctx := context.Background()
+	bus := keeltest.NewMemoryBus()
+	recorder := NewRecordingMailer()
+	mailer := &flakyMailer{failures: 2, next: recorder}
+	if err := MailConsumer(ctx, bus, mailer, "mail.outgoing", RetryConfig{InitialBackoff: time.Millisecond}); err != nil {
+		t.Fatalf("MailConsumer: %v", err)
+	}
+
+	if err := NewQueuedMailer(bus, "mail.outgoing").Send(ctx, testMail()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	published := bus.Published("mail.outgoing")
+	if len(published) != 1 || published[0].Headers[MessageHeaderContentType] != "application/json" {
+		t.Fatalf("published = %+v", published)
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(published[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := payload["attachments"].([]any)[0].(map[string]any)["data"].(string)
+	if decoded, err := base64.StdEncoding.DecodeString(data); err != nil || string(decoded) != string(testMail().Attachments[0].Data) {
+		t.Errorf("attachment data = %q, want the base64 of the file", data)
+	}
+
+	if mailer.calls != 3 {
+		t.Errorf("mailer calls = %d, want 3 (two transient failures)", mailer.calls)
+	}
+	sent := recorder.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("delivered %d mails, want 1", len(sent))
+	}
+	if !reflect.DeepEqual(sent[0], testMail()) {
+		t.Errorf("delivered mail = %+v, want %+v", sent[0], testMail())
+	}
+}
+
+func TestMailConsumerDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	bus := keeltest.NewMemoryBus()
+	recorder := NewRecordingMailer()
+	MailConsumer(ctx, bus, recorder, "mail.outgoing", RetryConfig{OnExhausted: DeadLetter(bus)}) //nolint
+
+	bus.Publish(ctx, contracts.Message{Topic: "mail.outgoing", Payload: []byte("not json")}) //nolint
+	if len(recorder.Sent()) != 0 {
+		t.Error("undecodable payload was sent")
+	}
+	if dead := bus.Published("mail.outgoing" + DeadLetterSuffix); len(dead) != 1 || dead[0].Headers[MessageHeaderDLQAttempts] != "1" {
+		t.Errorf("dead letters = %+v, want one after a single attempt", dead)
+	}
+}
+
+func TestQueuedMailerValidation(t *testing.T) {
+	ctx := context.Background()
+	bus := keeltest.NewMemoryBus()
+	big := testMail()
+	big.Attachments = []contracts.MailAttachment{{Filename: "a.bin", Data: make([]byte, 600)}, {Filename: "b.bin", Data: make([]byte, 500)}}
+
+	err := NewQueuedMailer(bus, "mail", WithMailQueueMaxAttachmentBytes(1000)).Send(ctx, big)
+	if !errors.Is(err, ErrMailTooLarge) || err.Error() != "mail: attachments too large to queue: 1100 bytes of attachments, limit is 1000" {
+		t.Errorf("err = %v, want ErrMailTooLarge with the sizes", err)
+	}
+	if err := NewQueuedMailer(bus, "mail", WithMailQueueMaxAttachmentBytes(0)).Send(ctx, big); err != nil {
+		t.Errorf("Send without limit: %v", err)
+	}
+
+	bad := testMail()
+	bad.To = []string{"not an address"}
+	if err := NewQueuedMailer(bus, "mail").Send(ctx, bad); err == nil {
+		t.Error("Send with an invalid recipient succeeded")
+	}
+	if got := len(bus.Published("mail")); got != 1 {
+		t.Errorf("published %d messages, want only the valid one", got)
+	}
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultSMTPTimeout bounds each Send of an SMTPMailer, from dialing to
+// QUIT, unless SMTPConfig.Timeout says otherwise.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// ErrSMTPStartTLSUnsupported is returned when an SMTPStartTLS server does
+// not offer STARTTLS. Credentials and mail are never sent in the clear.
+var ErrSMTPStartTLSUnsupported = errors.New("smtp: server does not support STARTTLS")
+
+// SMTPSecurity selects how an SMTPMailer secures the connection.
+type SMTPSecurity int
+
+const (
+	// SMTPStartTLS connects in plain text and upgrades with STARTTLS, which
+	// the server must support. The port defaults to 587.
+	SMTPStartTLS SMTPSecurity = iota
+	// SMTPImplicitTLS connects over TLS from the start. The port defaults
+	// to 465.
+	SMTPImplicitTLS
+	// SMTPPlaintext never uses TLS, for local relays and development
+	// servers. The port defaults to 25.
+	SMTPPlaintext
+)
+
+// SMTPConfig configures NewSMTPMailer.
+type SMTPConfig struct {
+	Host      string
+	Port      int    // defaults to the port of Security
+	Username  string // enables PLAIN authentication when set
+	Password  string
+	Security  SMTPSecurity  // defaults to SMTPStartTLS
+	TLSConfig *tls.Config   // ServerName defaults to Host
+	Timeout   time.Duration // defaults to DefaultSMTPTimeout
+}
+
+// SMTPRecipientError reports a recipient refused by the server.
+// Err is usually a *textproto.Error holding the SMTP reply code.
+type SMTPRecipientError struct {
+	Recipient string
+	Err       error
+}
+
+func (e *SMTPRecipientError) Error() string {
+	return fmt.Sprintf("smtp: recipient %q rejected: %v", e.Recipient, e.Err)
+}
+
+func (e *SMTPRecipientError) Unwrap() error { return e.Err }
+
+// SMTPMailer is a contracts.Mailer delivering mail to an SMTP server, one
+// connection per Send. It is safe for concurrent use.
+type SMTPMailer struct {
+	cfg  SMTPConfig
+	addr string
+}
+
+var _ contracts.Mailer = (*SMTPMailer)(nil)
+
+// NewSMTPMailer returns an SMTPMailer for cfg.
+//
+//	mailer := core.NewSMTPMailer(core.SMTPConfig{
+//		Host:     "smtp.example.com",
+//		Username: "apikey",
+//		Password: os.Getenv("SMTP_PASSWORD"),
+//	})
+func NewSMTPMailer(cfg SMTPConfig) *SMTPMailer {
+	if cfg.Port == 0 {
+		switch cfg.Security {
+		case SMTPImplicitTLS:
+			cfg.Port = 465
+		case SMTPPlaintext:
+			cfg.Port = 25
+		default:
+			cfg.Port = 587
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSMTPTimeout
+	}
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{}
+	} else {
+		cfg.TLSConfig = cfg.TLSConfig.Clone()
+	}
+	if cfg.TLSConfig.ServerName == "" {
+		cfg.TLSConfig.ServerName = cfg.Host
+	}
+	return &SMTPMailer{cfg: cfg, addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))}
+}
+
+// Send implements contracts.Mailer. The mail is delivered to every To, CC
+// and BCC recipient or to none: when the server rejects any recipient the
+// transaction is abandoned and the rejections are returned as joined
+// *SMTPRecipientError values.
+func (m *SMTPMailer) Send(ctx context.Context, mail contracts.Mail) error {
+	rcpts, err := mailRecipients(mail)
+	if err != nil {
+		return err
+	}
+	msg, err := buildMIMEMessage(mail, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("smtp: connect %s: %w", m.addr, err)
+	}
+	// Closing the connection unblocks the exchange when ctx ends.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	var session net.Conn = conn
+	if m.cfg.Security == SMTPImplicitTLS {
+		session = tls.Client(conn, m.cfg.TLSConfig)
+	}
+
+	c, err := smtp.NewClient(session, m.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return m.wrap(ctx, "connect", err)
+	}
+	defer c.Close()
+
+	if m.cfg.Security == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%w: %s", ErrSMTPStartTLSUnsupported, m.addr)
+		}
+		if err := c.StartTLS(m.cfg.TLSConfig); err != nil {
+			return m.wrap(ctx, "starttls", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return m.wrap(ctx, "auth", err)
+		}
+	}
+	from, err := mailAddresses("From", []string{mail.From})
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from[0].Address); err != nil {
+		return m.wrap(ctx, "MAIL FROM", err)
+	}
+	var rejected []error
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			if ctx.Err() != nil {
+				return m.wrap(ctx, "RCPT TO", err)
+			}
+			rejected = append(rejected, &SMTPRecipientError{Recipient: rcpt, Err: err})
+		}
+	}
+	if len(rejected) > 0 {
+		return errors.Join(rejected...)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return m.wrap(ctx, "DATA", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return m.wrap(ctx, "DATA", err)
+	}
+	if err := w.Close(); err != nil {
+		return m.wrap(ctx, "DATA", err)
+	}
+	// The server accepted the mail; a failed QUIT must not cause a resend.
+	_ = c.Quit()
+	return nil
+}
+
+// wrap names the failed step, reporting ctx's error when it ended the
+// exchange.
+func (m *SMTPMailer) wrap(ctx context.Context, step string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("smtp: %s %s: %w", step, m.addr, ctxErr)
+	}
+	return fmt.Errorf("smtp: %s %s: %w", step, m.addr, err)
+}
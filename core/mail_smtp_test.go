@@ -0,0 +1,364 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// fakeSMTPServer is a minimal SMTP server accepting every command in
+// sequence and recording delivered messages.
+type fakeSMTPServer struct {
+	ln       net.Listener
+	cert     *tls.Certificate // enables STARTTLS
+	reject   map[string]bool  // recipients answered with 550
+	mu       sync.Mutex
+	messages []fakeSMTPMessage
+	auth     []string
+}
+
+type fakeSMTPMessage struct {
+	from  string
+	rcpts []string
+	data  []byte
+}
+
+func newFakeSMTPServer(t *testing.T, cert *tls.Certificate, implicitTLS bool) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if implicitTLS {
+		ln = tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{*cert}})
+		cert = nil
+	}
+	s := &fakeSMTPServer{ln: ln, cert: cert, reject: map[string]bool{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) config(security SMTPSecurity, pool *x509.CertPool) SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return SMTPConfig{Host: host, Port: p, Security: security, TLSConfig: &tls.Config{RootCAs: pool}}
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP") //nolint
+	var msg fakeSMTPMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ext := "250-fake\r\n"
+			if s.cert != nil {
+				ext += "250-STARTTLS\r\n"
+			}
+			tp.PrintfLine("%s250 AUTH PLAIN", ext) //nolint
+		case "STARTTLS":
+			tp.PrintfLine("220 go ahead") //nolint
+			conn = tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{*s.cert}})
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = append(s.auth, arg)
+			s.mu.Unlock()
+			tp.PrintfLine("235 ok") //nolint
+		case "MAIL":
+			msg = fakeSMTPMessage{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			tp.PrintfLine("250 ok") //nolint
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if s.reject[rcpt] {
+				tp.PrintfLine("550 no such user") //nolint
+				continue
+			}
+			msg.rcpts = append(msg.rcpts, rcpt)
+			tp.PrintfLine("250 ok") //nolint
+		case "DATA":
+			tp.PrintfLine("354 go ahead") //nolint
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued") //nolint
+		case "QUIT":
+			tp.PrintfLine("221 bye") //nolint
+			return
+		default:
+			tp.PrintfLine("250 ok") //nolint
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() []fakeSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeSMTPMessage(nil), s.messages...)
+}
+
+// testCertificate borrows the certificate of an httptest TLS server, valid
+// for 127.0.0.1, and the pool trusting it.
+func testCertificate(t *testing.T) (*tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	return &srv.TLS.Certificates[0], srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+}
+
+func testMail() contracts.Mail {
+	return contracts.Mail{
+		From:     "Acme <no-reply@example.com>",
+		To:       []string{"José Núñez <jose@example.com>"},
+		CC:       []string{"ops@example.com"},
+		BCC:      []string{"audit@example.com"},
+		Subject:  "Bienvenido, José ✓",
+		HTMLBody: "<p>Hola José</p>",
+		TextBody: "Hola José",
+		Attachments: []contracts.MailAttachment{
+			{Filename: "invoice.pdf", ContentType: "application/pdf", Data: []byte(strings.Repeat("%PDF-1.7 ", 20))},
+		},
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	cert, pool := testCertificate(t)
+	for _, tc := range []struct {
+		name     string
+		security SMTPSecurity
+	}{
+		{"starttls", SMTPStartTLS},
+		{"implicit tls", SMTPImplicitTLS},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeSMTPServer(t, cert, tc.security == SMTPImplicitTLS)
+			cfg := srv.config(tc.security, pool)
+			cfg.Username, cfg.Password = "user", "secret"
+
+			if err := NewSMTPMailer(cfg).Send(context.Background(), testMail()); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			got := srv.received()
+			if len(got) != 1 {
+				t.Fatalf("received %d messages, want 1", len(got))
+			}
+			if got[0].from != "no-reply@example.com" {
+				t.Errorf("MAIL FROM = %q", got[0].from)
+			}
+			if want := "jose@example.com ops@example.com audit@example.com"; strings.Join(got[0].rcpts, " ") != want {
+				t.Errorf("RCPT TO = %v, want %s", got[0].rcpts, want)
+			}
+			srv.mu.Lock()
+			auth := srv.auth
+			srv.mu.Unlock()
+			if len(auth) != 1 || !strings.HasPrefix(auth[0], "PLAIN ") {
+				t.Errorf("AUTH = %v, want PLAIN", auth)
+			}
+			assertTestMailMIME(t, got[0].data)
+		})
+	}
+}
+
+func assertTestMailMIME(t *testing.T, data []byte) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	dec := new(mime.WordDecoder)
+	if subject, _ := dec.DecodeHeader(msg.Header.Get("Subject")); subject != "Bienvenido, José ✓" {
+		t.Errorf("Subject = %q (raw %q)", subject, msg.Header.Get("Subject"))
+	}
+	if to, err := msg.Header.AddressList("To"); err != nil || to[0].Name != "José Núñez" {
+		t.Errorf("To = %v, %v (raw %q)", to, err, msg.Header.Get("To"))
+	}
+	if msg.Header.Get("Bcc") != "" || strings.Contains(string(data), "audit@example.com") {
+		t.Error("BCC recipient leaked into the message")
+	}
+
+	parts := readMultipart(t, msg.Header.Get("Content-Type"), msg.Body, "multipart/mixed")
+	if len(parts) != 2 {
+		t.Fatalf("mixed parts = %d, want 2", len(parts))
+	}
+	alternative := readMultipart(t, parts[0].header.Get("Content-Type"), strings.NewReader(parts[0].body), "multipart/alternative")
+	if len(alternative) != 2 {
+		t.Fatalf("alternative parts = %d, want 2", len(alternative))
+	}
+	for i, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", "Hola José"},
+		{"text/html; charset=utf-8", "<p>Hola José</p>"},
+	} {
+		if ct := alternative[i].header.Get("Content-Type"); ct != want.contentType {
+			t.Errorf("alternative part %d Content-Type = %q, want %q", i, ct, want.contentType)
+		}
+		if alternative[i].body != want.body {
+			t.Errorf("alternative part %d body = %q, want %q", i, alternative[i].body, want.body)
+		}
+	}
+
+	attachment := parts[1]
+	if ct := attachment.header.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("attachment Content-Type = %q", ct)
+	}
+	if _, params, _ := mime.ParseMediaType(attachment.header.Get("Content-Disposition")); params["filename"] != "invoice.pdf" {
+		t.Errorf("attachment Content-Disposition = %q", attachment.header.Get("Content-Disposition"))
+	}
+	if enc := attachment.header.Get("Content-Transfer-Encoding"); enc != "base64" {
+		t.Errorf("attachment encoding = %q", enc)
+	}
+	for _, line := range strings.Fields(attachment.raw) {
+		if len(line) > 76 {
+			t.Errorf("base64 line of %d characters", len(line))
+		}
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(attachment.raw), "")); err != nil || string(decoded) != strings.Repeat("%PDF-1.7 ", 20) {
+		t.Errorf("attachment data = %q, %v", decoded, err)
+	}
+}
+
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   string // decoded when quoted-printable
+	raw    string
+}
+
+func readMultipart(t *testing.T, contentType string, r io.Reader, want string) []mimePart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != want {
+		t.Fatalf("Content-Type = %q, want %s", contentType, want)
+	}
+	mr := multipart.NewReader(r, params["boundary"])
+	var parts []mimePart
+	for {
+		p, err := mr.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("NextRawPart: %v", err)
+		}
+		raw, _ := io.ReadAll(p)
+		part := mimePart{header: p.Header, body: string(raw), raw: string(raw)}
+		if p.Header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+			decoded, _ := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(raw)))
+			part.body = string(decoded)
+		}
+		parts = append(parts, part)
+	}
+}
+
+func TestBuildMIMEMessageSingleBody(t *testing.T) {
+	data, err := buildMIMEMessage(contracts.Mail{
+		From:     "no-reply@example.com",
+		To:       []string{"ana@example.com"},
+		Subject:  "Plain",
+		TextBody: "Hello",
+	}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := msg.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if msg.Header.Get("Subject") != "Plain" || msg.Header.Get("Message-ID") == "" || msg.Header.Get("Date") == "" {
+		t.Errorf("header = %v", msg.Header)
+	}
+	if body, _ := io.ReadAll(msg.Body); string(body) != "Hello" {
+		t.Errorf("body = %q", body)
+	}
+
+	if _, err := buildMIMEMessage(contracts.Mail{From: "not an address"}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)); err == nil || !strings.Contains(err.Error(), "From") {
+		t.Errorf("invalid From error = %v", err)
+	}
+}
+
+func TestSMTPMailerErrors(t *testing.T) {
+	cert, pool := testCertificate(t)
+
+	t.Run("connection refused", func(t *testing.T) {
+		ln, _ := net.Listen("tcp", "127.0.0.1:0")
+		addr := ln.Addr().(*net.TCPAddr)
+		ln.Close()
+		err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: addr.Port, Security: SMTPPlaintext}).Send(context.Background(), testMail())
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) || !strings.Contains(err.Error(), "smtp: connect") {
+			t.Errorf("err = %v, want a wrapped dial error", err)
+		}
+	})
+
+	t.Run("recipient rejected", func(t *testing.T) {
+		srv := newFakeSMTPServer(t, cert, false)
+		srv.reject["ops@example.com"] = true
+		err := NewSMTPMailer(srv.config(SMTPStartTLS, pool)).Send(context.Background(), testMail())
+		var rcptErr *SMTPRecipientError
+		if !errors.As(err, &rcptErr) || rcptErr.Recipient != "ops@example.com" {
+			t.Fatalf("err = %v, want an SMTPRecipientError for ops@example.com", err)
+		}
+		var protoErr *textproto.Error
+		if !errors.As(err, &protoErr) || protoErr.Code != 550 {
+			t.Errorf("err = %v, want the 550 reply", err)
+		}
+		if got := srv.received(); len(got) != 0 {
+			t.Errorf("delivered %d messages despite the rejection", len(got))
+		}
+	})
+
+	t.Run("starttls unsupported", func(t *testing.T) {
+		srv := newFakeSMTPServer(t, nil, false)
+		err := NewSMTPMailer(srv.config(SMTPStartTLS, pool)).Send(context.Background(), testMail())
+		if !errors.Is(err, ErrSMTPStartTLSUnsupported) {
+			t.Errorf("err = %v, want ErrSMTPStartTLSUnsupported", err)
+		}
+	})
+
+	t.Run("no recipients", func(t *testing.T) {
+		err := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1"}).Send(context.Background(), contracts.Mail{From: "a@example.com"})
+		if err == nil || !strings.Contains(err.Error(), "no recipients") {
+			t.Errorf("err = %v", err)
+		}
+	})
+}
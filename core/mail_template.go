@@ -0,0 +1,225 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ErrMailTemplateNotFound is returned by MailTemplates.Render for names
+// without an .html template.
+var ErrMailTemplateNotFound = errors.New("mail template not found")
+
+// MailTemplatesOption configures NewMailTemplates.
+type MailTemplatesOption func(*mailTemplatesConfig)
+
+type mailTemplatesConfig struct {
+	layout string
+	funcs  map[string]any
+}
+
+// WithMailLayout wraps every template in the layout of that name, e.g.
+// "layouts/base". The layout includes the page with {{template "content" .}}.
+// The text variant uses the .txt layout when there is one.
+func WithMailLayout(name string) MailTemplatesOption {
+	return func(c *mailTemplatesConfig) { c.layout = name }
+}
+
+// WithMailFuncs adds functions callable from every template.
+func WithMailFuncs(funcs map[string]any) MailTemplatesOption {
+	return func(c *mailTemplatesConfig) {
+		if c.funcs == nil {
+			c.funcs = make(map[string]any)
+		}
+		for k, v := range funcs {
+			c.funcs[k] = v
+		}
+	}
+}
+
+// MailTemplates renders email bodies from html/template and text/template
+// files, escaping data in the HTML variant.
+//
+// Files are named <name>.html and <name>.txt, where name is the path
+// without extension, e.g. "auth/reset". Those under layouts/ and partials/
+// are shared by every template: partials are included with
+// {{template "partials/footer" .}}, layouts are chosen with WithMailLayout.
+type MailTemplates struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// NewMailTemplates parses the templates in fsys, typically an embed.FS.
+// Parse errors are reported with the file name.
+//
+//	//go:embed mail
+//	var mailFS embed.FS
+//
+//	sub, _ := fs.Sub(mailFS, "mail")
+//	templates, err := core.NewMailTemplates(sub, core.WithMailLayout("layouts/base"))
+func NewMailTemplates(fsys fs.FS, opts ...MailTemplatesOption) (*MailTemplates, error) {
+	var cfg mailTemplatesConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var shared, pages []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := path.Ext(p); ext != ".html" && ext != ".txt" {
+			return nil
+		}
+		if strings.HasPrefix(p, "layouts/") || strings.HasPrefix(p, "partials/") {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mail templates: %w", err)
+	}
+
+	htmlBase := htmltemplate.New("").Funcs(cfg.funcs)
+	textBase := texttemplate.New("").Funcs(cfg.funcs)
+	for _, p := range shared {
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("mail templates: %w", err)
+		}
+		name := strings.TrimSuffix(p, path.Ext(p))
+		if path.Ext(p) == ".html" {
+			_, err = htmlBase.New(name).Parse(string(src))
+		} else {
+			_, err = textBase.New(name).Parse(string(src))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mail templates: parse %s: %w", p, err)
+		}
+	}
+	htmlLayout := cfg.layout != "" && htmlBase.Lookup(cfg.layout) != nil
+	textLayout := cfg.layout != "" && textBase.Lookup(cfg.layout) != nil
+	if cfg.layout != "" && !htmlLayout {
+		return nil, fmt.Errorf("mail templates: layout %q: %w", cfg.layout, ErrMailTemplateNotFound)
+	}
+
+	t := &MailTemplates{
+		html: make(map[string]*htmltemplate.Template),
+		text: make(map[string]*texttemplate.Template),
+	}
+	for _, p := range pages {
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("mail templates: %w", err)
+		}
+		name := strings.TrimSuffix(p, path.Ext(p))
+		if path.Ext(p) == ".html" {
+			tmpl, err := htmltemplate.Must(htmlBase.Clone()).New("content").Parse(string(src))
+			if err != nil {
+				return nil, fmt.Errorf("mail templates: parse %s: %w", p, err)
+			}
+			if htmlLayout {
+				tmpl = tmpl.Lookup(cfg.layout)
+			}
+			t.html[name] = tmpl
+		} else {
+			tmpl, err := texttemplate.Must(textBase.Clone()).New("content").Parse(string(src))
+			if err != nil {
+				return nil, fmt.Errorf("mail templates: parse %s: %w", p, err)
+			}
+			if textLayout {
+				tmpl = tmpl.Lookup(cfg.layout)
+			}
+			t.text[name] = tmpl
+		}
+	}
+	return t, nil
+}
+
+// Render executes the template name with data and returns both bodies.
+// Without a <name>.txt template the text body is the HTML body stripped of
+// markup. Errors name the template.
+func (t *MailTemplates) Render(name string, data any) (htmlBody, textBody string, err error) {
+	htmlTmpl, ok := t.html[name]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %q", ErrMailTemplateNotFound, name)
+	}
+	var buf bytes.Buffer
+	if err := htmlTmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("mail template %q: %w", name, err)
+	}
+	htmlBody = buf.String()
+
+	textTmpl, ok := t.text[name]
+	if !ok {
+		return htmlBody, htmlToText(htmlBody), nil
+	}
+	buf.Reset()
+	if err := textTmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("mail template %q (text): %w", name, err)
+	}
+	return htmlBody, buf.String(), nil
+}
+
+// NewMailFromTemplate returns a Mail whose bodies are rendered from the
+// template name with data.
+func (t *MailTemplates) NewMailFromTemplate(from string, to []string, subject, name string, data any) (contracts.Mail, error) {
+	htmlBody, textBody, err := t.Render(name, data)
+	if err != nil {
+		return contracts.Mail{}, err
+	}
+	return contracts.Mail{
+		From:     from,
+		To:       to,
+		Subject:  subject,
+		HTMLBody: htmlBody,
+		TextBody: textBody,
+	}, nil
+}
+
+var (
+	htmlInvisible = regexp.MustCompile(`(?is)<(head|style|script)\b.*?</(head|style|script)>|<!--.*?-->`)
+	htmlLink      = regexp.MustCompile(`(?is)<a\b[^>]*?href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a>`)
+	htmlLineBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|h[1-6]|li|tr|table|blockquote)>`)
+	htmlListItem  = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	htmlTag       = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLineRun  = regexp.MustCompile(`\n{3,}`)
+	horizontalRun = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText returns a plain-text rendering of an HTML email body: links
+// become "label (url)", block ends become line breaks, list items get a
+// dash, and remaining tags are dropped.
+func htmlToText(s string) string {
+	s = htmlInvisible.ReplaceAllString(s, "")
+	s = htmlLink.ReplaceAllStringFunc(s, func(a string) string {
+		m := htmlLink.FindStringSubmatch(a)
+		label := strings.TrimSpace(htmlTag.ReplaceAllString(m[2], ""))
+		if label == "" || label == m[1] {
+			return m[1]
+		}
+		return label + " (" + m[1] + ")"
+	})
+	s = htmlListItem.ReplaceAllString(s, "- ")
+	s = htmlLineBreak.ReplaceAllString(s, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(horizontalRun.ReplaceAllString(line, " "))
+	}
+	s = blankLineRun.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestMailTemplates(t *testing.T) *MailTemplates {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"layouts/base.html":    {Data: []byte(`<html><head><style>p{}</style></head><body>{{template "content" .}}{{template "partials/footer" .}}</body></html>`)},
+		"layouts/base.txt":     {Data: []byte("{{template \"content\" .}}\n--\nAcme")},
+		"partials/footer.html": {Data: []byte(`<p>Sent to {{.Email}}</p>`)},
+		"welcome.html":         {Data: []byte(`<h1>Hi {{.Name}}</h1><p>Open <a href="{{.URL}}">your account</a>.</p>`)},
+		"welcome.txt":          {Data: []byte(`Hi {{.Name}}, open {{.URL}}.`)},
+		"auth/reset.html":      {Data: []byte(`<p>Reset &amp; go:</p><ul><li>{{.URL}}</li></ul>{{.Missing.Field}}`)},
+		"receipt.html":         {Data: []byte(`<p>Total: {{.Total}}</p>`)},
+		"notes.md":             {Data: []byte(`ignored`)},
+	}
+	templates, err := NewMailTemplates(fsys, WithMailLayout("layouts/base"))
+	if err != nil {
+		t.Fatalf("NewMailTemplates: %v", err)
+	}
+	return templates
+}
+
+func TestMailTemplatesRenderWithLayout(t *testing.T) {
+	templates := newTestMailTemplates(t)
+
+	htmlBody, textBody, err := templates.Render("welcome", map[string]string{
+		"Name":  "<Ana>",
+		"URL":   "https://example.com/me",
+		"Email": "ana@example.com",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	wantHTML := `<html><head><style>p{}</style></head><body><h1>Hi &lt;Ana&gt;</h1><p>Open <a href="https://example.com/me">your account</a>.</p><p>Sent to ana@example.com</p></body></html>`
+	if htmlBody != wantHTML {
+		t.Errorf("html body = %q, want %q", htmlBody, wantHTML)
+	}
+	if want := "Hi <Ana>, open https://example.com/me.\n--\nAcme"; textBody != want {
+		t.Errorf("text body = %q, want %q", textBody, want)
+	}
+}
+
+func TestMailTemplatesTextFallback(t *testing.T) {
+	templates := newTestMailTemplates(t)
+
+	mail, err := templates.NewMailFromTemplate("no-reply@example.com", []string{"ana@example.com"}, "Welcome", "welcome", map[string]string{
+		"Name": "Ana", "URL": "https://example.com/me", "Email": "ana@example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewMailFromTemplate: %v", err)
+	}
+	if mail.From != "no-reply@example.com" || mail.Subject != "Welcome" || len(mail.To) != 1 {
+		t.Errorf("mail = %+v", mail)
+	}
+	if !strings.Contains(mail.HTMLBody, "<h1>Hi Ana</h1>") || !strings.HasPrefix(mail.TextBody, "Hi Ana") {
+		t.Errorf("bodies = %q / %q", mail.HTMLBody, mail.TextBody)
+	}
+
+	_, textBody, err := templates.Render("receipt", map[string]string{"Total": "5 &euro;", "Email": "ana@example.com"})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "Total: 5 &euro;\nSent to ana@example.com\n"; textBody != want {
+		t.Errorf("fallback text body = %q, want %q", textBody, want)
+	}
+
+	got := htmlToText(`<html><head><title>x</title></head><body><h1>Hi Ana</h1><p>Open <a href="https://example.com/me">your account</a>.</p><ul><li>One</li><li>Two &amp; three</li></ul><!-- hidden --></body></html>`)
+	want := "Hi Ana\nOpen your account (https://example.com/me).\n- One\n- Two & three\n"
+	if got != want {
+		t.Errorf("htmlToText = %q, want %q", got, want)
+	}
+}
+
+func TestMailTemplatesErrors(t *testing.T) {
+	templates := newTestMailTemplates(t)
+
+	_, _, err := templates.Render("missing", nil)
+	if !errors.Is(err, ErrMailTemplateNotFound) || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("missing template error = %v", err)
+	}
+
+	_, _, err = templates.Render("auth/reset", struct{ URL, Email string }{})
+	if err == nil || !strings.Contains(err.Error(), `"auth/reset"`) {
+		t.Errorf("execution error = %v, want it to name the template", err)
+	}
+
+	_, err = NewMailTemplates(fstest.MapFS{"broken.html": {Data: []byte(`{{if}}`)}})
+	if err == nil || !strings.Contains(err.Error(), "broken.html") {
+		t.Errorf("parse error = %v, want it to name the file", err)
+	}
+
+	_, err = NewMailTemplates(fstest.MapFS{"welcome.html": {Data: []byte(`hi`)}}, WithMailLayout("layouts/base"))
+	if !errors.Is(err, ErrMailTemplateNotFound) {
+		t.Errorf("missing layout error = %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ErrMessageNacked is returned to a plain Subscriber by the AsAckSubscriber
+// shim when the handler calls Nack(true), asking the broker to redeliver.
+var ErrMessageNacked = errors.New("message nacked for redelivery")
+
+// AckableMessage is a message whose handler settles it explicitly. Only
+// the first Ack or Nack call counts. Handlers settle before returning;
+// see AckSubscriber for what happens when they do not.
+type AckableMessage struct {
+	contracts.Message
+	settle *ackSettlement
+}
+
+type ackSettlement struct {
+	mu   sync.Mutex
+	done bool
+	ack  func()
+	nack func(requeue bool)
+}
+
+// NewAckableMessage wraps msg for broker adapters implementing
+// AckSubscriber; ack and nack perform the broker operations.
+func NewAckableMessage(msg contracts.Message, ack func(), nack func(requeue bool)) AckableMessage {
+	return AckableMessage{Message: msg, settle: &ackSettlement{ack: ack, nack: nack}}
+}
+
+// Ack confirms the message was processed; it will not be delivered again.
+func (m AckableMessage) Ack() {
+	if m.settle.claim() {
+		m.settle.ack()
+	}
+}
+
+// Nack rejects the message. With requeue the broker delivers it again,
+// otherwise it is dropped, or dead-lettered where the broker supports it.
+func (m AckableMessage) Nack(requeue bool) {
+	if m.settle.claim() {
+		m.settle.nack(requeue)
+	}
+}
+
+// claim reports whether the caller is the first to settle the message.
+func (s *ackSettlement) claim() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	first := !s.done
+	s.done = true
+	return first
+}
+
+// settled reports whether Ack or Nack was called, and ignores later calls:
+// the subscriber settles unsettled messages itself once the handler returns.
+func (m AckableMessage) settled() bool {
+	return !m.settle.claim()
+}
+
+// AckHandler consumes an AckableMessage.
+type AckHandler func(ctx context.Context, msg AckableMessage) error
+
+// AckSubscriber is implemented by subscribers whose handlers acknowledge
+// messages explicitly, such as MemoryBus. Settlement follows this matrix:
+//
+//	handler calls    returns    outcome
+//	Ack()            any        acknowledged
+//	Nack(true)       any        redelivered
+//	Nack(false)      any        dropped (or dead-lettered by the broker)
+//	nothing          nil        acknowledged
+//	nothing          error      dropped, error logged by the subscriber
+//
+// Use AsAckSubscriber to get one from any contracts.Subscriber.
+type AckSubscriber interface {
+	SubscribeAck(ctx context.Context, topic string, handler AckHandler) error
+}
+
+// AsAckSubscriber returns s when it implements AckSubscriber, and otherwise
+// a shim over s.Subscribe: Ack, Nack(false) and a nil return make the plain
+// handler return nil; Nack(true) returns ErrMessageNacked, and an unsettled
+// error is returned as is, leaving redelivery to the broker adapter.
+func AsAckSubscriber(s contracts.Subscriber) AckSubscriber {
+	if as, ok := s.(AckSubscriber); ok {
+		return as
+	}
+	return ackShim{s}
+}
+
+var _ AckSubscriber = (*MemoryBus)(nil)
+
+type ackShim struct{ contracts.Subscriber }
+
+func (s ackShim) SubscribeAck(ctx context.Context, topic string, handler AckHandler) error {
+	return s.Subscribe(ctx, topic, func(ctx context.Context, msg contracts.Message) error {
+		var requeue bool
+		am := NewAckableMessage(msg, func() {}, func(r bool) { requeue = r })
+		err := handler(ctx, am)
+		switch {
+		case !am.settled():
+			return err
+		case requeue:
+			return ErrMessageNacked
+		default:
+			return nil
+		}
+	})
+}
+
+// SubscribeAck implements AckSubscriber. A message nacked with requeue is
+// delivered to the same handler again, ahead of the messages already queued
+// for it. After WithBusMaxRequeues redeliveries, or once the bus is closed,
+// Nack(true) drops the message instead and logs it.
+func (b *MemoryBus) SubscribeAck(ctx context.Context, topic string, handler AckHandler) error {
+	return b.subscribe(ctx, topic, func(s *busSubscriber) contracts.MessageHandler {
+		return func(ctx context.Context, msg contracts.Message) error {
+			requeues := s.requeues
+			am := NewAckableMessage(msg, func() {}, func(requeue bool) {
+				if !requeue {
+					return
+				}
+				if requeues >= b.maxRequeues {
+					b.logger.WarnCtx(ctx, "Message on topic %s dropped after %d redelivery(ies)", s.topic, requeues)
+					return
+				}
+				if !s.requeue(msg, requeues) {
+					b.logger.WarnCtx(ctx, "Message on topic %s nacked for redelivery after the bus closed; dropped", s.topic)
+				}
+			})
+			err := handler(ctx, am)
+			if am.settled() {
+				return nil
+			}
+			return err
+		}
+	})
+}
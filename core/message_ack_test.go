@@ -0,0 +1,190 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+func TestMemoryBusSubscribeAck(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	b := NewMemoryBus(WithBusLogger(logger.NewLogger(false).WithWriter(&buf)))
+
+	var mu sync.Mutex
+	deliveries := map[string]int{}
+	last := make(chan struct{})
+	err := b.SubscribeAck(ctx, "t", func(_ context.Context, msg AckableMessage) error {
+		mu.Lock()
+		deliveries[string(msg.Payload)]++
+		n := deliveries[string(msg.Payload)]
+		mu.Unlock()
+		switch string(msg.Payload) {
+		case "ack":
+			msg.Ack()
+			msg.Nack(true) // ignored: already settled
+		case "requeue":
+			if n < 3 {
+				msg.Nack(true)
+				return errors.New("not yet")
+			}
+		case "drop":
+			msg.Nack(false)
+		case "fail":
+			close(last)
+			return errors.New("unsettled failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"ack", "requeue", "drop", "auto", "fail"} {
+		if err := b.Publish(ctx, contracts.Message{Topic: "t", Payload: []byte(p)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Requeued messages go ahead of the queue, so all three deliveries of
+	// "requeue" happen before "fail", the last message, is delivered.
+	<-last
+	b.Close()
+
+	want := map[string]int{"ack": 1, "requeue": 3, "drop": 1, "auto": 1, "fail": 1}
+	for p, n := range want {
+		if deliveries[p] != n {
+			t.Errorf("%q delivered %d time(s), want %d", p, deliveries[p], n)
+		}
+	}
+	logs := buf.String()
+	if !strings.Contains(logs, "unsettled failure") {
+		t.Errorf("unsettled error not logged: %s", logs)
+	}
+	if strings.Contains(logs, "not yet") {
+		t.Errorf("error of a nacked message logged: %s", logs)
+	}
+}
+
+func TestMemoryBusMaxRequeues(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	b := NewMemoryBus(WithBusMaxRequeues(2), WithBusLogger(logger.NewLogger(false).WithWriter(&buf)))
+
+	var got []string
+	done := make(chan struct{})
+	err := b.SubscribeAck(ctx, "t", func(_ context.Context, msg AckableMessage) error {
+		got = append(got, string(msg.Payload))
+		if string(msg.Payload) == "next" {
+			close(done)
+			return nil
+		}
+		msg.Nack(true)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"poison", "next"} {
+		if err := b.Publish(ctx, contracts.Message{Topic: "t", Payload: []byte(p)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+	b.Close()
+
+	if want := []string{"poison", "poison", "poison", "next"}; !slices.Equal(got, want) {
+		t.Errorf("deliveries = %v, want %v", got, want)
+	}
+	if !strings.Contains(buf.String(), "dropped after 2 redelivery(ies)") {
+		t.Errorf("dropped message not logged: %s", buf.String())
+	}
+}
+
+func TestMemoryBusCloseWithHandlerAlwaysRequeueing(t *testing.T) {
+	ctx := context.Background()
+	b := NewMemoryBus(WithBusMaxRequeues(math.MaxInt), WithBusLogger(logger.NewLogger(false).WithWriter(io.Discard)))
+
+	delivered := make(chan struct{}, 1)
+	err := b.SubscribeAck(ctx, "t", func(_ context.Context, msg AckableMessage) error {
+		select {
+		case delivered <- struct{}{}:
+		default:
+		}
+		msg.Nack(true)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(ctx, contracts.Message{Topic: "t", Payload: []byte("poison")}); err != nil {
+		t.Fatal(err)
+	}
+	<-delivered
+
+	closed := make(chan error, 1)
+	go func() { closed <- b.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close() = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return while the handler kept requeueing")
+	}
+}
+
+func TestAsAckSubscriber(t *testing.T) {
+	if s := AsAckSubscriber(NewMemoryBus()); s == nil {
+		t.Fatal("nil AckSubscriber")
+	} else if _, ok := s.(*MemoryBus); !ok {
+		t.Errorf("AsAckSubscriber(*MemoryBus) = %T, want the bus itself", s)
+	}
+
+	ctx := context.Background()
+	bus := keeltest.NewMemoryBus()
+	failure := errors.New("handler failed")
+	err := AsAckSubscriber(bus).SubscribeAck(ctx, "t", func(_ context.Context, msg AckableMessage) error {
+		switch string(msg.Payload) {
+		case "ack":
+			msg.Ack()
+		case "requeue":
+			msg.Nack(true)
+		case "drop":
+			msg.Nack(false)
+			return failure
+		case "fail":
+			return failure
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		payload string
+		want    error
+	}{
+		{"ack", nil},
+		{"auto", nil},
+		{"requeue", ErrMessageNacked},
+		{"drop", nil},
+		{"fail", failure},
+	}
+	for _, tt := range tests {
+		err := bus.Publish(ctx, contracts.Message{Topic: "t", Payload: []byte(tt.payload)})
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: plain handler returned %v, want %v", tt.payload, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"maps"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// WithCorrelation returns a copy of msg carrying the request ID and trace
+// context of ctx in its request-id, traceparent and tracestate headers, so
+// consumers can tie their work back to the request that published it.
+// Headers already set on msg are kept.
+//
+//	err := bus.Publish(ctx, core.WithCorrelation(ctx, msg))
+func WithCorrelation(ctx context.Context, msg contracts.Message) contracts.Message {
+	headers := maps.Clone(msg.Headers)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	setDefault := func(key, value string) {
+		if value != "" && headers[key] == "" {
+			headers[key] = value
+		}
+	}
+	setDefault(MessageHeaderRequestID, logger.RequestIDFromContext(ctx))
+	if headers[MessageHeaderTraceParent] == "" {
+		traceParent, traceState := traceHeaders(ctx, nil)
+		setDefault(MessageHeaderTraceParent, traceParent)
+		setDefault(MessageHeaderTraceState, traceState)
+	}
+	msg.Headers = headers
+	return msg
+}
+
+// MessageCorrelation returns a middleware restoring the correlation set by
+// WithCorrelation: the request ID and the publisher's trace context are
+// added to the handler context, so ctx-aware logs carry them and a Tracer
+// continues the publisher's trace. Place it before MessageTracing and
+// MessageLogging.
+func MessageCorrelation() MessageMiddleware {
+	return func(next contracts.MessageHandler) contracts.MessageHandler {
+		return func(ctx context.Context, msg contracts.Message) error {
+			if rid := msg.Headers[MessageHeaderRequestID]; rid != "" {
+				ctx = logger.ContextWithRequestID(ctx, rid)
+			}
+			if parent, ok := parseTraceParent(msg.Headers[MessageHeaderTraceParent]); ok {
+				parent.TraceState = msg.Headers[MessageHeaderTraceState]
+				ctx = contracts.ContextWithTraceParent(ctx, parent)
+				ctx = logger.ContextWithTrace(ctx, parent.TraceID, parent.SpanID)
+			}
+			return next(ctx, msg)
+		}
+	}
+}
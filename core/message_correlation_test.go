@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+func TestWithCorrelation(t *testing.T) {
+	traced := logger.ContextWithTrace(logger.ContextWithRequestID(context.Background(), "req-1"), testTraceID, testSpanID)
+	remote := contracts.ContextWithTraceParent(traced, contracts.TraceParent{TraceID: testTraceID, SpanID: "1111111111111111", Sampled: false, TraceState: "vendor=x"})
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		headers map[string]string
+		want    map[string]string
+	}{
+		{"empty context", context.Background(), nil, map[string]string{}},
+		{"request and trace", traced, nil, map[string]string{
+			MessageHeaderRequestID:   "req-1",
+			MessageHeaderTraceParent: "00-" + testTraceID + "-" + testSpanID + "-01",
+		}},
+		{"remote parent keeps sampling and tracestate", remote, nil, map[string]string{
+			MessageHeaderRequestID:   "req-1",
+			MessageHeaderTraceParent: "00-" + testTraceID + "-" + testSpanID + "-00",
+			MessageHeaderTraceState:  "vendor=x",
+		}},
+		{"existing headers kept", remote, map[string]string{MessageHeaderRequestID: "other", MessageHeaderTraceParent: "tp"}, map[string]string{
+			MessageHeaderRequestID:   "other",
+			MessageHeaderTraceParent: "tp",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := contracts.Message{Topic: "orders", Headers: tt.headers}
+			got := WithCorrelation(tt.ctx, msg).Headers
+			if len(got) != len(tt.want) {
+				t.Errorf("headers = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+			if tt.headers != nil && len(tt.headers) != 2 {
+				t.Errorf("original headers modified: %v", tt.headers)
+			}
+		})
+	}
+}
+
+func TestCorrelationRoundTrip(t *testing.T) {
+	const traceParent = "00-" + testTraceID + "-" + testSpanID + "-01"
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true, Tracing: TracingConfig{Enabled: true}})
+	bus := NewMemoryBus()
+
+	var consumerCtx context.Context
+	err := app.SubscribeWith(context.Background(), bus, "orders", func(ctx context.Context, msg contracts.Message) error {
+		consumerCtx = ctx
+		return errors.New("out of stock")
+	}, MessageCorrelation(), MessageLogging(logger.NewLogger(false).WithWriter(&buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Fiber().Post("/orders", func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		return bus.Publish(ctx, WithCorrelation(ctx, contracts.Message{Topic: "orders"}))
+	})
+
+	req := httptest.NewRequest("POST", "/orders", nil)
+	req.Header.Set("traceparent", traceParent)
+	req.Header.Set("tracestate", "vendor=x")
+	resp, err := app.Fiber().Test(req)
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("POST /orders = %v, %v", resp, err)
+	}
+	bus.Close() //nolint
+
+	rid := resp.Header.Get(fiber.HeaderXRequestID)
+	if got := logger.RequestIDFromContext(consumerCtx); rid == "" || got != rid {
+		t.Errorf("consumer request ID = %q, want %q", got, rid)
+	}
+	if traceID, _ := logger.TraceFromContext(consumerCtx); traceID != testTraceID {
+		t.Errorf("consumer trace ID = %q, want %q", traceID, testTraceID)
+	}
+	if parent, ok := contracts.TraceParentFromContext(consumerCtx); !ok || parent.TraceState != "vendor=x" || !parent.Sampled {
+		t.Errorf("consumer trace parent = %+v, %v", parent, ok)
+	}
+	logs := buf.String()
+	for _, want := range []string{"Message orders failed", "request_id=" + rid, "trace_id=" + testTraceID} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("consumer logs missing %q:\n%s", want, logs)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// MessageMiddleware wraps a message handler, like fiber middleware wraps
+// HTTP handlers.
+type MessageMiddleware func(next contracts.MessageHandler) contracts.MessageHandler
+
+// ChainMessageHandlers wraps h with mws. The first middleware is the
+// outermost: it sees the message first and the result last.
+func ChainMessageHandlers(h contracts.MessageHandler, mws ...MessageMiddleware) contracts.MessageHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// MessageRecover returns a middleware turning a handler panic into an
+// error, so one bad message does not crash the consumer.
+func MessageRecover() MessageMiddleware {
+	return func(next contracts.MessageHandler) contracts.MessageHandler {
+		return func(ctx context.Context, msg contracts.Message) error {
+			return safeHandle(ctx, next, msg)
+		}
+	}
+}
+
+// MessageLogging returns a middleware logging each handled message with
+// its topic, duration and outcome: DEBUG on success, ERROR on failure.
+// Log lines carry the request and trace IDs found in the handler context.
+func MessageLogging(l *logger.Logger) MessageMiddleware {
+	return func(next contracts.MessageHandler) contracts.MessageHandler {
+		return func(ctx context.Context, msg contracts.Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			ms := time.Since(start).Milliseconds()
+			if err != nil {
+				l.ErrorCtx(ctx, "Message %s failed (%dms): %v", msg.Topic, ms, err)
+			} else {
+				l.DebugCtx(ctx, "Message %s handled (%dms)", msg.Topic, ms)
+			}
+			return err
+		}
+	}
+}
+
+// MessageTracing returns a middleware running each handler in a
+// "consume <topic>" span. A traceparent header on the message makes the
+// span a child of the publisher's, and the trace IDs are added to the
+// handler context for ctx-aware logging.
+func MessageTracing(t contracts.Tracer) MessageMiddleware {
+	return func(next contracts.MessageHandler) contracts.MessageHandler {
+		return func(ctx context.Context, msg contracts.Message) error {
+			parent, remote := parseTraceParent(msg.Headers[MessageHeaderTraceParent])
+			if remote {
+				parent.TraceState = msg.Headers[MessageHeaderTraceState]
+				ctx = contracts.ContextWithTraceParent(ctx, parent)
+			}
+			ctx, span := t.Start(ctx, "consume "+msg.Topic)
+			traceID, spanID := spanIDs(span, parent, remote)
+			ctx = logger.ContextWithTrace(ctx, traceID, spanID)
+
+			attrs := map[string]any{"messaging.destination": msg.Topic}
+			if id := msg.Headers[MessageHeaderID]; id != "" {
+				attrs["messaging.message_id"] = id
+			}
+			SpanSetAttributes(span, attrs)
+
+			err := next(ctx, msg)
+			if err != nil {
+				span.RecordError(err)
+				SpanSetStatus(span, contracts.SpanStatusError, err.Error())
+			}
+			span.End()
+			return err
+		}
+	}
+}
+
+// UseMessageMiddleware appends mws to the chain SubscribeWith applies to
+// every handler.
+func (a *App) UseMessageMiddleware(mws ...MessageMiddleware) {
+	a.messageMW = append(a.messageMW, mws...)
+}
+
+// SubscribeWith subscribes handler to topic on s, wrapped in the App chain
+// of UseMessageMiddleware followed by mws.
+//
+//	app.UseMessageMiddleware(core.MessageRecover(), core.MessageLogging(app.Logger()))
+//	app.SubscribeWith(ctx, bus, "orders", handleOrder)
+func (a *App) SubscribeWith(ctx context.Context, s contracts.Subscriber, topic string, handler contracts.MessageHandler, mws ...MessageMiddleware) error {
+	chain := append(append([]MessageMiddleware(nil), a.messageMW...), mws...)
+	return s.Subscribe(ctx, topic, ChainMessageHandlers(handler, chain...))
+}
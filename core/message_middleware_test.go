@@ -0,0 +1,118 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// traceMW records the order middlewares run in.
+func traceMW(name string, order *[]string) MessageMiddleware {
+	return func(next contracts.MessageHandler) contracts.MessageHandler {
+		return func(ctx context.Context, msg contracts.Message) error {
+			*order = append(*order, name+">")
+			err := next(ctx, msg)
+			*order = append(*order, "<"+name)
+			return err
+		}
+	}
+}
+
+func TestChainMessageHandlers(t *testing.T) {
+	var order []string
+	h := ChainMessageHandlers(func(context.Context, contracts.Message) error {
+		order = append(order, "handler")
+		return nil
+	}, traceMW("a", &order), traceMW("b", &order))
+
+	h(context.Background(), contracts.Message{}) //nolint
+	if got := strings.Join(order, " "); got != "a> b> handler <b <a" {
+		t.Errorf("order = %s", got)
+	}
+}
+
+func TestMessageRecoverAndLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.NewLogger(false).WithWriter(&buf)
+	h := ChainMessageHandlers(func(_ context.Context, msg contracts.Message) error {
+		if string(msg.Payload) == "panic" {
+			panic("boom")
+		}
+		return nil
+	}, MessageLogging(log), MessageRecover())
+
+	ctx := logger.ContextWithRequestID(context.Background(), "req-7")
+	if err := h(ctx, contracts.Message{Topic: "orders", Payload: []byte("panic")}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want the recovered panic", err)
+	}
+	if err := h(ctx, contracts.Message{Topic: "orders"}); err != nil {
+		t.Fatal(err)
+	}
+	logs := buf.String()
+	for _, want := range []string{"[ERROR]", "Message orders failed", "message handler panicked: boom", "request_id=req-7", "[DEBUG]", "Message orders handled"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestMessageTracing(t *testing.T) {
+	tracer := &recordingTracer{}
+	var gotTrace string
+	errFail := errors.New("fail")
+	h := ChainMessageHandlers(func(ctx context.Context, msg contracts.Message) error {
+		gotTrace, _ = logger.TraceFromContext(ctx)
+		if string(msg.Payload) == "fail" {
+			return errFail
+		}
+		return nil
+	}, MessageTracing(tracer))
+
+	h(context.Background(), contracts.Message{Topic: "orders", Headers: map[string]string{ //nolint
+		MessageHeaderTraceParent: "00-" + testTraceID + "-" + testSpanID + "-01",
+		MessageHeaderID:          "m-1",
+	}})
+	h(context.Background(), contracts.Message{Topic: "orders", Payload: []byte("fail")}) //nolint
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(tracer.spans))
+	}
+	first, second := tracer.spans[0], tracer.spans[1]
+	if first.name != "consume orders" || first.parent.SpanID != testSpanID || first.ended != 1 {
+		t.Errorf("first span = %+v", first)
+	}
+	if first.attrs["messaging.destination"] != "orders" || first.attrs["messaging.message_id"] != "m-1" {
+		t.Errorf("first span attrs = %v", first.attrs)
+	}
+	if gotTrace != testTraceID {
+		t.Errorf("handler trace ID = %q", gotTrace)
+	}
+	if second.parent.TraceID != "" || second.status != contracts.SpanStatusError || len(second.errs) != 1 {
+		t.Errorf("second span = %+v, want a root span recording the error", second)
+	}
+}
+
+func TestAppSubscribeWith(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	var order []string
+	app.UseMessageMiddleware(traceMW("app", &order))
+	bus := keeltest.NewMemoryBus()
+
+	err := app.SubscribeWith(context.Background(), bus, "orders", func(context.Context, contracts.Message) error {
+		order = append(order, "handler")
+		return nil
+	}, traceMW("route", &order))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish(context.Background(), contracts.Message{Topic: "orders"}) //nolint
+	if got := strings.Join(order, " "); got != "app> route> handler <route <app" {
+		t.Errorf("order = %s", got)
+	}
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// Headers set on messages republished by DeadLetter.
+const (
+	MessageHeaderDLQAttempts = "dlq-attempts"
+	MessageHeaderDLQError    = "dlq-error"
+	MessageHeaderDLQTopic    = "dlq-original-topic"
+	MessageHeaderDLQFailedAt = "dlq-failed-at" // RFC 3339, UTC
+)
+
+// DeadLetterSuffix is appended to the topic of messages republished by
+// DeadLetter.
+const DeadLetterSuffix = ".dlq"
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+)
+
+// RetryConfig configures WithRetry.
+type RetryConfig struct {
+	MaxAttempts    int           // total handler runs, defaults to 3
+	InitialBackoff time.Duration // wait before the second run, defaults to 100ms; doubles after each failure
+	MaxBackoff     time.Duration // cap on the wait, defaults to 10s
+	Jitter         float64       // 0 to 1: each wait is shortened by a random fraction up to Jitter
+	// Retryable reports whether err is worth another run. Defaults to
+	// every error except a *MessageDecodeError.
+	Retryable func(err error) bool
+	// OnExhausted is called with the last error once no run is left or the
+	// error is not retryable, e.g. DeadLetter(publisher).
+	OnExhausted func(ctx context.Context, msg contracts.Message, err error)
+}
+
+// WithRetry returns a handler running handler again on failure, with
+// exponential backoff and jitter between runs. When the attempts run out
+// or the error is not retryable, cfg.OnExhausted is called and the last
+// error returned. Cancelling ctx stops waiting and returns ctx.Err().
+func WithRetry(handler contracts.MessageHandler, cfg RetryConfig) contracts.MessageHandler {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if cfg.Retryable == nil {
+		cfg.Retryable = func(err error) bool {
+			var decodeErr *MessageDecodeError
+			return !errors.As(err, &decodeErr)
+		}
+	}
+	return func(ctx context.Context, msg contracts.Message) error {
+		backoff := cfg.InitialBackoff
+		for attempt := 1; ; attempt++ {
+			err := handler(ctx, msg)
+			if err == nil {
+				return nil
+			}
+			if attempt >= cfg.MaxAttempts || !cfg.Retryable(err) {
+				if cfg.OnExhausted != nil {
+					cfg.OnExhausted(withRetryAttempts(ctx, attempt), msg, err)
+				}
+				return err
+			}
+
+			wait := backoff
+			if cfg.Jitter > 0 {
+				wait -= time.Duration(rand.Float64() * min(cfg.Jitter, 1) * float64(wait))
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+			backoff = min(backoff*2, cfg.MaxBackoff)
+		}
+	}
+}
+
+type retryAttemptsKey struct{}
+
+func withRetryAttempts(ctx context.Context, attempts int) context.Context {
+	return context.WithValue(ctx, retryAttemptsKey{}, attempts)
+}
+
+// RetryAttempts returns how many times the handler ran, in the context
+// passed to RetryConfig.OnExhausted.
+func RetryAttempts(ctx context.Context) int {
+	n, _ := ctx.Value(retryAttemptsKey{}).(int)
+	return n
+}
+
+// DeadLetterOption configures DeadLetter.
+type DeadLetterOption func(*deadLetterConfig)
+
+type deadLetterConfig struct {
+	logger *logger.Logger
+}
+
+// WithDeadLetterLogger sets the logger for failed republishes, such as
+// App.Logger(); by default DeadLetter creates a development logger.
+func WithDeadLetterLogger(l *logger.Logger) DeadLetterOption {
+	return func(c *deadLetterConfig) { c.logger = l }
+}
+
+// DeadLetter returns a RetryConfig.OnExhausted hook republishing the failed
+// message to "<topic>.dlq" on p, with the attempts, last error, original
+// topic and failure time in headers. A failed republish is logged.
+func DeadLetter(p contracts.Publisher, opts ...DeadLetterOption) func(ctx context.Context, msg contracts.Message, err error) {
+	var cfg deadLetterConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	log := cfg.logger
+	if log == nil {
+		log = logger.NewLogger(false)
+	}
+	return func(ctx context.Context, msg contracts.Message, err error) {
+		dead := msg
+		dead.Topic = msg.Topic + DeadLetterSuffix
+		dead.Headers = maps.Clone(msg.Headers)
+		if dead.Headers == nil {
+			dead.Headers = make(map[string]string)
+		}
+		dead.Headers[MessageHeaderDLQAttempts] = strconv.Itoa(RetryAttempts(ctx))
+		dead.Headers[MessageHeaderDLQError] = err.Error()
+		dead.Headers[MessageHeaderDLQTopic] = msg.Topic
+		dead.Headers[MessageHeaderDLQFailedAt] = time.Now().UTC().Format(time.RFC3339)
+		if perr := p.Publish(context.WithoutCancel(ctx), dead); perr != nil {
+			log.ErrorCtx(ctx, "Dead-lettering message from %s failed: %v (handler error: %v)", msg.Topic, perr, err)
+		}
+	}
+}
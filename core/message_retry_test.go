@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// failingTimes returns a handler failing n times, then succeeding.
+func failingTimes(n int, calls *int) contracts.MessageHandler {
+	return func(context.Context, contracts.Message) error {
+		*calls++
+		if *calls <= n {
+			return fmt.Errorf("db blip %d", *calls)
+		}
+		return nil
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	msg := contracts.Message{Topic: "orders", Payload: []byte("o-1"), Headers: map[string]string{"tenant": "acme"}}
+
+	t.Run("succeeds on last attempt", func(t *testing.T) {
+		calls, exhausted := 0, false
+		h := WithRetry(failingTimes(4, &calls), RetryConfig{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+			Jitter:         0.5,
+			OnExhausted:    func(context.Context, contracts.Message, error) { exhausted = true },
+		})
+		if err := h(ctx, msg); err != nil || calls != 5 || exhausted {
+			t.Errorf("err %v calls %d exhausted %v; want success on the 5th call", err, calls, exhausted)
+		}
+	})
+
+	t.Run("exhausted into dead letter", func(t *testing.T) {
+		dlq := keeltest.NewMemoryBus()
+		calls := 0
+		h := WithRetry(failingTimes(10, &calls), RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			OnExhausted:    DeadLetter(dlq),
+		})
+		if err := h(ctx, msg); err == nil || err.Error() != "db blip 3" || calls != 3 {
+			t.Fatalf("err %v calls %d; want the 3rd error", err, calls)
+		}
+		dead := dlq.Published("orders.dlq")
+		if len(dead) != 1 {
+			t.Fatalf("dead letters = %d, want 1", len(dead))
+		}
+		dh := dead[0].Headers
+		if string(dead[0].Payload) != "o-1" || dh["tenant"] != "acme" || dh[MessageHeaderDLQAttempts] != "3" ||
+			dh[MessageHeaderDLQError] != "db blip 3" || dh[MessageHeaderDLQTopic] != "orders" || dh[MessageHeaderDLQFailedAt] == "" {
+			t.Errorf("dead letter = %+v", dead[0])
+		}
+		if msg.Headers[MessageHeaderDLQError] != "" {
+			t.Error("DeadLetter mutated the original headers")
+		}
+	})
+
+	t.Run("dead letter publish failure is logged", func(t *testing.T) {
+		dlq := keeltest.NewMemoryBus()
+		dlq.Close() //nolint
+		var buf bytes.Buffer
+		dead := DeadLetter(dlq, WithDeadLetterLogger(logger.NewLogger(false).WithWriter(&buf)))
+		dead(ctx, msg, errors.New("db blip"))
+		if out := buf.String(); !strings.Contains(out, "Dead-lettering message from orders failed") || !strings.Contains(out, "db blip") {
+			t.Errorf("log = %q, want the failed dead letter", out)
+		}
+	})
+
+	t.Run("not retryable", func(t *testing.T) {
+		calls := 0
+		var exhaustedErr error
+		h := WithRetry(func(context.Context, contracts.Message) error {
+			calls++
+			return &MessageDecodeError{Topic: "orders", Err: errors.New("bad json")}
+		}, RetryConfig{OnExhausted: func(ctx context.Context, _ contracts.Message, err error) {
+			exhaustedErr = err
+			if n := RetryAttempts(ctx); n != 1 {
+				t.Errorf("RetryAttempts = %d, want 1", n)
+			}
+		}})
+		h(ctx, msg) //nolint
+		if calls != 1 || exhaustedErr == nil {
+			t.Errorf("calls %d exhausted with %v; want one call straight to OnExhausted", calls, exhaustedErr)
+		}
+	})
+
+	t.Run("cancel aborts backoff", func(t *testing.T) {
+		calls := 0
+		h := WithRetry(failingTimes(10, &calls), RetryConfig{MaxAttempts: 5, InitialBackoff: time.Hour})
+		cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if err := h(cctx, msg); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("err = %v, want DeadlineExceeded", err)
+		}
+		if time.Since(start) > time.Second || calls != 1 {
+			t.Errorf("took %v with %d calls; want a prompt return", time.Since(start), calls)
+		}
+	})
+}
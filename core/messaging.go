@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// Standard message headers.
+const (
+	MessageHeaderContentType = "content-type"
+	MessageHeaderID          = "message-id"
+	MessageHeaderPublishedAt = "published-at" // RFC 3339, UTC
+	MessageHeaderRequestID   = "request-id"   // request ID of the publishing context
+	MessageHeaderTraceParent = "traceparent"  // W3C trace context, read by MessageTracing and MessageCorrelation
+	MessageHeaderTraceState  = "tracestate"
+)
+
+// PublishJSON publishes payload to topic as JSON. It sets the content-type,
+// a generated message-id and published-at headers, and those of
+// WithCorrelation; headers are applied last and can override them.
+func PublishJSON[T any](ctx context.Context, p contracts.Publisher, topic, key string, payload T, headers ...map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("publish %s: encode payload: %w", topic, err)
+	}
+	msg := contracts.Message{
+		Topic:   topic,
+		Payload: body,
+		Headers: map[string]string{
+			MessageHeaderContentType: "application/json",
+			MessageHeaderID:          utils.UUIDv4(),
+			MessageHeaderPublishedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	}
+	if key != "" {
+		msg.Key = []byte(key)
+	}
+	msg = WithCorrelation(ctx, msg)
+	for _, h := range headers {
+		for k, v := range h {
+			msg.Headers[k] = v
+		}
+	}
+	return p.Publish(ctx, msg)
+}
+
+// MessageDecodeError is returned by the handler of SubscribeJSON when a
+// payload is not valid JSON for the expected type, so adapters and retry
+// policies can tell it apart from handler failures with errors.As.
+type MessageDecodeError struct {
+	Topic string
+	Err   error
+}
+
+func (e *MessageDecodeError) Error() string {
+	return fmt.Sprintf("decode message on %s: %v", e.Topic, e.Err)
+}
+
+func (e *MessageDecodeError) Unwrap() error { return e.Err }
+
+// SubscribeJSON subscribes fn to topic, decoding each payload as JSON into
+// a T. A payload that does not decode fails with a *MessageDecodeError
+// without calling fn; errors from fn are returned unchanged.
+func SubscribeJSON[T any](ctx context.Context, s contracts.Subscriber, topic string, fn func(ctx context.Context, payload T, msg contracts.Message) error) error {
+	return s.Subscribe(ctx, topic, func(ctx context.Context, msg contracts.Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			return &MessageDecodeError{Topic: msg.Topic, Err: err}
+		}
+		return fn(ctx, payload, msg)
+	})
+}
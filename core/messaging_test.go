@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+type orderPlaced struct {
+	ID    string  `json:"id"`
+	Total float64 `json:"total"`
+}
+
+func TestPublishSubscribeJSON(t *testing.T) {
+	bus := keeltest.NewMemoryBus()
+	ctx := context.Background()
+
+	var got orderPlaced
+	var gotMsg contracts.Message
+	errHandler := errors.New("handler failed")
+	SubscribeJSON(ctx, bus, "orders", func(_ context.Context, o orderPlaced, msg contracts.Message) error { //nolint
+		got, gotMsg = o, msg
+		if o.ID == "fail" {
+			return errHandler
+		}
+		return nil
+	})
+
+	reqCtx := logger.ContextWithRequestID(ctx, "req-42")
+	if err := PublishJSON(reqCtx, bus, "orders", "o-1", orderPlaced{ID: "o-1", Total: 9.5}, map[string]string{"tenant": "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if got != (orderPlaced{ID: "o-1", Total: 9.5}) {
+		t.Errorf("payload = %+v", got)
+	}
+	if string(gotMsg.Key) != "o-1" {
+		t.Errorf("key = %q", gotMsg.Key)
+	}
+	h := gotMsg.Headers
+	if h[MessageHeaderContentType] != "application/json" || h[MessageHeaderRequestID] != "req-42" || h["tenant"] != "acme" {
+		t.Errorf("headers = %v", h)
+	}
+	if len(h[MessageHeaderID]) != 36 {
+		t.Errorf("message-id = %q, want a UUID", h[MessageHeaderID])
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, h[MessageHeaderPublishedAt]); err != nil || time.Since(ts) > time.Minute {
+		t.Errorf("published-at = %q (%v)", h[MessageHeaderPublishedAt], err)
+	}
+
+	PublishJSON(ctx, bus, "orders", "", orderPlaced{ID: "o-2"}, map[string]string{MessageHeaderID: "fixed"}) //nolint
+	if gotMsg.Headers[MessageHeaderID] != "fixed" || gotMsg.Key != nil {
+		t.Errorf("override: headers %v key %q", gotMsg.Headers, gotMsg.Key)
+	}
+	if _, ok := gotMsg.Headers[MessageHeaderRequestID]; ok {
+		t.Error("request-id set without one in the context")
+	}
+
+	if err := PublishJSON(ctx, bus, "orders", "", orderPlaced{ID: "fail"}); !errors.Is(err, errHandler) {
+		t.Errorf("handler failure err = %v", err)
+	}
+	var decodeErr *MessageDecodeError
+	if errors.As(PublishJSON(ctx, bus, "orders", "", orderPlaced{ID: "fail"}), &decodeErr) {
+		t.Error("handler failure reported as a decode error")
+	}
+
+	got = orderPlaced{}
+	err := bus.Publish(ctx, contracts.Message{Topic: "orders", Payload: []byte(`{"id":1}`)})
+	if !errors.As(err, &decodeErr) || decodeErr.Topic != "orders" {
+		t.Fatalf("bad payload err = %v, want a MessageDecodeError", err)
+	}
+	if got != (orderPlaced{}) {
+		t.Errorf("handler called with %+v for a bad payload", got)
+	}
+
+	if err := PublishJSON(ctx, bus, "orders", "", make(chan int)); err == nil {
+		t.Error("PublishJSON of an unencodable payload succeeded")
+	}
+}
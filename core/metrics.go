@@ -0,0 +1,353 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultMetricsBuckets are the request duration histogram buckets, in
+// seconds, used when none are configured.
+var DefaultMetricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// PrometheusCollector is an in-process MetricsCollector that exposes request
+// counters, latency histograms, the in-flight request gauge, error and panic
+// counters, audit sink failures, health check results, scheduled job runs
+// and outbox relay progress in the Prometheus text exposition format.
+// Request series are labelled by method, route pattern and status.
+type PrometheusCollector struct {
+	mu       sync.Mutex
+	buckets  []float64
+	requests map[requestSeriesKey]*requestSeries
+	inFlight int64
+	errors   map[errorSeriesKey]uint64
+	panics   map[string]uint64 // by route pattern
+	audit    uint64            // audit sink failures
+	health   map[string]healthSeries
+	jobs     map[string]*jobSeries
+	outbox   outboxSeries
+}
+
+type outboxSeries struct {
+	published uint64
+	failed    uint64
+	lag       time.Duration
+}
+
+type healthSeries struct {
+	up       bool
+	duration time.Duration
+}
+
+type jobSeries struct {
+	successes    uint64
+	failures     uint64
+	lastDuration time.Duration
+}
+
+type errorSeriesKey struct {
+	code   string
+	status int
+}
+
+type requestSeriesKey struct {
+	method string
+	path   string
+	status int
+}
+
+type requestSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // cumulative counts, one per upper bound
+}
+
+var (
+	_ contracts.MetricsCollector  = (*PrometheusCollector)(nil)
+	_ contracts.InFlightCollector = (*PrometheusCollector)(nil)
+	_ contracts.ErrorCollector    = (*PrometheusCollector)(nil)
+	_ contracts.AuditCollector    = (*PrometheusCollector)(nil)
+	_ contracts.HealthCollector   = (*PrometheusCollector)(nil)
+	_ contracts.JobCollector      = (*PrometheusCollector)(nil)
+	_ contracts.OutboxCollector   = (*PrometheusCollector)(nil)
+)
+
+// NewPrometheusCollector creates a collector with the given histogram bucket
+// upper bounds in seconds. DefaultMetricsBuckets is used when none are given.
+func NewPrometheusCollector(buckets ...float64) *PrometheusCollector {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusCollector{
+		buckets:  sorted,
+		requests: make(map[requestSeriesKey]*requestSeries),
+		errors:   make(map[errorSeriesKey]uint64),
+		panics:   make(map[string]uint64),
+		health:   make(map[string]healthSeries),
+		jobs:     make(map[string]*jobSeries),
+	}
+}
+
+// RecordRequest implements contracts.MetricsCollector.
+func (p *PrometheusCollector) RecordRequest(m contracts.RequestMetrics) {
+	seconds := m.Duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := requestSeriesKey{method: m.Method, path: m.Path, status: m.StatusCode}
+	s, ok := p.requests[key]
+	if !ok {
+		// Fiber reuses request buffers, so labels must be copied before
+		// they are retained as map keys.
+		key.method = strings.Clone(key.method)
+		key.path = strings.Clone(key.path)
+		s = &requestSeries{buckets: make([]uint64, len(p.buckets))}
+		p.requests[key] = s
+	}
+	s.count++
+	s.sum += seconds
+	for i, upper := range p.buckets {
+		if seconds <= upper {
+			s.buckets[i]++
+		}
+	}
+}
+
+// RequestStarted implements contracts.InFlightCollector.
+func (p *PrometheusCollector) RequestStarted() {
+	p.mu.Lock()
+	p.inFlight++
+	p.mu.Unlock()
+}
+
+// RequestFinished implements contracts.InFlightCollector.
+func (p *PrometheusCollector) RequestFinished() {
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+}
+
+// RecordError implements contracts.ErrorCollector.
+func (p *PrometheusCollector) RecordError(code string, status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errors[errorSeriesKey{code: strings.Clone(code), status: status}]++
+}
+
+// RecordPanic implements contracts.ErrorCollector.
+func (p *PrometheusCollector) RecordPanic(route string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.panics[strings.Clone(route)]++
+}
+
+// RecordAuditFailure implements contracts.AuditCollector.
+func (p *PrometheusCollector) RecordAuditFailure() {
+	p.mu.Lock()
+	p.audit++
+	p.mu.Unlock()
+}
+
+// RecordHealthCheck implements contracts.HealthCollector.
+func (p *PrometheusCollector) RecordHealthCheck(name string, up bool, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.health[name] = healthSeries{up: up, duration: d}
+}
+
+// RecordJobRun implements contracts.JobCollector.
+func (p *PrometheusCollector) RecordJobRun(name string, success bool, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.jobs[name]
+	if !ok {
+		s = &jobSeries{}
+		p.jobs[name] = s
+	}
+	if success {
+		s.successes++
+	} else {
+		s.failures++
+	}
+	s.lastDuration = d
+}
+
+// RecordOutboxRelay implements contracts.OutboxCollector.
+func (p *PrometheusCollector) RecordOutboxRelay(published, failed int, lag time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.outbox.published += uint64(published)
+	p.outbox.failed += uint64(failed)
+	p.outbox.lag = lag
+}
+
+// WriteTo writes all series in the Prometheus text exposition format.
+func (p *PrometheusCollector) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, p.expose())
+	return int64(n), err
+}
+
+func (p *PrometheusCollector) expose() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]requestSeriesKey, 0, len(p.requests))
+	for k := range p.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP http_requests_total Total number of HTTP requests.\n")
+	b.WriteString("# TYPE http_requests_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "http_requests_total{%s} %d\n", k.labels(), p.requests[k].count)
+	}
+
+	b.WriteString("# HELP http_request_duration_seconds HTTP request latency in seconds.\n")
+	b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+	for _, k := range keys {
+		s := p.requests[k]
+		labels := k.labels()
+		for i, upper := range p.buckets {
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=%q} %d\n", labels, formatFloat(upper), s.buckets[i])
+		}
+		fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.count)
+		fmt.Fprintf(&b, "http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(s.sum))
+		fmt.Fprintf(&b, "http_request_duration_seconds_count{%s} %d\n", labels, s.count)
+	}
+
+	b.WriteString("# HELP http_requests_in_flight Number of HTTP requests being served.\n")
+	b.WriteString("# TYPE http_requests_in_flight gauge\n")
+	fmt.Fprintf(&b, "http_requests_in_flight %d\n", p.inFlight)
+
+	errorKeys := make([]errorSeriesKey, 0, len(p.errors))
+	for k := range p.errors {
+		errorKeys = append(errorKeys, k)
+	}
+	sort.Slice(errorKeys, func(i, j int) bool {
+		if errorKeys[i].status != errorKeys[j].status {
+			return errorKeys[i].status < errorKeys[j].status
+		}
+		return errorKeys[i].code < errorKeys[j].code
+	})
+	b.WriteString("# HELP http_errors_total Total number of HTTP error responses.\n")
+	b.WriteString("# TYPE http_errors_total counter\n")
+	for _, k := range errorKeys {
+		fmt.Fprintf(&b, "http_errors_total{code=\"%s\",status=\"%d\"} %d\n", escapeLabel(k.code), k.status, p.errors[k])
+	}
+
+	routes := make([]string, 0, len(p.panics))
+	for r := range p.panics {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+	b.WriteString("# HELP http_panics_total Total number of panics recovered from route handlers.\n")
+	b.WriteString("# TYPE http_panics_total counter\n")
+	for _, r := range routes {
+		fmt.Fprintf(&b, "http_panics_total{route=\"%s\"} %d\n", escapeLabel(r), p.panics[r])
+	}
+
+	b.WriteString("# HELP audit_write_failures_total Total number of audit events the audit sink failed to write.\n")
+	b.WriteString("# TYPE audit_write_failures_total counter\n")
+	fmt.Fprintf(&b, "audit_write_failures_total %d\n", p.audit)
+
+	checks := make([]string, 0, len(p.health))
+	for name := range p.health {
+		checks = append(checks, name)
+	}
+	sort.Strings(checks)
+	b.WriteString("# HELP health_check_up Whether the last run of a health check succeeded.\n")
+	b.WriteString("# TYPE health_check_up gauge\n")
+	for _, name := range checks {
+		up := 0
+		if p.health[name].up {
+			up = 1
+		}
+		fmt.Fprintf(&b, "health_check_up{check=\"%s\"} %d\n", escapeLabel(name), up)
+	}
+	b.WriteString("# HELP health_check_duration_seconds Duration of the last run of a health check.\n")
+	b.WriteString("# TYPE health_check_duration_seconds gauge\n")
+	for _, name := range checks {
+		fmt.Fprintf(&b, "health_check_duration_seconds{check=\"%s\"} %s\n", escapeLabel(name), formatFloat(p.health[name].duration.Seconds()))
+	}
+
+	jobs := make([]string, 0, len(p.jobs))
+	for name := range p.jobs {
+		jobs = append(jobs, name)
+	}
+	sort.Strings(jobs)
+	b.WriteString("# HELP scheduler_job_runs_total Total number of scheduled job runs.\n")
+	b.WriteString("# TYPE scheduler_job_runs_total counter\n")
+	for _, name := range jobs {
+		s := p.jobs[name]
+		fmt.Fprintf(&b, "scheduler_job_runs_total{job=\"%s\",result=\"success\"} %d\n", escapeLabel(name), s.successes)
+		fmt.Fprintf(&b, "scheduler_job_runs_total{job=\"%s\",result=\"failure\"} %d\n", escapeLabel(name), s.failures)
+	}
+	b.WriteString("# HELP scheduler_job_last_duration_seconds Duration of the last run of a scheduled job.\n")
+	b.WriteString("# TYPE scheduler_job_last_duration_seconds gauge\n")
+	for _, name := range jobs {
+		fmt.Fprintf(&b, "scheduler_job_last_duration_seconds{job=\"%s\"} %s\n", escapeLabel(name), formatFloat(p.jobs[name].lastDuration.Seconds()))
+	}
+
+	b.WriteString("# HELP outbox_messages_published_total Total number of outbox messages published by the relay.\n")
+	b.WriteString("# TYPE outbox_messages_published_total counter\n")
+	fmt.Fprintf(&b, "outbox_messages_published_total %d\n", p.outbox.published)
+	b.WriteString("# HELP outbox_publish_failures_total Total number of failed outbox publish attempts.\n")
+	b.WriteString("# TYPE outbox_publish_failures_total counter\n")
+	fmt.Fprintf(&b, "outbox_publish_failures_total %d\n", p.outbox.failed)
+	b.WriteString("# HELP outbox_lag_seconds Age of the oldest pending outbox message after the last relay poll.\n")
+	b.WriteString("# TYPE outbox_lag_seconds gauge\n")
+	fmt.Fprintf(&b, "outbox_lag_seconds %s\n", formatFloat(p.outbox.lag.Seconds()))
+	return b.String()
+}
+
+// Handler returns a Fiber handler that serves the collected metrics.
+func (p *PrometheusCollector) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		_, err := p.WriteTo(c)
+		return err
+	}
+}
+
+func (k requestSeriesKey) labels() string {
+	return fmt.Sprintf(`method="%s",route="%s",status="%d"`, escapeLabel(k.method), escapeLabel(k.path), k.status)
+}
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// registerMetrics installs the built-in collector and mounts its endpoint.
+// The endpoint is served directly by Fiber so it is neither documented in
+// OpenAPI nor recorded in its own metrics.
+func (a *App) registerMetrics() {
+	collector := NewPrometheusCollector(a.config.Metrics.Buckets...)
+	a.metricsCollector = collector
+	a.fiber.Get(a.config.Metrics.Path, collector.Handler())
+}
@@ -0,0 +1,295 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+func TestPrometheusCollectorWriteTo(t *testing.T) {
+	p := NewPrometheusCollector(0.1, 1)
+	p.RecordRequest(contracts.RequestMetrics{Method: "GET", Path: "/users", StatusCode: 200, Duration: 50 * time.Millisecond})
+	p.RecordRequest(contracts.RequestMetrics{Method: "GET", Path: "/users", StatusCode: 200, Duration: 500 * time.Millisecond})
+
+	var b strings.Builder
+	if _, err := p.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/users",status="200"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users",status="200",le="0.1"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users",status="200",le="1"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users",status="200",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/users",status="200"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestPrometheusCollectorSeries(t *testing.T) {
+	p := NewPrometheusCollector(0.01, 0.1)
+	for _, m := range []contracts.RequestMetrics{
+		{Method: "GET", Path: "/users/:id", StatusCode: 200, Duration: 5 * time.Millisecond},
+		{Method: "GET", Path: "/users/:id", StatusCode: 200, Duration: 50 * time.Millisecond},
+		{Method: "GET", Path: "/users/:id", StatusCode: 404, Duration: 200 * time.Millisecond},
+		{Method: "POST", Path: `/say/"hi"`, StatusCode: 201, Duration: time.Millisecond},
+	} {
+		p.RecordRequest(m)
+	}
+	out := p.expose()
+
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`http_requests_total{method="GET",route="/users/:id",status="404"} 1`,
+		`http_requests_total{method="POST",route="/say/\"hi\"",status="201"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="0.01"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="0.1"} 2`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="404",le="0.1"} 0`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="404",le="+Inf"} 1`,
+		`http_request_duration_seconds_sum{method="GET",route="/users/:id",status="200"} 0.055`,
+		"# TYPE http_requests_in_flight gauge",
+		"http_requests_in_flight 0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricsInFlightGauge(t *testing.T) {
+	app := New(KConfig{DisableHealth: true, Metrics: MetricsConfig{Enabled: true}})
+	collector := app.metricsCollector.(*PrometheusCollector)
+	var during string
+	app.Fiber().Get("/work", func(c *fiber.Ctx) error {
+		during = collector.expose()
+		return c.SendString("done")
+	})
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/work", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(during, "http_requests_in_flight 1\n") {
+		t.Errorf("in-flight gauge during the request:\n%s", during)
+	}
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "http_requests_in_flight 0\n") {
+		t.Errorf("in-flight gauge after the request, scraping excluded:\n%s", body)
+	}
+}
+
+// errorRecordingCollector records the optional ErrorCollector calls.
+type errorRecordingCollector struct {
+	mockMetricsCollector
+	errors []string
+	panics []string
+}
+
+func (e *errorRecordingCollector) RecordError(code string, status int) {
+	e.errors = append(e.errors, fmt.Sprintf("%s %d", code, status))
+}
+
+func (e *errorRecordingCollector) RecordPanic(route string) {
+	e.panics = append(e.panics, route)
+}
+
+func TestErrorCollector(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    fiber.Handler
+		wantErrors []string
+		wantPanics []string
+	}{
+		{"200", func(c *fiber.Ctx) error { return c.SendString("ok") }, nil, nil},
+		{"KError", func(c *fiber.Ctx) error { return NotFound("missing") }, []string{"NOT_FOUND 404"}, nil},
+		{"fiber error", func(c *fiber.Ctx) error { return fiber.ErrConflict }, []string{"CONFLICT 409"}, nil},
+		{"panic", func(c *fiber.Ctx) error { panic("boom") }, []string{"INTERNAL_SERVER_ERROR 500"}, []string{"/items/:id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &errorRecordingCollector{}
+			app := New(KConfig{DisableHealth: true})
+			app.SetMetricsCollector(collector)
+			app.Fiber().Get("/items/:id", tt.handler)
+
+			if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/items/7", nil)); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(collector.errors, tt.wantErrors) {
+				t.Errorf("RecordError calls = %v, want %v", collector.errors, tt.wantErrors)
+			}
+			if !slices.Equal(collector.panics, tt.wantPanics) {
+				t.Errorf("RecordPanic calls = %v, want %v", collector.panics, tt.wantPanics)
+			}
+		})
+	}
+}
+
+// outcomeRecordingCollector records the optional HealthCollector and
+// JobCollector calls.
+type outcomeRecordingCollector struct {
+	mockMetricsCollector
+	mu     sync.Mutex
+	checks map[string]bool
+	jobs   []string
+}
+
+func (o *outcomeRecordingCollector) RecordHealthCheck(name string, up bool, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.checks == nil {
+		o.checks = make(map[string]bool)
+	}
+	o.checks[name] = up
+}
+
+func (o *outcomeRecordingCollector) RecordJobRun(name string, success bool, _ time.Duration) {
+	o.jobs = append(o.jobs, fmt.Sprintf("%s %t", name, success))
+}
+
+func TestHealthAndJobCollector(t *testing.T) {
+	collector := &outcomeRecordingCollector{}
+	app := New(KConfig{})
+	app.SetMetricsCollector(collector)
+	app.RegisterHealthChecker(HealthCheckerFunc("db", func(context.Context) error { return nil }))
+	app.RegisterHealthChecker(HealthCheckerFunc("cache", func(context.Context) error { return errors.New("refused") }))
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/health", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 503 {
+		t.Fatalf("status = %d, want 503", resp.StatusCode)
+	}
+	if want := map[string]bool{"db": true, "cache": false}; !maps.Equal(collector.checks, want) {
+		t.Errorf("RecordHealthCheck calls = %v, want %v", collector.checks, want)
+	}
+
+	if err := app.AddJob(contracts.Job{Name: "sync"}); err == nil {
+		t.Error("AddJob without a scheduler should fail")
+	}
+	s := &schedulerSpy{}
+	app.RegisterScheduler(s)
+	fail := true
+	if err := app.AddJob(contracts.Job{Name: "sync", Handler: func(context.Context) error {
+		if fail {
+			return errors.New("upstream down")
+		}
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	s.jobs[0].Handler(context.Background()) //nolint
+	fail = false
+	s.jobs[0].Handler(context.Background()) //nolint
+	if want := []string{"sync false", "sync true"}; !slices.Equal(collector.jobs, want) {
+		t.Errorf("RecordJobRun calls = %v, want %v", collector.jobs, want)
+	}
+}
+
+func TestPrometheusCollectorOutcomes(t *testing.T) {
+	p := NewPrometheusCollector()
+	p.RecordHealthCheck("db", true, 20*time.Millisecond)
+	p.RecordHealthCheck("cache", false, 2*time.Second)
+	p.RecordJobRun("sync", true, 1500*time.Millisecond)
+	p.RecordJobRun("sync", false, 500*time.Millisecond)
+	out := p.expose()
+
+	for _, want := range []string{
+		"# TYPE health_check_up gauge",
+		`health_check_up{check="cache"} 0`,
+		`health_check_up{check="db"} 1`,
+		`health_check_duration_seconds{check="db"} 0.02`,
+		"# TYPE scheduler_job_runs_total counter",
+		`scheduler_job_runs_total{job="sync",result="success"} 1`,
+		`scheduler_job_runs_total{job="sync",result="failure"} 1`,
+		`scheduler_job_last_duration_seconds{job="sync"} 0.5`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestPrometheusCollectorErrorCounters(t *testing.T) {
+	p := NewPrometheusCollector()
+	p.RecordError("NOT_FOUND", 404)
+	p.RecordError("NOT_FOUND", 404)
+	p.RecordError("VALIDATION", 422)
+	p.RecordPanic("/items/:id")
+	p.RecordAuditFailure()
+	out := p.expose()
+
+	for _, want := range []string{
+		"# TYPE http_errors_total counter",
+		`http_errors_total{code="NOT_FOUND",status="404"} 2`,
+		`http_errors_total{code="VALIDATION",status="422"} 1`,
+		"# TYPE http_panics_total counter",
+		`http_panics_total{route="/items/:id"} 1`,
+		"audit_write_failures_total 1",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	app := New(KConfig{Metrics: MetricsConfig{Enabled: true}})
+	app.Fiber().Get("/ping", func(c *fiber.Ctx) error { return c.SendString("pong") })
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/ping", nil)); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/metrics", nil)); err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	out := string(body)
+
+	if !strings.Contains(out, `http_requests_total{method="GET",route="/ping",status="200"} 1`) {
+		t.Errorf("expected /ping series, got:\n%s", out)
+	}
+	if strings.Contains(out, `route="/metrics"`) {
+		t.Errorf("metrics endpoint should not record itself, got:\n%s", out)
+	}
+}
+
+func TestMetricsDisabledByDefault(t *testing.T) {
+	app := New(KConfig{})
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -1,61 +1,254 @@
 package core
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"time"
+	"net/url"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
+// maxRequestIDLength caps incoming request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// requestIDMiddleware assigns every request an ID, stores it in the
+// "requestid" local and the request context, and echoes it in the configured
+// response header.
+func (a *App) requestIDMiddleware() fiber.Handler {
+	cfg := a.config.RequestID
+	return func(c *fiber.Ctx) error {
+		rid := ""
+		if cfg.AcceptIncoming {
+			// Clone: the header bytes are reused once the request ends, but
+			// the ID outlives it in contexts, audit events and messages.
+			rid = strings.Clone(c.Get(cfg.Header))
+			if !validRequestID(rid) {
+				rid = ""
+			}
+		}
+		if rid == "" {
+			rid = cfg.Generator()
+		}
+
+		c.Set(cfg.Header, rid)
+		c.Locals("requestid", rid)
+		c.SetUserContext(logger.ContextWithRequestID(c.UserContext(), rid))
+		return c.Next()
+	}
+}
+
+// validRequestID reports whether an incoming request ID is safe to reuse in
+// headers and logs.
+func validRequestID(rid string) bool {
+	if rid == "" || len(rid) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(rid); i++ {
+		ch := rid[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '-', ch == '_', ch == '.', ch == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // keelLogger provides request logging and optional metrics collection for HTTP requests.
+// It runs the error handler for errors returned further down the chain, so
+// the logged size is that of the response actually sent.
 func (a *App) keelLogger() fiber.Handler {
-	log := a.logger
+	var sampler *logger.Sampler
+	if cfg := a.config.AccessLog.Sampling; cfg != nil {
+		sampler = logger.NewSampler(*cfg)
+	}
 	return func(c *fiber.Ctx) error {
-		start := time.Now()
+		log := a.logger.WithCallerSkip(1)
+		if inFlight, ok := a.metricsCollector.(contracts.InFlightCollector); ok && !a.isMetricsEndpoint(c.Path()) {
+			inFlight.RequestStarted()
+			defer inFlight.RequestFinished()
+		}
+		start := a.clock.Now()
 		err := c.Next()
-		duration := time.Since(start)
+		if err != nil {
+			// Write the error response now so its size is logged; returning
+			// nil below keeps Fiber from running the error handler again.
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		duration := a.clock.Now().Sub(start)
 
 		status := resolveStatus(c, err)
-		method := c.Method()
-		path := c.Path()
-		ip := c.IP()
-		rid := c.Locals("requestid")
+		// Method, path and headers point into buffers reused by the next
+		// request, while log hooks and collectors may keep them.
+		method := strings.Clone(c.Method())
+		path := strings.Clone(c.Path())
+		route, matched := matchedRoute(c, err)
+		ip := strings.Clone(c.IP())
+		rid := httpx.RequestID(c)
+		size := len(c.Response().Body())
 
-		msg := fmt.Sprintf("%s %s %s [%d] %s (%dms)", ip, rid, method, status, path, duration.Milliseconds())
+		logged := !a.config.AccessLog.skips(path)
+		if logged && sampler != nil && status >= 400 && status < 500 {
+			var dropped int
+			logged, dropped = sampler.Allow(fmt.Sprintf("%d %s %s", status, method, c.Route().Path))
+			if dropped > 0 {
+				log = log.With("sampled", true).With("dropped", dropped)
+			}
+		}
 
-		if status >= 400 {
-			log.Warn("HTTP %s", msg)
-		} else {
-			log.Info("HTTP %s", msg)
+		if tid := traceID(c); tid != "" {
+			log = log.With("trace_id", tid)
 		}
 
-		if a.metricsCollector != nil {
+		if logged {
+			target := path
+			if query := c.Request().URI().QueryString(); len(query) > 0 {
+				target += "?" + a.redactQuery(query)
+			}
+
+			if log.Structured() {
+				logPath := route
+				if !matched {
+					logPath = path
+				}
+				log = log.WithFields(map[string]any{
+					"method":      method,
+					"path":        logPath,
+					"status":      status,
+					"duration_ms": duration.Milliseconds(),
+					"ip":          ip,
+					"request_id":  rid,
+					"bytes_in":    len(c.Request().Body()),
+					"bytes_out":   size,
+					"user_agent":  strings.Clone(c.Get(fiber.HeaderUserAgent)),
+				})
+				logAccess(log, status, method+" "+target)
+			} else {
+				msg := fmt.Sprintf("%s %s %s [%d] %s (%dms, %dB)", ip, rid, method, status, target, duration.Milliseconds(), size)
+				logAccess(log, status, msg)
+			}
+		}
+
+		if a.metricsCollector != nil && !a.isMetricsEndpoint(path) {
+			if !matched {
+				route = unmatchedRoute
+			}
 			a.metricsCollector.RecordRequest(contracts.RequestMetrics{
-				Method:     method,
-				Path:       path,
-				StatusCode: status,
-				Duration:   duration,
+				Method:       method,
+				Path:         route,
+				RawPath:      path,
+				StatusCode:   status,
+				Duration:     duration,
+				ResponseSize: int64(size),
+				RequestSize:  int64(len(c.Request().Body())),
+				ErrorCode:    errorCode(err),
+				RequestID:    rid,
 			})
 		}
 
-		return err
+		return nil
 	}
 }
 
+// logAccess writes one access-log line. Callers pass a logger with one extra
+// caller skip so the line is attributed to the middleware, not this helper.
+func logAccess(log *logger.Logger, status int, msg string) {
+	if status >= 400 {
+		log.Warn("HTTP %s", msg)
+		return
+	}
+	log.Info("HTTP %s", msg)
+}
+
+// unmatchedRoute is the metrics path of requests no route handled, so
+// probes for random URLs share one series.
+const unmatchedRoute = "unmatched"
+
+// matchedRoute returns the pattern of the route that handled the request,
+// e.g. "/users/:id". When routing found no handler, Fiber leaves the last
+// matched middleware as the route and returns a plain *fiber.Error 404, so
+// ok is false.
+func matchedRoute(c *fiber.Ctx, err error) (pattern string, ok bool) {
+	var fe *fiber.Error
+	if errors.As(err, &fe) && fe.Code == fiber.StatusNotFound {
+		return "", false
+	}
+	return c.Route().Path, true
+}
+
+// redactQuery returns the raw query string with the values of the configured
+// redact keys replaced, so tokens passed as parameters never reach the logs.
+// Other parameters keep their original encoding.
+func (a *App) redactQuery(raw []byte) string {
+	params := strings.Split(string(raw), "&")
+	for i, p := range params {
+		key, _, _ := strings.Cut(p, "=")
+		if name, err := url.QueryUnescape(key); err == nil && a.config.Logging.redacts(name) {
+			params[i] = key + "=" + logger.Redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// isMetricsEndpoint reports whether path is the built-in metrics endpoint,
+// which is excluded from its own metrics.
+func (a *App) isMetricsEndpoint(path string) bool {
+	return a.config.Metrics.Enabled && path == a.config.Metrics.Path
+}
+
+// errorCode returns the code of a *KError returned by the handler, or "".
+// The error handler has not run yet when middleware sees the error, so the
+// code is read from the error itself.
+func errorCode(err error) string {
+	var ke *KError
+	if errors.As(err, &ke) {
+		return ke.Code
+	}
+	return ""
+}
+
 // resolveStatus returns the true HTTP status code for the request.
 // c.Response().StatusCode() reads 200 before Fiber's error handler runs,
-// so we inspect the returned error directly when one is present.
+// so we inspect the returned error directly when one is present. Other
+// errors become 500, as in the error handler.
 func resolveStatus(c *fiber.Ctx, err error) int {
 	if err != nil {
 		var ke *KError
 		if errors.As(err, &ke) {
 			return ke.StatusCode
 		}
-		if fe, ok := err.(*fiber.Error); ok {
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
 			return fe.Code
 		}
+		return fiber.StatusInternalServerError
 	}
 	return c.Response().StatusCode()
 }
+
+// basicAuth rejects requests whose HTTP Basic credentials do not match the
+// single configured user.
+func basicAuth(username, password, realm string) fiber.Handler {
+	return BasicAuthGuard(map[string]string{username: password}, realm).Middleware()
+}
+
+// parseBasicAuth extracts the credentials from a Basic Authorization header.
+func parseBasicAuth(header string) (string, string, bool) {
+	const prefix = "Basic "
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
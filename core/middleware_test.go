@@ -1,10 +1,21 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
 func TestResolveStatus_noError(t *testing.T) {
@@ -71,3 +82,356 @@ func TestResolveStatus_fiberError(t *testing.T) {
 		t.Fatalf("resolveStatus = %d, want 403", captured)
 	}
 }
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      RequestIDConfig
+		incoming string
+		want     func(got string) bool
+	}{
+		{
+			name:     "incoming ID is propagated when accepted",
+			cfg:      RequestIDConfig{Header: "X-Correlation-ID", AcceptIncoming: true},
+			incoming: "gw-42",
+			want:     func(got string) bool { return got == "gw-42" },
+		},
+		{
+			name:     "incoming ID is ignored by default",
+			cfg:      RequestIDConfig{Header: "X-Correlation-ID", Generator: func() string { return "generated" }},
+			incoming: "gw-42",
+			want:     func(got string) bool { return got == "generated" },
+		},
+		{
+			name: "ID is generated when none is sent",
+			cfg:  RequestIDConfig{Header: "X-Correlation-ID", AcceptIncoming: true, Generator: func() string { return "generated" }},
+			want: func(got string) bool { return got == "generated" },
+		},
+		{
+			name:     "over-long incoming ID is replaced",
+			cfg:      RequestIDConfig{Header: "X-Correlation-ID", AcceptIncoming: true, Generator: func() string { return "generated" }},
+			incoming: strings.Repeat("a", maxRequestIDLength+1),
+			want:     func(got string) bool { return got == "generated" },
+		},
+		{
+			name:     "incoming ID with unsafe characters is replaced",
+			cfg:      RequestIDConfig{Header: "X-Correlation-ID", AcceptIncoming: true, Generator: func() string { return "generated" }},
+			incoming: "abc<script>",
+			want:     func(got string) bool { return got == "generated" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &mockMetricsCollector{}
+			app := New(KConfig{DisableHealth: true, RequestID: tt.cfg})
+			app.SetMetricsCollector(mc)
+
+			var local string
+			app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+				return []httpx.Route{
+					httpx.GET("/rid", func(c *httpx.Ctx) error {
+						local = c.RequestID()
+						return NotFound("nothing here")
+					}),
+				}
+			}))
+
+			req := httptest.NewRequest("GET", "/rid", nil)
+			if tt.incoming != "" {
+				req.Header.Set("X-Correlation-ID", tt.incoming)
+			}
+			resp, err := app.Fiber().Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := resp.Header.Get("X-Correlation-ID")
+			if !tt.want(got) {
+				t.Fatalf("X-Correlation-ID = %q", got)
+			}
+			if local != got {
+				t.Errorf("Ctx.RequestID() = %q, want %q", local, got)
+			}
+			if mc.lastMetrics.RequestID != got {
+				t.Errorf("RequestMetrics.RequestID = %q, want %q", mc.lastMetrics.RequestID, got)
+			}
+
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["request_id"] != got {
+				t.Errorf("error body request_id = %v, want %q", body["request_id"], got)
+			}
+		})
+	}
+}
+
+func TestRequestIDOutlivesRequest(t *testing.T) {
+	app := New(KConfig{DisableHealth: true, RequestID: RequestIDConfig{AcceptIncoming: true}})
+	var got []string
+	app.SetAuditSink(func(_ context.Context, e AuditEvent) error {
+		got = append(got, e.RequestID)
+		return nil
+	})
+	app.Fiber().Post("/orders", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	want := []string{strings.Repeat("a", 12), strings.Repeat("b", 12), strings.Repeat("c", 12)}
+	for _, rid := range want {
+		req := httptest.NewRequest("POST", "/orders", nil)
+		req.Header.Set(fiber.HeaderXRequestID, rid)
+		if _, err := app.Fiber().Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("audited request IDs = %v, want %v", got, want)
+	}
+}
+
+func TestRequestIDInUserContext(t *testing.T) {
+	app := New(KConfig{
+		DisableHealth: true,
+		RequestID:     RequestIDConfig{Generator: func() string { return "rid-1" }},
+	})
+
+	var fromCtx string
+	app.Fiber().Get("/ctx", func(c *fiber.Ctx) error {
+		fromCtx = logger.RequestIDFromContext(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/ctx", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if fromCtx != "rid-1" {
+		t.Errorf("request ID in user context = %q, want rid-1", fromCtx)
+	}
+}
+
+func TestAccessLogRedactsQuery(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{
+		DisableHealth: true,
+		Logging:       LoggingConfig{RedactKeys: []string{"session"}},
+	})
+	app.logger = app.logger.WithWriter(&buf)
+	app.Fiber().Get("/search", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req := httptest.NewRequest("GET", "/search?q=go+lang&Token=abc123&session=s1&page=2", nil)
+	if _, err := app.Fiber().Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "/search?q=go+lang&Token=[REDACTED]&session=[REDACTED]&page=2") {
+		t.Errorf("access log should redact sensitive query values, got:\n%s", out)
+	}
+	for _, secret := range []string{"abc123", "s1&"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("access log leaked %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestAppLoggerRedactsAuthorizationField(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.Logger().With("Authorization", "Bearer abc").WithWriter(&buf).Info("incoming")
+
+	if strings.Contains(buf.String(), "Bearer abc") {
+		t.Errorf("Authorization field should be redacted, got %q", buf.String())
+	}
+}
+
+func TestLogCallSites(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.logger = app.logger.WithWriter(&buf)
+	app.Fiber().Get("/fail", func(c *fiber.Ctx) error { return Internal("boom", nil) })
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/fail", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		switch {
+		case strings.Contains(l, "HTTP Error"):
+			if !strings.Contains(l, "[app_new.go:") {
+				t.Errorf("error log should point at the error handler, got %q", l)
+			}
+		case strings.Contains(l, "HTTP "):
+			if !strings.Contains(l, "[middleware.go:") {
+				t.Errorf("access log should point at the middleware, got %q", l)
+			}
+		}
+	}
+}
+
+func TestAccessLogErrorResponseSize(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.logger = app.logger.WithWriter(&buf)
+	app.Fiber().Get("/fail", func(c *fiber.Ctx) error { return BadRequest("bad input") })
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/fail", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest || len(body) == 0 {
+		t.Fatalf("response = %d %q, want a 400 error body", resp.StatusCode, body)
+	}
+	if want := fmt.Sprintf("ms, %dB)", len(body)); !strings.Contains(buf.String(), want) {
+		t.Errorf("access log should contain %q, got:\n%s", want, buf.String())
+	}
+}
+
+func TestAccessLogStructuredFields(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.logger = logger.NewLoggerWithFormat(false, logger.LogFormatJSON).WithWriter(&buf)
+	app.Fiber().Post("/users/:id", func(c *fiber.Ctx) error { return c.SendString("created") })
+
+	req := httptest.NewRequest("POST", "/users/42?token=abc", strings.NewReader("hello"))
+	req.Header.Set("User-Agent", "keel-test")
+	if _, err := app.Fiber().Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("access log is not a single JSON entry: %v\n%s", err, buf.String())
+	}
+	want := map[string]any{
+		"method":     "POST",
+		"path":       "/users/:id",
+		"status":     float64(200),
+		"bytes_in":   float64(5),
+		"bytes_out":  float64(7),
+		"user_agent": "keel-test",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+	for _, k := range []string{"duration_ms", "ip", "request_id"} {
+		if _, ok := entry[k]; !ok {
+			t.Errorf("missing field %q in %v", k, entry)
+		}
+	}
+	if strings.Contains(buf.String(), "abc") {
+		t.Errorf("structured access log leaked the token:\n%s", buf.String())
+	}
+}
+
+func TestAccessLogUnmatchedRouteUsesRawPath(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.logger = logger.NewLoggerWithFormat(false, logger.LogFormatJSON).WithWriter(&buf)
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/missing", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"path":"/missing"`) {
+		t.Errorf("unmatched request should log its raw path, got:\n%s", buf.String())
+	}
+}
+
+func TestAccessLogFieldsOutliveRequest(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	entries := make(chan logger.LogEntry, 3)
+	app.logger = logger.NewLoggerWithFormat(false, logger.LogFormatJSON).WithWriter(io.Discard)
+	app.logger.AddHook(logger.AsyncHook(func(e logger.LogEntry) {
+		if _, ok := e.Fields["status"]; ok {
+			entries <- e
+		}
+	}, 16))
+
+	requests := []struct{ method, path, agent string }{
+		{"DELETE", "/missing-aaaaaa", "agent-aaaaaa"},
+		{"PATCH", "/missing-bbbbbb", "agent-bbbbbb"},
+		{"GET", "/missing-cccccc", "agent-cccccc"},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.method, r.path, nil)
+		req.Header.Set("User-Agent", r.agent)
+		if _, err := app.Fiber().Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range requests {
+		select {
+		case e := <-entries:
+			if e.Fields["method"] != r.method || e.Fields["path"] != r.path || e.Fields["user_agent"] != r.agent {
+				t.Errorf("fields = %v, want %s %s from %s", e.Fields, r.method, r.path, r.agent)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("access log entry not delivered to the hook")
+		}
+	}
+}
+
+func TestAccessLogSkipping(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AccessLogConfig
+		path    string
+		wantLog bool
+	}{
+		{"logged by default", AccessLogConfig{}, "/health", true},
+		{"skip path", AccessLogConfig{SkipPaths: []string{"/health"}}, "/health", false},
+		{"other path still logged", AccessLogConfig{SkipPaths: []string{"/health"}}, "/ping", true},
+		{"disabled", AccessLogConfig{Disabled: true}, "/ping", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			app := New(KConfig{DisableHealth: true, AccessLog: tt.cfg})
+			app.logger = app.logger.WithWriter(&buf)
+			ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+			app.Fiber().Get("/health", ok)
+			app.Fiber().Get("/ping", ok)
+
+			if _, err := app.Fiber().Test(httptest.NewRequest("GET", tt.path, nil)); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(buf.String(), "HTTP "); got != tt.wantLog {
+				t.Errorf("logged = %v, want %v; output:\n%s", got, tt.wantLog, buf.String())
+			}
+		})
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{
+		DisableHealth: true,
+		AccessLog:     AccessLogConfig{Sampling: &logger.SamplingConfig{Initial: 2, Window: time.Hour}},
+	})
+	app.logger = app.logger.WithWriter(&buf)
+	app.Fiber().Get("/ok", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	for i := 0; i < 5; i++ {
+		if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/missing", nil)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/ok", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := buf.String()
+	if n := strings.Count(out, "GET [404] /missing"); n != 2 {
+		t.Errorf("expected 2 sampled 404 lines, got %d:\n%s", n, out)
+	}
+	if n := strings.Count(out, "GET [200] /ok"); n != 5 {
+		t.Errorf("2xx lines must not be sampled, got %d:\n%s", n, out)
+	}
+}
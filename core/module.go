@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Initializer is implemented by modules that need to set up resources, such
+// as opening a connection pool, before the server accepts traffic.
+// Init runs during Listen, after the OnStart hooks, in registration order.
+type Initializer interface {
+	Init(ctx context.Context, app *App) error
+}
+
+// Shutdowner is implemented by modules that own resources to release on
+// graceful shutdown. Modules are shut down in reverse registration order.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// trackModule records the lifecycle interfaces implemented by m.
+func (a *App) trackModule(m any) {
+	if i, ok := m.(Initializer); ok {
+		a.initializers = append(a.initializers, i)
+	}
+	if s, ok := m.(Shutdowner); ok {
+		// A single hook closes every module so they unwind in reverse order
+		// relative to each other, while staying in sequence with OnShutdown.
+		if len(a.shutdowners) == 0 {
+			a.OnShutdown(a.shutdownModules)
+		}
+		a.shutdowners = append(a.shutdowners, s)
+	}
+}
+
+// initModules runs module Init methods in registration order and stops at
+// the first failure.
+func (a *App) initModules(ctx context.Context) error {
+	for _, m := range a.initializers {
+		start := time.Now()
+		if err := m.Init(ctx, a); err != nil {
+			return fmt.Errorf("module %T init failed: %w", m, err)
+		}
+		a.logger.Info("Module %T initialized in %dms", m, time.Since(start).Milliseconds())
+	}
+	return nil
+}
+
+// shutdownModules runs module Shutdown methods in reverse registration order.
+// Every module is shut down even if an earlier one fails.
+func (a *App) shutdownModules(ctx context.Context) error {
+	var errs []error
+	for i := len(a.shutdowners) - 1; i >= 0; i-- {
+		m := a.shutdowners[i]
+		if err := m.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("module %T: %w", m, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type lifecycleModule struct {
+	name    string
+	initErr error
+	events  *[]string
+}
+
+func (m *lifecycleModule) Register(_ *App) {
+	*m.events = append(*m.events, "register:"+m.name)
+}
+
+func (m *lifecycleModule) Init(_ context.Context, _ *App) error {
+	*m.events = append(*m.events, "init:"+m.name)
+	return m.initErr
+}
+
+func (m *lifecycleModule) Shutdown(_ context.Context) error {
+	*m.events = append(*m.events, "shutdown:"+m.name)
+	return nil
+}
+
+func TestModuleLifecycleOrder(t *testing.T) {
+	var events []string
+	app := New(KConfig{DisableHealth: true})
+
+	app.OnStart(func(context.Context) error {
+		events = append(events, "start-hook")
+		return nil
+	})
+	app.Use(&lifecycleModule{name: "db", events: &events})
+	app.Group("/api").Use(&lifecycleModule{name: "cache", events: &events})
+
+	if err := app.run(context.Background(), func() error { return nil }); err != nil {
+		t.Fatalf("run() returned %v", err)
+	}
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() returned %v", err)
+	}
+
+	want := []string{
+		"register:db", "register:cache",
+		"start-hook",
+		"init:db", "init:cache",
+		"shutdown:cache", "shutdown:db",
+	}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestModuleInitFailureAbortsStartup(t *testing.T) {
+	var events []string
+	app := New(KConfig{DisableHealth: true})
+	app.Use(&lifecycleModule{name: "db", initErr: errors.New("connection refused"), events: &events})
+	app.Use(&lifecycleModule{name: "cache", events: &events})
+
+	served := false
+	err := app.run(context.Background(), func() error {
+		served = true
+		return nil
+	})
+	if err == nil {
+		t.Fatal("run() should return an error when a module Init fails")
+	}
+	if !strings.Contains(err.Error(), "*core.lifecycleModule") || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("error should identify the module and cause, got %q", err)
+	}
+	if served {
+		t.Error("server should not start when a module Init fails")
+	}
+	for _, e := range events {
+		if e == "init:cache" {
+			t.Error("modules after the failing one should not be initialized")
+		}
+	}
+}
+
+func TestUseWithoutLifecycleInterfaces(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	app.Use(&moduleSpy{})
+
+	if len(app.initializers) != 0 || len(app.shutdowners) != 0 || len(app.shutdownHooks) != 0 {
+		t.Error("plain modules should not be hooked into the lifecycle")
+	}
+}
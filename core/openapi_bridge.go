@@ -7,6 +7,12 @@ import (
 	"github.com/slice-soft/ss-keel-core/openapi"
 )
 
+// OpenAPISpec builds the OpenAPI spec of the routes registered so far, as
+// served on the docs endpoint.
+func (a *App) OpenAPISpec() openapi.Spec {
+	return openapi.Build(toBuildInput(a.config, a.routes))
+}
+
 // toBuildInput maps App configuration and routes to the OpenAPI BuildInput structure.
 func toBuildInput(cfg KConfig, routes []httpx.Route) openapi.BuildInput {
 	bi := openapi.BuildInput{
@@ -14,6 +20,8 @@ func toBuildInput(cfg KConfig, routes []httpx.Route) openapi.BuildInput {
 		Version:     cfg.Docs.Version,
 		Description: cfg.Docs.Description,
 		Routes:      toOpenAPIRoutes(routes),
+
+		SecuritySchemes: routeSecuritySchemes(routes),
 	}
 	if cfg.Docs.Contact != nil {
 		bi.Contact = &openapi.Contact{
@@ -50,7 +58,7 @@ func toOpenAPIRoutes(routes []httpx.Route) []openapi.RouteInput {
 			Method:      r.Method(),
 			Path:        r.Path(),
 			Summary:     r.Summary(),
-			Description: r.Description(),
+			Description: describeRoles(r.Description(), r.Roles()),
 			Tags:        r.Tags(),
 			Secured:     r.Secured(),
 			Deprecated:  r.Deprecated(),
@@ -62,6 +70,13 @@ func toOpenAPIRoutes(routes []httpx.Route) []openapi.RouteInput {
 			ri.Response = r.Response().Type
 			ri.StatusCode = r.Response().StatusCode
 		}
+		for _, res := range r.ExtraResponses() {
+			ri.ExtraResponses = append(ri.ExtraResponses, openapi.ResponseInput{
+				StatusCode:  res.StatusCode,
+				Description: res.Description,
+				Type:        res.Type,
+			})
+		}
 		for _, qp := range r.QueryParams() {
 			ri.QueryParams = append(ri.QueryParams, openapi.QueryParamInput{
 				Name:        qp.Name,
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/openapi"
+)
+
+type snapshotUserDTO struct {
+	ID    string `json:"id"`
+	Email string `json:"email" validate:"required,email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// TestOpenAPISnapshot pins the generated API contract. After an intended
+// change, regenerate the golden file with:
+//
+//	KEEL_UPDATE_SNAPSHOTS=1 go test ./core -run TestOpenAPISnapshot
+func TestOpenAPISnapshot(t *testing.T) {
+	noop := func(c *httpx.Ctx) error { return nil }
+	app := NewTestAppWith(KConfig{Docs: DocsConfig{Title: "Users API", Version: "1.0.0"}},
+		httpx.GET("/users", noop).
+			Tag("users").
+			Describe("List users").
+			WithQueryParam("page", "integer", false).
+			WithResponse(httpx.WithResponse[[]snapshotUserDTO](200)),
+		httpx.POST("/users", noop).
+			Tag("users").
+			Describe("Create a user").
+			WithBody(httpx.WithBody[snapshotUserDTO]()).
+			WithResponse(httpx.WithResponse[snapshotUserDTO](201)).
+			WithSecured("bearerAuth").
+			WithRoles("admin"),
+		httpx.DELETE("/users/:id", noop).
+			Tag("users").
+			WithSecured("bearerAuth"),
+	)
+
+	openapi.SnapshotTest(t, app.OpenAPISpec(), "testdata/openapi.golden.json")
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+// Option configures an App built with NewWithOptions.
+type Option func(*options)
+
+type options struct {
+	config      KConfig
+	logger      *logger.Logger
+	fiberConfig []func(*fiber.Config)
+}
+
+// NewWithOptions creates a new App from functional options. Unset values get
+// the same defaults as New, and it panics on an invalid configuration.
+func NewWithOptions(opts ...Option) *App {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	app, err := newApp(o)
+	if err != nil {
+		panic(err)
+	}
+	return app
+}
+
+// WithConfig uses cfg as the base configuration. Options applied after it
+// override the corresponding fields.
+func WithConfig(cfg KConfig) Option {
+	return func(o *options) { o.config = cfg }
+}
+
+// WithPort sets the listen port.
+func WithPort(port int) Option {
+	return func(o *options) { o.config.Port = port }
+}
+
+// WithServiceName sets the service name shown in the banner and docs.
+func WithServiceName(name string) Option {
+	return func(o *options) { o.config.ServiceName = name }
+}
+
+// WithEnv sets the environment, e.g. "production".
+func WithEnv(env string) Option {
+	return func(o *options) { o.config.Env = env }
+}
+
+// WithDocs sets the OpenAPI documentation config.
+func WithDocs(docs DocsConfig) Option {
+	return func(o *options) { o.config.Docs = docs }
+}
+
+// WithLogger replaces the logger the app would otherwise create.
+func WithLogger(l *logger.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// WithFiberConfig adjusts the Fiber config after Keel has filled it in and
+// before the Fiber app is created. Overriding ErrorHandler bypasses Keel's
+// error responses.
+func WithFiberConfig(fn func(*fiber.Config)) Option {
+	return func(o *options) { o.fiberConfig = append(o.fiberConfig, fn) }
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	l := logger.NewLogger(false)
+	app := NewWithOptions(
+		WithPort(8080),
+		WithServiceName("orders"),
+		WithEnv("staging"),
+		WithDocs(DocsConfig{Path: "/reference", Title: "Orders API"}),
+		WithLogger(l),
+	)
+
+	if app.config.Port != 8080 {
+		t.Errorf("Port = %d, want 8080", app.config.Port)
+	}
+	if app.config.ServiceName != "orders" {
+		t.Errorf("ServiceName = %q, want orders", app.config.ServiceName)
+	}
+	if app.config.Env != "staging" {
+		t.Errorf("Env = %q, want staging", app.config.Env)
+	}
+	if app.config.Docs.Path != "/reference" || app.config.Docs.Title != "Orders API" {
+		t.Errorf("Docs = %+v, want custom path and title", app.config.Docs)
+	}
+	if app.Logger() != l {
+		t.Error("WithLogger should replace the default logger")
+	}
+}
+
+func TestNewWithOptionsAppliesDefaults(t *testing.T) {
+	app := NewWithOptions(WithConfig(KConfig{ServiceName: "base"}), WithPort(9000))
+
+	if app.config.Port != 9000 || app.config.ServiceName != "base" {
+		t.Errorf("config = %+v, want port 9000 and name base", app.config)
+	}
+	if app.config.Env != "development" || app.config.Docs.Version != "1.0.0" {
+		t.Errorf("defaults not applied: Env=%q Docs.Version=%q", app.config.Env, app.config.Docs.Version)
+	}
+}
+
+func TestNewWithOptionsPanicsOnInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewWithOptions() should panic for an invalid config")
+		}
+	}()
+	NewWithOptions(WithPort(70000))
+}
+
+func TestWithFiberConfig(t *testing.T) {
+	app := NewWithOptions(
+		WithFiberConfig(func(c *fiber.Config) {
+			c.JSONEncoder = func(v interface{}) ([]byte, error) {
+				return json.Marshal(map[string]interface{}{"wrapped": v})
+			}
+		}),
+		WithFiberConfig(func(c *fiber.Config) { c.AppName = "custom" }),
+	)
+	app.Fiber().Get("/data", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"ok": true})
+	})
+
+	if app.Fiber().Config().AppName != "custom" {
+		t.Errorf("AppName = %q, want custom", app.Fiber().Config().AppName)
+	}
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/data", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"wrapped":{"ok":true}}` {
+		t.Errorf("body = %s, want custom JSON encoder output", body)
+	}
+
+	if app.Fiber().Config().BodyLimit != 4*1024*1024 {
+		t.Error("Keel defaults should still be applied to the Fiber config")
+	}
+}
@@ -0,0 +1,248 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+const (
+	// DefaultOutboxBatchSize is how many pending messages a relay poll
+	// reads at most.
+	DefaultOutboxBatchSize = 100
+	// DefaultOutboxPollInterval is the relay poll interval used when none
+	// is given.
+	DefaultOutboxPollInterval = time.Second
+)
+
+// Outbox implements the transactional outbox pattern: Store writes a
+// message in the same database transaction as the business change, and
+// Relay publishes stored messages once committed. A rolled back transaction
+// publishes nothing, and a committed one is never lost.
+//
+// Delivery is at least once: a message published but not marked sent, e.g.
+// because the process stopped in between, is published again. Consumers
+// deduplicate on the message-id header, which Store sets to the record ID.
+type Outbox struct {
+	store     contracts.OutboxStore
+	batchSize int
+	maxLag    time.Duration
+	clock     Clock
+	logger    *logger.Logger
+	metrics   contracts.MetricsCollector
+}
+
+// OutboxOption configures NewOutbox.
+type OutboxOption func(*Outbox)
+
+// WithOutboxBatchSize sets how many pending messages a relay poll reads at
+// most, DefaultOutboxBatchSize by default.
+func WithOutboxBatchSize(n int) OutboxOption {
+	return func(o *Outbox) { o.batchSize = n }
+}
+
+// WithOutboxMaxLag makes the outbox health check fail when the oldest
+// pending message is older than d.
+func WithOutboxMaxLag(d time.Duration) OutboxOption {
+	return func(o *Outbox) { o.maxLag = d }
+}
+
+// WithOutboxLogger sets the logger for relay failures. RegisterOutbox uses
+// the App logger unless one is set.
+func WithOutboxLogger(l *logger.Logger) OutboxOption {
+	return func(o *Outbox) { o.logger = l }
+}
+
+// WithOutboxMetrics sets the collector receiving relay progress when it
+// implements contracts.OutboxCollector. RegisterOutbox uses the App metrics
+// collector unless one is set.
+func WithOutboxMetrics(mc contracts.MetricsCollector) OutboxOption {
+	return func(o *Outbox) { o.metrics = mc }
+}
+
+// NewOutbox returns an Outbox reading and settling records in store.
+//
+//	outbox := core.NewOutbox(store, core.WithOutboxMaxLag(time.Minute))
+//	app.RegisterOutbox(outbox, publisher, time.Second)
+//
+//	// in a transaction
+//	err := outbox.Store(ctx, tx, contracts.Message{Topic: "orders.placed", Payload: body})
+func NewOutbox(store contracts.OutboxStore, opts ...OutboxOption) *Outbox {
+	o := &Outbox{store: store, batchSize: DefaultOutboxBatchSize}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.batchSize <= 0 {
+		o.batchSize = DefaultOutboxBatchSize
+	}
+	return o
+}
+
+// Store writes msg to the outbox through tx. The message-id header is set
+// to the generated record ID unless msg already has one.
+func (o *Outbox) Store(ctx context.Context, tx contracts.OutboxTx, msg contracts.Message) error {
+	rec := contracts.OutboxRecord{ID: utils.UUIDv4(), Message: msg, CreatedAt: o.now()}
+	rec.Message.Headers = maps.Clone(msg.Headers)
+	if rec.Message.Headers == nil {
+		rec.Message.Headers = make(map[string]string)
+	}
+	if rec.Message.Headers[MessageHeaderID] == "" {
+		rec.Message.Headers[MessageHeaderID] = rec.ID
+	}
+	if err := tx.InsertOutbox(ctx, rec); err != nil {
+		return fmt.Errorf("outbox: store message for %s: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Relay publishes pending messages to p, then again every poll interval,
+// blocking until ctx ends. Failures are logged and retried on the next poll.
+func (o *Outbox) Relay(ctx context.Context, p contracts.Publisher, poll time.Duration) {
+	if poll <= 0 {
+		poll = DefaultOutboxPollInterval
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		if _, err := o.RelayOnce(ctx, p); err != nil && ctx.Err() == nil {
+			o.log().ErrorCtx(ctx, "Outbox relay failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending messages to p, oldest first, and
+// returns how many were published. A message failing to publish is marked
+// failed and stays pending; the rest of the batch is still published. The
+// returned error joins the failures.
+func (o *Outbox) RelayOnce(ctx context.Context, p contracts.Publisher) (int, error) {
+	recs, err := o.store.Pending(ctx, o.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("outbox: read pending messages: %w", err)
+	}
+
+	var errs []error
+	published, failed := 0, 0
+	for _, rec := range recs {
+		if err := p.Publish(ctx, rec.Message); err != nil {
+			failed++
+			errs = append(errs, fmt.Errorf("outbox: publish %s to %s: %w", rec.ID, rec.Message.Topic, err))
+			if err := o.store.MarkFailed(ctx, rec.ID, err); err != nil {
+				errs = append(errs, fmt.Errorf("outbox: mark %s failed: %w", rec.ID, err))
+			}
+			continue
+		}
+		published++
+		// Left pending, the message is published again on the next poll.
+		if err := o.store.MarkSent(ctx, rec.ID); err != nil {
+			errs = append(errs, fmt.Errorf("outbox: mark %s sent: %w", rec.ID, err))
+		}
+	}
+
+	if oc, ok := o.metrics.(contracts.OutboxCollector); ok {
+		lag, err := o.Lag(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		oc.RecordOutboxRelay(published, failed, lag)
+	}
+	return published, errors.Join(errs...)
+}
+
+// Lag returns the age of the oldest pending message, or zero when none is
+// pending.
+func (o *Outbox) Lag(ctx context.Context) (time.Duration, error) {
+	recs, err := o.store.Pending(ctx, 1)
+	if err != nil {
+		return 0, fmt.Errorf("outbox: read pending messages: %w", err)
+	}
+	if len(recs) == 0 {
+		return 0, nil
+	}
+	return max(o.now().Sub(recs[0].CreatedAt), 0), nil
+}
+
+// HealthChecker returns a checker named "outbox" that fails when the store
+// cannot be read or, with WithOutboxMaxLag, when Lag exceeds the maximum.
+func (o *Outbox) HealthChecker() contracts.HealthChecker {
+	return HealthCheckerFunc("outbox", func(ctx context.Context) error {
+		lag, err := o.Lag(ctx)
+		if err != nil {
+			return err
+		}
+		if o.maxLag > 0 && lag > o.maxLag {
+			return fmt.Errorf("outbox lag %s exceeds %s", lag, o.maxLag)
+		}
+		return nil
+	})
+}
+
+func (o *Outbox) now() time.Time {
+	if o.clock == nil {
+		return time.Now()
+	}
+	return o.clock.Now()
+}
+
+func (o *Outbox) log() *logger.Logger {
+	if o.logger == nil {
+		return logger.NewLogger(false)
+	}
+	return o.logger
+}
+
+// RegisterOutbox relays o to p every poll interval while the App runs:
+// the relay starts with the OnStart hooks, in the main process only under
+// prefork, and stops on shutdown or when starting the App fails. The outbox
+// health checker is registered, and o uses the App clock, logger and
+// metrics collector unless configured with its own.
+func (a *App) RegisterOutbox(o *Outbox, p contracts.Publisher, poll time.Duration) {
+	if o.clock == nil {
+		o.clock = a.clock
+	}
+	if o.logger == nil {
+		o.logger = a.logger
+	}
+	a.RegisterHealthChecker(o.HealthChecker())
+
+	var stop context.CancelFunc
+	done := make(chan struct{})
+	stopRelay := func(ctx context.Context) error {
+		if stop == nil {
+			return nil
+		}
+		stop()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	a.OnStart(func(ctx context.Context) error {
+		if a.isPreforkChild() {
+			return nil
+		}
+		if o.metrics == nil {
+			o.metrics = a.metricsCollector
+		}
+		ctx, stop = context.WithCancel(context.WithoutCancel(ctx))
+		go func() {
+			defer close(done)
+			o.Relay(ctx, p, poll)
+		}()
+		return nil
+	})
+	a.OnShutdown(stopRelay)
+	a.onAbort(func() { _ = stopRelay(context.Background()) })
+}
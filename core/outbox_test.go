@@ -0,0 +1,179 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+func TestOutboxStoreThenRelay(t *testing.T) {
+	ctx := context.Background()
+	store := keeltest.NewMemoryOutboxStore()
+	outbox := NewOutbox(store)
+	bus := keeltest.NewMemoryBus()
+
+	committed := store.Begin()
+	if err := outbox.Store(ctx, committed, contracts.Message{Topic: "orders.placed", Payload: []byte("o-1")}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := outbox.RelayOnce(ctx, bus); n != 0 || err != nil {
+		t.Fatalf("RelayOnce before commit = %d, %v; want nothing published", n, err)
+	}
+	committed.Commit() //nolint
+
+	rolledBack := store.Begin()
+	outbox.Store(ctx, rolledBack, contracts.Message{Topic: "orders.placed", Payload: []byte("ghost")}) //nolint
+	rolledBack.Rollback()                                                                              //nolint
+
+	if n, err := outbox.RelayOnce(ctx, bus); n != 1 || err != nil {
+		t.Fatalf("RelayOnce = %d, %v; want 1 published", n, err)
+	}
+	got := bus.Published("orders.placed")
+	if len(got) != 1 || string(got[0].Payload) != "o-1" {
+		t.Fatalf("published %v, want only the committed message", got)
+	}
+	sent := store.Sent()
+	if len(sent) != 1 || got[0].Headers[MessageHeaderID] != sent[0].ID {
+		t.Errorf("message-id %q, want the record ID of %v", got[0].Headers[MessageHeaderID], sent)
+	}
+
+	// Re-relaying publishes nothing again.
+	if n, err := outbox.RelayOnce(ctx, bus); n != 0 || err != nil {
+		t.Errorf("second RelayOnce = %d, %v; want nothing published", n, err)
+	}
+	if got := bus.Published("orders.placed"); len(got) != 1 {
+		t.Errorf("published %d message(s) after re-relay, want 1", len(got))
+	}
+}
+
+func TestOutboxPublishFailure(t *testing.T) {
+	ctx := context.Background()
+	clock := keeltest.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	app := New(KConfig{DisableHealth: true})
+	app.SetClock(clock)
+	store := keeltest.NewMemoryOutboxStore()
+	outbox := NewOutbox(store, WithOutboxMaxLag(time.Minute))
+	app.RegisterOutbox(outbox, keeltest.NewMemoryBus(), time.Hour)
+
+	bus := keeltest.NewMemoryBus()
+	down := errors.New("broker down")
+	failing := true
+	bus.Subscribe(ctx, "orders.placed", func(context.Context, contracts.Message) error { //nolint
+		if failing {
+			return down
+		}
+		return nil
+	})
+
+	outbox.Store(ctx, store, contracts.Message{Topic: "orders.placed", Payload: []byte("o-1")}) //nolint
+	outbox.Store(ctx, store, contracts.Message{Topic: "orders.shipped"})                        //nolint
+	n, err := outbox.RelayOnce(ctx, bus)
+	if n != 1 || !errors.Is(err, down) {
+		t.Fatalf("RelayOnce = %d, %v; want 1 published and the broker error", n, err)
+	}
+	pending, _ := store.Pending(ctx, 10)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "broker down" {
+		t.Fatalf("pending %+v, want the failed message with one attempt recorded", pending)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if lag, _ := outbox.Lag(ctx); lag != 2*time.Minute {
+		t.Errorf("Lag = %s, want 2m0s", lag)
+	}
+	if err := outbox.HealthChecker().Check(ctx); err == nil || !strings.Contains(err.Error(), "exceeds 1m0s") {
+		t.Errorf("health check = %v, want lag error", err)
+	}
+	if report := app.healthReport(ctx); report.status != "DOWN" {
+		t.Errorf("App health %s, want DOWN from the registered outbox checker", report.status)
+	}
+
+	failing = false
+	if n, err := outbox.RelayOnce(ctx, bus); n != 1 || err != nil {
+		t.Fatalf("retry RelayOnce = %d, %v; want 1 published", n, err)
+	}
+	if err := outbox.HealthChecker().Check(ctx); err != nil {
+		t.Errorf("health check after catching up = %v", err)
+	}
+}
+
+func TestAppRegisterOutbox(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true})
+	app.logger = app.logger.WithWriter(&buf)
+	collector := NewPrometheusCollector()
+	app.SetMetricsCollector(collector)
+	store := keeltest.NewMemoryOutboxStore()
+	outbox := NewOutbox(store)
+	bus := keeltest.NewMemoryBus()
+	delivered := make(chan struct{}, 1)
+	bus.Subscribe(context.Background(), "orders.placed", func(context.Context, contracts.Message) error { //nolint
+		delivered <- struct{}{}
+		return nil
+	})
+	app.RegisterOutbox(outbox, bus, 5*time.Millisecond)
+
+	if err := app.runStartHooks(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	outbox.Store(context.Background(), store, contracts.Message{Topic: "orders.placed"}) //nolint
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("relay did not publish the stored message")
+	}
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	outbox.Store(context.Background(), store, contracts.Message{Topic: "orders.placed"}) //nolint
+	time.Sleep(20 * time.Millisecond)
+	if got := len(bus.Published("orders.placed")); got != 1 {
+		t.Errorf("published %d message(s), want the relay stopped after shutdown", got)
+	}
+
+	var out strings.Builder
+	collector.WriteTo(&out) //nolint
+	for _, want := range []string{"outbox_messages_published_total 1", "outbox_publish_failures_total 0"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestAppRegisterOutboxStopsWhenStartFails(t *testing.T) {
+	app := New(KConfig{DisableHealth: true, Port: freePort(t), Env: "production"})
+	store := keeltest.NewMemoryOutboxStore()
+	outbox := NewOutbox(store)
+	bus := keeltest.NewMemoryBus()
+	delivered := make(chan struct{}, 1)
+	bus.Subscribe(context.Background(), "orders.placed", func(context.Context, contracts.Message) error { //nolint
+		delivered <- struct{}{}
+		return nil
+	})
+	app.RegisterOutbox(outbox, bus, 5*time.Millisecond)
+
+	errStart := errors.New("migrations failed")
+	app.OnStart(func(ctx context.Context) error {
+		outbox.Store(ctx, store, contracts.Message{Topic: "orders.placed"}) //nolint
+		select {
+		case <-delivered:
+		case <-time.After(time.Second):
+			t.Error("relay did not start")
+		}
+		return errStart
+	})
+
+	if err := app.ListenWithContext(context.Background()); !errors.Is(err, errStart) {
+		t.Fatalf("ListenWithContext() = %v, want the start hook error", err)
+	}
+	outbox.Store(context.Background(), store, contracts.Message{Topic: "orders.placed"}) //nolint
+	time.Sleep(20 * time.Millisecond)
+	if got := len(bus.Published("orders.placed")); got != 1 {
+		t.Errorf("published %d message(s), want the relay stopped after the failed start", got)
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Provide registers value as the service of type T on app, replacing any
+// previous registration. Register interface-typed services by naming the
+// interface explicitly, e.g. Provide[UserRepository](app, repo).
+func Provide[T any](app *App, value T) {
+	app.servicesMu.Lock()
+	defer app.servicesMu.Unlock()
+
+	if app.services == nil {
+		app.services = make(map[reflect.Type]any)
+	}
+	app.services[reflect.TypeFor[T]()] = value
+}
+
+// Resolve returns the service of type T registered with Provide. The lookup
+// is exact: a concrete type registered with Provide does not satisfy an
+// interface-typed Resolve.
+func Resolve[T any](app *App) (T, bool) {
+	app.servicesMu.RLock()
+	defer app.servicesMu.RUnlock()
+
+	value, ok := app.services[reflect.TypeFor[T]()]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return value.(T), true
+}
+
+// MustResolve is like Resolve but panics when no service of type T has been
+// provided.
+func MustResolve[T any](app *App) T {
+	value, ok := Resolve[T](app)
+	if !ok {
+		panic(fmt.Sprintf("keel: no service of type %s has been provided; call core.Provide[%s] before resolving it", reflect.TypeFor[T](), reflect.TypeFor[T]()))
+	}
+	return value
+}
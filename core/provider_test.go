@@ -0,0 +1,87 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet() string { return "hello" }
+
+type userService struct {
+	name string
+}
+
+func TestProvideResolve(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	Provide(app, &userService{name: "users"})
+
+	got, ok := Resolve[*userService](app)
+	if !ok {
+		t.Fatal("Resolve() should find a provided service")
+	}
+	if got.name != "users" {
+		t.Errorf("name = %q, want users", got.name)
+	}
+
+	if _, ok := Resolve[userService](app); ok {
+		t.Error("Resolve() should not match a different type")
+	}
+}
+
+func TestProvideInterfaceType(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	Provide[greeter](app, englishGreeter{})
+
+	g, ok := Resolve[greeter](app)
+	if !ok {
+		t.Fatal("Resolve() should find a service registered under its interface")
+	}
+	if g.Greet() != "hello" {
+		t.Errorf("Greet() = %q, want hello", g.Greet())
+	}
+
+	if _, ok := Resolve[englishGreeter](app); ok {
+		t.Error("an interface-typed registration should not resolve by concrete type")
+	}
+}
+
+func TestMustResolvePanicsOnMiss(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("MustResolve() should panic for a missing service")
+		}
+		if msg, _ := r.(string); !strings.Contains(msg, "*core.userService") {
+			t.Errorf("panic message should name the missing type, got %v", r)
+		}
+	}()
+	MustResolve[*userService](app)
+}
+
+type providerModule struct{}
+
+func (providerModule) Register(app *App) { Provide(app, &userService{name: "from module"}) }
+
+type consumerModule struct{ resolved *userService }
+
+func (m *consumerModule) Register(app *App) { m.resolved = MustResolve[*userService](app) }
+
+func TestModulesResolveEarlierProviders(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	consumer := &consumerModule{}
+
+	app.Use(providerModule{})
+	app.Group("/api").Use(consumer)
+
+	if consumer.resolved == nil || consumer.resolved.name != "from module" {
+		t.Errorf("consumer resolved %+v, want service from provider module", consumer.resolved)
+	}
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// maxRateLimitKeys bounds how many keys the in-memory limiter store tracks
+// before it forgets those idle for two windows.
+const maxRateLimitKeys = 10000
+
+// RateLimitConfig configures RateLimit.
+type RateLimitConfig struct {
+	Max     int           // requests allowed per Window and key
+	Window  time.Duration // defaults to one minute
+	KeyFunc func(c *fiber.Ctx) string
+	// Store shares counters between instances. Defaults to process memory.
+	// Counts kept in a Store are read and written separately, so concurrent
+	// instances may let a few extra requests through; store errors let the
+	// request through rather than failing it.
+	Store   contracts.Cache
+	OnLimit func(c *fiber.Ctx) // called before a request is rejected
+	// Clock defaults to the clock of the App serving the request; see
+	// App.SetClock.
+	Clock Clock
+}
+
+// RateLimit returns a middleware allowing cfg.Max requests per cfg.Window
+// for each key, by default the client IP. Requests are counted in a sliding
+// window: the previous window's count, weighted by how much of it still
+// overlaps, is added to the current one, so bursts at a window boundary are
+// not allowed twice. Every response carries X-RateLimit-Limit,
+// X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the window
+// ends); rejected requests get a 429 TOO_MANY_REQUESTS KError and
+// Retry-After.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	return newRateLimiter(cfg).handler
+}
+
+// RateLimitByUser returns a RateLimitConfig.KeyFunc keying requests by the
+// authenticated user stored with Ctx.SetUser, falling back to the client IP
+// for anonymous requests.
+func RateLimitByUser[T any](id func(T) string) func(*fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		if user, ok := httpx.UserAs[T](&httpx.Ctx{Ctx: c}); ok {
+			return "user:" + id(user)
+		}
+		return "ip:" + c.IP()
+	}
+}
+
+type rateLimiter struct {
+	cfg   RateLimitConfig
+	store rateStore
+}
+
+// rateStore counts hits per key and window.
+type rateStore interface {
+	// take reads the counts of the previous and current windows and, when
+	// allow accepts them, counts one more hit in the current window.
+	take(ctx context.Context, key string, start time.Time, window time.Duration, allow func(prev, curr int) bool) (prev, curr int, ok bool, err error)
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = func(c *fiber.Ctx) string { return c.IP() }
+	}
+	var store rateStore = &memoryRateStore{counters: make(map[string]*rateCounter)}
+	if cfg.Store != nil {
+		store = cacheRateStore{cache: cfg.Store}
+	}
+	return &rateLimiter{cfg: cfg, store: store}
+}
+
+func (l *rateLimiter) handler(c *fiber.Ctx) error {
+	clock := l.cfg.Clock
+	if clock == nil {
+		clock = ClockFrom(c)
+	}
+	now := clock.Now()
+	window := l.cfg.Window
+	start := now.Truncate(window)
+	// Weight of the previous window still inside the sliding window.
+	weight := 1 - float64(now.Sub(start))/float64(window)
+	estimate := func(prev, curr int) float64 { return float64(prev)*weight + float64(curr) }
+
+	prev, curr, ok, err := l.store.take(c.UserContext(), l.cfg.KeyFunc(c), start, window, func(prev, curr int) bool {
+		return estimate(prev, curr)+1 <= float64(l.cfg.Max)
+	})
+	if err != nil {
+		return c.Next()
+	}
+
+	reset := int(math.Ceil(start.Add(window).Sub(now).Seconds()))
+	remaining := l.cfg.Max - int(math.Ceil(estimate(prev, curr)))
+	c.Set("X-RateLimit-Limit", strconv.Itoa(l.cfg.Max))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(max(remaining, 0)))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(reset))
+
+	if !ok {
+		if l.cfg.OnLimit != nil {
+			l.cfg.OnLimit(c)
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(reset))
+		return TooManyRequests("rate limit exceeded")
+	}
+	return c.Next()
+}
+
+// memoryRateStore keeps exact counts in process memory.
+type memoryRateStore struct {
+	mu       sync.Mutex
+	counters map[string]*rateCounter
+}
+
+type rateCounter struct {
+	start      time.Time // start of the current window
+	prev, curr int
+}
+
+func (m *memoryRateStore) take(_ context.Context, key string, start time.Time, window time.Duration, allow func(prev, curr int) bool) (int, int, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.counters[key]
+	if c == nil {
+		if len(m.counters) >= maxRateLimitKeys {
+			m.prune(start, window)
+		}
+		c = &rateCounter{start: start}
+		// Keys often come from Fiber's reused request buffers.
+		m.counters[strings.Clone(key)] = c
+	}
+	switch {
+	case c.start.Equal(start):
+	case c.start.Add(window).Equal(start):
+		c.start, c.prev, c.curr = start, c.curr, 0
+	default:
+		c.start, c.prev, c.curr = start, 0, 0
+	}
+
+	if !allow(c.prev, c.curr) {
+		return c.prev, c.curr, false, nil
+	}
+	c.curr++
+	return c.prev, c.curr, true, nil
+}
+
+// prune forgets keys with no hits in the current or previous window.
+func (m *memoryRateStore) prune(start time.Time, window time.Duration) {
+	for k, c := range m.counters {
+		if c.start.Add(window).Before(start) {
+			delete(m.counters, k)
+		}
+	}
+}
+
+// cacheRateStore keeps one counter per key and window in a Cache.
+type cacheRateStore struct {
+	cache contracts.Cache
+}
+
+func (s cacheRateStore) take(ctx context.Context, key string, start time.Time, window time.Duration, allow func(prev, curr int) bool) (int, int, bool, error) {
+	prevKey := rateLimitKey(key, start.Add(-window))
+	currKey := rateLimitKey(key, start)
+	prev, err := s.count(ctx, prevKey)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	curr, err := s.count(ctx, currKey)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if !allow(prev, curr) {
+		return prev, curr, false, nil
+	}
+	curr++
+	// Keep the counter while it can still be the previous window.
+	if err := s.cache.Set(ctx, currKey, []byte(strconv.Itoa(curr)), 2*window); err != nil {
+		return 0, 0, false, err
+	}
+	return prev, curr, true, nil
+}
+
+func (s cacheRateStore) count(ctx context.Context, key string) (int, error) {
+	b, err := s.cache.Get(ctx, key)
+	if errors.Is(err, contracts.ErrCacheMiss) || (err == nil && len(b) == 0) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(b))
+}
+
+func rateLimitKey(key string, start time.Time) string {
+	return "ratelimit:" + key + ":" + strconv.FormatInt(start.UnixNano(), 10)
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+// mapCache is a minimal contracts.Cache for tests; it ignores TTLs.
+type mapCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (m *mapCache) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok {
+		return nil, contracts.ErrCacheMiss
+	}
+	return v, nil
+}
+
+func (m *mapCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		m.data = make(map[string][]byte)
+	}
+	m.data[key] = value
+	return nil
+}
+
+func (m *mapCache) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mapCache) Exists(_ context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.data[key]
+	return ok, nil
+}
+
+// newRateLimitApp serves / behind RateLimit(cfg), reading time from clock
+// through App.SetClock.
+func newRateLimitApp(cfg RateLimitConfig, clock Clock) *App {
+	app := New(KConfig{DisableHealth: true})
+	app.SetClock(clock)
+	app.Fiber().Use(RateLimit(cfg))
+	app.Fiber().Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+	return app
+}
+
+func hit(t *testing.T, app *App, key string) (status int, remaining, retryAfter string) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Client", key)
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header.Get("X-RateLimit-Remaining"), resp.Header.Get("Retry-After")
+}
+
+func TestRateLimit(t *testing.T) {
+	stores := map[string]func() contracts.Cache{
+		"memory": func() contracts.Cache { return nil },
+		"cache":  func() contracts.Cache { return &mapCache{} },
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			newApp := func(onLimit func(*fiber.Ctx)) (*App, *keeltest.FakeClock) {
+				clock := keeltest.NewFakeClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+				return newRateLimitApp(RateLimitConfig{
+					Max:     2,
+					Window:  time.Minute,
+					KeyFunc: func(c *fiber.Ctx) string { return c.Get("X-Client") },
+					Store:   store(),
+					OnLimit: onLimit,
+				}, clock), clock
+			}
+
+			t.Run("limit", func(t *testing.T) {
+				limited := 0
+				app, _ := newApp(func(*fiber.Ctx) { limited++ })
+				for i, want := range []string{"1", "0"} {
+					status, remaining, _ := hit(t, app, "a")
+					if status != 200 || remaining != want {
+						t.Fatalf("request %d: status %d remaining %q, want 200 %q", i+1, status, remaining, want)
+					}
+				}
+				status, remaining, retryAfter := hit(t, app, "a")
+				if status != fiber.StatusTooManyRequests || remaining != "0" || retryAfter != "60" {
+					t.Errorf("third request: status %d remaining %q Retry-After %q", status, remaining, retryAfter)
+				}
+				if limited != 1 {
+					t.Errorf("OnLimit calls = %d, want 1", limited)
+				}
+			})
+
+			t.Run("reset after window", func(t *testing.T) {
+				app, clock := newApp(nil)
+				hit(t, app, "a")
+				hit(t, app, "a")
+
+				// Halfway into the next window half of the previous count
+				// still applies, leaving room for one request.
+				clock.Advance(90 * time.Second)
+				if status, _, _ := hit(t, app, "a"); status != 200 {
+					t.Fatalf("status = %d after 1.5 windows, want 200", status)
+				}
+				if status, _, _ := hit(t, app, "a"); status != fiber.StatusTooManyRequests {
+					t.Fatalf("status = %d, want 429 while the sliding window is full", status)
+				}
+
+				clock.Advance(2 * time.Minute)
+				if status, remaining, _ := hit(t, app, "a"); status != 200 || remaining != "1" {
+					t.Errorf("after two idle windows: status %d remaining %q, want 200 \"1\"", status, remaining)
+				}
+			})
+
+			t.Run("keys are isolated", func(t *testing.T) {
+				app, _ := newApp(nil)
+				hit(t, app, "a")
+				hit(t, app, "a")
+				if status, _, _ := hit(t, app, "b"); status != 200 {
+					t.Errorf("key b status = %d, want 200", status)
+				}
+				if status, _, _ := hit(t, app, "a"); status != fiber.StatusTooManyRequests {
+					t.Errorf("key a status = %d, want 429", status)
+				}
+			})
+		})
+	}
+}
+
+func TestRateLimitByUser(t *testing.T) {
+	type user struct{ ID string }
+	keyFunc := RateLimitByUser(func(u user) string { return u.ID })
+
+	app := fiber.New()
+	var keys []string
+	app.Get("/", func(c *fiber.Ctx) error {
+		if c.Get("X-User") != "" {
+			c.Locals("_keel_user", user{ID: c.Get("X-User")})
+		}
+		keys = append(keys, keyFunc(c))
+		return nil
+	})
+	for _, u := range []string{"42", ""} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if u != "" {
+			req.Header.Set("X-User", u)
+		}
+		if _, err := app.Test(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(keys) != 2 || keys[0] != "user:42" || keys[1] != "ip:0.0.0.0" {
+		t.Errorf("keys = %v", keys)
+	}
+}
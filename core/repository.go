@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// PageQuery and Page are the pagination types of the Repository contract
+// as implemented by Keel database modules.
+type (
+	PageQuery   = httpx.PageQuery
+	Page[T any] = httpx.Page[T]
+)
+
+var (
+	// ErrRecordNotFound is returned by repositories for IDs or queries
+	// matching no entity.
+	ErrRecordNotFound = errors.New("repository: record not found")
+	// ErrRecordExists is returned when creating an entity whose ID is
+	// taken.
+	ErrRecordExists = errors.New("repository: record already exists")
+	// ErrInvalidQuery is returned for filters and sorts a repository cannot
+	// apply, such as unknown fields or operators.
+	ErrInvalidQuery = errors.New("repository: invalid query")
+)
+
+// FilterOp is the comparison of a Filter.
+type FilterOp string
+
+// Filter operators. OpIn takes a slice of values; OpLike takes a pattern in
+// which % matches any run of characters and _ a single one, as in SQL.
+const (
+	OpEq   FilterOp = "eq"
+	OpNeq  FilterOp = "neq"
+	OpGt   FilterOp = "gt"
+	OpGte  FilterOp = "gte"
+	OpLt   FilterOp = "lt"
+	OpLte  FilterOp = "lte"
+	OpIn   FilterOp = "in"
+	OpLike FilterOp = "like"
+)
+
+var filterOps = []FilterOp{OpEq, OpNeq, OpGt, OpGte, OpLt, OpLte, OpIn, OpLike}
+
+// Filter restricts a Query to entities whose Field compares to Value with
+// Op. Field is the name the adapter maps to a column or document key,
+// usually the JSON name of the struct field.
+type Filter struct {
+	Field string
+	Op    FilterOp
+	Value any
+}
+
+// SortField orders a Query by Field, ascending unless Desc.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Query selects entities matching every filter, ordered by Sort, one page
+// at a time.
+type Query struct {
+	Filters []Filter
+	Sort    []SortField
+	Page    PageQuery
+}
+
+// Where returns a copy of q with an additional filter.
+//
+//	q := core.Query{}.Where("status", core.OpEq, "active").Where("created_at", core.OpGt, since)
+func (q Query) Where(field string, op FilterOp, value any) Query {
+	q.Filters = append(slices.Clip(q.Filters), Filter{Field: field, Op: op, Value: value})
+	return q
+}
+
+// QueryableRepository is implemented by repositories answering Query
+// values, such as MemoryRepository and the Keel database modules. Find
+// returns the page of matching entities selected by q.Page, with the total
+// number of matches; a zero q.Page returns every match. FindOne returns the
+// first match in q.Sort order, or an error wrapping ErrRecordNotFound.
+type QueryableRepository[T any, ID any] interface {
+	Find(ctx context.Context, q Query) (Page[T], error)
+	FindOne(ctx context.Context, q Query) (*T, error)
+}
+
+// QueryFromRequest builds a Query from the query string of c, accepting
+// only the given fields:
+//
+//   - field=value filters on equality and field[op]=value with any other
+//     operator, e.g. created_at[gte]=2024-01-01T00:00:00Z. Values are
+//     strings; the values of in are comma-separated.
+//   - sort=-created_at,name sorts by the listed fields, descending when
+//     prefixed with -.
+//   - page and limit are read with ParsePagination.
+//
+// Other parameters are ignored. An unknown operator, or a bracketed filter
+// or sort on a field not listed, yields a 400 *KError.
+//
+//	q, err := core.QueryFromRequest(c, "status", "created_at")
+//	page, err := repo.Find(c.Context(), q)
+func QueryFromRequest(c *httpx.Ctx, fields ...string) (Query, error) {
+	q := Query{Page: c.ParsePagination()}
+	var err error
+	c.Context().QueryArgs().VisitAll(func(k, v []byte) {
+		if err != nil {
+			return
+		}
+		key, value := string(k), string(v)
+		if key == "sort" {
+			err = q.parseSort(value, fields)
+			return
+		}
+		field, op := key, OpEq
+		if name, rest, ok := strings.Cut(key, "["); ok && strings.HasSuffix(rest, "]") {
+			field, op = name, FilterOp(strings.TrimSuffix(rest, "]"))
+			if !slices.Contains(fields, field) {
+				err = BadRequest(fmt.Sprintf("cannot filter on %q", field))
+				return
+			}
+			if !slices.Contains(filterOps, op) {
+				err = BadRequest(fmt.Sprintf("unknown filter operator %q", op))
+				return
+			}
+		}
+		if !slices.Contains(fields, field) {
+			return
+		}
+		if op == OpIn {
+			q.Filters = append(q.Filters, Filter{Field: field, Op: op, Value: strings.Split(value, ",")})
+		} else {
+			q.Filters = append(q.Filters, Filter{Field: field, Op: op, Value: value})
+		}
+	})
+	return q, err
+}
+
+func (q *Query) parseSort(value string, fields []string) error {
+	for _, s := range strings.Split(value, ",") {
+		sf := SortField{Field: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+		if sf.Field == "" {
+			continue
+		}
+		if !slices.Contains(fields, sf.Field) {
+			return BadRequest(fmt.Sprintf("cannot sort on %q", sf.Field))
+		}
+		q.Sort = append(q.Sort, sf)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// BatchRepository is implemented by repositories writing many entities in
+// one round trip, such as MemoryRepository and the Keel database modules.
+// Native implementations apply a batch atomically. An error names the
+// index of the first failing entity or ID in the batch.
+type BatchRepository[T any, ID any] interface {
+	CreateAll(ctx context.Context, entities []*T) error
+	UpdateAll(ctx context.Context, entities []*T) error
+	DeleteAll(ctx context.Context, ids []ID) error
+}
+
+// CRUDRepository is the part of contracts.Repository the BatchRepository
+// fallback of AsBatchRepository relies on.
+type CRUDRepository[T any, ID any] interface {
+	Create(ctx context.Context, entity *T) error
+	Update(ctx context.Context, id ID, entity *T) error
+	Delete(ctx context.Context, id ID) error
+}
+
+// AsBatchRepository returns repo itself when it implements BatchRepository,
+// and otherwise an adapter calling Create, Update and Delete once per
+// entity, with the IDs given by idOf. The adapter stops at the first error,
+// leaving the earlier entities written; run it within UnitOfWork.WithinTx
+// to make a batch atomic.
+//
+//	batch := core.AsBatchRepository[User, string](repo, func(u *User) string { return u.ID })
+//	err := core.InBatches(users, 500, func(chunk []*User) error { return batch.CreateAll(ctx, chunk) })
+func AsBatchRepository[T any, ID any](repo CRUDRepository[T, ID], idOf func(*T) ID) BatchRepository[T, ID] {
+	if br, ok := repo.(BatchRepository[T, ID]); ok {
+		return br
+	}
+	return batchFallback[T, ID]{repo: repo, idOf: idOf}
+}
+
+type batchFallback[T any, ID any] struct {
+	repo CRUDRepository[T, ID]
+	idOf func(*T) ID
+}
+
+func (b batchFallback[T, ID]) CreateAll(ctx context.Context, entities []*T) error {
+	for i, e := range entities {
+		if err := b.repo.Create(ctx, e); err != nil {
+			return batchErr("create", i, err)
+		}
+	}
+	return nil
+}
+
+func (b batchFallback[T, ID]) UpdateAll(ctx context.Context, entities []*T) error {
+	for i, e := range entities {
+		if err := b.repo.Update(ctx, b.idOf(e), e); err != nil {
+			return batchErr("update", i, err)
+		}
+	}
+	return nil
+}
+
+func (b batchFallback[T, ID]) DeleteAll(ctx context.Context, ids []ID) error {
+	for i, id := range ids {
+		if err := b.repo.Delete(ctx, id); err != nil {
+			return batchErr("delete", i, err)
+		}
+	}
+	return nil
+}
+
+func batchErr(op string, i int, err error) error {
+	return fmt.Errorf("repository: %s item %d: %w", op, i, err)
+}
+
+// InBatches calls fn with consecutive chunks of items of at most size
+// elements, stopping at the first error. A size of 0 or less passes all
+// items in one chunk; empty items never call fn. Chunks share the backing
+// array of items but cannot append into each other.
+func InBatches[T any](items []T, size int, fn func(chunk []T) error) error {
+	if size <= 0 {
+		size = len(items)
+	}
+	for start := 0; start < len(items); start += size {
+		end := min(start+size, len(items))
+		if err := fn(items[start:end:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ BatchRepository[struct{}, int] = (*MemoryRepository[struct{}, int])(nil)
+
+// CreateAll implements BatchRepository. Nothing is created when an ID is
+// taken or repeated in entities.
+func (r *MemoryRepository[T, ID]) CreateAll(_ context.Context, entities []*T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]ID, len(entities))
+	for i, e := range entities {
+		ids[i] = r.idOf(e)
+		if _, ok := r.items[ids[i]]; ok || slices.Contains(ids[:i], ids[i]) {
+			return batchErr("create", i, fmt.Errorf("%w: %v", ErrRecordExists, ids[i]))
+		}
+	}
+	for i, e := range entities {
+		r.items[ids[i]] = *e
+	}
+	r.order = append(r.order, ids...)
+	return nil
+}
+
+// UpdateAll implements BatchRepository, replacing each entity stored under
+// its ID. Nothing is updated when an ID is missing.
+func (r *MemoryRepository[T, ID]) UpdateAll(ctx context.Context, entities []*T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range entities {
+		if id := r.idOf(e); !r.isVisible(ctx, id) {
+			return batchErr("update", i, fmt.Errorf("%w: %v", ErrRecordNotFound, id))
+		}
+	}
+	for _, e := range entities {
+		r.items[r.idOf(e)] = *e
+	}
+	return nil
+}
+
+// DeleteAll implements BatchRepository, deleting like Delete. Nothing is
+// deleted when an ID is missing.
+func (r *MemoryRepository[T, ID]) DeleteAll(ctx context.Context, ids []ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, id := range ids {
+		if !r.isVisible(ctx, id) {
+			return batchErr("delete", i, fmt.Errorf("%w: %v", ErrRecordNotFound, id))
+		}
+	}
+	r.delete(ids)
+	return nil
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// crudOnly hides the BatchRepository methods of a repository.
+type crudOnly struct {
+	CRUDRepository[testAccount, int]
+}
+
+func TestInBatches(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		name  string
+		items []int
+		size  int
+		want  [][]int
+	}{
+		{"uneven", items, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"exact", items, 5, [][]int{{1, 2, 3, 4, 5}}},
+		{"larger than items", items, 7, [][]int{{1, 2, 3, 4, 5}}},
+		{"one per chunk", items[:2], 1, [][]int{{1}, {2}}},
+		{"zero size", items, 0, [][]int{{1, 2, 3, 4, 5}}},
+		{"empty", nil, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]int
+			err := InBatches(tt.items, tt.size, func(chunk []int) error {
+				got = append(got, slices.Clone(chunk))
+				return nil
+			})
+			if err != nil || !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("chunks = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("stops at the first error and keeps chunks apart", func(t *testing.T) {
+		items := []int{1, 2, 3, 4, 5}
+		errStop := errors.New("stop")
+		calls := 0
+		err := InBatches(items, 2, func(chunk []int) error {
+			calls++
+			_ = append(chunk, 99)
+			if calls == 2 {
+				return errStop
+			}
+			return nil
+		})
+		if !errors.Is(err, errStop) || calls != 2 || !slices.Equal(items, []int{1, 2, 3, 4, 5}) {
+			t.Errorf("err %v calls %d items %v", err, calls, items)
+		}
+	})
+}
+
+func TestBatchRepository(t *testing.T) {
+	ctx := context.Background()
+	variants := []struct {
+		name    string
+		wrap    func(*MemoryRepository[testAccount, int]) CRUDRepository[testAccount, int]
+		native  bool
+		partial bool // a failing batch keeps the entities before the failure
+	}{
+		{"native", func(r *MemoryRepository[testAccount, int]) CRUDRepository[testAccount, int] { return r }, true, false},
+		{"fallback", func(r *MemoryRepository[testAccount, int]) CRUDRepository[testAccount, int] { return crudOnly{r} }, false, true},
+	}
+	idOf := func(a *testAccount) int { return a.ID }
+
+	for _, v := range variants {
+		t.Run(v.name, func(t *testing.T) {
+			repo := NewMemoryRepository(idOf)
+			batch := AsBatchRepository(v.wrap(repo), idOf)
+			if _, isNative := batch.(*MemoryRepository[testAccount, int]); isNative != v.native {
+				t.Fatalf("AsBatchRepository returned %T", batch)
+			}
+			ids := func() []int {
+				page, _ := repo.FindAll(ctx, PageQuery{})
+				return accountIDs(page.Data)
+			}
+
+			if err := batch.CreateAll(ctx, []*testAccount{{ID: 1}, {ID: 2}, {ID: 3}}); err != nil {
+				t.Fatalf("CreateAll: %v", err)
+			}
+			if err := batch.UpdateAll(ctx, []*testAccount{{ID: 1, Status: "active"}, {ID: 3, Status: "active"}}); err != nil {
+				t.Fatalf("UpdateAll: %v", err)
+			}
+			if page, _ := repo.Find(ctx, Query{}.Where("status", OpEq, "active")); !slices.Equal(accountIDs(page.Data), []int{1, 3}) {
+				t.Errorf("updated ids = %v, want [1 3]", accountIDs(page.Data))
+			}
+			if err := batch.DeleteAll(ctx, []int{2}); err != nil {
+				t.Fatalf("DeleteAll: %v", err)
+			}
+			if got := ids(); !slices.Equal(got, []int{1, 3}) {
+				t.Fatalf("ids = %v, want [1 3]", got)
+			}
+
+			err := batch.CreateAll(ctx, []*testAccount{{ID: 4}, {ID: 1}, {ID: 5}})
+			if !errors.Is(err, ErrRecordExists) || !strings.Contains(err.Error(), "item 1") {
+				t.Errorf("CreateAll err = %v, want ErrRecordExists on item 1", err)
+			}
+			want := []int{1, 3}
+			if v.partial {
+				want = []int{1, 3, 4}
+			}
+			if got := ids(); !slices.Equal(got, want) {
+				t.Errorf("ids after failed CreateAll = %v, want %v", got, want)
+			}
+
+			err = batch.UpdateAll(ctx, []*testAccount{{ID: 1, Status: "x"}, {ID: 9}})
+			if !errors.Is(err, ErrRecordNotFound) || !strings.Contains(err.Error(), "item 1") {
+				t.Errorf("UpdateAll err = %v, want ErrRecordNotFound on item 1", err)
+			}
+			if got, _ := repo.FindByID(ctx, 1); (got.Status == "x") != v.partial {
+				t.Errorf("status after failed UpdateAll = %q", got.Status)
+			}
+
+			err = batch.DeleteAll(ctx, []int{3, 9})
+			if !errors.Is(err, ErrRecordNotFound) || !strings.Contains(err.Error(), "item 1") {
+				t.Errorf("DeleteAll err = %v, want ErrRecordNotFound on item 1", err)
+			}
+			if _, err := repo.FindByID(ctx, 3); (err != nil) != v.partial {
+				t.Errorf("FindByID(3) after failed DeleteAll err = %v", err)
+			}
+		})
+	}
+
+	t.Run("native rejects repeated IDs", func(t *testing.T) {
+		repo := NewMemoryRepository(idOf)
+		if err := repo.CreateAll(ctx, []*testAccount{{ID: 1}, {ID: 1}}); !errors.Is(err, ErrRecordExists) {
+			t.Errorf("err = %v, want ErrRecordExists", err)
+		}
+	})
+}
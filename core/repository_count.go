@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// CountableRepository is implemented by repositories counting entities
+// without loading them, such as MemoryRepository. Count ignores q.Page and
+// q.Sort. Both skip soft-deleted entities unless the context is from
+// IncludeDeleted.
+type CountableRepository[ID any] interface {
+	Count(ctx context.Context, q Query) (int64, error)
+	Exists(ctx context.Context, id ID) (bool, error)
+}
+
+// counter is the part of CountableRepository NewQueryPage uses, which does
+// not depend on the ID type.
+type counter interface {
+	Count(ctx context.Context, q Query) (int64, error)
+}
+
+// NewQueryPage returns the Page of data, the entities of q.Page loaded by
+// the caller. When repo implements CountableRepository the total is its
+// Count of q; otherwise it is estimated from the entities before and on
+// this page, which undercounts when the page is full.
+//
+//	users, err := loadUsers(ctx, q)
+//	page, err := core.NewQueryPage(ctx, repo, q, users)
+func NewQueryPage[T any](ctx context.Context, repo any, q Query, data []T) (Page[T], error) {
+	if data == nil {
+		data = []T{}
+	}
+	page, limit := max(q.Page.Page, 1), q.Page.Limit
+	total := len(data)
+	if limit > 0 {
+		total += (page - 1) * limit
+	}
+	if c, ok := repo.(counter); ok {
+		n, err := c.Count(ctx, Query{Filters: q.Filters})
+		if err != nil {
+			return Page[T]{}, err
+		}
+		total = int(n)
+	}
+	return httpx.NewPage(data, total, page, limit), nil
+}
+
+var _ CountableRepository[int] = (*MemoryRepository[struct{}, int])(nil)
+
+// Count implements CountableRepository.
+func (r *MemoryRepository[T, ID]) Count(ctx context.Context, q Query) (int64, error) {
+	matches, err := r.find(ctx, Query{Filters: q.Filters})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(matches)), nil
+}
+
+// Exists implements CountableRepository.
+func (r *MemoryRepository[T, ID]) Exists(ctx context.Context, id ID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.isVisible(ctx, id), nil
+}
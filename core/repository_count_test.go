@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryRepositoryCount(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestAccountRepo(t)
+	tests := []struct {
+		name string
+		q    Query
+		want int64
+	}{
+		{"all", Query{}, 4},
+		{"filtered", Query{}.Where("status", OpEq, "active"), 2},
+		{"several filters", Query{}.Where("score", OpGte, "7").Where("verified", OpEq, "false"), 2},
+		{"no match", Query{}.Where("status", OpEq, "closed"), 0},
+		{"ignores paging", Query{Page: PageQuery{Page: 2, Limit: 3}}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := repo.Count(ctx, tt.q); err != nil || got != tt.want {
+				t.Errorf("Count = %d, %v; want %d", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := repo.Count(ctx, Query{}.Where("nope", OpEq, 1)); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Count on unknown field err = %v, want ErrInvalidQuery", err)
+	}
+
+	t.Run("soft-deleted", func(t *testing.T) {
+		notes := newTestNoteRepo(t)
+		if err := notes.Delete(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := notes.Count(ctx, Query{}); n != 2 {
+			t.Errorf("Count = %d, want 2", n)
+		}
+		if n, _ := notes.Count(IncludeDeleted(ctx), Query{}); n != 3 {
+			t.Errorf("Count with deleted = %d, want 3", n)
+		}
+	})
+}
+
+func TestMemoryRepositoryExists(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestNoteRepo(t)
+	if err := repo.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		id   int
+		want bool
+	}{
+		{"stored", ctx, 1, true},
+		{"missing", ctx, 9, false},
+		{"soft-deleted", ctx, 2, false},
+		{"soft-deleted with IncludeDeleted", IncludeDeleted(ctx), 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := repo.Exists(tt.ctx, tt.id); err != nil || got != tt.want {
+				t.Errorf("Exists(%d) = %v, %v; want %v", tt.id, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewQueryPage(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestAccountRepo(t)
+	q := Query{Page: PageQuery{Page: 2, Limit: 1}}.Where("status", OpEq, "active")
+	data := []testAccount{{ID: 2}}
+
+	page, err := NewQueryPage(ctx, repo, q, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.TotalPages != 2 || page.Page != 2 || len(page.Data) != 1 {
+		t.Errorf("countable page = %+v, want total 2 of 2 pages", page)
+	}
+
+	page, err = NewQueryPage(ctx, crudOnly{repo}, q, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 || page.Page != 2 {
+		t.Errorf("estimated page = %+v, want total 2", page)
+	}
+
+	page, _ = NewQueryPage[testAccount](ctx, nil, Query{}, nil)
+	if page.Data == nil || page.Total != 0 {
+		t.Errorf("empty page = %+v", page)
+	}
+
+	bad := Query{}.Where("nope", OpEq, 1)
+	if _, err := NewQueryPage(ctx, repo, bad, data); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("err = %v, want ErrInvalidQuery", err)
+	}
+}
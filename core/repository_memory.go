@@ -0,0 +1,502 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// MemoryRepository is an in-memory reference implementation of the
+// repository contracts for tests and prototypes. T must be a struct; filter
+// and sort fields are matched against its exported fields by JSON name or
+// Go name, case-insensitively. Entities are stored and returned as copies,
+// in insertion order unless sorted. Soft-deletable entities, see
+// IsSoftDeletable, are soft-deleted. It is safe for concurrent use.
+type MemoryRepository[T any, ID comparable] struct {
+	mu        sync.RWMutex
+	idOf      func(*T) ID
+	items     map[ID]T
+	order     []ID
+	fields    map[string][]int // lower-cased JSON and Go names to field index
+	deletedAt []int            // index of the DeletedAt field, nil unless soft-deletable
+}
+
+var (
+	_ contracts.Repository[struct{}, int, PageQuery, Page[struct{}]] = (*MemoryRepository[struct{}, int])(nil)
+	_ QueryableRepository[struct{}, int]                             = (*MemoryRepository[struct{}, int])(nil)
+)
+
+// NewMemoryRepository returns an empty MemoryRepository. idOf returns the
+// ID of an entity, which Create expects to be set.
+//
+//	repo := core.NewMemoryRepository(func(u *User) string { return u.ID })
+func NewMemoryRepository[T any, ID comparable](idOf func(*T) ID) *MemoryRepository[T, ID] {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("core: MemoryRepository entity %s is not a struct", t))
+	}
+	fields := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		names := []string{f.Name}
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			names = append(names, tag)
+		}
+		for _, name := range names {
+			if _, taken := fields[strings.ToLower(name)]; !taken {
+				fields[strings.ToLower(name)] = f.Index
+			}
+		}
+	}
+	r := &MemoryRepository[T, ID]{idOf: idOf, items: make(map[ID]T), fields: fields}
+	if f, ok := softDeleteField(t); ok {
+		r.deletedAt = f.Index
+	}
+	return r
+}
+
+// FindByID implements contracts.Repository.
+func (r *MemoryRepository[T, ID]) FindByID(ctx context.Context, id ID) (*T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.visible(ctx, id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrRecordNotFound, id)
+	}
+	return &e, nil
+}
+
+// FindAll implements contracts.Repository, returning one page of every
+// entity.
+func (r *MemoryRepository[T, ID]) FindAll(ctx context.Context, q PageQuery) (Page[T], error) {
+	return r.Find(ctx, Query{Page: q})
+}
+
+// Create implements contracts.Repository. An entity whose ID is taken,
+// even by a soft-deleted entity, yields an error wrapping ErrRecordExists.
+func (r *MemoryRepository[T, ID]) Create(_ context.Context, entity *T) error {
+	id := r.idOf(entity)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[id]; ok {
+		return fmt.Errorf("%w: %v", ErrRecordExists, id)
+	}
+	r.items[id] = *entity
+	r.order = append(r.order, id)
+	return nil
+}
+
+// Update implements contracts.Repository, replacing the entity stored under
+// id.
+func (r *MemoryRepository[T, ID]) Update(ctx context.Context, id ID, entity *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.visible(ctx, id); !ok {
+		return fmt.Errorf("%w: %v", ErrRecordNotFound, id)
+	}
+	r.items[id] = *entity
+	return nil
+}
+
+// Patch implements contracts.Repository, copying the fields of patch that
+// are not zero onto the entity stored under id.
+func (r *MemoryRepository[T, ID]) Patch(ctx context.Context, id ID, patch *T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.visible(ctx, id)
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrRecordNotFound, id)
+	}
+	dst, src := reflect.ValueOf(&e).Elem(), reflect.ValueOf(patch).Elem()
+	for i := range src.NumField() {
+		if f := src.Field(i); dst.Type().Field(i).IsExported() && !f.IsZero() {
+			dst.Field(i).Set(f)
+		}
+	}
+	r.items[id] = e
+	return nil
+}
+
+// Delete implements contracts.Repository. Soft-deletable entities are kept
+// with DeletedAt set to the current time.
+func (r *MemoryRepository[T, ID]) Delete(ctx context.Context, id ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.visible(ctx, id); !ok {
+		return fmt.Errorf("%w: %v", ErrRecordNotFound, id)
+	}
+	r.delete([]ID{id})
+	return nil
+}
+
+// delete removes ids, or marks them deleted when soft-deletable.
+func (r *MemoryRepository[T, ID]) delete(ids []ID) {
+	if r.deletedAt == nil {
+		for _, id := range ids {
+			delete(r.items, id)
+		}
+		r.order = slices.DeleteFunc(r.order, func(id ID) bool { return !r.exists(id) })
+		return
+	}
+	now := time.Now()
+	for _, id := range ids {
+		r.setDeletedAt(id, &now)
+	}
+}
+
+func (r *MemoryRepository[T, ID]) setDeletedAt(id ID, at *time.Time) {
+	e := r.items[id]
+	reflect.ValueOf(&e).Elem().FieldByIndex(r.deletedAt).Set(reflect.ValueOf(at))
+	r.items[id] = e
+}
+
+func (r *MemoryRepository[T, ID]) exists(id ID) bool {
+	_, ok := r.items[id]
+	return ok
+}
+
+// visible returns the entity stored under id unless it is soft-deleted and
+// ctx does not include deleted entities.
+func (r *MemoryRepository[T, ID]) visible(ctx context.Context, id ID) (T, bool) {
+	e, ok := r.items[id]
+	return e, ok && (IncludesDeleted(ctx) || !r.isDeleted(&e))
+}
+
+func (r *MemoryRepository[T, ID]) isVisible(ctx context.Context, id ID) bool {
+	_, ok := r.visible(ctx, id)
+	return ok
+}
+
+func (r *MemoryRepository[T, ID]) isDeleted(e *T) bool {
+	return r.deletedAt != nil && !reflect.ValueOf(e).Elem().FieldByIndex(r.deletedAt).IsNil()
+}
+
+// Find implements QueryableRepository. Filters and sorts on unknown fields,
+// and values that do not convert to the field type, yield an error wrapping
+// ErrInvalidQuery. String values are parsed for numeric, boolean and
+// time.Time fields, the latter as RFC 3339 or a date. Soft-deleted
+// entities are left out unless ctx is from IncludeDeleted.
+func (r *MemoryRepository[T, ID]) Find(ctx context.Context, q Query) (Page[T], error) {
+	matches, err := r.find(ctx, q)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	total := len(matches)
+	page, limit := max(q.Page.Page, 1), q.Page.Limit
+	if limit <= 0 {
+		return httpx.NewPage(matches, total, 1, total), nil
+	}
+	start := min((page-1)*limit, total)
+	return httpx.NewPage(matches[start:min(start+limit, total)], total, page, limit), nil
+}
+
+// FindOne implements QueryableRepository.
+func (r *MemoryRepository[T, ID]) FindOne(ctx context.Context, q Query) (*T, error) {
+	matches, err := r.find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return &matches[0], nil
+}
+
+// find returns copies of the entities matching q, sorted.
+func (r *MemoryRepository[T, ID]) find(ctx context.Context, q Query) ([]T, error) {
+	filters := make([]memoryFilter, len(q.Filters))
+	for i, f := range q.Filters {
+		var err error
+		if filters[i], err = r.compileFilter(f); err != nil {
+			return nil, err
+		}
+	}
+	sorts := make([][]int, len(q.Sort))
+	for i, s := range q.Sort {
+		index, ok := r.fields[strings.ToLower(s.Field)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, s.Field)
+		}
+		if !orderable(baseType(reflect.TypeFor[T]().FieldByIndex(index).Type)) {
+			return nil, fmt.Errorf("%w: cannot sort on %q", ErrInvalidQuery, s.Field)
+		}
+		sorts[i] = index
+	}
+
+	r.mu.RLock()
+	matches := []T{}
+	for _, id := range r.order {
+		e, ok := r.visible(ctx, id)
+		if ok && matchesAll(reflect.ValueOf(&e).Elem(), filters) {
+			matches = append(matches, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortStableFunc(matches, func(a, b T) int {
+		av, bv := reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem()
+		for i, index := range sorts {
+			c := compareNullable(av.FieldByIndex(index), bv.FieldByIndex(index))
+			if q.Sort[i].Desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return matches, nil
+}
+
+// memoryFilter is a Filter resolved against the entity type.
+type memoryFilter struct {
+	index  []int
+	op     FilterOp
+	isNil  bool            // compares to nil
+	values []reflect.Value // of the field base type; several for OpIn
+	like   *regexp.Regexp
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (r *MemoryRepository[T, ID]) compileFilter(f Filter) (memoryFilter, error) {
+	index, ok := r.fields[strings.ToLower(f.Field)]
+	if !ok {
+		return memoryFilter{}, fmt.Errorf("%w: unknown filter field %q", ErrInvalidQuery, f.Field)
+	}
+	base := baseType(reflect.TypeFor[T]().FieldByIndex(index).Type)
+	mf := memoryFilter{index: index, op: f.Op}
+	invalid := func(format string, args ...any) (memoryFilter, error) {
+		return memoryFilter{}, fmt.Errorf("%w: %s %s: %s", ErrInvalidQuery, f.Field, f.Op, fmt.Sprintf(format, args...))
+	}
+
+	if f.Value == nil {
+		if f.Op != OpEq && f.Op != OpNeq {
+			return invalid("nil value")
+		}
+		mf.isNil = true
+		return mf, nil
+	}
+	switch f.Op {
+	case OpEq, OpNeq, OpGt, OpGte, OpLt, OpLte:
+		if f.Op != OpEq && f.Op != OpNeq && !orderable(base) {
+			return invalid("%s is not ordered", base)
+		}
+		v, err := coerceValue(f.Value, base)
+		if err != nil {
+			return invalid("%v", err)
+		}
+		mf.values = []reflect.Value{v}
+	case OpIn:
+		list := reflect.ValueOf(f.Value)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return invalid("want a slice, got %T", f.Value)
+		}
+		for i := range list.Len() {
+			v, err := coerceValue(list.Index(i).Interface(), base)
+			if err != nil {
+				return invalid("%v", err)
+			}
+			mf.values = append(mf.values, v)
+		}
+	case OpLike:
+		pattern, ok := f.Value.(string)
+		if !ok || base.Kind() != reflect.String {
+			return invalid("want a string field and pattern")
+		}
+		mf.like = likePattern(pattern)
+	default:
+		return memoryFilter{}, fmt.Errorf("%w: unknown operator %q", ErrInvalidQuery, f.Op)
+	}
+	return mf, nil
+}
+
+func matchesAll(entity reflect.Value, filters []memoryFilter) bool {
+	for _, f := range filters {
+		if !f.matches(entity.FieldByIndex(f.index)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f memoryFilter) matches(field reflect.Value) bool {
+	null := field.Kind() == reflect.Pointer && field.IsNil()
+	if f.isNil {
+		return null == (f.op == OpEq)
+	}
+	if null {
+		return f.op == OpNeq
+	}
+	field = reflect.Indirect(field)
+	switch f.op {
+	case OpEq:
+		return equalValues(field, f.values[0])
+	case OpNeq:
+		return !equalValues(field, f.values[0])
+	case OpGt:
+		return compareValues(field, f.values[0]) > 0
+	case OpGte:
+		return compareValues(field, f.values[0]) >= 0
+	case OpLt:
+		return compareValues(field, f.values[0]) < 0
+	case OpLte:
+		return compareValues(field, f.values[0]) <= 0
+	case OpIn:
+		return slices.ContainsFunc(f.values, func(v reflect.Value) bool { return equalValues(field, v) })
+	case OpLike:
+		return f.like.MatchString(field.String())
+	}
+	return false
+}
+
+// likePattern translates an SQL LIKE pattern to an anchored regexp.
+func likePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(`.*`)
+		case '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+func baseType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+func orderable(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+// coerceValue converts v to t, parsing strings for non-string fields and
+// refusing lossy numeric conversions.
+func coerceValue(v any, t reflect.Type) (reflect.Value, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return rv, fmt.Errorf("nil value")
+	}
+	if rv.Type() == t {
+		return rv, nil
+	}
+	if s, ok := v.(string); ok && t.Kind() != reflect.String {
+		return parseValue(s, t)
+	}
+	if (isNumber(rv.Kind()) && isNumber(t.Kind())) || (rv.Kind() == reflect.String && t.Kind() == reflect.String) {
+		converted := rv.Convert(t)
+		if converted.Convert(rv.Type()).Interface() == rv.Interface() {
+			return converted, nil
+		}
+		return rv, fmt.Errorf("%v does not fit %s", v, t)
+	}
+	return rv, fmt.Errorf("cannot compare %s with %T", t, v)
+}
+
+func parseValue(s string, t reflect.Type) (reflect.Value, error) {
+	if t == timeType {
+		for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+			if ts, err := time.Parse(layout, s); err == nil {
+				return reflect.ValueOf(ts), nil
+			}
+		}
+		return reflect.Value{}, fmt.Errorf("%q is not an RFC 3339 time or a date", s)
+	}
+	var v any
+	var err error
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(s, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(s, 10, t.Bits())
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(s, t.Bits())
+	case reflect.Bool:
+		v, err = strconv.ParseBool(s)
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot compare %s with a string", t)
+	}
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("%q is not a valid %s", s, t)
+	}
+	return reflect.ValueOf(v).Convert(t), nil
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+func equalValues(a, b reflect.Value) bool {
+	if orderable(a.Type()) {
+		return compareValues(a, b) == 0
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// compareValues orders two values of the same orderable type.
+func compareValues(a, b reflect.Value) int {
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time))
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return cmp.Compare(boolInt(a.Bool()), boolInt(b.Bool()))
+	}
+	return 0
+}
+
+// compareNullable orders field values, nil pointers first.
+func compareNullable(a, b reflect.Value) int {
+	aNull := a.Kind() == reflect.Pointer && a.IsNil()
+	bNull := b.Kind() == reflect.Pointer && b.IsNil()
+	if aNull || bNull {
+		return cmp.Compare(boolInt(!aNull), boolInt(!bNull))
+	}
+	return compareValues(reflect.Indirect(a), reflect.Indirect(b))
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
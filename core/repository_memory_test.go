@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testAccount struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Status    string    `json:"status"`
+	Score     float64   `json:"score"`
+	Verified  bool      `json:"verified"`
+	CreatedAt time.Time `json:"created_at"`
+	ManagerID *int      `json:"manager_id,omitempty"`
+}
+
+var testAccountEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestAccountRepo(t *testing.T) *MemoryRepository[testAccount, int] {
+	t.Helper()
+	repo := NewMemoryRepository(func(a *testAccount) int { return a.ID })
+	manager := 1
+	for _, a := range []testAccount{
+		{ID: 1, Email: "ana@example.com", Status: "active", Score: 9.5, Verified: true, CreatedAt: testAccountEpoch},
+		{ID: 2, Email: "bob@example.org", Status: "active", Score: 7, CreatedAt: testAccountEpoch.AddDate(0, 1, 0), ManagerID: &manager},
+		{ID: 3, Email: "carla@example.com", Status: "suspended", Score: 4.25, Verified: true, CreatedAt: testAccountEpoch.AddDate(0, 2, 0), ManagerID: &manager},
+		{ID: 4, Email: "dan@example.net", Status: "pending", Score: 7, CreatedAt: testAccountEpoch.AddDate(0, 3, 0)},
+	} {
+		if err := repo.Create(context.Background(), &a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func accountIDs(accounts []testAccount) []int {
+	ids := make([]int, len(accounts))
+	for i, a := range accounts {
+		ids[i] = a.ID
+	}
+	return ids
+}
+
+func TestMemoryRepositoryFindOperators(t *testing.T) {
+	repo := newTestAccountRepo(t)
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    []int
+	}{
+		{"eq", []Filter{{"status", OpEq, "active"}}, []int{1, 2}},
+		{"neq", []Filter{{"status", OpNeq, "active"}}, []int{3, 4}},
+		{"gt", []Filter{{"score", OpGt, 7}}, []int{1}},
+		{"gte", []Filter{{"score", OpGte, 7}}, []int{1, 2, 4}},
+		{"lt", []Filter{{"score", OpLt, "7"}}, []int{3}},
+		{"lte time", []Filter{{"created_at", OpLte, testAccountEpoch.AddDate(0, 1, 0)}}, []int{1, 2}},
+		{"gt date string", []Filter{{"created_at", OpGt, "2025-02-01"}}, []int{3, 4}},
+		{"in", []Filter{{"status", OpIn, []string{"pending", "suspended"}}}, []int{3, 4}},
+		{"in ints", []Filter{{"id", OpIn, []any{1, int64(4), 9}}}, []int{1, 4}},
+		{"like", []Filter{{"email", OpLike, "%@example.com"}}, []int{1, 3}},
+		{"like single char", []Filter{{"email", OpLike, "_ob@%"}}, []int{2}},
+		{"bool string", []Filter{{"verified", OpEq, "true"}}, []int{1, 3}},
+		{"pointer eq", []Filter{{"manager_id", OpEq, 1}}, []int{2, 3}},
+		{"nil", []Filter{{"manager_id", OpEq, nil}}, []int{1, 4}},
+		{"not nil", []Filter{{"manager_id", OpNeq, nil}}, []int{2, 3}},
+		{"go field name", []Filter{{"CreatedAt", OpGte, "2025-03-01T00:00:00Z"}}, []int{3, 4}},
+		{"combined", []Filter{{"status", OpEq, "active"}, {"score", OpLt, 9}, {"created_at", OpGt, testAccountEpoch}}, []int{2}},
+		{"no match", []Filter{{"status", OpEq, "active"}, {"verified", OpEq, false}, {"score", OpGt, 8}}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repo.Find(context.Background(), Query{Filters: tt.filters})
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			if got := accountIDs(page.Data); !slices.Equal(got, tt.want) {
+				t.Errorf("ids = %v, want %v", got, tt.want)
+			}
+			if page.Total != len(tt.want) {
+				t.Errorf("total = %d, want %d", page.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMemoryRepositorySortAndPage(t *testing.T) {
+	repo := newTestAccountRepo(t)
+	ctx := context.Background()
+
+	q := Query{Sort: []SortField{{Field: "score", Desc: true}, {Field: "email"}}, Page: PageQuery{Page: 2, Limit: 2}}
+	page, err := repo.Find(ctx, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := accountIDs(page.Data); !slices.Equal(got, []int{4, 3}) || page.Total != 4 || page.TotalPages != 2 || page.Page != 2 {
+		t.Errorf("page = %v %+v, want ids [4 3] of 4 in 2 pages", got, page)
+	}
+
+	page, _ = repo.Find(ctx, Query{Sort: []SortField{{Field: "manager_id"}}, Page: PageQuery{Page: 9, Limit: 2}})
+	if len(page.Data) != 0 || page.Total != 4 {
+		t.Errorf("page past the end = %+v", page)
+	}
+
+	one, err := repo.FindOne(ctx, Query{}.Where("status", OpEq, "active").Where("id", OpGt, 1))
+	if err != nil || one.ID != 2 {
+		t.Errorf("FindOne = %+v, %v; want account 2", one, err)
+	}
+	if _, err := repo.FindOne(ctx, Query{}.Where("status", OpEq, "deleted")); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("FindOne without match err = %v", err)
+	}
+}
+
+func TestMemoryRepositoryInvalidQuery(t *testing.T) {
+	repo := newTestAccountRepo(t)
+	for _, q := range []Query{
+		{Filters: []Filter{{"nope", OpEq, 1}}},
+		{Filters: []Filter{{"status", "between", 1}}},
+		{Filters: []Filter{{"score", OpGt, "high"}}},
+		{Filters: []Filter{{"id", OpEq, 1.5}}},
+		{Filters: []Filter{{"id", OpIn, 1}}},
+		{Filters: []Filter{{"score", OpLike, "1%"}}},
+		{Filters: []Filter{{"status", OpGt, nil}}},
+		{Sort: []SortField{{Field: "nope"}}},
+	} {
+		if _, err := repo.Find(context.Background(), q); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("Find(%+v) err = %v, want ErrInvalidQuery", q, err)
+		}
+	}
+}
+
+func TestMemoryRepositoryCRUD(t *testing.T) {
+	repo := newTestAccountRepo(t)
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &testAccount{ID: 1}); !errors.Is(err, ErrRecordExists) {
+		t.Errorf("Create duplicate err = %v", err)
+	}
+	if err := repo.Patch(ctx, 2, &testAccount{Status: "suspended"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.FindByID(ctx, 2)
+	if err != nil || got.Status != "suspended" || got.Email != "bob@example.org" {
+		t.Errorf("patched = %+v, %v", got, err)
+	}
+	got.Email = "changed"
+	if again, _ := repo.FindByID(ctx, 2); again.Email != "bob@example.org" {
+		t.Error("FindByID returned the stored entity")
+	}
+	if err := repo.Update(ctx, 2, &testAccount{ID: 2, Email: "new@example.org"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.FindByID(ctx, 2); got.Email != "new@example.org" || got.Status != "" {
+		t.Errorf("updated = %+v", got)
+	}
+	if err := repo.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		func() error { _, err := repo.FindByID(ctx, 2); return err }(),
+		repo.Delete(ctx, 2),
+		repo.Update(ctx, 2, &testAccount{}),
+		repo.Patch(ctx, 2, &testAccount{}),
+	} {
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("err = %v, want ErrRecordNotFound", err)
+		}
+	}
+	page, _ := repo.FindAll(ctx, PageQuery{Page: 1, Limit: 10})
+	if got := accountIDs(page.Data); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("FindAll ids = %v", got)
+	}
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// SoftDeletable is implemented by repositories that soft-delete entities,
+// such as MemoryRepository for entities with a DeletedAt field. By
+// convention, an entity is soft-deletable when IsSoftDeletable reports so:
+// Delete sets its DeletedAt to the current time instead of removing it,
+// finders, Count and Exists skip it unless the context is from
+// IncludeDeleted, and Restore clears DeletedAt.
+type SoftDeletable[ID any] interface {
+	Restore(ctx context.Context, id ID) error
+}
+
+// IsSoftDeletable reports whether T is a struct with a DeletedAt field of
+// type *time.Time, possibly promoted from an embedded struct.
+func IsSoftDeletable[T any]() bool {
+	_, ok := softDeleteField(reflect.TypeFor[T]())
+	return ok
+}
+
+func softDeleteField(t reflect.Type) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	f, ok := t.FieldByName("DeletedAt")
+	return f, ok && f.IsExported() && f.Type == reflect.TypeFor[*time.Time]()
+}
+
+type includeDeletedKey struct{}
+
+// IncludeDeleted returns a copy of ctx under which repositories also find,
+// count and update soft-deleted entities.
+//
+//	all, err := repo.FindAll(core.IncludeDeleted(ctx), q)
+func IncludeDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx is from IncludeDeleted.
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
+
+var _ SoftDeletable[int] = (*MemoryRepository[struct{}, int])(nil)
+
+// Restore implements SoftDeletable, clearing DeletedAt. Restoring an entity
+// that is not deleted does nothing; an ID that was never stored, or any ID
+// when T is not soft-deletable, yields an error wrapping ErrRecordNotFound.
+func (r *MemoryRepository[T, ID]) Restore(_ context.Context, id ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.deletedAt == nil || !r.exists(id) {
+		return fmt.Errorf("%w: %v", ErrRecordNotFound, id)
+	}
+	r.setDeletedAt(id, nil)
+	return nil
+}
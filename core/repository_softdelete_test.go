@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testModel struct {
+	ID        int
+	DeletedAt *time.Time
+}
+
+type testNote struct {
+	testModel
+	Title string
+}
+
+func newTestNoteRepo(t *testing.T) *MemoryRepository[testNote, int] {
+	t.Helper()
+	repo := NewMemoryRepository(func(n *testNote) int { return n.ID })
+	for i, title := range []string{"alpha", "beta", "gamma"} {
+		if err := repo.Create(context.Background(), &testNote{testModel{ID: i + 1}, title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func noteIDs(notes []testNote) []int {
+	ids := make([]int, len(notes))
+	for i, n := range notes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func TestIsSoftDeletable(t *testing.T) {
+	if !IsSoftDeletable[testNote]() || !IsSoftDeletable[testModel]() {
+		t.Error("entities with DeletedAt *time.Time are not soft-deletable")
+	}
+	type valueDeletedAt struct{ DeletedAt time.Time }
+	type unexported struct{ deletedAt *time.Time }
+	if IsSoftDeletable[testAccount]() || IsSoftDeletable[valueDeletedAt]() || IsSoftDeletable[unexported]() || IsSoftDeletable[int]() {
+		t.Error("entities without DeletedAt *time.Time are soft-deletable")
+	}
+}
+
+func TestMemoryRepositorySoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestNoteRepo(t)
+	if err := repo.Delete(ctx, 2); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	t.Run("finders exclude deleted", func(t *testing.T) {
+		if _, err := repo.FindByID(ctx, 2); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("FindByID err = %v, want ErrRecordNotFound", err)
+		}
+		page, err := repo.FindAll(ctx, PageQuery{})
+		if err != nil || !slices.Equal(noteIDs(page.Data), []int{1, 3}) || page.Total != 2 {
+			t.Errorf("FindAll = %v (total %d), %v; want [1 3]", noteIDs(page.Data), page.Total, err)
+		}
+		if _, err := repo.FindOne(ctx, Query{}.Where("title", OpEq, "beta")); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("FindOne err = %v, want ErrRecordNotFound", err)
+		}
+		if err := repo.Update(ctx, 2, &testNote{testModel{ID: 2}, "x"}); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Update err = %v, want ErrRecordNotFound", err)
+		}
+		if err := repo.Delete(ctx, 2); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("second Delete err = %v, want ErrRecordNotFound", err)
+		}
+		if err := repo.Create(ctx, &testNote{testModel{ID: 2}, "again"}); !errors.Is(err, ErrRecordExists) {
+			t.Errorf("Create err = %v, want ErrRecordExists", err)
+		}
+	})
+
+	t.Run("IncludeDeleted finds deleted", func(t *testing.T) {
+		all := IncludeDeleted(ctx)
+		note, err := repo.FindByID(all, 2)
+		if err != nil || note.DeletedAt == nil {
+			t.Fatalf("FindByID = %+v, %v; want deleted note", note, err)
+		}
+		page, err := repo.Find(all, Query{})
+		if err != nil || !slices.Equal(noteIDs(page.Data), []int{1, 2, 3}) {
+			t.Errorf("Find = %v, %v; want [1 2 3]", noteIDs(page.Data), err)
+		}
+		if IncludesDeleted(ctx) || !IncludesDeleted(all) {
+			t.Error("IncludesDeleted does not follow IncludeDeleted")
+		}
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		if err := repo.Restore(ctx, 2); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+		note, err := repo.FindByID(ctx, 2)
+		if err != nil || note.DeletedAt != nil || note.Title != "beta" {
+			t.Errorf("FindByID after Restore = %+v, %v", note, err)
+		}
+		if err := repo.Restore(ctx, 2); err != nil {
+			t.Errorf("Restore of a live note: %v", err)
+		}
+		if err := repo.Restore(ctx, 9); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Restore(9) err = %v, want ErrRecordNotFound", err)
+		}
+	})
+
+	t.Run("DeleteAll soft-deletes", func(t *testing.T) {
+		if err := repo.DeleteAll(ctx, []int{1, 3}); err != nil {
+			t.Fatalf("DeleteAll: %v", err)
+		}
+		page, _ := repo.FindAll(ctx, PageQuery{})
+		all, _ := repo.FindAll(IncludeDeleted(ctx), PageQuery{})
+		if !slices.Equal(noteIDs(page.Data), []int{2}) || len(all.Data) != 3 {
+			t.Errorf("ids = %v, with deleted %v", noteIDs(page.Data), noteIDs(all.Data))
+		}
+	})
+
+	t.Run("hard delete without DeletedAt", func(t *testing.T) {
+		accounts := newTestAccountRepo(t)
+		if err := accounts.Delete(ctx, 1); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := accounts.FindByID(IncludeDeleted(ctx), 1); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("FindByID err = %v, want ErrRecordNotFound", err)
+		}
+		if err := accounts.Restore(ctx, 2); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Restore err = %v, want ErrRecordNotFound", err)
+		}
+	})
+}
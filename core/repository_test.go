@@ -0,0 +1,55 @@
+package core
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+func TestQueryFromRequest(t *testing.T) {
+	var got Query
+	app := NewTestApp().AddRoutes(httpx.GET("/accounts", func(c *httpx.Ctx) error {
+		q, err := QueryFromRequest(c, "status", "score", "created_at")
+		if err != nil {
+			return err
+		}
+		got = q
+		return c.NoContent()
+	}))
+
+	tests := []struct {
+		path   string
+		status int
+		want   Query
+	}{
+		{
+			path:   "/accounts?status=active&score[gte]=7&status[in]=a,b&other=1&sort=-created_at,score&page=2&limit=5",
+			status: http.StatusNoContent,
+			want: Query{
+				Filters: []Filter{
+					{Field: "status", Op: OpEq, Value: "active"},
+					{Field: "score", Op: OpGte, Value: "7"},
+					{Field: "status", Op: OpIn, Value: []string{"a", "b"}},
+				},
+				Sort: []SortField{{Field: "created_at", Desc: true}, {Field: "score"}},
+				Page: PageQuery{Page: 2, Limit: 5},
+			},
+		},
+		{path: "/accounts?email[like]=%25x", status: http.StatusBadRequest},
+		{path: "/accounts?score[between]=1", status: http.StatusBadRequest},
+		{path: "/accounts?sort=email", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		got = Query{}
+		resp := app.GET(tt.path, nil)
+		if resp.StatusCode != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.path, resp.StatusCode, tt.status)
+			continue
+		}
+		if tt.status == http.StatusNoContent && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s query = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+// RoleCarrier is implemented by user types stored with Ctx.SetUser that
+// carry roles, for RequireRoles.
+type RoleCarrier interface {
+	Roles() []string
+}
+
+// RequireRoles returns a middleware requiring the user stored by a guard to
+// hold every role. Roles are read through RoleCarrier, or from the "roles"
+// claim of JWTGuard's default claims map. Requests without a user get a 401
+// KError; a missing role gets a 403 naming it.
+//
+// Usage:
+//
+//	route.Use(jwtGuard.Middleware(), core.RequireRoles("admin"))
+func RequireRoles(roles ...string) fiber.Handler {
+	return RequireRolesFunc(userRoles, roles...)
+}
+
+// RequireRolesFunc is RequireRoles reading the roles of the stored user with
+// extract.
+func RequireRolesFunc(extract func(user any) []string, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := (&httpx.Ctx{Ctx: c}).User()
+		if user == nil {
+			return Unauthorized("authentication required")
+		}
+		held := extract(user)
+		for _, role := range roles {
+			if !slices.Contains(held, role) {
+				return Forbidden(fmt.Sprintf("missing role %q", role))
+			}
+		}
+		return c.Next()
+	}
+}
+
+// userRoles is the default RequireRoles extractor.
+func userRoles(user any) []string {
+	switch u := user.(type) {
+	case RoleCarrier:
+		return u.Roles()
+	case map[string]any:
+		claim, _ := u["roles"].([]any)
+		roles := make([]string, 0, len(claim))
+		for _, r := range claim {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	return nil
+}
+
+// describeRoles appends the roles required by a route to its OpenAPI
+// description.
+func describeRoles(description string, roles []string) string {
+	if len(roles) == 0 {
+		return description
+	}
+	note := "Requires roles: " + strings.Join(roles, ", ") + "."
+	if description == "" {
+		return note
+	}
+	return description + "\n\n" + note
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+type roleUser struct {
+	ID    string
+	roles []string
+}
+
+func (u roleUser) Roles() []string { return u.roles }
+
+// asUser stands in for a guard storing user.
+func asUser(user any) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if user != nil {
+			(&httpx.Ctx{Ctx: c}).SetUser(user)
+		}
+		return c.Next()
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       any
+		wantStatus int
+		wantBody   string
+	}{
+		{"allowed", roleUser{ID: "u1", roles: []string{"admin", "billing"}}, 200, "ok"},
+		{"missing role", roleUser{ID: "u2", roles: []string{"admin"}}, 403, `missing role \"billing\"`},
+		{"no roles", roleUser{ID: "u3"}, 403, `missing role \"admin\"`},
+		{"unauthenticated", nil, 401, "authentication required"},
+		{"JWT claims", map[string]any{"roles": []any{"admin", "billing"}}, 200, "ok"},
+		{"user without roles", BasicUser{Username: "alice"}, 403, `missing role \"admin\"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{DisableHealth: true})
+			app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+				return []httpx.Route{
+					httpx.GET("/admin", func(c *httpx.Ctx) error { return c.SendString("ok") }).
+						Use(asUser(tt.user)).
+						WithRoles("admin", "billing"),
+				}
+			}))
+
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/admin", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("response = %d %s, want %d containing %s", resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRequireRolesFunc(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	extract := func(user any) []string { return strings.Split(user.(string), ",") }
+	app.Fiber().Get("/", asUser("viewer,editor"), RequireRolesFunc(extract, "editor"), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestWithRolesDocumented(t *testing.T) {
+	routes := []httpx.Route{
+		httpx.DELETE("/users/:id", dummyHandler).Describe("Delete user", "Removes the user.").WithRoles("admin"),
+		httpx.GET("/reports", dummyHandler).WithRoles("admin", "auditor"),
+	}
+	got := toOpenAPIRoutes(routes)
+
+	want := []string{"Removes the user.\n\nRequires roles: admin.", "Requires roles: admin, auditor."}
+	for i, ri := range got {
+		if ri.Description != want[i] {
+			t.Errorf("%s description = %q, want %q", ri.Path, ri.Description, want[i])
+		}
+	}
+}
@@ -143,7 +143,7 @@ func TestBody(t *testing.T) {
 	}
 }
 
-func TestResponse(t *testing.T) {
+func TestRouteResponse(t *testing.T) {
 	tests := []struct {
 		name           string
 		route          httpx.Route
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Default security header values used when SecurityHeadersConfig leaves a
+// field empty.
+const (
+	DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	// DefaultDocsContentSecurityPolicy lets the Swagger UI page load its
+	// assets from unpkg and run its inline bootstrap script.
+	DefaultDocsContentSecurityPolicy = "default-src 'self'; " +
+		"script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+		"style-src 'self' 'unsafe-inline' https://unpkg.com; " +
+		"img-src 'self' data: https:; connect-src 'self'; frame-ancestors 'none'"
+	DefaultHSTS = "max-age=31536000; includeSubDomains"
+)
+
+// SecurityHeadersConfig configures the security response headers. Empty
+// fields use the defaults; set a field to "-" to omit its header.
+type SecurityHeadersConfig struct {
+	Enabled                   *bool  // nil applies the headers in production only; ignored by SecurityHeaders
+	ContentTypeOptions        string // X-Content-Type-Options; defaults to "nosniff"
+	FrameOptions              string // X-Frame-Options; defaults to "DENY"
+	ReferrerPolicy            string // Referrer-Policy; defaults to "strict-origin-when-cross-origin"
+	HSTS                      string // Strict-Transport-Security; defaults to DefaultHSTS
+	ContentSecurityPolicy     string // defaults to DefaultContentSecurityPolicy
+	DocsContentSecurityPolicy string // CSP of the docs page; defaults to DefaultDocsContentSecurityPolicy
+}
+
+// withDefaults returns c with empty fields set to their defaults.
+func (c SecurityHeadersConfig) withDefaults() SecurityHeadersConfig {
+	set := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	set(&c.ContentTypeOptions, "nosniff")
+	set(&c.FrameOptions, "DENY")
+	set(&c.ReferrerPolicy, "strict-origin-when-cross-origin")
+	set(&c.HSTS, DefaultHSTS)
+	set(&c.ContentSecurityPolicy, DefaultContentSecurityPolicy)
+	set(&c.DocsContentSecurityPolicy, DefaultDocsContentSecurityPolicy)
+	return c
+}
+
+// SecurityHeaders returns a middleware setting X-Content-Type-Options,
+// X-Frame-Options, Referrer-Policy and Content-Security-Policy on every
+// response, plus Strict-Transport-Security on HTTPS requests. New installs
+// it in production; configure it there with KConfig.SecurityHeaders.
+func SecurityHeaders(cfg ...SecurityHeadersConfig) fiber.Handler {
+	var c SecurityHeadersConfig
+	if len(cfg) > 0 {
+		c = cfg[0]
+	}
+	return securityHeaders(c, false)
+}
+
+// securityHeaders builds the middleware. forceHSTS sends HSTS on plain HTTP
+// requests too, for apps behind a TLS-terminating proxy.
+func securityHeaders(cfg SecurityHeadersConfig, forceHSTS bool) fiber.Handler {
+	cfg = cfg.withDefaults()
+	headers := [][2]string{
+		{fiber.HeaderXContentTypeOptions, cfg.ContentTypeOptions},
+		{fiber.HeaderXFrameOptions, cfg.FrameOptions},
+		{fiber.HeaderReferrerPolicy, cfg.ReferrerPolicy},
+		{fiber.HeaderContentSecurityPolicy, cfg.ContentSecurityPolicy},
+	}
+	return func(c *fiber.Ctx) error {
+		for _, h := range headers {
+			setHeader(c, h[0], h[1])
+		}
+		if forceHSTS || c.Secure() {
+			setHeader(c, fiber.HeaderStrictTransportSecurity, cfg.HSTS)
+		}
+		return c.Next()
+	}
+}
+
+// docsContentSecurityPolicy replaces the API CSP on the docs page.
+func docsContentSecurityPolicy(cfg SecurityHeadersConfig) fiber.Handler {
+	csp := cfg.withDefaults().DocsContentSecurityPolicy
+	return func(c *fiber.Ctx) error {
+		c.Response().Header.Del(fiber.HeaderContentSecurityPolicy)
+		setHeader(c, fiber.HeaderContentSecurityPolicy, csp)
+		return c.Next()
+	}
+}
+
+// setHeader sets a response header unless value is "-".
+func setHeader(c *fiber.Ctx, name, value string) {
+	if value != "-" {
+		c.Set(name, value)
+	}
+}
+
+// securityHeadersEnabled reports whether New installs SecurityHeaders.
+func (c KConfig) securityHeadersEnabled() bool {
+	if c.SecurityHeaders.Enabled != nil {
+		return *c.SecurityHeaders.Enabled
+	}
+	return c.isProduction()
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name     string
+		cfg      KConfig
+		wantAPI  map[string]string
+		wantDocs string
+	}{
+		{
+			name: "production defaults",
+			cfg:  KConfig{Env: "production", Docs: DocsConfig{Enabled: &enabled}},
+			wantAPI: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "strict-origin-when-cross-origin",
+				"Strict-Transport-Security": DefaultHSTS,
+				"Content-Security-Policy":   DefaultContentSecurityPolicy,
+			},
+			wantDocs: DefaultDocsContentSecurityPolicy,
+		},
+		{
+			name: "configured outside production",
+			cfg: KConfig{Env: "development", SecurityHeaders: SecurityHeadersConfig{
+				Enabled:                   &enabled,
+				FrameOptions:              "SAMEORIGIN",
+				ContentSecurityPolicy:     "-",
+				DocsContentSecurityPolicy: "default-src 'self'",
+			}},
+			wantAPI: map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "SAMEORIGIN",
+				"Strict-Transport-Security": "",
+				"Content-Security-Policy":   "",
+			},
+			wantDocs: "default-src 'self'",
+		},
+		{
+			name: "off outside production by default",
+			cfg:  KConfig{Env: "development"},
+			wantAPI: map[string]string{
+				"X-Content-Type-Options":  "",
+				"Content-Security-Policy": "",
+			},
+		},
+		{
+			name: "disabled in production",
+			cfg:  KConfig{Env: "production", SecurityHeaders: SecurityHeadersConfig{Enabled: &disabled}},
+			wantAPI: map[string]string{
+				"X-Frame-Options":           "",
+				"Strict-Transport-Security": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.DisableHealth = true
+			app := New(tt.cfg)
+			app.Fiber().Get("/api", func(c *fiber.Ctx) error { return c.JSON(fiber.Map{"ok": true}) })
+			app.registerDocsRoutes()
+
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/api", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.wantAPI {
+				if got := resp.Header.Get(name); got != want {
+					t.Errorf("API %s = %q, want %q", name, got, want)
+				}
+			}
+
+			if tt.wantDocs == "" {
+				return
+			}
+			resp, err = app.Fiber().Test(httptest.NewRequest("GET", "/docs", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != 200 {
+				t.Fatalf("docs status = %d", resp.StatusCode)
+			}
+			if got := resp.Header.Get("Content-Security-Policy"); got != tt.wantDocs {
+				t.Errorf("docs Content-Security-Policy = %q, want %q", got, tt.wantDocs)
+			}
+			if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+				t.Errorf("docs X-Content-Type-Options = %q, want nosniff", got)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersStandaloneHSTS(t *testing.T) {
+	app := fiber.New()
+	app.Use(SecurityHeaders())
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	resp, err := app.Test(httptest.NewRequest("GET", "http://example.com/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("HSTS on plain HTTP = %q, want none", got)
+	}
+	if got := resp.Header.Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("X-Frame-Options = %q, want DENY", got)
+	}
+}
@@ -0,0 +1,315 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ErrInvalidStorageKey is returned for keys that are empty, absolute, or
+// would resolve outside the storage root.
+var ErrInvalidStorageKey = errors.New("storage: invalid key")
+
+const (
+	// DefaultStorageFileMode is the permission of files written by
+	// LocalStorage.
+	DefaultStorageFileMode fs.FileMode = 0o644
+	// DefaultStorageDirMode is the permission of directories created by
+	// LocalStorage.
+	DefaultStorageDirMode fs.FileMode = 0o755
+)
+
+// localStorageDir holds LocalStorage bookkeeping under the root: content
+// type sidecars in meta/, files being written in tmp/ and multipart uploads
+// in uploads/. Keys may not start with it.
+const localStorageDir = ".keel"
+
+// LocalStorage is a contracts.Storage keeping objects as files under a root
+// directory, for development and small deployments. Keys are slash-separated
+// paths relative to the root; directories are created as needed. It is safe
+// for concurrent use.
+type LocalStorage struct {
+	root     string
+	baseURL  string
+	fileMode fs.FileMode
+	dirMode  fs.FileMode
+}
+
+var _ contracts.Storage = (*LocalStorage)(nil)
+
+// LocalStorageOption configures NewLocalStorage.
+type LocalStorageOption func(*LocalStorage)
+
+// WithStorageBaseURL makes URL return baseURL followed by the key, for
+// roots served by a web server or CDN. Without it URL returns file:// URLs.
+func WithStorageBaseURL(baseURL string) LocalStorageOption {
+	return func(s *LocalStorage) { s.baseURL = strings.TrimSuffix(baseURL, "/") }
+}
+
+// WithStoragePermissions sets the permissions of written files and created
+// directories, DefaultStorageFileMode and DefaultStorageDirMode by default.
+func WithStoragePermissions(file, dir fs.FileMode) LocalStorageOption {
+	return func(s *LocalStorage) { s.fileMode, s.dirMode = file, dir }
+}
+
+// NewLocalStorage returns a LocalStorage rooted at rootDir, creating the
+// directory when missing.
+//
+//	storage, err := core.NewLocalStorage("./uploads", core.WithStorageBaseURL("https://cdn.example.com/uploads"))
+func NewLocalStorage(rootDir string, opts ...LocalStorageOption) (*LocalStorage, error) {
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("storage: root %q: %w", rootDir, err)
+	}
+	s := &LocalStorage{root: root, fileMode: DefaultStorageFileMode, dirMode: DefaultStorageDirMode}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for _, dir := range []string{root, s.internal("tmp")} {
+		if err := os.MkdirAll(dir, s.dirMode); err != nil {
+			return nil, fmt.Errorf("storage: create %s: %w", dir, err)
+		}
+	}
+	return s, nil
+}
+
+// Root returns the absolute root directory.
+func (s *LocalStorage) Root() string {
+	return s.root
+}
+
+// Put implements contracts.Storage. The object is written to a temporary
+// file renamed over key once complete, so readers never see a partial
+// object. size is checked against the bytes read unless it is negative. An
+// empty contentType leaves Stat to infer it.
+func (s *LocalStorage) Put(_ context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := s.writeFile(p, func(w io.Writer) error {
+		n, err := io.Copy(w, r)
+		if err != nil {
+			return err
+		}
+		if size >= 0 && n != size {
+			return fmt.Errorf("read %d bytes, want %d", n, size)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("storage: put %q: %w", key, err)
+	}
+	if err := s.writeMeta(key, localObjectMeta{ContentType: contentType}); err != nil {
+		return fmt.Errorf("storage: put %q: %w", key, err)
+	}
+	return nil
+}
+
+// Get implements contracts.Storage, streaming the file. Missing keys yield
+// errors wrapping fs.ErrNotExist.
+func (s *LocalStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, objectErr(key, err)
+	}
+	if info, err := f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		if err == nil {
+			err = fs.ErrNotExist
+		}
+		return nil, objectErr(key, err)
+	}
+	return f, nil
+}
+
+// Delete implements contracts.Storage. Deleting a missing key is not an
+// error.
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	for _, f := range []string{p, s.metaPath(key)} {
+		if err := os.Remove(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("storage: delete %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// URL implements contracts.Storage. It returns the base URL set with
+// WithStorageBaseURL followed by the key, or a file:// URL; expiry is
+// ignored since neither expires.
+func (s *LocalStorage) URL(_ context.Context, key string, _ time.Duration) (string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(p); err != nil {
+		return "", objectErr(key, err)
+	}
+	if s.baseURL != "" {
+		return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath(), nil
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String(), nil
+}
+
+// Stat implements contracts.Storage. The content type is the one given to
+// Put, or else inferred from the key extension or, failing that, sniffed
+// from the first bytes of the file.
+func (s *LocalStorage) Stat(_ context.Context, key string) (*contracts.StorageObject, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, objectErr(key, err)
+	}
+	if info.IsDir() {
+		return nil, objectErr(key, fs.ErrNotExist)
+	}
+	contentType, err := s.contentType(key, p)
+	if err != nil {
+		return nil, fmt.Errorf("storage: stat %q: %w", key, err)
+	}
+	return &contracts.StorageObject{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  contentType,
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// localObjectMeta is the sidecar stored next to an object.
+type localObjectMeta struct {
+	ContentType string `json:"content_type,omitempty"`
+}
+
+func (s *LocalStorage) contentType(key, p string) (string, error) {
+	data, err := os.ReadFile(s.metaPath(key))
+	switch {
+	case err == nil:
+		var meta localObjectMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return "", fmt.Errorf("read metadata: %w", err)
+		}
+		if meta.ContentType != "" {
+			return meta.ContentType, nil
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return "", err
+	}
+
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		return ct, nil
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// writeMeta stores meta for key, or removes the sidecar when meta is empty.
+func (s *LocalStorage) writeMeta(key string, meta localObjectMeta) error {
+	p := s.metaPath(key)
+	if meta == (localObjectMeta{}) {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(p, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFile writes p atomically: write fills a temporary file that is then
+// renamed to p.
+func (s *LocalStorage) writeFile(p string, write func(w io.Writer) error) (err error) {
+	if err := os.MkdirAll(filepath.Dir(p), s.dirMode); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.internal("tmp"), "put-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(s.fileMode); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// path returns the file of key, rejecting keys that would escape the root
+// or reach the bookkeeping directory.
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.ContainsAny(key, "\\\x00") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidStorageKey, key)
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("%w: %q", ErrInvalidStorageKey, key)
+		}
+	}
+	if key == localStorageDir || strings.HasPrefix(key, localStorageDir+"/") {
+		return "", fmt.Errorf("%w: %q is reserved", ErrInvalidStorageKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStorage) metaPath(key string) string {
+	return s.internal("meta", filepath.FromSlash(key)+".json")
+}
+
+func (s *LocalStorage) internal(elem ...string) string {
+	return filepath.Join(append([]string{s.root, localStorageDir}, elem...)...)
+}
+
+// objectErr wraps an error about key, hiding the file path.
+func objectErr(key string, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("storage: object %q: %w", key, fs.ErrNotExist)
+	}
+	return fmt.Errorf("storage: object %q: %w", key, err)
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ExtendedStorage is implemented by storages that copy and move objects
+// without streaming them through the application, such as LocalStorage.
+// All implementations follow the same rules:
+//
+//   - Copy and Move overwrite dst when it exists, like Put, keeping the
+//     content type of src.
+//   - A missing src yields an error wrapping fs.ErrNotExist and leaves dst
+//     untouched.
+//   - Copying or moving a key onto itself leaves the object as is.
+//   - Exists reports false, without error, for missing keys.
+//
+// Use StorageCopy, StorageMove and StorageExists to fall back to Get, Put,
+// Stat and Delete on other storages.
+type ExtendedStorage interface {
+	Copy(ctx context.Context, src, dst string) error
+	Move(ctx context.Context, src, dst string) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// StorageCopy copies src to dst with s.Copy when s is an ExtendedStorage,
+// and otherwise streams it with Get and Put.
+func StorageCopy(ctx context.Context, s contracts.Storage, src, dst string) error {
+	if es, ok := s.(ExtendedStorage); ok {
+		return es.Copy(ctx, src, dst)
+	}
+	return copyObject(ctx, s, src, dst)
+}
+
+// StorageMove moves src to dst with s.Move when s is an ExtendedStorage,
+// and otherwise copies it as StorageCopy does, then deletes src. The
+// fallback is not atomic: when the delete fails, both keys exist.
+func StorageMove(ctx context.Context, s contracts.Storage, src, dst string) error {
+	if es, ok := s.(ExtendedStorage); ok {
+		return es.Move(ctx, src, dst)
+	}
+	if src == dst {
+		_, err := s.Stat(ctx, src)
+		return err
+	}
+	if err := copyObject(ctx, s, src, dst); err != nil {
+		return err
+	}
+	if err := s.Delete(ctx, src); err != nil {
+		return fmt.Errorf("storage: move %q: delete source: %w", src, err)
+	}
+	return nil
+}
+
+// StorageExists reports whether key exists with s.Exists when s is an
+// ExtendedStorage, and otherwise with Stat, treating errors wrapping
+// fs.ErrNotExist as false.
+func StorageExists(ctx context.Context, s contracts.Storage, key string) (bool, error) {
+	if es, ok := s.(ExtendedStorage); ok {
+		return es.Exists(ctx, key)
+	}
+	_, err := s.Stat(ctx, key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func copyObject(ctx context.Context, s contracts.Storage, src, dst string) error {
+	obj, err := s.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	if src == dst {
+		return nil
+	}
+	r, err := s.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := s.Put(ctx, dst, r, obj.Size, obj.ContentType); err != nil {
+		return fmt.Errorf("storage: copy %q to %q: %w", src, dst, err)
+	}
+	return nil
+}
+
+var _ ExtendedStorage = (*LocalStorage)(nil)
+
+// Copy implements ExtendedStorage, writing dst atomically like Put.
+func (s *LocalStorage) Copy(_ context.Context, src, dst string) error {
+	srcPath, dstPath, err := s.paths(src, dst)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return objectErr(src, err)
+	}
+	defer in.Close()
+	if info, err := in.Stat(); err != nil || info.IsDir() {
+		return objectErr(src, fs.ErrNotExist)
+	}
+	if src == dst {
+		return nil
+	}
+	contentType, err := s.contentType(src, srcPath)
+	if err != nil {
+		return fmt.Errorf("storage: copy %q: %w", src, err)
+	}
+	if err := s.writeFile(dstPath, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	}); err != nil {
+		return fmt.Errorf("storage: copy %q to %q: %w", src, dst, err)
+	}
+	if err := s.writeMeta(dst, localObjectMeta{ContentType: contentType}); err != nil {
+		return fmt.Errorf("storage: copy %q to %q: %w", src, dst, err)
+	}
+	return nil
+}
+
+// Move implements ExtendedStorage with a rename, so dst never holds a
+// partial object.
+func (s *LocalStorage) Move(_ context.Context, src, dst string) error {
+	srcPath, dstPath, err := s.paths(src, dst)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(srcPath); err != nil || info.IsDir() {
+		if err == nil {
+			err = fs.ErrNotExist
+		}
+		return objectErr(src, err)
+	}
+	if src == dst {
+		return nil
+	}
+	contentType, err := s.contentType(src, srcPath)
+	if err != nil {
+		return fmt.Errorf("storage: move %q: %w", src, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), s.dirMode); err != nil {
+		return fmt.Errorf("storage: move %q to %q: %w", src, dst, err)
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return fmt.Errorf("storage: move %q to %q: %w", src, dst, err)
+	}
+	if err := s.writeMeta(dst, localObjectMeta{ContentType: contentType}); err != nil {
+		return fmt.Errorf("storage: move %q to %q: %w", src, dst, err)
+	}
+	if err := s.writeMeta(src, localObjectMeta{}); err != nil {
+		return fmt.Errorf("storage: move %q to %q: %w", src, dst, err)
+	}
+	return nil
+}
+
+// Exists implements ExtendedStorage.
+func (s *LocalStorage) Exists(_ context.Context, key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(p)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, objectErr(key, err)
+	}
+	return !info.IsDir(), nil
+}
+
+func (s *LocalStorage) paths(src, dst string) (string, string, error) {
+	srcPath, err := s.path(src)
+	if err != nil {
+		return "", "", err
+	}
+	dstPath, err := s.path(dst)
+	if err != nil {
+		return "", "", err
+	}
+	return srcPath, dstPath, nil
+}
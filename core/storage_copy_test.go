@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+func TestStorageCopyMoveExists(t *testing.T) {
+	stores := map[string]func(t *testing.T) contracts.Storage{
+		"local native":    func(t *testing.T) contracts.Storage { return newTestLocalStorage(t) },
+		"memory native":   func(*testing.T) contracts.Storage { return keeltest.NewMemoryStorage() },
+		"local fallback":  func(t *testing.T) contracts.Storage { return plainStorage{newTestLocalStorage(t)} },
+		"memory fallback": func(*testing.T) contracts.Storage { return plainStorage{keeltest.NewMemoryStorage()} },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore(t)
+			_, native := s.(ExtendedStorage)
+			if native == strings.HasSuffix(name, "fallback") {
+				t.Fatalf("%T: ExtendedStorage = %v", s, native)
+			}
+			content := func(key string) string {
+				r, err := s.Get(ctx, key)
+				if err != nil {
+					return "<" + err.Error() + ">"
+				}
+				defer r.Close()
+				data, _ := io.ReadAll(r)
+				return string(data)
+			}
+			exists := func(key string) bool {
+				ok, err := StorageExists(ctx, s, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return ok
+			}
+
+			s.Put(ctx, "tmp/upload", strings.NewReader("report"), 6, "application/pdf") //nolint
+			s.Put(ctx, "final/existing", strings.NewReader("old"), 3, "text/plain")     //nolint
+
+			if err := StorageCopy(ctx, s, "tmp/upload", "final/copy"); err != nil {
+				t.Fatal(err)
+			}
+			if content("final/copy") != "report" || content("tmp/upload") != "report" {
+				t.Errorf("after Copy: dst %q src %q", content("final/copy"), content("tmp/upload"))
+			}
+			if obj, _ := s.Stat(ctx, "final/copy"); obj == nil || obj.ContentType != "application/pdf" || obj.Key != "final/copy" {
+				t.Errorf("copied object = %+v, want the source content type", obj)
+			}
+
+			if err := StorageMove(ctx, s, "tmp/upload", "final/existing"); err != nil {
+				t.Fatal(err)
+			}
+			if content("final/existing") != "report" || exists("tmp/upload") {
+				t.Errorf("after Move over an existing key: dst %q, src exists %v", content("final/existing"), exists("tmp/upload"))
+			}
+			if obj, _ := s.Stat(ctx, "final/existing"); obj == nil || obj.ContentType != "application/pdf" {
+				t.Errorf("moved object = %+v, want the source content type", obj)
+			}
+
+			for _, op := range []func(context.Context, contracts.Storage, string, string) error{StorageCopy, StorageMove} {
+				if err := op(ctx, s, "final/copy", "final/copy"); err != nil || content("final/copy") != "report" {
+					t.Errorf("onto itself: %v, content %q", err, content("final/copy"))
+				}
+				if err := op(ctx, s, "missing", "final/copy"); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("missing source: %v, want fs.ErrNotExist", err)
+				}
+				if content("final/copy") != "report" {
+					t.Errorf("missing source changed dst to %q", content("final/copy"))
+				}
+			}
+			if !exists("final/copy") || exists("missing") {
+				t.Errorf("Exists(final/copy) = %v, Exists(missing) = %v", exists("final/copy"), exists("missing"))
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultStorageListLimit is the page size of LocalStorage.List when
+// ListOptions.Limit is not set.
+const DefaultStorageListLimit = 1000
+
+// ErrStorageListUnsupported is returned by ListAll for storages that are
+// not a ListableStorage.
+var ErrStorageListUnsupported = errors.New("storage: listing not supported")
+
+// ListOptions controls one page of ListableStorage.List; see
+// contracts.StorageListOptions.
+type ListOptions = contracts.StorageListOptions
+
+// ListResult is one page of ListableStorage.List; see
+// contracts.StorageListResult.
+type ListResult = contracts.StorageListResult
+
+// ListableStorage is implemented by storages that can enumerate their
+// objects, such as LocalStorage. List returns the objects whose key starts
+// with prefix in lexicographic key order, one page at a time: pass the
+// NextToken of a page as the Token of the next. Objects added or removed
+// between pages do not make a listing repeat or skip the others.
+type ListableStorage interface {
+	List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error)
+}
+
+// ListAll calls fn for every object of s whose key starts with prefix, in
+// key order, fetching pages as needed. It stops at the first error from s
+// or fn, and returns ErrStorageListUnsupported when s is not a
+// ListableStorage.
+//
+//	err := core.ListAll(ctx, storage, "invoices/2024/", func(obj contracts.StorageObject) error {
+//		total += obj.Size
+//		return nil
+//	})
+func ListAll(ctx context.Context, s contracts.Storage, prefix string, fn func(obj contracts.StorageObject) error) error {
+	ls, ok := s.(ListableStorage)
+	if !ok {
+		return ErrStorageListUnsupported
+	}
+	var token string
+	for {
+		page, err := ls.List(ctx, prefix, ListOptions{Token: token})
+		if err != nil {
+			return err
+		}
+		for _, obj := range page.Objects {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+		if page.NextToken == "" {
+			return nil
+		}
+		token = page.NextToken
+	}
+}
+
+var _ ListableStorage = (*LocalStorage)(nil)
+
+// List implements ListableStorage. The token is the last key of the
+// previous page.
+func (s *LocalStorage) List(ctx context.Context, prefix string, opts ListOptions) (ListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultStorageListLimit
+	}
+	keys, err := s.keys(prefix)
+	if err != nil {
+		return ListResult{}, fmt.Errorf("storage: list %q: %w", prefix, err)
+	}
+	slices.Sort(keys)
+	if opts.Token != "" {
+		start, found := slices.BinarySearch(keys, opts.Token)
+		if found {
+			start++
+		}
+		keys = keys[start:]
+	}
+
+	var result ListResult
+	for _, key := range keys {
+		if len(result.Objects) == limit {
+			result.NextToken = result.Objects[limit-1].Key
+			break
+		}
+		obj, err := s.Stat(ctx, key)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since the walk
+		}
+		if err != nil {
+			return ListResult{}, err
+		}
+		result.Objects = append(result.Objects, *obj)
+	}
+	return result, nil
+}
+
+// keys returns the keys starting with prefix, unsorted.
+func (s *LocalStorage) keys(prefix string) ([]string, error) {
+	dir := prefix[:strings.LastIndex(prefix, "/")+1]
+	if dir != "" {
+		if _, err := s.path(strings.TrimSuffix(dir, "/")); err != nil {
+			return nil, err
+		}
+	}
+	var keys []string
+	err := filepath.WalkDir(filepath.Join(s.root, filepath.FromSlash(dir)), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == localStorageDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
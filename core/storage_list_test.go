@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+type listableTestStorage interface {
+	contracts.Storage
+	ListableStorage
+}
+
+func listableStorages(t *testing.T) map[string]listableTestStorage {
+	return map[string]listableTestStorage{
+		"local":  newTestLocalStorage(t),
+		"memory": keeltest.NewMemoryStorage(),
+	}
+}
+
+// listKeys pages through s with limit, returning the keys of each page.
+func listKeys(t *testing.T, s ListableStorage, prefix string, limit int) [][]string {
+	t.Helper()
+	var pages [][]string
+	token := ""
+	for {
+		page, err := s.List(context.Background(), prefix, ListOptions{Limit: limit, Token: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, obj := range page.Objects {
+			keys = append(keys, obj.Key)
+		}
+		pages = append(pages, keys)
+		if page.NextToken == "" {
+			return pages
+		}
+		if len(pages) > 10 {
+			t.Fatal("listing does not end")
+		}
+		token = page.NextToken
+	}
+}
+
+func TestStorageList(t *testing.T) {
+	ctx := context.Background()
+	keys := []string{"invoices/2024/b.pdf", "invoices/2024/a.pdf", "invoices/2024.csv", "invoices/2025/c.pdf", "a.txt", "a/b.txt", "z"}
+	for name, s := range listableStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range keys {
+				if err := s.Put(ctx, k, strings.NewReader(k), -1, "text/plain"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			tests := []struct {
+				prefix string
+				limit  int
+				want   [][]string
+			}{
+				{"", 0, [][]string{{"a.txt", "a/b.txt", "invoices/2024.csv", "invoices/2024/a.pdf", "invoices/2024/b.pdf", "invoices/2025/c.pdf", "z"}}},
+				{"invoices/2024/", 0, [][]string{{"invoices/2024/a.pdf", "invoices/2024/b.pdf"}}},
+				{"invoices/2024", 2, [][]string{{"invoices/2024.csv", "invoices/2024/a.pdf"}, {"invoices/2024/b.pdf"}}},
+				{"invoices/", 2, [][]string{{"invoices/2024.csv", "invoices/2024/a.pdf"}, {"invoices/2024/b.pdf", "invoices/2025/c.pdf"}}},
+				{"invoices/", 4, [][]string{{"invoices/2024.csv", "invoices/2024/a.pdf", "invoices/2024/b.pdf", "invoices/2025/c.pdf"}}},
+				{"invoices/", 1, [][]string{{"invoices/2024.csv"}, {"invoices/2024/a.pdf"}, {"invoices/2024/b.pdf"}, {"invoices/2025/c.pdf"}}},
+				{"missing/", 0, [][]string{nil}},
+			}
+			for _, tt := range tests {
+				if got := listKeys(t, s, tt.prefix, tt.limit); !slices.EqualFunc(got, tt.want, slices.Equal[[]string]) {
+					t.Errorf("List(%q, limit %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+				}
+			}
+
+			page, err := s.List(ctx, "invoices/2024/", ListOptions{})
+			if err != nil || len(page.Objects) == 0 {
+				t.Fatal(page, err)
+			}
+			if obj := page.Objects[0]; obj.Size != int64(len("invoices/2024/a.pdf")) || obj.ContentType != "text/plain" || obj.LastModified.IsZero() {
+				t.Errorf("listed object = %+v", obj)
+			}
+
+			// Keys added before the token between pages are not listed again.
+			first, _ := s.List(ctx, "invoices/", ListOptions{Limit: 2})
+			s.Put(ctx, "invoices/2024/0.pdf", strings.NewReader("x"), 1, "") //nolint
+			second, _ := s.List(ctx, "invoices/", ListOptions{Limit: 2, Token: first.NextToken})
+			if len(second.Objects) != 2 || second.Objects[0].Key != "invoices/2024/b.pdf" {
+				t.Errorf("page after insert = %+v", second.Objects)
+			}
+		})
+	}
+}
+
+func TestListAll(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	for i := range DefaultStorageListLimit + 5 {
+		s.Put(ctx, fmt.Sprintf("n/%d/%04d", i%3, i), strings.NewReader("."), 1, "") //nolint
+	}
+
+	var got []string
+	if err := ListAll(ctx, s, "n/", func(obj contracts.StorageObject) error {
+		got = append(got, obj.Key)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != DefaultStorageListLimit+5 || !slices.IsSorted(got) {
+		t.Errorf("ListAll visited %d keys (sorted %v), want %d in order", len(got), slices.IsSorted(got), DefaultStorageListLimit+5)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	if err := ListAll(ctx, s, "n/", func(contracts.StorageObject) error { calls++; return stop }); !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ListAll = %v after %d call(s), want fn's error after 1", err, calls)
+	}
+	if err := ListAll(ctx, plainStorage{s}, "", func(contracts.StorageObject) error { return nil }); !errors.Is(err, ErrStorageListUnsupported) {
+		t.Errorf("ListAll on a plain Storage = %v", err)
+	}
+}
+
+// plainStorage hides the optional interfaces of a Storage.
+type plainStorage struct{ contracts.Storage }
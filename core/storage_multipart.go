@@ -0,0 +1,261 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// DefaultStoragePartSize is the part size PutStreaming uses when none is
+// given.
+const DefaultStoragePartSize = 8 << 20
+
+// maxUploadParts is the highest part number accepted by LocalStorage, as
+// in S3.
+const maxUploadParts = 10000
+
+// ErrUploadNotFound is returned for multipart upload IDs that do not exist,
+// were completed or aborted, or belong to another key.
+var ErrUploadNotFound = errors.New("storage: upload not found")
+
+// CompletedPart identifies an uploaded part when completing a multipart
+// upload.
+type CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// MultipartStorage is implemented by storages accepting an object in
+// separately uploaded parts, such as LocalStorage, so large objects need
+// neither a known size nor to fit in memory. Parts are numbered from 1,
+// may be uploaded in any order and concurrently, and uploading a part
+// number again replaces it. CompleteUpload assembles the listed parts in
+// part number order into the object, replacing any existing one; parts not
+// listed are discarded. AbortUpload discards every part.
+type MultipartStorage interface {
+	InitiateUpload(ctx context.Context, key, contentType string) (uploadID string, err error)
+	UploadPart(ctx context.Context, key, uploadID string, partNum int, r io.Reader, size int64) (etag string, err error)
+	CompleteUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	AbortUpload(ctx context.Context, key, uploadID string) error
+}
+
+// PutStreaming stores r under key. With a MultipartStorage it uploads r in
+// parts of partSize bytes, holding one part in memory at a time, and aborts
+// the upload on failure; other storages receive a single Put of r buffered
+// in memory. partSize defaults to DefaultStoragePartSize.
+//
+//	err := core.PutStreaming(ctx, storage, "exports/db.sql.gz", file, "application/gzip", 0)
+func PutStreaming(ctx context.Context, s contracts.Storage, key string, r io.Reader, contentType string, partSize int) error {
+	if partSize <= 0 {
+		partSize = DefaultStoragePartSize
+	}
+	ms, ok := s.(MultipartStorage)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("storage: put %q: %w", key, err)
+		}
+		return s.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+	}
+
+	uploadID, err := ms.InitiateUpload(ctx, key, contentType)
+	if err != nil {
+		return err
+	}
+	if err := uploadParts(ctx, ms, key, uploadID, r, partSize); err != nil {
+		if abortErr := ms.AbortUpload(context.WithoutCancel(ctx), key, uploadID); abortErr != nil {
+			return errors.Join(err, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func uploadParts(ctx context.Context, ms MultipartStorage, key, uploadID string, r io.Reader, partSize int) error {
+	buf := make([]byte, partSize)
+	var parts []CompletedPart
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("storage: put %q: %w", key, readErr)
+		}
+		// An empty object is still uploaded as one empty part.
+		if n > 0 || len(parts) == 0 {
+			num := len(parts) + 1
+			etag, err := ms.UploadPart(ctx, key, uploadID, num, bytes.NewReader(buf[:n]), int64(n))
+			if err != nil {
+				return err
+			}
+			parts = append(parts, CompletedPart{PartNumber: num, ETag: etag})
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	return ms.CompleteUpload(ctx, key, uploadID, parts)
+}
+
+var _ MultipartStorage = (*LocalStorage)(nil)
+
+// localUpload is the manifest of a LocalStorage multipart upload.
+type localUpload struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+// InitiateUpload implements MultipartStorage. Parts are kept as files under
+// the bookkeeping directory of the root until the upload is completed or
+// aborted.
+func (s *LocalStorage) InitiateUpload(_ context.Context, key, contentType string) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	uploadID := randomHex(16)
+	manifest, err := json.Marshal(localUpload{Key: key, ContentType: contentType})
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(s.internal("uploads", uploadID), s.dirMode); err != nil {
+		return "", fmt.Errorf("storage: initiate upload of %q: %w", key, err)
+	}
+	if err := s.writeFile(s.internal("uploads", uploadID, "upload.json"), func(w io.Writer) error {
+		_, err := w.Write(manifest)
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("storage: initiate upload of %q: %w", key, err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart implements MultipartStorage. The ETag is the hex MD5 of the
+// part. size is checked against the bytes read unless it is negative.
+func (s *LocalStorage) UploadPart(_ context.Context, key, uploadID string, partNum int, r io.Reader, size int64) (string, error) {
+	if _, err := s.upload(key, uploadID); err != nil {
+		return "", err
+	}
+	if partNum < 1 || partNum > maxUploadParts {
+		return "", fmt.Errorf("storage: upload %s: part number %d outside 1-%d", uploadID, partNum, maxUploadParts)
+	}
+	sum := md5.New()
+	part := s.partPath(uploadID, partNum)
+	if err := s.writeFile(part, func(w io.Writer) error {
+		n, err := io.Copy(io.MultiWriter(w, sum), r)
+		if err != nil {
+			return err
+		}
+		if size >= 0 && n != size {
+			return fmt.Errorf("read %d bytes, want %d", n, size)
+		}
+		return nil
+	}); err != nil {
+		return "", fmt.Errorf("storage: upload %s part %d: %w", uploadID, partNum, err)
+	}
+	etag := hex.EncodeToString(sum.Sum(nil))
+	if err := s.writeFile(part+".etag", func(w io.Writer) error {
+		_, err := io.WriteString(w, etag)
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("storage: upload %s part %d: %w", uploadID, partNum, err)
+	}
+	return etag, nil
+}
+
+// CompleteUpload implements MultipartStorage. parts may be listed in any
+// order; each must have been uploaded with the given ETag. On failure the
+// upload is left as is so it can be completed again or aborted.
+func (s *LocalStorage) CompleteUpload(_ context.Context, key, uploadID string, parts []CompletedPart) error {
+	upload, err := s.upload(key, uploadID)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("storage: complete upload %s: no parts", uploadID)
+	}
+	parts = slices.Clone(parts)
+	slices.SortFunc(parts, func(a, b CompletedPart) int { return a.PartNumber - b.PartNumber })
+	for i, p := range parts {
+		if i > 0 && parts[i-1].PartNumber == p.PartNumber {
+			return fmt.Errorf("storage: complete upload %s: part %d listed twice", uploadID, p.PartNumber)
+		}
+		etag, err := os.ReadFile(s.partPath(uploadID, p.PartNumber) + ".etag")
+		if err != nil || string(etag) != p.ETag {
+			return fmt.Errorf("storage: complete upload %s: part %d with ETag %q not uploaded", uploadID, p.PartNumber, p.ETag)
+		}
+	}
+
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := s.writeFile(p, func(w io.Writer) error {
+		for _, part := range parts {
+			if err := appendFile(w, s.partPath(uploadID, part.PartNumber)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("storage: complete upload %s: %w", uploadID, err)
+	}
+	if err := s.writeMeta(key, localObjectMeta{ContentType: upload.ContentType}); err != nil {
+		return fmt.Errorf("storage: complete upload %s: %w", uploadID, err)
+	}
+	return os.RemoveAll(s.internal("uploads", uploadID))
+}
+
+// AbortUpload implements MultipartStorage, removing the uploaded parts.
+func (s *LocalStorage) AbortUpload(_ context.Context, key, uploadID string) error {
+	if _, err := s.upload(key, uploadID); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(s.internal("uploads", uploadID)); err != nil {
+		return fmt.Errorf("storage: abort upload %s: %w", uploadID, err)
+	}
+	return nil
+}
+
+// upload returns the manifest of uploadID, checking it belongs to key.
+func (s *LocalStorage) upload(key, uploadID string) (localUpload, error) {
+	var upload localUpload
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return upload, fmt.Errorf("%w: %q", ErrUploadNotFound, uploadID)
+	}
+	data, err := os.ReadFile(s.internal("uploads", uploadID, "upload.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return upload, fmt.Errorf("%w: %q", ErrUploadNotFound, uploadID)
+	}
+	if err != nil {
+		return upload, fmt.Errorf("storage: upload %s: %w", uploadID, err)
+	}
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return upload, fmt.Errorf("storage: upload %s: %w", uploadID, err)
+	}
+	if upload.Key != key {
+		return upload, fmt.Errorf("%w: %q is not an upload of %q", ErrUploadNotFound, uploadID, key)
+	}
+	return upload, nil
+}
+
+func (s *LocalStorage) partPath(uploadID string, partNum int) string {
+	return s.internal("uploads", uploadID, fmt.Sprintf("part-%05d", partNum))
+}
+
+func appendFile(w io.Writer, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/slice-soft/ss-keel-core/keeltest"
+)
+
+func uploadsLeft(t *testing.T, s *LocalStorage) int {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(s.Root(), ".keel", "uploads"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestLocalStorageMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+
+	id, err := s.InitiateUpload(ctx, "videos/intro.mp4", "video/mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []CompletedPart
+	for _, p := range []struct {
+		num  int
+		data string
+	}{{3, "ccc"}, {1, "aaa"}, {2, "xxx"}, {2, "bbb"}} {
+		etag, err := s.UploadPart(ctx, "videos/intro.mp4", id, p.num, strings.NewReader(p.data), int64(len(p.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.data != "xxx" {
+			parts = append(parts, CompletedPart{PartNumber: p.num, ETag: etag})
+		}
+	}
+
+	if _, err := s.UploadPart(ctx, "videos/other.mp4", id, 4, strings.NewReader("d"), 1); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("UploadPart for another key = %v, want ErrUploadNotFound", err)
+	}
+	if _, err := s.UploadPart(ctx, "videos/intro.mp4", "../../etc", 1, strings.NewReader("d"), 1); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("UploadPart with a path as upload ID = %v, want ErrUploadNotFound", err)
+	}
+	if _, err := s.UploadPart(ctx, "videos/intro.mp4", id, 0, strings.NewReader("d"), 1); err == nil {
+		t.Error("UploadPart accepted part number 0")
+	}
+	stale := append([]CompletedPart{}, parts...)
+	stale[2].ETag = "stale"
+	if err := s.CompleteUpload(ctx, "videos/intro.mp4", id, stale); err == nil {
+		t.Fatal("CompleteUpload accepted a wrong ETag")
+	}
+
+	if err := s.CompleteUpload(ctx, "videos/intro.mp4", id, parts); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject(t, s, "videos/intro.mp4"); got != "aaabbbccc" {
+		t.Errorf("object = %q, want parts in part number order", got)
+	}
+	if obj, _ := s.Stat(ctx, "videos/intro.mp4"); obj.ContentType != "video/mp4" || obj.Size != 9 {
+		t.Errorf("Stat = %+v", obj)
+	}
+	if n := uploadsLeft(t, s); n != 0 {
+		t.Errorf("%d upload(s) left after completion", n)
+	}
+	if err := s.CompleteUpload(ctx, "videos/intro.mp4", id, parts); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("completing twice = %v, want ErrUploadNotFound", err)
+	}
+}
+
+func TestLocalStorageAbortUpload(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	id, _ := s.InitiateUpload(ctx, "big.bin", "")
+	s.UploadPart(ctx, "big.bin", id, 1, strings.NewReader("part"), 4) //nolint
+
+	if err := s.AbortUpload(ctx, "big.bin", id); err != nil {
+		t.Fatal(err)
+	}
+	if n := uploadsLeft(t, s); n != 0 {
+		t.Errorf("%d upload(s) left after abort", n)
+	}
+	if ok, _ := s.Exists(ctx, "big.bin"); ok {
+		t.Error("aborted upload created the object")
+	}
+	if _, err := s.UploadPart(ctx, "big.bin", id, 2, strings.NewReader("x"), 1); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("UploadPart after abort = %v, want ErrUploadNotFound", err)
+	}
+}
+
+// partCounter counts the parts uploaded through it.
+type partCounter struct {
+	*LocalStorage
+	parts int
+}
+
+func (p *partCounter) UploadPart(ctx context.Context, key, uploadID string, partNum int, r io.Reader, size int64) (string, error) {
+	p.parts++
+	return p.LocalStorage.UploadPart(ctx, key, uploadID, partNum, r, size)
+}
+
+func TestPutStreaming(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("multipart", func(t *testing.T) {
+		for _, tt := range []struct {
+			content string
+			parts   int
+		}{{"0123456789", 3}, {"01234567", 2}, {"", 1}} {
+			s := &partCounter{LocalStorage: newTestLocalStorage(t)}
+			if err := PutStreaming(ctx, s, "export.csv", strings.NewReader(tt.content), "text/csv", 4); err != nil {
+				t.Fatal(err)
+			}
+			if got := readObject(t, s.LocalStorage, "export.csv"); got != tt.content || s.parts != tt.parts {
+				t.Errorf("content %q in %d part(s), want %q in %d", got, s.parts, tt.content, tt.parts)
+			}
+		}
+	})
+
+	t.Run("read failure aborts", func(t *testing.T) {
+		s := newTestLocalStorage(t)
+		r := io.MultiReader(strings.NewReader("01234567"), iotest.ErrReader(errors.New("disk gone")))
+		if err := PutStreaming(ctx, s, "export.csv", r, "text/csv", 4); err == nil || !strings.Contains(err.Error(), "disk gone") {
+			t.Fatalf("PutStreaming = %v, want the read error", err)
+		}
+		if n := uploadsLeft(t, s); n != 0 {
+			t.Errorf("%d upload(s) left after a failed PutStreaming", n)
+		}
+		if ok, _ := s.Exists(ctx, "export.csv"); ok {
+			t.Error("failed PutStreaming created the object")
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		s := keeltest.NewMemoryStorage()
+		if err := PutStreaming(ctx, s, "export.csv", strings.NewReader("a,b\n1,2\n"), "text/csv", 0); err != nil {
+			t.Fatal(err)
+		}
+		if obj, _ := s.Stat(ctx, "export.csv"); string(s.Content("export.csv")) != "a,b\n1,2\n" || obj.ContentType != "text/csv" || obj.Size != 8 {
+			t.Errorf("object %+v content %q", obj, s.Content("export.csv"))
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T, opts ...LocalStorageOption) *LocalStorage {
+	t.Helper()
+	s, err := NewLocalStorage(filepath.Join(t.TempDir(), "uploads"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func readObject(t *testing.T, s *LocalStorage, key string) string {
+	t.Helper()
+	r, err := s.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLocalStorageRejectsInvalidKeys(t *testing.T) {
+	s := newTestLocalStorage(t)
+	ctx := context.Background()
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a/./b", "a//b", "a/", `a\..\b`, ".keel/meta/x", ".keel"} {
+		t.Run(key, func(t *testing.T) {
+			if err := s.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidStorageKey) {
+				t.Errorf("Put(%q) = %v, want ErrInvalidStorageKey", key, err)
+			}
+			if _, err := s.Get(ctx, key); !errors.Is(err, ErrInvalidStorageKey) {
+				t.Errorf("Get(%q) = %v, want ErrInvalidStorageKey", key, err)
+			}
+			if err := s.Delete(ctx, key); !errors.Is(err, ErrInvalidStorageKey) {
+				t.Errorf("Delete(%q) = %v, want ErrInvalidStorageKey", key, err)
+			}
+		})
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(s.Root()), "secret")); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("a file was written outside the root")
+	}
+}
+
+func TestLocalStoragePutGetDelete(t *testing.T) {
+	s := newTestLocalStorage(t, WithStoragePermissions(0o600, 0o700))
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "invoices/2024/a.pdf", strings.NewReader("first"), 5, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "invoices/2024/a.pdf", strings.NewReader("second"), 6, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject(t, s, "invoices/2024/a.pdf"); got != "second" {
+		t.Errorf("content after overwrite = %q", got)
+	}
+	if err := s.Put(ctx, "invoices/2024/a.pdf", strings.NewReader("short"), 10, ""); err == nil {
+		t.Error("Put with a wrong size should fail")
+	}
+	if got := readObject(t, s, "invoices/2024/a.pdf"); got != "second" {
+		t.Errorf("failed Put replaced the object with %q", got)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(s.Root(), ".keel", "tmp")); len(tmp) != 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	info, err := os.Stat(filepath.Join(s.Root(), "invoices", "2024", "a.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if dir, _ := os.Stat(filepath.Join(s.Root(), "invoices")); dir.Mode().Perm() != 0o700 {
+		t.Errorf("dir mode = %v, want 0700", dir.Mode().Perm())
+	}
+
+	if _, err := s.Get(ctx, "invoices"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get of a directory = %v, want fs.ErrNotExist", err)
+	}
+	if err := s.Delete(ctx, "invoices/2024/a.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "invoices/2024/a.pdf"); err != nil {
+		t.Errorf("deleting a missing key = %v", err)
+	}
+	if _, err := s.Get(ctx, "invoices/2024/a.pdf"); !errors.Is(err, fs.ErrNotExist) || strings.Contains(err.Error(), s.Root()) {
+		t.Errorf("Get after Delete = %v, want fs.ErrNotExist without the file path", err)
+	}
+}
+
+func TestLocalStorageStat(t *testing.T) {
+	s := newTestLocalStorage(t)
+	ctx := context.Background()
+	before := time.Now().Add(-time.Second)
+	s.Put(ctx, "explicit.bin", strings.NewReader("{}"), -1, "application/vnd.keel+json") //nolint
+	s.Put(ctx, "photo.png", strings.NewReader("not really"), -1, "")                     //nolint
+	s.Put(ctx, "noext", strings.NewReader("<html><body>hi</body></html>"), -1, "")       //nolint
+
+	tests := []struct {
+		key         string
+		size        int64
+		contentType string
+	}{
+		{"explicit.bin", 2, "application/vnd.keel+json"},
+		{"photo.png", 10, "image/png"},
+		{"noext", 28, "text/html; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		obj, err := s.Stat(ctx, tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if obj.Key != tt.key || obj.Size != tt.size || obj.ContentType != tt.contentType {
+			t.Errorf("Stat(%s) = %+v, want size %d type %q", tt.key, obj, tt.size, tt.contentType)
+		}
+		if obj.LastModified.Before(before) || obj.LastModified.After(time.Now().Add(time.Second)) {
+			t.Errorf("Stat(%s).LastModified = %v", tt.key, obj.LastModified)
+		}
+	}
+
+	// Overwriting without a content type drops the stored one.
+	s.Put(ctx, "explicit.bin", strings.NewReader("\x00\x01"), -1, "") //nolint
+	if obj, _ := s.Stat(ctx, "explicit.bin"); obj.ContentType != "application/octet-stream" {
+		t.Errorf("content type after overwrite = %q", obj.ContentType)
+	}
+	if _, err := s.Stat(ctx, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(missing) = %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	ctx := context.Background()
+	local := newTestLocalStorage(t)
+	local.Put(ctx, "docs/a b.txt", strings.NewReader("x"), 1, "") //nolint
+	if got, err := local.URL(ctx, "docs/a b.txt", time.Hour); err != nil || got != "file://"+filepath.ToSlash(local.Root())+"/docs/a%20b.txt" {
+		t.Errorf("URL = %q, %v", got, err)
+	}
+
+	public := newTestLocalStorage(t, WithStorageBaseURL("https://cdn.example.com/files/"))
+	public.Put(ctx, "docs/a b.txt", strings.NewReader("x"), 1, "") //nolint
+	if got, err := public.URL(ctx, "docs/a b.txt", time.Hour); err != nil || got != "https://cdn.example.com/files/docs/a%20b.txt" {
+		t.Errorf("URL = %q, %v", got, err)
+	}
+	if _, err := public.URL(ctx, "missing", time.Hour); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("URL(missing) = %v, want fs.ErrNotExist", err)
+	}
+}
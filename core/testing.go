@@ -1,20 +1,136 @@
 package core
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
 // TestApp wraps App for use in unit tests.
 // It uses Fiber's built-in test helper so no port binding is needed.
 type TestApp struct {
 	*App
+	user any // stored for every request; see WithUser
+	logs testLogs
+}
+
+// testLogs collects the entries logged by a TestApp.
+type testLogs struct {
+	mu      sync.Mutex
+	entries []logger.LogEntry
+}
+
+func (l *testLogs) add(e logger.LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
 }
 
 // NewTestApp creates a minimal App suitable for controller testing.
 func NewTestApp() *TestApp {
-	cfg := applyDefaults(KConfig{DisableHealth: true})
-	return &TestApp{App: New(cfg)}
+	return buildTestApp(applyDefaults(KConfig{DisableHealth: true}))
+}
+
+func buildTestApp(cfg KConfig) *TestApp {
+	t := &TestApp{App: New(cfg)}
+	// Logs are captured rather than printed; SetLogger makes them visible.
+	log := logger.NewLoggerWithFormat(false, logger.LogFormatJSON).WithWriter(io.Discard)
+	log.RedactKeys(t.config.Logging.redactKeys()...)
+	t.SetLogger(log)
+	// Installed before any route so WithUser covers routes registered
+	// before and after it.
+	t.fiber.Use(func(c *fiber.Ctx) error {
+		if t.user != nil {
+			(&httpx.Ctx{Ctx: c}).SetUser(t.user)
+		}
+		return c.Next()
+	})
+	return t
+}
+
+// NewTestAppWith creates a TestApp from cfg with routes already registered,
+// ready for requests:
+//
+//	app := core.NewTestAppWith(core.KConfig{ServiceName: "users"}, httpx.GET("/users", list))
+//
+// Health routes are off, as in NewTestApp, whatever cfg.DisableHealth says;
+// call WithHealth to register them.
+func NewTestAppWith(cfg KConfig, routes ...httpx.Route) *TestApp {
+	cfg.DisableHealth = true
+	return buildTestApp(cfg).AddRoutes(routes...)
+}
+
+// WithUser makes every following request authenticated as user, as if a
+// guard had stored it with Ctx.SetUser, so httpx.UserAs works in handlers.
+// WithUser(nil) makes requests anonymous again.
+func (t *TestApp) WithUser(user any) *TestApp {
+	t.user = user
+	return t
+}
+
+// SetLogger replaces the app logger, e.g. with one writing to os.Stdout to
+// see the output, and keeps capturing entries for Logs.
+func (t *TestApp) SetLogger(l *logger.Logger) {
+	l.AddHook(t.logs.add)
+	t.App.SetLogger(l)
+}
+
+// Logs returns the entries logged so far, including access-log lines.
+func (t *TestApp) Logs() []logger.LogEntry {
+	t.logs.mu.Lock()
+	defer t.logs.mu.Unlock()
+	return append([]logger.LogEntry(nil), t.logs.entries...)
+}
+
+// ResetLogs discards the captured entries.
+func (t *TestApp) ResetLogs() {
+	t.logs.mu.Lock()
+	defer t.logs.mu.Unlock()
+	t.logs.entries = nil
+}
+
+// AssertLogged checks that an entry at level contains substr in its message.
+func (t *TestApp) AssertLogged(tt TestingT, level logger.LogLevel, substr string) {
+	tt.Helper()
+	var messages []string
+	for _, e := range t.Logs() {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			return
+		}
+		messages = append(messages, fmt.Sprintf("[%s] %s", e.Level, e.Message))
+	}
+	tt.Errorf("no %s entry contains %q; logged:\n%s", level, substr, strings.Join(messages, "\n"))
+}
+
+// AddRoutes registers routes on the app.
+func (t *TestApp) AddRoutes(routes ...httpx.Route) *TestApp {
+	t.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route { return routes }))
+	return t
+}
+
+// UseModules registers modules on the app, in order.
+func (t *TestApp) UseModules(modules ...contracts.Module[*App]) *TestApp {
+	for _, m := range modules {
+		t.Use(m)
+	}
+	return t
+}
+
+// WithHealth registers the health routes left out by NewTestApp and
+// NewTestAppWith, using the app config.
+func (t *TestApp) WithHealth() *TestApp {
+	t.registerHealth()
+	return t
 }
 
 // Request performs an HTTP request against the app without starting a real server.
@@ -42,3 +158,230 @@ func (t *TestApp) RequestJSON(method, path string, body io.Reader) *http.Respons
 		"Content-Type": "application/json",
 	})
 }
+
+// TestResponse is a fully read response returned by the TestApp helpers.
+type TestResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// JSON decodes the response body into v.
+func (r *TestResponse) JSON(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// JSONAs decodes the body of r as a T.
+//
+//	user, err := core.JSONAs[UserDTO](app.GET("/users/1", nil))
+func JSONAs[T any](r *TestResponse) (T, error) {
+	var v T
+	err := r.JSON(&v)
+	return v, err
+}
+
+// DecodeJSON decodes and closes the body of resp as a T.
+func DecodeJSON[T any](resp *http.Response) (T, error) {
+	defer resp.Body.Close()
+	var v T
+	err := json.NewDecoder(resp.Body).Decode(&v)
+	return v, err
+}
+
+// GET performs a GET request; see Do.
+func (t *TestApp) GET(path string, body any) *TestResponse { return t.Do("GET", path, body) }
+
+// POST performs a POST request; see Do.
+func (t *TestApp) POST(path string, body any) *TestResponse { return t.Do("POST", path, body) }
+
+// PUT performs a PUT request; see Do.
+func (t *TestApp) PUT(path string, body any) *TestResponse { return t.Do("PUT", path, body) }
+
+// PATCH performs a PATCH request; see Do.
+func (t *TestApp) PATCH(path string, body any) *TestResponse { return t.Do("PATCH", path, body) }
+
+// DELETE performs a DELETE request; see Do.
+func (t *TestApp) DELETE(path string, body any) *TestResponse { return t.Do("DELETE", path, body) }
+
+// Do performs a request and reads the whole response. A nil body sends
+// none; a string, []byte or io.Reader is sent as is with a JSON content
+// type; any other value is marshaled to JSON. It panics on transport
+// errors, like Request.
+func (t *TestApp) Do(method, path string, body any) *TestResponse {
+	return t.NewRequest(method, path).JSON(body).Send()
+}
+
+// jsonBody returns the request body for a Do body argument.
+func jsonBody(body any) (io.Reader, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.NewReader(b), nil
+	case []byte:
+		return bytes.NewReader(b), nil
+	case io.Reader:
+		return b, nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal test request body: %w", err)
+	}
+	return bytes.NewReader(data), nil
+}
+
+func readTestResponse(resp *http.Response) *TestResponse {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		panic(err)
+	}
+	return &TestResponse{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+}
+
+// TestingT is the subset of *testing.T used by the TestResponse assertions.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// maxAssertBody caps the response body printed by a failed assertion.
+const maxAssertBody = 512
+
+// AssertStatus checks the status code.
+func (r *TestResponse) AssertStatus(t TestingT, want int) *TestResponse {
+	t.Helper()
+	if r.StatusCode != want {
+		t.Errorf("status = %d, want %d\n%s", r.StatusCode, want, r.bodyForError())
+	}
+	return r
+}
+
+// AssertHeader checks that header name has the value want.
+func (r *TestResponse) AssertHeader(t TestingT, name, want string) *TestResponse {
+	t.Helper()
+	if got := r.Header.Get(name); got != want {
+		t.Errorf("header %s = %q, want %q\n%s", name, got, want, r.bodyForError())
+	}
+	return r
+}
+
+// AssertHeaderPresent checks that header name is set.
+func (r *TestResponse) AssertHeaderPresent(t TestingT, name string) *TestResponse {
+	t.Helper()
+	if _, ok := r.Header[http.CanonicalHeaderKey(name)]; !ok {
+		t.Errorf("header %s missing\n%s", name, r.bodyForError())
+	}
+	return r
+}
+
+// AssertBodyContains checks that the body contains substr.
+func (r *TestResponse) AssertBodyContains(t TestingT, substr string) *TestResponse {
+	t.Helper()
+	if !bytes.Contains(r.Body, []byte(substr)) {
+		t.Errorf("body does not contain %q\n%s", substr, r.bodyForError())
+	}
+	return r
+}
+
+// AssertJSONPath checks the JSON value at path, written with dots and
+// brackets as in "data.items[0].id". want is compared as JSON, so 1 matches
+// the number 1 in the body; a func(any) bool is called with the value
+// instead.
+func (r *TestResponse) AssertJSONPath(t TestingT, path string, want any) *TestResponse {
+	t.Helper()
+	got, err := r.jsonPath(path)
+	if err != nil {
+		t.Errorf("JSON path %s: %v\n%s", path, err, r.bodyForError())
+		return r
+	}
+	if match, ok := want.(func(any) bool); ok {
+		if !match(got) {
+			t.Errorf("JSON path %s = %v, does not match\n%s", path, got, r.bodyForError())
+		}
+		return r
+	}
+	wantJSON, err := json.Marshal(want)
+	if err != nil {
+		t.Errorf("JSON path %s: cannot marshal want: %v", path, err)
+		return r
+	}
+	if gotJSON, _ := json.Marshal(got); !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("JSON path %s = %s, want %s\n%s", path, gotJSON, wantJSON, r.bodyForError())
+	}
+	return r
+}
+
+// AssertJSONPathExists checks that path is present in the JSON body.
+func (r *TestResponse) AssertJSONPathExists(t TestingT, path string) *TestResponse {
+	t.Helper()
+	if _, err := r.jsonPath(path); err != nil {
+		t.Errorf("JSON path %s: %v\n%s", path, err, r.bodyForError())
+	}
+	return r
+}
+
+// jsonPath returns the value at path in the decoded body.
+func (r *TestResponse) jsonPath(path string) (any, error) {
+	var v any
+	if err := json.Unmarshal(r.Body, &v); err != nil {
+		return nil, fmt.Errorf("body is not JSON: %w", err)
+	}
+	steps, err := splitJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, step := range steps {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[step]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", strings.Join(steps[:i+1], "."))
+			}
+			v = next
+		case []any:
+			idx, err := strconv.Atoi(step)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, fmt.Errorf("index %s out of range at %q", step, strings.Join(steps[:i], "."))
+			}
+			v = node[idx]
+		default:
+			return nil, fmt.Errorf("%q is not an object or array", strings.Join(steps[:i], "."))
+		}
+	}
+	return v, nil
+}
+
+// splitJSONPath splits "a.b[0].c" into "a", "b", "0", "c".
+func splitJSONPath(path string) ([]string, error) {
+	var steps []string
+	for _, part := range strings.Split(path, ".") {
+		name, rest, indexed := strings.Cut(part, "[")
+		if name != "" {
+			steps = append(steps, name)
+		}
+		if indexed && rest == "" {
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+		for rest != "" {
+			idx, after, ok := strings.Cut(rest, "]")
+			if !ok || idx == "" {
+				return nil, fmt.Errorf("invalid JSON path %q", path)
+			}
+			steps = append(steps, idx)
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("invalid JSON path %q", path)
+	}
+	return steps, nil
+}
+
+// bodyForError returns the body for a failure message, truncated.
+func (r *TestResponse) bodyForError() string {
+	if len(r.Body) > maxAssertBody {
+		return fmt.Sprintf("body (%d bytes): %s…", len(r.Body), r.Body[:maxAssertBody])
+	}
+	return fmt.Sprintf("body: %s", r.Body)
+}
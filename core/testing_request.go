@@ -0,0 +1,172 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestRequest builds a request against a TestApp:
+//
+//	res := app.NewRequest("GET", "/users").
+//		Query("page", "2").
+//		Bearer(token).
+//		Header("X-Tenant", "acme").
+//		Send()
+type TestRequest struct {
+	app    *TestApp
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   io.Reader
+	form   []formPart
+	err    error
+}
+
+// formPart is a multipart field or, when filename is set, a file.
+type formPart struct {
+	name     string
+	filename string
+	content  []byte
+}
+
+// NewRequest starts building a request for method and path.
+func (t *TestApp) NewRequest(method, path string) *TestRequest {
+	return &TestRequest{app: t, method: method, path: path, query: url.Values{}, header: http.Header{}}
+}
+
+// Query adds a query parameter. Values are URL-encoded and appended to any
+// query already in the path.
+func (r *TestRequest) Query(key, value string) *TestRequest {
+	r.query.Add(key, value)
+	return r
+}
+
+// Header adds a header value; repeated calls for one name send every value.
+func (r *TestRequest) Header(key, value string) *TestRequest {
+	r.header.Add(key, value)
+	return r
+}
+
+// Bearer sets the Authorization header to a bearer token.
+func (r *TestRequest) Bearer(token string) *TestRequest {
+	r.header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	return r
+}
+
+// JSON sets the request body with a JSON content type. body is handled as
+// by TestApp.Do; nil leaves the request without a body.
+func (r *TestRequest) JSON(body any) *TestRequest {
+	reader, err := jsonBody(body)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	if reader != nil {
+		r.body = reader
+		r.header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	return r
+}
+
+// FormField adds a multipart/form-data field. Together with File it
+// replaces any JSON body.
+func (r *TestRequest) FormField(name, value string) *TestRequest {
+	r.form = append(r.form, formPart{name: name, content: []byte(value)})
+	return r
+}
+
+// File adds a file part to a multipart/form-data body, readable in handlers
+// with FormFile or MultipartForm. Repeated calls for one field send several
+// files.
+func (r *TestRequest) File(field, filename string, content []byte) *TestRequest {
+	r.form = append(r.form, formPart{name: field, filename: filename, content: content})
+	return r
+}
+
+// multipartBody encodes the form parts and sets the matching content type.
+func (r *TestRequest) multipartBody() (io.Reader, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range r.form {
+		var (
+			part io.Writer
+			err  error
+		)
+		if p.filename != "" {
+			part, err = w.CreateFormFile(p.name, p.filename)
+		} else {
+			part, err = w.CreateFormField(p.name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(p.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	r.header.Set(fiber.HeaderContentType, w.FormDataContentType())
+	return &buf, nil
+}
+
+// Send performs the request and reads the response. It panics on errors
+// building or performing the request, like TestApp.Request.
+func (r *TestRequest) Send() *TestResponse {
+	if r.err != nil {
+		panic(r.err)
+	}
+	target := r.path
+	if len(r.query) > 0 {
+		u, err := url.Parse(r.path)
+		if err != nil {
+			panic(err)
+		}
+		q := u.Query()
+		for k, vs := range r.query {
+			q[k] = append(q[k], vs...)
+		}
+		u.RawQuery = q.Encode()
+		target = u.String()
+	}
+	body := r.body
+	if len(r.form) > 0 {
+		var err error
+		if body, err = r.multipartBody(); err != nil {
+			panic(err)
+		}
+	}
+	req, err := http.NewRequest(r.method, target, body)
+	if err != nil {
+		panic(err)
+	}
+	req.Header = r.header
+	resp, err := r.app.fiber.Test(req, -1)
+	if err != nil {
+		panic(err)
+	}
+	return readTestResponse(resp)
+}
+
+// Upload POSTs a multipart/form-data request with one file per files entry,
+// named after its form field, and the given form fields. Use NewRequest with
+// File for custom file names or several files per field.
+func (t *TestApp) Upload(path string, files map[string][]byte, fields map[string]string) *TestResponse {
+	r := t.NewRequest(fiber.MethodPost, path)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		r.FormField(name, fields[name])
+	}
+	for _, field := range slices.Sorted(maps.Keys(files)) {
+		r.File(field, field, files[field])
+	}
+	return r.Send()
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+)
+
+func newEchoTestApp() *TestApp {
+	return NewTestAppWith(KConfig{},
+		httpx.GET("/echo", func(c *httpx.Ctx) error {
+			return c.OK(map[string]any{
+				"q":        c.Query("q"),
+				"page":     c.Query("page"),
+				"raw":      string(c.Request().URI().QueryString()),
+				"auth":     c.Get("Authorization"),
+				"tenants":  c.GetReqHeaders()["X-Tenant"],
+				"has_body": len(c.Body()) > 0,
+			})
+		}),
+		httpx.POST("/echo", func(c *httpx.Ctx) error {
+			return c.OK(map[string]any{"type": c.Get("Content-Type"), "body": string(c.Body())})
+		}),
+	)
+}
+
+func TestTestRequestBuilder(t *testing.T) {
+	app := newEchoTestApp()
+
+	app.NewRequest("GET", "/echo?page=1").
+		Query("q", "a b&c=d/é?").
+		Query("page", "2").
+		Bearer("tok").
+		Header("X-Tenant", "acme").
+		Header("X-Tenant", "globex").
+		Send().
+		AssertStatus(t, http.StatusOK).
+		AssertJSONPath(t, "q", "a b&c=d/é?").
+		AssertJSONPath(t, "page", "1").
+		AssertJSONPath(t, "raw", "page=1&page=2&q=a+b%26c%3Dd%2F%C3%A9%3F").
+		AssertJSONPath(t, "auth", "Bearer tok").
+		AssertJSONPath(t, "tenants", []string{"acme", "globex"}).
+		AssertJSONPath(t, "has_body", false)
+
+	app.NewRequest("POST", "/echo").
+		JSON(map[string]int{"n": 1}).
+		Send().
+		AssertJSONPath(t, "type", "application/json").
+		AssertJSONPath(t, "body", `{"n":1}`)
+}
+
+func TestTestRequestSendPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(error).Error(), "marshal test request body") {
+			t.Errorf("recovered %v, want a marshal error", r)
+		}
+	}()
+	newEchoTestApp().NewRequest("POST", "/echo").JSON(make(chan int)).Send()
+}
+
+func newUploadTestApp() *TestApp {
+	return NewTestAppWith(KConfig{},
+		httpx.POST("/upload", func(c *httpx.Ctx) error {
+			form, err := c.MultipartForm()
+			if err != nil {
+				return BadRequest(err.Error())
+			}
+			files := map[string][]string{}
+			for field, headers := range form.File {
+				for _, h := range headers {
+					f, err := h.Open()
+					if err != nil {
+						return err
+					}
+					content, _ := io.ReadAll(f)
+					f.Close()
+					files[field] = append(files[field], h.Filename+"="+string(content))
+				}
+			}
+			avatar, err := c.FormFile("avatar")
+			if err != nil {
+				return BadRequest(err.Error())
+			}
+			return c.OK(map[string]any{
+				"title":  c.FormValue("title"),
+				"tags":   form.Value["tag"],
+				"files":  files,
+				"avatar": avatar.Filename,
+			})
+		}),
+	)
+}
+
+func TestTestRequestMultipart(t *testing.T) {
+	app := newUploadTestApp()
+
+	app.NewRequest("POST", "/upload").
+		FormField("title", "Holiday").
+		FormField("tag", "beach").
+		FormField("tag", "2024").
+		File("avatar", "me.png", []byte("png-bytes")).
+		File("photos", "a.jpg", []byte("aaa")).
+		File("photos", "b.jpg", []byte("bbb")).
+		Send().
+		AssertStatus(t, http.StatusOK).
+		AssertJSONPath(t, "title", "Holiday").
+		AssertJSONPath(t, "tags", []string{"beach", "2024"}).
+		AssertJSONPath(t, "avatar", "me.png").
+		AssertJSONPath(t, "files.avatar", []string{"me.png=png-bytes"}).
+		AssertJSONPath(t, "files.photos", []string{"a.jpg=aaa", "b.jpg=bbb"})
+
+	app.Upload("/upload",
+		map[string][]byte{"avatar": []byte("png-bytes"), "cv": []byte("%PDF")},
+		map[string]string{"title": "Profile"}).
+		AssertStatus(t, http.StatusOK).
+		AssertJSONPath(t, "title", "Profile").
+		AssertJSONPath(t, "files.avatar", []string{"avatar=png-bytes"}).
+		AssertJSONPath(t, "files.cv", []string{"cv=%PDF"})
+
+	app.Upload("/upload", nil, map[string]string{"title": "No file"}).
+		AssertStatus(t, http.StatusBadRequest)
+}
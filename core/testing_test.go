@@ -1,13 +1,15 @@
 package core
 
 import (
-	"encoding/json"
+	"bytes"
+	"fmt"
 	"net/http"
 	"strings"
 	"testing"
 
 	"github.com/slice-soft/ss-keel-core/contracts"
 	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
 )
 
 func TestNewTestApp(t *testing.T) {
@@ -57,11 +59,211 @@ func TestTestAppRequestHelpers(t *testing.T) {
 		t.Fatalf("RequestJSON() status = %d, want %d", resp.StatusCode, http.StatusOK)
 	}
 
-	var out bodyDTO
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	out, err := DecodeJSON[bodyDTO](resp)
+	if err != nil {
 		t.Fatal(err)
 	}
 	if out.Name != "ana" {
 		t.Fatalf("decoded body = %+v, want name=ana", out)
 	}
 }
+
+func TestTestAppJSONHelpers(t *testing.T) {
+	type userDTO struct {
+		ID   int    `json:"id"`
+		Name string `json:"name" validate:"required"`
+	}
+
+	app := NewTestApp()
+	app.RegisterController(contracts.ControllerFunc[httpx.Route](func() []httpx.Route {
+		return []httpx.Route{
+			httpx.GET("/users/:id", func(c *httpx.Ctx) error {
+				c.Set("X-Source", "test")
+				return c.OK(userDTO{ID: 1, Name: "ana"})
+			}),
+			httpx.POST("/users", func(c *httpx.Ctx) error {
+				var in userDTO
+				if err := c.ParseBody(&in); err != nil {
+					return err
+				}
+				in.ID = 2
+				return c.Created(in)
+			}),
+			httpx.PUT("/users/:id", func(c *httpx.Ctx) error { return c.OK(userDTO{ID: 1, Name: "put"}) }),
+			httpx.DELETE("/users/:id", func(c *httpx.Ctx) error { return c.NoContent() }),
+		}
+	}))
+
+	res := app.GET("/users/1", nil)
+	user, err := JSONAs[userDTO](res)
+	if err != nil || res.StatusCode != http.StatusOK || user != (userDTO{ID: 1, Name: "ana"}) || res.Header.Get("X-Source") != "test" {
+		t.Errorf("GET = %d %s %v, decoded %+v", res.StatusCode, res.Body, err, user)
+	}
+
+	for name, body := range map[string]any{
+		"struct": userDTO{Name: "bea"},
+		"map":    map[string]string{"name": "bea"},
+		"string": `{"name":"bea"}`,
+		"bytes":  []byte(`{"name":"bea"}`),
+	} {
+		res := app.POST("/users", body)
+		var created userDTO
+		if err := res.JSON(&created); err != nil || res.StatusCode != http.StatusCreated || created != (userDTO{ID: 2, Name: "bea"}) {
+			t.Errorf("POST %s body = %d %s", name, res.StatusCode, res.Body)
+		}
+	}
+
+	if res := app.POST("/users", nil); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST nil body status = %d, want 400", res.StatusCode)
+	}
+	if res := app.PUT("/users/1", userDTO{Name: "put"}); res.StatusCode != http.StatusOK {
+		t.Errorf("PUT status = %d", res.StatusCode)
+	}
+	if res := app.DELETE("/users/1", nil); res.StatusCode != http.StatusNoContent || len(res.Body) != 0 {
+		t.Errorf("DELETE = %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// stubT records assertion failures instead of failing the test.
+type stubT struct {
+	failures []string
+}
+
+func (s *stubT) Helper() {}
+func (s *stubT) Errorf(format string, args ...any) {
+	s.failures = append(s.failures, fmt.Sprintf(format, args...))
+}
+
+func TestTestResponseAssertions(t *testing.T) {
+	res := &TestResponse{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Location": {"/users/123"}, "X-Empty": {""}},
+		Body:       []byte(`{"data":{"id":"123","tags":["a","b"],"items":[{"qty":2}]},"ok":true}`),
+	}
+	notEmpty := func(v any) bool { return v != nil && v != "" }
+
+	t.Run("passing", func(t *testing.T) {
+		res.AssertStatus(t, 201).
+			AssertHeader(t, "Location", "/users/123").
+			AssertHeaderPresent(t, "X-Empty").
+			AssertJSONPath(t, "data.id", notEmpty).
+			AssertJSONPath(t, "data.id", "123").
+			AssertJSONPath(t, "data.tags[1]", "b").
+			AssertJSONPath(t, "data.items[0].qty", 2).
+			AssertJSONPath(t, "data.tags", []string{"a", "b"}).
+			AssertJSONPath(t, "ok", true).
+			AssertJSONPathExists(t, "data.items[0]").
+			AssertBodyContains(t, `"ok":true`)
+	})
+
+	tests := []struct {
+		name   string
+		assert func(t TestingT)
+		want   string
+	}{
+		{"status", func(t TestingT) { res.AssertStatus(t, 200) }, "status = 201, want 200"},
+		{"header value", func(t TestingT) { res.AssertHeader(t, "Location", "/users/9") }, `header Location = "/users/123", want "/users/9"`},
+		{"header missing", func(t TestingT) { res.AssertHeaderPresent(t, "X-Missing") }, "header X-Missing missing"},
+		{"body", func(t TestingT) { res.AssertBodyContains(t, "nope") }, `body does not contain "nope"`},
+		{"path value", func(t TestingT) { res.AssertJSONPath(t, "data.items[0].qty", 3) }, "JSON path data.items[0].qty = 2, want 3"},
+		{"path matcher", func(t TestingT) { res.AssertJSONPath(t, "data.id", func(any) bool { return false }) }, "JSON path data.id = 123, does not match"},
+		{"path missing", func(t TestingT) { res.AssertJSONPathExists(t, "data.name") }, `"data.name" not found`},
+		{"index out of range", func(t TestingT) { res.AssertJSONPathExists(t, "data.tags[5]") }, `index 5 out of range at "data.tags"`},
+		{"bad path", func(t TestingT) { res.AssertJSONPathExists(t, "data.tags[") }, `invalid JSON path "data.tags["`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubT{}
+			tt.assert(stub)
+			if len(stub.failures) != 1 || !strings.Contains(stub.failures[0], tt.want) || !strings.Contains(stub.failures[0], `body: {"data"`) {
+				t.Errorf("failures = %q, want one containing %q and the body", stub.failures, tt.want)
+			}
+		})
+	}
+}
+
+func TestTestResponseAssertionTruncatesBody(t *testing.T) {
+	stub := &stubT{}
+	res := &TestResponse{StatusCode: 500, Body: []byte(strings.Repeat("x", 2000))}
+	res.AssertStatus(stub, 200)
+	if len(stub.failures) != 1 || !strings.Contains(stub.failures[0], "body (2000 bytes): ") || len(stub.failures[0]) > 600 {
+		t.Errorf("failure = %q", stub.failures)
+	}
+}
+
+func TestNewTestAppWith(t *testing.T) {
+	app := NewTestAppWith(KConfig{}, httpx.GET("/ping", func(c *httpx.Ctx) error { return c.OK("pong") }))
+	app.GET("/ping", nil).AssertStatus(t, http.StatusOK).AssertBodyContains(t, "pong")
+	app.GET("/health", nil).AssertStatus(t, http.StatusNotFound)
+
+	app.AddRoutes(httpx.GET("/later", func(c *httpx.Ctx) error { return c.NoContent() }))
+	app.GET("/later", nil).AssertStatus(t, http.StatusNoContent)
+}
+
+func TestNewTestAppWithConfigAndModules(t *testing.T) {
+	app := NewTestAppWith(KConfig{ServiceName: "users", DisableHealth: false}).
+		UseModules(providerModule{}).
+		WithHealth()
+
+	if svc, ok := Resolve[*userService](app.App); !ok || svc.name != "from module" {
+		t.Errorf("Resolve after UseModules = %+v, %v", svc, ok)
+	}
+	app.GET("/health", nil).
+		AssertStatus(t, http.StatusOK).
+		AssertJSONPath(t, "service", "users").
+		AssertJSONPath(t, "status", "UP")
+}
+
+func TestTestAppWithUser(t *testing.T) {
+	whoami := func(c *httpx.Ctx) error {
+		if u, ok := httpx.UserAs[BasicUser](c); ok {
+			return c.OK(u.Username)
+		}
+		return c.OK("anonymous")
+	}
+	app := NewTestAppWith(KConfig{}, httpx.GET("/before", whoami))
+
+	app.GET("/before", nil).AssertBodyContains(t, `"anonymous"`)
+
+	app.WithUser(BasicUser{Username: "ana"})
+	app.AddRoutes(httpx.GET("/after", whoami))
+	app.GET("/before", nil).AssertBodyContains(t, `"ana"`)
+	app.GET("/after", nil).AssertBodyContains(t, `"ana"`)
+
+	app.WithUser(nil)
+	app.GET("/after", nil).AssertBodyContains(t, `"anonymous"`)
+}
+
+func TestTestAppLogs(t *testing.T) {
+	app := NewTestApp()
+	app.AddRoutes(httpx.GET("/old", func(c *httpx.Ctx) error {
+		app.Logger().Warn("deprecated route %s used", c.Path())
+		return c.NoContent()
+	}))
+
+	app.GET("/old", nil)
+	app.GET("/old", nil)
+	app.AssertLogged(t, logger.LevelWarn, "deprecated route /old used")
+	app.AssertLogged(t, logger.LevelInfo, "HTTP GET /old")
+	if got := len(app.Logs()); got != 5 {
+		t.Errorf("captured %d entries, want 5 (route registration, 2 warnings, 2 access-log lines)", got)
+	}
+
+	stub := &stubT{}
+	app.AssertLogged(stub, logger.LevelError, "deprecated")
+	if len(stub.failures) != 1 || !strings.Contains(stub.failures[0], "[WARN] deprecated route /old used") {
+		t.Errorf("failures = %q, want one listing the logged entries", stub.failures)
+	}
+
+	app.ResetLogs()
+	if got := app.Logs(); len(got) != 0 {
+		t.Errorf("after ResetLogs got %d entries", len(got))
+	}
+
+	var buf bytes.Buffer
+	app.SetLogger(logger.NewLogger(false).WithWriter(&buf))
+	app.GET("/old", nil)
+	if !strings.Contains(buf.String(), "deprecated route /old used") || len(app.Logs()) != 2 {
+		t.Errorf("SetLogger output %q, captured %d entries", buf.String(), len(app.Logs()))
+	}
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Timeout returns a middleware that gives the handlers after it a deadline d
+// away, carried by c.UserContext(). When the deadline passes before they
+// return, or they return context.DeadlineExceeded, the request fails with a
+// 504 GATEWAY_TIMEOUT KError and anything they wrote is discarded, so a
+// handler that ignores the context cannot answer after the timeout.
+//
+// Handlers run on the request goroutine and must watch the context to stop
+// early. Nested timeouts compose: the shortest deadline wins.
+func Timeout(d time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		parent := c.UserContext()
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+		c.SetUserContext(parent)
+
+		var ke *KError
+		if errors.As(err, &ke) && ke.StatusCode == fiber.StatusGatewayTimeout {
+			// An inner Timeout already answered.
+			return err
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+			c.Response().ResetBody()
+			return GatewayTimeout("request timed out")
+		}
+		return err
+	}
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    fiber.Handler
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "completes under the deadline",
+			handler: func(c *fiber.Ctx) error {
+				return c.SendString("done")
+			},
+			wantStatus: 200,
+			wantBody:   "done",
+		},
+		{
+			name: "handler watching the context",
+			handler: func(c *fiber.Ctx) error {
+				<-c.UserContext().Done()
+				return c.UserContext().Err()
+			},
+			wantStatus: fiber.StatusGatewayTimeout,
+			wantBody:   "GATEWAY_TIMEOUT",
+		},
+		{
+			name: "late write is discarded",
+			handler: func(c *fiber.Ctx) error {
+				time.Sleep(50 * time.Millisecond)
+				return c.SendString("too late")
+			},
+			wantStatus: fiber.StatusGatewayTimeout,
+			wantBody:   "GATEWAY_TIMEOUT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{DisableHealth: true})
+			app.Fiber().Get("/", Timeout(20*time.Millisecond), tt.handler)
+
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if !strings.Contains(string(body), tt.wantBody) || (tt.wantStatus != 200 && strings.Contains(string(body), "too late")) {
+				t.Errorf("body = %s, want it to contain %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTimeoutNesting(t *testing.T) {
+	tests := []struct {
+		name         string
+		outer, inner time.Duration
+	}{
+		{"inner shorter", time.Hour, 20 * time.Millisecond},
+		{"outer shorter", 20 * time.Millisecond, time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{DisableHealth: true})
+			var remaining time.Duration
+			group := app.Fiber().Group("/reports", Timeout(tt.outer))
+			group.Get("/", Timeout(tt.inner), func(c *fiber.Ctx) error {
+				deadline, _ := c.UserContext().Deadline()
+				remaining = time.Until(deadline)
+				<-c.UserContext().Done()
+				return c.UserContext().Err()
+			})
+
+			start := time.Now()
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/reports/", nil), -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != fiber.StatusGatewayTimeout {
+				t.Errorf("status = %d, want 504", resp.StatusCode)
+			}
+			if remaining > time.Second || time.Since(start) > time.Second {
+				t.Errorf("deadline %v away, took %v; want the shortest deadline", remaining, time.Since(start))
+			}
+		})
+	}
+}
+
+func TestTimeoutRestoresContext(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	var after error
+	app.Fiber().Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		after = c.UserContext().Err()
+		return err
+	})
+	app.Fiber().Get("/", Timeout(time.Hour), func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	if _, err := app.Fiber().Test(httptest.NewRequest("GET", "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if after != nil {
+		t.Errorf("context seen after Timeout returned has error %v, want the original context", after)
+	}
+}
@@ -2,8 +2,29 @@ package core
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+const (
+	headerTraceParent   = "traceparent"
+	headerTraceState    = "tracestate"
+	headerTraceResponse = "traceresponse"
+	headerTraceID       = "X-Trace-ID"
+)
+
+// Fiber locals holding the trace and span IDs of the request, read by
+// httpx.Ctx.TraceID and SpanID.
+const (
+	traceIDLocal = "traceid"
+	spanIDLocal  = "spanid"
 )
 
 // noopTracer is the default tracer — performs no operations.
@@ -16,6 +37,206 @@ func (noopTracer) Start(ctx context.Context, _ string) (context.Context, contrac
 // noopSpan is a span that does nothing.
 type noopSpan struct{}
 
-func (noopSpan) SetAttribute(_ string, _ any) {}
-func (noopSpan) RecordError(_ error)          {}
-func (noopSpan) End()                         {}
+func (noopSpan) SetAttribute(_ string, _ any)               {}
+func (noopSpan) RecordError(_ error)                        {}
+func (noopSpan) End()                                       {}
+func (noopSpan) SetStatus(_ contracts.SpanStatus, _ string) {}
+func (noopSpan) AddEvent(_ string, _ map[string]any)        {}
+func (noopSpan) SetAttributes(_ map[string]any)             {}
+
+// SpanSetStatus sets the status of span when it implements
+// contracts.StatusSpan. Otherwise it does nothing; record failures with
+// RecordError, which every span supports.
+func SpanSetStatus(span contracts.Span, code contracts.SpanStatus, description string) {
+	if s, ok := span.(contracts.StatusSpan); ok {
+		s.SetStatus(code, description)
+	}
+}
+
+// SpanAddEvent adds a timestamped event to span when it implements
+// contracts.EventSpan. Otherwise the event is dropped.
+func SpanAddEvent(span contracts.Span, name string, attrs map[string]any) {
+	if s, ok := span.(contracts.EventSpan); ok {
+		s.AddEvent(name, attrs)
+	}
+}
+
+// SpanSetAttributes sets attrs on span in one call when it implements
+// contracts.AttributeBatchSpan, or one SetAttribute call per key, in key
+// order, otherwise.
+func SpanSetAttributes(span contracts.Span, attrs map[string]any) {
+	if s, ok := span.(contracts.AttributeBatchSpan); ok {
+		s.SetAttributes(attrs)
+		return
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		span.SetAttribute(k, attrs[k])
+	}
+}
+
+// tracingEnabled reports whether requests are traced: once SetTracer installs
+// a real tracer, or when KConfig.Tracing.Enabled forces it on.
+func (a *App) tracingEnabled() bool {
+	_, noop := a.tracer.(noopTracer)
+	return !noop || a.config.Tracing.Enabled
+}
+
+// tracingMiddleware wraps each request in a server span. The remote span
+// context from traceparent/tracestate is stored in the request context before
+// the span starts, and the span's context replaces the request context so
+// handlers can start children from c.UserContext(). The tracer is looked up
+// per request because SetTracer may run after New.
+func (a *App) tracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !a.tracingEnabled() {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		parent, remote := parseTraceParent(c.Get(headerTraceParent))
+		if remote {
+			parent.TraceState = c.Get(headerTraceState)
+			ctx = contracts.ContextWithTraceParent(ctx, parent)
+		}
+
+		method := c.Method()
+		ctx, span := a.tracer.Start(ctx, method)
+		traceID, spanID := spanIDs(span, parent, remote)
+		ctx = logger.ContextWithTrace(ctx, traceID, spanID)
+		c.SetUserContext(ctx)
+		c.Locals(traceIDLocal, traceID)
+		c.Locals(spanIDLocal, spanID)
+		c.Set(headerTraceID, traceID)
+		c.Set(headerTraceResponse, formatTraceParent(traceID, spanID, !remote || parent.Sampled))
+
+		err := c.Next()
+
+		status := resolveStatus(c, err)
+		attrs := map[string]any{
+			"http.method":      method,
+			"http.status_code": status,
+			"http.client_ip":   c.IP(),
+		}
+		if route, matched := matchedRoute(c, err); matched {
+			attrs["http.route"] = route
+			if n, ok := span.(contracts.SpanNamer); ok {
+				n.SetName(method + " " + route)
+			}
+		}
+		SpanSetAttributes(span, attrs)
+		if status >= fiber.StatusInternalServerError {
+			spanErr := err
+			if spanErr == nil {
+				spanErr = fmt.Errorf("HTTP %d", status)
+			}
+			span.RecordError(spanErr)
+			SpanSetStatus(span, contracts.SpanStatusError, spanErr.Error())
+		}
+		span.End()
+
+		return err
+	}
+}
+
+// traceID returns the trace ID stored by the tracing middleware, or "".
+func traceID(c *fiber.Ctx) string {
+	id, _ := c.Locals(traceIDLocal).(string)
+	return id
+}
+
+// spanIDs returns the IDs used to correlate the request with its trace. They
+// come from the span when it exposes them; otherwise the caller's trace ID is
+// kept, or a new one generated, with a fresh span ID.
+func spanIDs(span contracts.Span, parent contracts.TraceParent, remote bool) (traceID, spanID string) {
+	if s, ok := span.(contracts.SpanIdentifier); ok && s.TraceID() != "" {
+		return s.TraceID(), s.SpanID()
+	}
+	traceID = parent.TraceID
+	if !remote {
+		traceID = randomHex(16)
+	}
+	return traceID, randomHex(8)
+}
+
+// traceHeaders returns the traceparent and tracestate values propagating
+// the trace in ctx to a downstream call. span is the parent when it exposes
+// its IDs; otherwise the span stored by the tracing middleware is.
+// traceParent is empty outside a trace.
+func traceHeaders(ctx context.Context, span contracts.Span) (traceParent, traceState string) {
+	var traceID, spanID string
+	if s, ok := span.(contracts.SpanIdentifier); ok {
+		traceID, spanID = s.TraceID(), s.SpanID()
+	}
+	if traceID == "" {
+		traceID, spanID = logger.TraceFromContext(ctx)
+	}
+	if traceID == "" || spanID == "" {
+		return "", ""
+	}
+	parent, remote := contracts.TraceParentFromContext(ctx)
+	if remote {
+		traceState = parent.TraceState
+	}
+	return formatTraceParent(traceID, spanID, !remote || parent.Sampled), traceState
+}
+
+// parseTraceParent parses a W3C traceparent header of the form
+// "00-<trace-id>-<parent-id>-<flags>". Unknown future versions are accepted
+// as long as the known fields are valid.
+func parseTraceParent(header string) (contracts.TraceParent, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return contracts.TraceParent{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return contracts.TraceParent{}, false
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return contracts.TraceParent{}, false
+	}
+	if allZero(traceID) || allZero(spanID) {
+		return contracts.TraceParent{}, false
+	}
+	flagBits, _ := hex.DecodeString(flags)
+	return contracts.TraceParent{TraceID: traceID, SpanID: spanID, Sampled: flagBits[0]&1 == 1}, true
+}
+
+// formatTraceParent renders IDs in the traceparent header format.
+func formatTraceParent(traceID, spanID string, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + traceID + "-" + spanID + "-" + flags
+}
+
+// isHex reports whether s is n lowercase hex characters.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func allZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// randomHex returns n random bytes encoded as lowercase hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
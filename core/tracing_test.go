@@ -0,0 +1,321 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/slice-soft/ss-keel-core/contracts"
+	"github.com/slice-soft/ss-keel-core/core/httpx"
+	"github.com/slice-soft/ss-keel-core/logger"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+// recordingTracer records every span it starts.
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, contracts.Span) {
+	parent, _ := contracts.TraceParentFromContext(ctx)
+	s := &recordingSpan{name: name, parent: parent, attrs: map[string]any{}}
+	r.spans = append(r.spans, s)
+	return context.WithValue(ctx, recordingSpanKey{}, s), s
+}
+
+type recordingSpanKey struct{}
+
+type recordingSpan struct {
+	name   string
+	parent contracts.TraceParent
+	attrs  map[string]any
+	errs   []error
+	status contracts.SpanStatus
+	desc   string
+	events []string
+	ended  int
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *recordingSpan) RecordError(err error)              { s.errs = append(s.errs, err) }
+func (s *recordingSpan) End()                               { s.ended++ }
+func (s *recordingSpan) SetName(name string)                { s.name = name }
+func (s *recordingSpan) TraceID() string                    { return testTraceID }
+func (s *recordingSpan) SpanID() string                     { return testSpanID }
+func (s *recordingSpan) SetStatus(code contracts.SpanStatus, desc string) {
+	s.status, s.desc = code, desc
+}
+func (s *recordingSpan) AddEvent(name string, _ map[string]any) { s.events = append(s.events, name) }
+
+// thinSpan implements only contracts.Span.
+type thinSpan struct{ keys []string }
+
+func (s *thinSpan) SetAttribute(key string, _ any) { s.keys = append(s.keys, key) }
+func (s *thinSpan) RecordError(error)              {}
+func (s *thinSpan) End()                           {}
+
+func TestTracingMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    fiber.Handler
+		wantStatus int
+		wantErr    bool
+	}{
+		{"200 response", func(c *fiber.Ctx) error { return c.SendString("ok") }, 200, false},
+		{"500 KError", func(c *fiber.Ctx) error { return Internal("boom", nil) }, 500, true},
+		{"500 plain error", func(c *fiber.Ctx) error { return errors.New("boom") }, 500, true},
+		{"404 KError", func(c *fiber.Ctx) error { return NotFound("missing") }, 404, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			app := New(KConfig{DisableHealth: true})
+			app.SetTracer(tracer)
+			var inHandler *recordingSpan
+			app.Fiber().Get("/users/:id", func(c *fiber.Ctx) error {
+				inHandler, _ = c.UserContext().Value(recordingSpanKey{}).(*recordingSpan)
+				return tt.handler(c)
+			})
+
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/users/42", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(tracer.spans) != 1 {
+				t.Fatalf("spans started = %d, want 1", len(tracer.spans))
+			}
+			span := tracer.spans[0]
+			if inHandler != span {
+				t.Error("span context is not reachable from the handler's UserContext")
+			}
+			if span.name != "GET /users/:id" {
+				t.Errorf("span name = %q, want %q", span.name, "GET /users/:id")
+			}
+			if span.ended != 1 {
+				t.Errorf("End() calls = %d, want 1", span.ended)
+			}
+			want := map[string]any{
+				"http.method":      "GET",
+				"http.route":       "/users/:id",
+				"http.status_code": tt.wantStatus,
+				"http.client_ip":   "0.0.0.0",
+			}
+			for k, v := range want {
+				if span.attrs[k] != v {
+					t.Errorf("attribute %s = %v, want %v", k, span.attrs[k], v)
+				}
+			}
+			if got := len(span.errs) > 0; got != tt.wantErr {
+				t.Errorf("recorded error = %v, want %v", span.errs, tt.wantErr)
+			}
+			if got := span.status == contracts.SpanStatusError; got != tt.wantErr {
+				t.Errorf("status = %v, want error: %v", span.status, tt.wantErr)
+			}
+			if got := resp.Header.Get("traceresponse"); got != "00-"+testTraceID+"-"+testSpanID+"-01" {
+				t.Errorf("traceresponse = %q", got)
+			}
+		})
+	}
+}
+
+func TestTracingMiddlewarePropagatesTraceParent(t *testing.T) {
+	tracer := &recordingTracer{}
+	app := New(KConfig{DisableHealth: true})
+	app.SetTracer(tracer)
+	app.Fiber().Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-"+testTraceID+"-b7ad6b7169203331-01")
+	req.Header.Set("tracestate", "congo=t61rcWkgMzE")
+	if _, err := app.Fiber().Test(req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := contracts.TraceParent{TraceID: testTraceID, SpanID: "b7ad6b7169203331", Sampled: true, TraceState: "congo=t61rcWkgMzE"}
+	if got := tracer.spans[0].parent; got != want {
+		t.Errorf("remote parent = %+v, want %+v", got, want)
+	}
+}
+
+func TestTracingMiddlewareDisabledByDefault(t *testing.T) {
+	app := New(KConfig{DisableHealth: true})
+	app.Fiber().Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("traceresponse"); got != "" {
+		t.Errorf("traceresponse = %q, want none without a tracer", got)
+	}
+}
+
+func TestTracingEnabledWithNoopTracer(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(KConfig{DisableHealth: true, Tracing: TracingConfig{Enabled: true}})
+	app.logger = logger.NewLoggerWithFormat(false, logger.LogFormatJSON).WithWriter(&buf)
+	var handlerTraceID string
+	app.Fiber().Get("/", func(c *fiber.Ctx) error {
+		handlerTraceID, _ = logger.TraceFromContext(c.UserContext())
+		return c.SendString("ok")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-"+testTraceID+"-b7ad6b7169203331-00")
+	resp, err := app.Fiber().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if handlerTraceID != testTraceID {
+		t.Errorf("trace ID in handler context = %q, want %q", handlerTraceID, testTraceID)
+	}
+	tp, ok := parseTraceParent(resp.Header.Get("traceresponse"))
+	if !ok || tp.TraceID != testTraceID || tp.Sampled {
+		t.Errorf("traceresponse = %q, want the caller's unsampled trace", resp.Header.Get("traceresponse"))
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry); err != nil {
+		t.Fatalf("access log is not a single JSON entry: %v\n%s", err, buf.String())
+	}
+	if entry["trace_id"] != testTraceID {
+		t.Errorf("access log trace_id = %v, want %s", entry["trace_id"], testTraceID)
+	}
+}
+
+func TestTraceIDOnCtxAndErrorBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		tracer    contracts.Tracer
+		wantTrace string
+		wantSpan  string
+	}{
+		{"with tracer", &recordingTracer{}, testTraceID, testSpanID},
+		{"tracing off", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New(KConfig{DisableHealth: true})
+			if tt.tracer != nil {
+				app.SetTracer(tt.tracer)
+			}
+			var gotTrace, gotSpan string
+			app.Fiber().Get("/", httpx.WrapHandler(func(c *httpx.Ctx) error {
+				gotTrace, gotSpan = c.TraceID(), c.SpanID()
+				return NotFound("missing")
+			}))
+
+			resp, err := app.Fiber().Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotTrace != tt.wantTrace || gotSpan != tt.wantSpan {
+				t.Errorf("TraceID/SpanID = %q/%q, want %q/%q", gotTrace, gotSpan, tt.wantTrace, tt.wantSpan)
+			}
+			if got := resp.Header.Get("X-Trace-ID"); got != tt.wantTrace {
+				t.Errorf("X-Trace-ID = %q, want %q", got, tt.wantTrace)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := body["trace_id"]
+			if tt.wantTrace == "" && ok {
+				t.Errorf("error body has trace_id %v with tracing off", got)
+			}
+			if tt.wantTrace != "" && got != tt.wantTrace {
+				t.Errorf("error body trace_id = %v, want %s", got, tt.wantTrace)
+			}
+		})
+	}
+}
+
+func TestSpanHelpers(t *testing.T) {
+	t.Run("noop span", func(t *testing.T) {
+		span := noopSpan{}
+		SpanSetStatus(span, contracts.SpanStatusOK, "")
+		SpanAddEvent(span, "cache miss", nil)
+		SpanSetAttributes(span, map[string]any{"k": 1})
+	})
+
+	t.Run("rich span", func(t *testing.T) {
+		span := &recordingSpan{attrs: map[string]any{}}
+		SpanSetStatus(span, contracts.SpanStatusError, "db down")
+		SpanAddEvent(span, "cache miss", map[string]any{"key": "user:1"})
+		SpanSetAttributes(span, map[string]any{"db.system": "postgres"})
+
+		if span.status != contracts.SpanStatusError || span.desc != "db down" {
+			t.Errorf("status = %v %q, want error \"db down\"", span.status, span.desc)
+		}
+		if len(span.events) != 1 || span.events[0] != "cache miss" {
+			t.Errorf("events = %v", span.events)
+		}
+		if span.attrs["db.system"] != "postgres" {
+			t.Errorf("attrs = %v", span.attrs)
+		}
+	})
+
+	t.Run("thin span falls back to SetAttribute", func(t *testing.T) {
+		span := &thinSpan{}
+		SpanSetStatus(span, contracts.SpanStatusError, "ignored")
+		SpanAddEvent(span, "ignored", nil)
+		SpanSetAttributes(span, map[string]any{"b": 2, "a": 1, "c": 3})
+
+		if want := []string{"a", "b", "c"}; !slices.Equal(span.keys, want) {
+			t.Errorf("SetAttribute keys = %v, want %v", span.keys, want)
+		}
+	})
+}
+
+func TestSpanStatusString(t *testing.T) {
+	for status, want := range map[contracts.SpanStatus]string{
+		contracts.SpanStatusUnset: "unset",
+		contracts.SpanStatusOK:    "ok",
+		contracts.SpanStatusError: "error",
+	} {
+		if got := status.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{"valid sampled", "00-" + testTraceID + "-" + testSpanID + "-01", true},
+		{"future version with extra field", "cc-" + testTraceID + "-" + testSpanID + "-01-what", true},
+		{"empty", "", false},
+		{"version ff", "ff-" + testTraceID + "-" + testSpanID + "-01", false},
+		{"version 00 with extra field", "00-" + testTraceID + "-" + testSpanID + "-01-x", false},
+		{"uppercase trace id", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01", false},
+		{"short span id", "00-" + testTraceID + "-00f067aa-01", false},
+		{"zero trace id", "00-00000000000000000000000000000000-" + testSpanID + "-01", false},
+		{"zero span id", "00-" + testTraceID + "-0000000000000000-01", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, ok := parseTraceParent(tt.header)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && (tp.TraceID != testTraceID || tp.SpanID != testSpanID || !tp.Sampled) {
+				t.Errorf("parsed = %+v", tp)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTxRequired is returned by RequireTx outside a transaction.
+var ErrTxRequired = errors.New("repository: transaction required")
+
+// UnitOfWork runs service code in a database transaction, so several
+// repository calls commit or roll back together:
+//
+//	err := uow.WithinTx(ctx, func(ctx context.Context) error {
+//		if err := orders.Create(ctx, order); err != nil {
+//			return err
+//		}
+//		return stock.Patch(ctx, order.ItemID, &Stock{Reserved: order.Quantity})
+//	})
+//
+// WithinTx commits when fn returns nil and rolls back when it returns an
+// error or panics, returning that error or re-panicking. Called within a
+// transaction, it runs fn in the outer one.
+//
+// Implementations follow one context contract so repositories of the same
+// database module find the transaction without extra parameters: WithinTx
+// passes fn a context made with ContextWithTx, and repositories use the
+// transaction returned by TxFromContext, when there is one, instead of
+// their connection. RunTx implements WithinTx on top of a begin function.
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Tx is a transaction of a UnitOfWork implementation. Repositories type
+// assert it to the module's own type, e.g. to reach the underlying handle.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+type txKey struct{}
+
+// ContextWithTx returns a copy of ctx carrying tx, for UnitOfWork
+// implementations.
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction of ctx, set by ContextWithTx.
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+// InTx reports whether ctx carries a transaction.
+func InTx(ctx context.Context) bool {
+	_, ok := TxFromContext(ctx)
+	return ok
+}
+
+// RequireTx returns ErrTxRequired unless ctx carries a transaction, for
+// code that must not run outside one.
+func RequireTx(ctx context.Context) error {
+	if !InTx(ctx) {
+		return ErrTxRequired
+	}
+	return nil
+}
+
+// RunTx implements UnitOfWork.WithinTx for modules: it runs fn in the
+// transaction of ctx when there is one, and otherwise in a transaction
+// from begin, committed or rolled back according to fn.
+//
+//	func (u *GormUnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+//		return core.RunTx(ctx, u.begin, fn)
+//	}
+func RunTx(ctx context.Context, begin func(ctx context.Context) (Tx, error), fn func(ctx context.Context) error) error {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback(context.WithoutCancel(ctx))
+			panic(r)
+		}
+	}()
+	if err := fn(ContextWithTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(context.WithoutCancel(ctx)); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// NoopUnitOfWork is a UnitOfWork without a database, for tests of services:
+// fn runs in a context where InTx reports true, and nothing is rolled back.
+type NoopUnitOfWork struct{}
+
+var _ UnitOfWork = NoopUnitOfWork{}
+
+// NewNoopUnitOfWork returns a NoopUnitOfWork.
+func NewNoopUnitOfWork() NoopUnitOfWork {
+	return NoopUnitOfWork{}
+}
+
+// WithinTx implements UnitOfWork.
+func (NoopUnitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunTx(ctx, func(context.Context) (Tx, error) { return noopTx{}, nil }, fn)
+}
+
+type noopTx struct{}
+
+func (noopTx) Commit(context.Context) error   { return nil }
+func (noopTx) Rollback(context.Context) error { return nil }
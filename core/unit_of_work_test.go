@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+)
+
+// fakeDB is a database whose writes are buffered by fakeTx until commit,
+// with fakeDB itself as its UnitOfWork.
+type fakeDB struct {
+	rows   map[string]int
+	begins int
+}
+
+type fakeTx struct {
+	db                    *fakeDB
+	writes                map[string]int
+	committed, rolledBack bool
+}
+
+func (tx *fakeTx) Commit(context.Context) error {
+	maps.Copy(tx.db.rows, tx.writes)
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+func (db *fakeDB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunTx(ctx, func(context.Context) (Tx, error) {
+		db.begins++
+		return &fakeTx{db: db, writes: map[string]int{}}, nil
+	}, fn)
+}
+
+// set is a repository method writing through the transaction of ctx.
+func (db *fakeDB) set(ctx context.Context, key string, v int) {
+	if tx, ok := TxFromContext(ctx); ok {
+		tx.(*fakeTx).writes[key] = v
+		return
+	}
+	db.rows[key] = v
+}
+
+func TestRunTx(t *testing.T) {
+	ctx := context.Background()
+	errOutOfStock := errors.New("out of stock")
+
+	t.Run("commit", func(t *testing.T) {
+		db := &fakeDB{rows: map[string]int{}}
+		err := db.WithinTx(ctx, func(ctx context.Context) error {
+			db.set(ctx, "order:1", 1)
+			db.set(ctx, "stock:a", 9)
+			return nil
+		})
+		if err != nil || db.rows["order:1"] != 1 || db.rows["stock:a"] != 9 {
+			t.Errorf("err %v rows %v; want both writes committed", err, db.rows)
+		}
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		db := &fakeDB{rows: map[string]int{"stock:a": 0}}
+		var tx Tx
+		err := db.WithinTx(ctx, func(ctx context.Context) error {
+			tx, _ = TxFromContext(ctx)
+			db.set(ctx, "order:1", 1)
+			return errOutOfStock
+		})
+		if !errors.Is(err, errOutOfStock) {
+			t.Errorf("err = %v, want errOutOfStock", err)
+		}
+		if _, ok := db.rows["order:1"]; ok || !tx.(*fakeTx).rolledBack || tx.(*fakeTx).committed {
+			t.Errorf("rows %v tx %+v; want the order rolled back", db.rows, tx)
+		}
+	})
+
+	t.Run("rollback on panic", func(t *testing.T) {
+		db := &fakeDB{rows: map[string]int{}}
+		var tx Tx
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("recovered %v, want the original panic", r)
+				}
+			}()
+			db.WithinTx(ctx, func(ctx context.Context) error { //nolint
+				tx, _ = TxFromContext(ctx)
+				db.set(ctx, "order:1", 1)
+				panic("boom")
+			})
+		}()
+		if len(db.rows) != 0 || !tx.(*fakeTx).rolledBack {
+			t.Errorf("rows %v; want the write rolled back", db.rows)
+		}
+	})
+
+	t.Run("nested reuses the outer transaction", func(t *testing.T) {
+		db := &fakeDB{rows: map[string]int{}}
+		err := db.WithinTx(ctx, func(ctx context.Context) error {
+			db.set(ctx, "order:1", 1)
+			return db.WithinTx(ctx, func(ctx context.Context) error {
+				db.set(ctx, "stock:a", 9)
+				return errOutOfStock
+			})
+		})
+		if !errors.Is(err, errOutOfStock) || db.begins != 1 || len(db.rows) != 0 {
+			t.Errorf("err %v begins %d rows %v; want one rolled back transaction", err, db.begins, db.rows)
+		}
+	})
+}
+
+func TestNoopUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	if InTx(ctx) || !errors.Is(RequireTx(ctx), ErrTxRequired) {
+		t.Error("background context reported as in a transaction")
+	}
+
+	var uow UnitOfWork = NewNoopUnitOfWork()
+	calls := 0
+	err := uow.WithinTx(ctx, func(ctx context.Context) error {
+		calls++
+		if !InTx(ctx) || RequireTx(ctx) != nil {
+			t.Error("fn context not in a transaction")
+		}
+		return uow.WithinTx(ctx, func(context.Context) error {
+			calls++
+			return errors.New("inner")
+		})
+	})
+	if err == nil || err.Error() != "inner" || calls != 2 {
+		t.Errorf("err %v calls %d; want the inner error after two calls", err, calls)
+	}
+}
@@ -0,0 +1,141 @@
+package keeltest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ErrClosed is returned by MemoryBus methods called after Close.
+var ErrClosed = errors.New("keeltest: closed")
+
+// MemoryBus is an in-memory message bus implementing contracts.Publisher and
+// contracts.Subscriber. Publish delivers synchronously to every handler
+// subscribed to the topic, so assertions can follow it directly.
+type MemoryBus struct {
+	mu        sync.Mutex
+	handlers  map[string][]subscription
+	published map[string][]contracts.Message
+	delivered map[string][]contracts.Message
+	closed    bool
+}
+
+type subscription struct {
+	ctx     context.Context
+	handler contracts.MessageHandler
+}
+
+var (
+	_ contracts.Publisher  = (*MemoryBus)(nil)
+	_ contracts.Subscriber = (*MemoryBus)(nil)
+)
+
+// NewMemoryBus returns a MemoryBus without subscribers.
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		handlers:  make(map[string][]subscription),
+		published: make(map[string][]contracts.Message),
+		delivered: make(map[string][]contracts.Message),
+	}
+}
+
+// Publish implements contracts.Publisher. It returns the errors of the
+// handlers, joined.
+func (b *MemoryBus) Publish(ctx context.Context, msg contracts.Message) error {
+	msg = cloneMessage(msg)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.published[msg.Topic] = append(b.published[msg.Topic], msg)
+	var subs []subscription
+	for _, s := range b.handlers[msg.Topic] {
+		if s.ctx.Err() == nil {
+			subs = append(subs, s)
+		}
+	}
+	b.handlers[msg.Topic] = subs
+	b.mu.Unlock()
+
+	var errs []error
+	for _, s := range subs {
+		if err := s.handler(ctx, cloneMessage(msg)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(subs) > 0 {
+		b.mu.Lock()
+		b.delivered[msg.Topic] = append(b.delivered[msg.Topic], msg)
+		b.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Subscribe implements contracts.Subscriber. The handler receives messages
+// published to topic until ctx is cancelled or the bus is closed.
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string, handler contracts.MessageHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	b.handlers[topic] = append(b.handlers[topic], subscription{ctx: ctx, handler: handler})
+	return nil
+}
+
+// Close implements contracts.Publisher and contracts.Subscriber. Published
+// messages stay available for inspection.
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.handlers = make(map[string][]subscription)
+	return nil
+}
+
+// Published returns the messages published to topic, in order.
+func (b *MemoryBus) Published(topic string) []contracts.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return cloneMessages(b.published[topic])
+}
+
+// Delivered returns the messages published to topic that reached at least
+// one subscriber, in order.
+func (b *MemoryBus) Delivered(topic string) []contracts.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return cloneMessages(b.delivered[topic])
+}
+
+// Reset forgets published and delivered messages; subscriptions are kept.
+func (b *MemoryBus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = make(map[string][]contracts.Message)
+	b.delivered = make(map[string][]contracts.Message)
+}
+
+func cloneMessages(msgs []contracts.Message) []contracts.Message {
+	out := make([]contracts.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = cloneMessage(m)
+	}
+	return out
+}
+
+func cloneMessage(m contracts.Message) contracts.Message {
+	m.Key = clone(m.Key)
+	m.Payload = clone(m.Payload)
+	if m.Headers != nil {
+		headers := make(map[string]string, len(m.Headers))
+		for k, v := range m.Headers {
+			headers[k] = v
+		}
+		m.Headers = headers
+	}
+	return m
+}
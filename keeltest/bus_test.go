@@ -0,0 +1,111 @@
+package keeltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+func TestMemoryBus(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+
+	var got []string
+	record := func(name string) contracts.MessageHandler {
+		return func(_ context.Context, msg contracts.Message) error {
+			got = append(got, name+":"+string(msg.Payload))
+			return nil
+		}
+	}
+	subCtx, cancel := context.WithCancel(ctx)
+	bus.Subscribe(ctx, "orders", record("a"))    //nolint
+	bus.Subscribe(subCtx, "orders", record("b")) //nolint
+	bus.Subscribe(ctx, "users", record("c"))     //nolint
+
+	bus.Publish(ctx, contracts.Message{Topic: "orders", Payload: []byte("1")}) //nolint
+	cancel()
+	bus.Publish(ctx, contracts.Message{Topic: "orders", Payload: []byte("2")}) //nolint
+	bus.Publish(ctx, contracts.Message{Topic: "audit", Payload: []byte("3")})  //nolint
+
+	if want := []string{"a:1", "b:1", "a:2"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("deliveries = %v, want %v", got, want)
+	}
+	if n := len(bus.Published("orders")); n != 2 {
+		t.Errorf("Published(orders) = %d messages, want 2", n)
+	}
+	if n := len(bus.Published("audit")); n != 1 {
+		t.Errorf("Published(audit) = %d messages, want 1", n)
+	}
+	if n := len(bus.Delivered("audit")); n != 0 {
+		t.Errorf("Delivered(audit) = %d messages, want 0 without subscribers", n)
+	}
+
+	bus.Reset()
+	if n := len(bus.Published("orders")); n != 0 {
+		t.Errorf("Published after Reset = %d messages", n)
+	}
+
+	failing := errors.New("handler failed")
+	bus.Subscribe(ctx, "jobs", func(context.Context, contracts.Message) error { return failing }) //nolint
+	if err := bus.Publish(ctx, contracts.Message{Topic: "jobs"}); !errors.Is(err, failing) {
+		t.Errorf("Publish err = %v, want the handler error", err)
+	}
+
+	bus.Close() //nolint
+	if err := bus.Publish(ctx, contracts.Message{Topic: "orders"}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish after Close err = %v, want ErrClosed", err)
+	}
+	if err := bus.Subscribe(ctx, "orders", record("d")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe after Close err = %v, want ErrClosed", err)
+	}
+}
+
+func TestMemoryBusCopiesMessages(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+	bus.Subscribe(ctx, "t", func(_ context.Context, msg contracts.Message) error { //nolint
+		msg.Payload[0] = 'X'
+		msg.Headers["h"] = "changed"
+		return nil
+	})
+	msg := contracts.Message{Topic: "t", Payload: []byte("p"), Headers: map[string]string{"h": "v"}}
+	bus.Publish(ctx, msg) //nolint
+
+	published := bus.Published("t")[0]
+	if string(published.Payload) != "p" || published.Headers["h"] != "v" || string(msg.Payload) != "p" {
+		t.Errorf("published = %+v, original = %+v; handlers must not alter them", published, msg)
+	}
+}
+
+func TestMemoryBusConcurrent(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemoryBus()
+	var mu sync.Mutex
+	received := 0
+	bus.Subscribe(ctx, "t", func(context.Context, contracts.Message) error { //nolint
+		mu.Lock()
+		received++
+		mu.Unlock()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				bus.Publish(ctx, contracts.Message{Topic: "t"}) //nolint
+				bus.Published("t")
+			}
+		}()
+	}
+	wg.Wait()
+	if received != 400 || len(bus.Delivered("t")) != 400 {
+		t.Errorf("received %d, delivered %d; want 400", received, len(bus.Delivered("t")))
+	}
+}
@@ -0,0 +1,111 @@
+package keeltest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// MemoryCache is an in-memory contracts.Cache honouring TTLs.
+type MemoryCache struct {
+	mu    sync.Mutex
+	items map[string]cacheItem
+	now   func() time.Time
+}
+
+type cacheItem struct {
+	value   []byte
+	expires time.Time // zero means no expiry
+}
+
+var _ contracts.Cache = (*MemoryCache)(nil)
+
+// NewMemoryCache returns an empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{items: make(map[string]cacheItem), now: time.Now}
+}
+
+// SetClock makes TTLs expire according to clock, e.g. a FakeClock.
+func (c *MemoryCache) SetClock(clock contracts.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = clock.Now
+}
+
+// Get implements contracts.Cache. It returns contracts.ErrCacheMiss for
+// missing and expired keys.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.live(key)
+	if !ok {
+		return nil, contracts.ErrCacheMiss
+	}
+	return clone(item.value), nil
+}
+
+// Set implements contracts.Cache. A ttl of zero or less never expires.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item := cacheItem{value: clone(value)}
+	if ttl > 0 {
+		item.expires = c.now().Add(ttl)
+	}
+	c.items[key] = item
+	return nil
+}
+
+// Delete implements contracts.Cache.
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+// Exists implements contracts.Cache.
+func (c *MemoryCache) Exists(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.live(key)
+	return ok, nil
+}
+
+// Keys returns the live keys, sorted.
+func (c *MemoryCache) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.items))
+	for k := range c.items {
+		if _, ok := c.live(k); ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// live returns the item stored under key unless it has expired, dropping
+// expired items. c.mu must be held.
+func (c *MemoryCache) live(key string) (cacheItem, bool) {
+	item, ok := c.items[key]
+	if !ok {
+		return cacheItem{}, false
+	}
+	if !item.expires.IsZero() && !c.now().Before(item.expires) {
+		delete(c.items, key)
+		return cacheItem{}, false
+	}
+	return item, true
+}
+
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,72 @@
+package keeltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	c := NewMemoryCache()
+	c.now = func() time.Time { return now }
+
+	value := []byte("v1")
+	c.Set(ctx, "session", value, time.Minute) //nolint
+	c.Set(ctx, "config", []byte("c"), 0)      //nolint
+	value[0] = 'X'
+
+	got, err := c.Get(ctx, "session")
+	if err != nil || string(got) != "v1" {
+		t.Fatalf("Get = %q, %v; want v1 unaffected by caller mutation", got, err)
+	}
+	if ok, _ := c.Exists(ctx, "session"); !ok {
+		t.Error("Exists(session) = false before expiry")
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := c.Get(ctx, "session"); !errors.Is(err, contracts.ErrCacheMiss) {
+		t.Errorf("Get after TTL err = %v, want ErrCacheMiss", err)
+	}
+	if ok, _ := c.Exists(ctx, "session"); ok {
+		t.Error("Exists(session) = true after expiry")
+	}
+	if keys := c.Keys(); !slices.Equal(keys, []string{"config"}) {
+		t.Errorf("Keys = %v, want [config]; entries without TTL never expire", keys)
+	}
+
+	c.Delete(ctx, "config") //nolint
+	if _, err := c.Get(ctx, "config"); !errors.Is(err, contracts.ErrCacheMiss) {
+		t.Errorf("Get after Delete err = %v, want ErrCacheMiss", err)
+	}
+}
+
+func TestMemoryCacheConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("k%d", j%10)
+				c.Set(ctx, key, []byte{byte(i)}, time.Minute) //nolint
+				c.Get(ctx, key)                               //nolint
+				c.Exists(ctx, key)                            //nolint
+				c.Keys()
+				if j%7 == 0 {
+					c.Delete(ctx, key) //nolint
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
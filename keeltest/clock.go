@@ -0,0 +1,74 @@
+package keeltest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// FakeClock is a contracts.Clock that only moves when told to. Sleep blocks
+// until Advance moves the clock past the sleeper's deadline, so code waiting
+// on the clock runs without real delays.
+type FakeClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	sleepers []fakeSleeper
+}
+
+type fakeSleeper struct {
+	until time.Time
+	done  chan struct{}
+}
+
+var _ contracts.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a FakeClock reading start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now implements contracts.Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep implements contracts.Clock. It returns once Advance has moved the
+// clock by d; d <= 0 returns immediately.
+func (c *FakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.mu.Lock()
+	s := fakeSleeper{until: c.now.Add(d), done: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.mu.Unlock()
+	<-s.done
+}
+
+// Advance moves the clock forward by d and wakes the sleepers whose
+// deadline has passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	waiting := c.sleepers[:0]
+	for _, s := range c.sleepers {
+		if c.now.Before(s.until) {
+			waiting = append(waiting, s)
+		} else {
+			close(s.done)
+		}
+	}
+	c.sleepers = waiting
+}
+
+// Sleepers returns how many goroutines are blocked in Sleep, letting a test
+// wait for a sleeper before calling Advance.
+func (c *FakeClock) Sleepers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sleepers)
+}
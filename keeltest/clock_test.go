@@ -0,0 +1,56 @@
+package keeltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	clock.Sleep(0)
+
+	woke := make(chan time.Time)
+	go func() {
+		clock.Sleep(time.Minute)
+		woke <- clock.Now()
+	}()
+	for clock.Sleepers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-woke:
+		t.Fatal("Sleep returned before its deadline")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(30 * time.Second)
+	if got := <-woke; !got.Equal(start.Add(time.Minute)) {
+		t.Errorf("woke at %v, want %v", got, start.Add(time.Minute))
+	}
+	if n := clock.Sleepers(); n != 0 {
+		t.Errorf("Sleepers = %d after wake-up, want 0", n)
+	}
+}
+
+func TestMemoryCacheSetClock(t *testing.T) {
+	ctx := context.Background()
+	clock := NewFakeClock(time.Unix(1000, 0))
+	c := NewMemoryCache()
+	c.SetClock(clock)
+
+	c.Set(ctx, "k", []byte("v"), time.Minute) //nolint
+	clock.Advance(59 * time.Second)
+	if _, err := c.Get(ctx, "k"); err != nil {
+		t.Fatalf("Get before TTL: %v", err)
+	}
+	clock.Advance(time.Second)
+	if _, err := c.Get(ctx, "k"); !errors.Is(err, contracts.ErrCacheMiss) {
+		t.Errorf("Get after TTL err = %v, want ErrCacheMiss", err)
+	}
+}
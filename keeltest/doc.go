@@ -0,0 +1,5 @@
+// Package keeltest provides in-memory fakes of the Keel contracts for unit
+// tests: a Cache, a message bus implementing Publisher and Subscriber, a
+// Storage, an OutboxStore and a Clock. Each fake is safe for concurrent use
+// and exposes inspection helpers for assertions.
+package keeltest
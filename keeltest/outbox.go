@@ -0,0 +1,188 @@
+package keeltest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// MemoryOutboxStore is an in-memory contracts.OutboxStore. It implements
+// contracts.OutboxTx itself, storing records right away; Begin returns a
+// transaction whose records are only stored on Commit.
+type MemoryOutboxStore struct {
+	mu      sync.Mutex
+	records []outboxEntry // in insertion order
+}
+
+type outboxEntry struct {
+	rec  contracts.OutboxRecord
+	sent bool
+}
+
+var (
+	_ contracts.OutboxStore = (*MemoryOutboxStore)(nil)
+	_ contracts.OutboxTx    = (*MemoryOutboxStore)(nil)
+	_ contracts.OutboxTx    = (*MemoryOutboxTx)(nil)
+)
+
+// NewMemoryOutboxStore returns an empty MemoryOutboxStore.
+func NewMemoryOutboxStore() *MemoryOutboxStore {
+	return &MemoryOutboxStore{}
+}
+
+// InsertOutbox implements contracts.OutboxTx without a transaction.
+func (s *MemoryOutboxStore) InsertOutbox(_ context.Context, rec contracts.OutboxRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.insert(rec)
+}
+
+// insert adds rec; s.mu must be held.
+func (s *MemoryOutboxStore) insert(rec contracts.OutboxRecord) error {
+	for _, e := range s.records {
+		if e.rec.ID == rec.ID {
+			return fmt.Errorf("keeltest: outbox record %q already exists", rec.ID)
+		}
+	}
+	rec.Message = cloneMessage(rec.Message)
+	s.records = append(s.records, outboxEntry{rec: rec})
+	return nil
+}
+
+// Begin starts a transaction.
+func (s *MemoryOutboxStore) Begin() *MemoryOutboxTx {
+	return &MemoryOutboxTx{store: s}
+}
+
+// Pending implements contracts.OutboxStore.
+func (s *MemoryOutboxStore) Pending(_ context.Context, limit int) ([]contracts.OutboxRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []contracts.OutboxRecord
+	for _, e := range s.records {
+		if len(out) == limit {
+			break
+		}
+		if !e.sent {
+			out = append(out, cloneRecord(e.rec))
+		}
+	}
+	return out, nil
+}
+
+// MarkSent implements contracts.OutboxStore.
+func (s *MemoryOutboxStore) MarkSent(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := s.entry(id)
+	if err != nil {
+		return err
+	}
+	e.sent = true
+	return nil
+}
+
+// MarkFailed implements contracts.OutboxStore.
+func (s *MemoryOutboxStore) MarkFailed(_ context.Context, id string, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := s.entry(id)
+	if err != nil {
+		return err
+	}
+	e.rec.Attempts++
+	e.rec.LastError = cause.Error()
+	return nil
+}
+
+// Sent returns the published records, in insertion order.
+func (s *MemoryOutboxStore) Sent() []contracts.OutboxRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []contracts.OutboxRecord
+	for _, e := range s.records {
+		if e.sent {
+			out = append(out, cloneRecord(e.rec))
+		}
+	}
+	return out
+}
+
+// Record returns the record with the given ID, sent or not.
+func (s *MemoryOutboxStore) Record(id string) (contracts.OutboxRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := s.entry(id)
+	if err != nil {
+		return contracts.OutboxRecord{}, false
+	}
+	return cloneRecord(e.rec), true
+}
+
+// entry returns the entry for id; s.mu must be held.
+func (s *MemoryOutboxStore) entry(id string) (*outboxEntry, error) {
+	for i := range s.records {
+		if s.records[i].rec.ID == id {
+			return &s.records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("keeltest: outbox record %q not found", id)
+}
+
+// MemoryOutboxTx is a MemoryOutboxStore transaction.
+type MemoryOutboxTx struct {
+	store   *MemoryOutboxStore
+	mu      sync.Mutex
+	records []contracts.OutboxRecord
+	done    bool
+}
+
+// InsertOutbox implements contracts.OutboxTx; the record is stored on
+// Commit.
+func (tx *MemoryOutboxTx) InsertOutbox(_ context.Context, rec contracts.OutboxRecord) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return fmt.Errorf("keeltest: outbox transaction already finished")
+	}
+	tx.records = append(tx.records, cloneRecord(rec))
+	return nil
+}
+
+// Commit stores the records inserted in tx, all or none.
+func (tx *MemoryOutboxTx) Commit() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.done {
+		return fmt.Errorf("keeltest: outbox transaction already finished")
+	}
+	tx.done = true
+
+	s := tx.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.records)
+	for _, rec := range tx.records {
+		if err := s.insert(rec); err != nil {
+			s.records = s.records[:n]
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback discards the records inserted in tx.
+func (tx *MemoryOutboxTx) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.done = true
+	tx.records = nil
+	return nil
+}
+
+func cloneRecord(rec contracts.OutboxRecord) contracts.OutboxRecord {
+	rec.Message = cloneMessage(rec.Message)
+	return rec
+}
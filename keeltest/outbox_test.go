@@ -0,0 +1,66 @@
+package keeltest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+func TestMemoryOutboxStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryOutboxStore()
+	rec := func(id string) contracts.OutboxRecord {
+		return contracts.OutboxRecord{ID: id, Message: contracts.Message{Topic: "t", Payload: []byte(id)}}
+	}
+
+	if err := s.InsertOutbox(ctx, rec("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertOutbox(ctx, rec("a")); err == nil {
+		t.Error("inserting a duplicate ID should fail")
+	}
+
+	tx := s.Begin()
+	tx.InsertOutbox(ctx, rec("b")) //nolint
+	if pending, _ := s.Pending(ctx, 10); len(pending) != 1 {
+		t.Errorf("pending %d record(s) before commit, want 1", len(pending))
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.InsertOutbox(ctx, rec("late")); err == nil {
+		t.Error("inserting after commit should fail")
+	}
+	discarded := s.Begin()
+	discarded.InsertOutbox(ctx, rec("c")) //nolint
+	discarded.Rollback()                  //nolint
+
+	pending, _ := s.Pending(ctx, 1)
+	if len(pending) != 1 || pending[0].ID != "a" {
+		t.Fatalf("Pending(1) = %+v, want the oldest record", pending)
+	}
+	pending[0].Message.Payload[0] = 'x'
+
+	s.MarkFailed(ctx, "a", errors.New("broker down")) //nolint
+	if got, _ := s.Record("a"); got.Attempts != 1 || got.LastError != "broker down" || string(got.Message.Payload) != "a" {
+		t.Errorf("Record(a) = %+v after MarkFailed", got)
+	}
+	for range 2 {
+		if err := s.MarkSent(ctx, "a"); err != nil {
+			t.Errorf("MarkSent: %v", err)
+		}
+	}
+	if err := s.MarkSent(ctx, "missing"); err == nil {
+		t.Error("MarkSent of a missing record should fail")
+	}
+
+	pending, _ = s.Pending(ctx, 10)
+	if len(pending) != 1 || pending[0].ID != "b" {
+		t.Errorf("Pending = %+v, want only b", pending)
+	}
+	if sent := s.Sent(); len(sent) != 1 || sent[0].ID != "a" {
+		t.Errorf("Sent = %+v, want only a", sent)
+	}
+}
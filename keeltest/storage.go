@@ -0,0 +1,203 @@
+package keeltest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// MemoryStorage is an in-memory contracts.Storage. Missing keys yield errors
+// wrapping fs.ErrNotExist.
+type MemoryStorage struct {
+	mu      sync.Mutex
+	objects map[string]storedObject
+	now     func() time.Time
+}
+
+type storedObject struct {
+	data []byte
+	meta contracts.StorageObject
+}
+
+var _ contracts.Storage = (*MemoryStorage)(nil)
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string]storedObject), now: time.Now}
+}
+
+// SetClock makes modification and URL expiry times read clock, e.g. a
+// FakeClock.
+func (s *MemoryStorage) SetClock(clock contracts.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = clock.Now
+}
+
+// Put implements contracts.Storage. size is checked against the bytes read
+// unless it is negative.
+func (s *MemoryStorage) Put(_ context.Context, key string, r io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && int64(len(data)) != size {
+		return fmt.Errorf("keeltest: put %q: read %d bytes, want %d", key, len(data), size)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = storedObject{data: data, meta: contracts.StorageObject{
+		Key:          key,
+		Size:         int64(len(data)),
+		ContentType:  contentType,
+		LastModified: s.now(),
+	}}
+	return nil
+}
+
+// Get implements contracts.Storage.
+func (s *MemoryStorage) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+// Delete implements contracts.Storage. Deleting a missing key is not an
+// error.
+func (s *MemoryStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+// URL implements contracts.Storage with a stub "memory://" URL carrying the
+// expiry time.
+func (s *MemoryStorage) URL(_ context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := s.object(key); err != nil {
+		return "", err
+	}
+	q := url.Values{"expires": {s.now().Add(expiry).UTC().Format(time.RFC3339)}}
+	return (&url.URL{Scheme: "memory", Path: "/" + key, RawQuery: q.Encode()}).String(), nil
+}
+
+// Stat implements contracts.Storage.
+func (s *MemoryStorage) Stat(_ context.Context, key string) (*contracts.StorageObject, error) {
+	obj, err := s.object(key)
+	if err != nil {
+		return nil, err
+	}
+	meta := obj.meta
+	return &meta, nil
+}
+
+// Copy copies src to dst, overwriting dst; it has the signature of
+// core.ExtendedStorage.Copy.
+func (s *MemoryStorage) Copy(_ context.Context, src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[src]
+	if !ok {
+		return fmt.Errorf("keeltest: object %q: %w", src, fs.ErrNotExist)
+	}
+	if src != dst {
+		obj.meta.Key = dst
+		obj.meta.LastModified = s.now()
+		s.objects[dst] = obj
+	}
+	return nil
+}
+
+// Move moves src to dst, overwriting dst; it has the signature of
+// core.ExtendedStorage.Move.
+func (s *MemoryStorage) Move(_ context.Context, src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[src]
+	if !ok {
+		return fmt.Errorf("keeltest: object %q: %w", src, fs.ErrNotExist)
+	}
+	if src != dst {
+		obj.meta.Key = dst
+		s.objects[dst] = obj
+		delete(s.objects, src)
+	}
+	return nil
+}
+
+// Exists reports whether key is stored; it has the signature of
+// core.ExtendedStorage.Exists.
+func (s *MemoryStorage) Exists(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+// List returns the objects whose key starts with prefix in key order, one
+// page at a time; it has the signature of core.ListableStorage.List. The
+// token is the last key of the previous page, and Limit defaults to 1000.
+func (s *MemoryStorage) List(_ context.Context, prefix string, opts contracts.StorageListOptions) (contracts.StorageListResult, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 1000
+	}
+	var result contracts.StorageListResult
+	for _, key := range s.Keys() {
+		if !strings.HasPrefix(key, prefix) || (opts.Token != "" && key <= opts.Token) {
+			continue
+		}
+		if len(result.Objects) == limit {
+			result.NextToken = result.Objects[limit-1].Key
+			break
+		}
+		obj, err := s.object(key)
+		if err != nil {
+			continue // deleted since Keys
+		}
+		result.Objects = append(result.Objects, obj.meta)
+	}
+	return result, nil
+}
+
+// Keys returns the stored keys, sorted.
+func (s *MemoryStorage) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.objects))
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Content returns a copy of the object stored under key, or nil.
+func (s *MemoryStorage) Content(key string) []byte {
+	obj, err := s.object(key)
+	if err != nil {
+		return nil
+	}
+	return clone(obj.data)
+}
+
+func (s *MemoryStorage) object(key string) (storedObject, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	if !ok {
+		return storedObject{}, fmt.Errorf("keeltest: object %q: %w", key, fs.ErrNotExist)
+	}
+	return obj, nil
+}
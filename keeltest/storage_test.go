@@ -0,0 +1,128 @@
+package keeltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewMemoryStorage()
+	s.now = func() time.Time { return now }
+
+	if err := s.Put(ctx, "avatars/1.png", strings.NewReader("png"), 3, "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, "short", strings.NewReader("ab"), 5, "text/plain"); err == nil {
+		t.Error("Put with a wrong size should fail")
+	}
+
+	r, err := s.Get(ctx, "avatars/1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "png" || string(s.Content("avatars/1.png")) != "png" {
+		t.Errorf("Get = %q, Content = %q", data, s.Content("avatars/1.png"))
+	}
+
+	obj, err := s.Stat(ctx, "avatars/1.png")
+	if err != nil || obj.Key != "avatars/1.png" || obj.Size != 3 || obj.ContentType != "image/png" || !obj.LastModified.Equal(now) {
+		t.Errorf("Stat = %+v, %v", obj, err)
+	}
+
+	u, err := s.URL(ctx, "avatars/1.png", time.Hour)
+	if err != nil || u != "memory:///avatars/1.png?expires=2026-01-02T04%3A04%3A05Z" {
+		t.Errorf("URL = %q, %v", u, err)
+	}
+	if keys := s.Keys(); !slices.Equal(keys, []string{"avatars/1.png"}) {
+		t.Errorf("Keys = %v", keys)
+	}
+
+	s.Delete(ctx, "avatars/1.png") //nolint
+	for name, call := range map[string]func() error{
+		"Get":  func() error { _, err := s.Get(ctx, "avatars/1.png"); return err },
+		"Stat": func() error { _, err := s.Stat(ctx, "avatars/1.png"); return err },
+		"URL":  func() error { _, err := s.URL(ctx, "avatars/1.png", time.Hour); return err },
+	} {
+		if err := call(); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s after Delete err = %v, want fs.ErrNotExist", name, err)
+		}
+	}
+}
+
+func TestMemoryStorageConcurrent(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				key := fmt.Sprintf("k%d", j%5)
+				s.Put(ctx, key, strings.NewReader("data"), -1, "text/plain") //nolint
+				s.Stat(ctx, key)                                             //nolint
+				s.Content(key)
+				s.Keys()
+				if j%3 == i%3 {
+					s.Delete(ctx, key) //nolint
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestMemoryStorageList(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	for _, k := range []string{"b/2", "a/1", "b/1", "b/3"} {
+		s.Put(ctx, k, strings.NewReader(k), -1, "") //nolint
+	}
+
+	first, err := s.List(ctx, "b/", contracts.StorageListOptions{Limit: 2})
+	if err != nil || len(first.Objects) != 2 || first.Objects[0].Key != "b/1" || first.NextToken != "b/2" {
+		t.Fatalf("first page = %+v, %v", first, err)
+	}
+	second, _ := s.List(ctx, "b/", contracts.StorageListOptions{Limit: 2, Token: first.NextToken})
+	if len(second.Objects) != 1 || second.Objects[0].Key != "b/3" || second.NextToken != "" {
+		t.Errorf("second page = %+v", second)
+	}
+}
+
+func TestMemoryStorageCopyMove(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStorage()
+	s.Put(ctx, "a", strings.NewReader("x"), 1, "text/plain") //nolint
+
+	if err := s.Copy(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Move(ctx, "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Keys(); !slices.Equal(got, []string{"a", "c"}) {
+		t.Errorf("keys = %v, want [a c]", got)
+	}
+	if obj, _ := s.Stat(ctx, "c"); obj.Key != "c" || obj.ContentType != "text/plain" {
+		t.Errorf("Stat(c) = %+v", obj)
+	}
+	if err := s.Move(ctx, "missing", "c"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Move(missing) = %v", err)
+	}
+	if ok, _ := s.Exists(ctx, "b"); ok {
+		t.Error("Exists(b) after Move")
+	}
+}
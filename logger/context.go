@@ -0,0 +1,81 @@
+package logger
+
+import "context"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceKey
+)
+
+type traceIDs struct {
+	traceID string
+	spanID  string
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID.
+// Keel's request middleware stores it on every request context.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// ContextWithTrace returns a copy of ctx carrying the active trace and span IDs.
+func ContextWithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceKey, traceIDs{traceID: traceID, spanID: spanID})
+}
+
+// TraceFromContext returns the trace and span IDs stored in ctx, if any.
+func TraceFromContext(ctx context.Context) (traceID, spanID string) {
+	ids, _ := ctx.Value(traceKey).(traceIDs)
+	return ids.traceID, ids.spanID
+}
+
+// fromContext returns l with the request and trace IDs found in ctx added
+// as fields.
+func (l *Logger) fromContext(ctx context.Context) *Logger {
+	if ctx == nil {
+		return l
+	}
+	child := l
+	if id := RequestIDFromContext(ctx); id != "" {
+		child = child.With("request_id", id)
+	}
+	if traceID, spanID := TraceFromContext(ctx); traceID != "" {
+		child = child.With("trace_id", traceID).With("span_id", spanID)
+	}
+	return child
+}
+
+// InfoCtx logs an informational message with the IDs carried by ctx.
+func (l *Logger) InfoCtx(ctx context.Context, format string, args ...interface{}) {
+	f, line := l.caller()
+	l.fromContext(ctx).log(LevelInfo, f, line, format, args...)
+}
+
+// WarnCtx logs a warning message with the IDs carried by ctx.
+func (l *Logger) WarnCtx(ctx context.Context, format string, args ...interface{}) {
+	f, line := l.caller()
+	l.fromContext(ctx).log(LevelWarn, f, line, format, args...)
+}
+
+// ErrorCtx logs an error message with the IDs carried by ctx.
+func (l *Logger) ErrorCtx(ctx context.Context, format string, args ...interface{}) {
+	f, line := l.caller()
+	l.fromContext(ctx).log(LevelError, f, line, format, args...)
+	l.exitIfErrorExits()
+}
+
+// DebugCtx logs a debug message with the IDs carried by ctx. Disabled in production.
+func (l *Logger) DebugCtx(ctx context.Context, format string, args ...interface{}) {
+	if !l.isProduction {
+		f, line := l.caller()
+		l.fromContext(ctx).log(LevelDebug, f, line, format, args...)
+	}
+}
@@ -0,0 +1,66 @@
+package logger
+
+import "time"
+
+// LogEntry is a single log record as passed to hooks. Message and Fields
+// have already been redacted.
+type LogEntry struct {
+	Level   LogLevel
+	Time    time.Time
+	File    string
+	Line    int
+	Message string
+	Fields  map[string]any
+}
+
+// AddHook makes l call fn for every entry after it has been written. Hooks
+// run synchronously in the logging goroutine and filter levels themselves;
+// wrap slow ones with AsyncHook. A panicking hook is recovered and does not
+// affect logging. Child loggers created afterwards inherit the hook.
+func (l *Logger) AddHook(fn func(entry LogEntry)) {
+	l.hooks = append(l.hooks, fn)
+}
+
+func newLogEntry(level LogLevel, now time.Time, fileName string, line int, message string, fields []field) LogEntry {
+	entry := LogEntry{Level: level, Time: now, File: fileName, Line: line, Message: message}
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]any, len(fields))
+		for _, f := range fields {
+			entry.Fields[f.key] = f.value
+		}
+	}
+	return entry
+}
+
+// runHooks delivers entry to every hook, isolating each from panics in the
+// others.
+func (l *Logger) runHooks(entry LogEntry) {
+	for _, hook := range l.hooks {
+		func() {
+			defer func() { _ = recover() }()
+			hook(entry)
+		}()
+	}
+}
+
+// AsyncHook wraps fn so entries are queued and delivered from a background
+// goroutine, keeping slow sinks off the logging path. When the queue holds
+// queueSize entries, new ones are dropped rather than blocking the caller.
+func AsyncHook(fn func(entry LogEntry), queueSize int) func(entry LogEntry) {
+	queue := make(chan LogEntry, queueSize)
+	go func() {
+		for entry := range queue {
+			func() {
+				defer func() { _ = recover() }()
+				fn(entry)
+			}()
+		}
+	}()
+
+	return func(entry LogEntry) {
+		select {
+		case queue <- entry:
+		default:
+		}
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	logGolang "log"
+	"log/slog"
 	"os"
 	"path"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/slice-soft/ss-keel-core/contracts"
@@ -25,17 +29,62 @@ type Logger struct {
 	isProduction bool
 	writer       io.Writer
 	format       LogFormat
+	fields       []field
+	outputs      []output // additional sinks; see AddOutput
+	redactKeys   []string // lower-cased field names; see RedactKeys
+	redactRegexp []*regexp.Regexp
+	hooks        []func(LogEntry)
+	callerSkip   int          // extra frames to skip when reporting file:line
+	slog         slog.Handler // when set, entries are emitted through it instead of writer
+	sampler      *Sampler     // shared by child loggers; see WithSampling
+
+	// ErrorExits restores the old behavior of Error exiting the process.
+	//
+	// Deprecated: use Fatal for unrecoverable errors. This flag will be
+	// removed in the next release.
+	ErrorExits bool
+}
+
+// field is a structured key/value attached to every entry of a Logger.
+type field struct {
+	key   string
+	value any
+}
+
+// output is an additional sink with its own format and minimum level.
+type output struct {
+	writer   io.Writer
+	format   LogFormat
+	minLevel LogLevel
 }
 
+// LogLevel is the severity of a log entry.
 type LogLevel string
 
 const (
-	infoLevel  LogLevel = "INFO"
-	warnLevel  LogLevel = "WARN"
-	errorLevel LogLevel = "ERROR"
-	debugLevel LogLevel = "DEBUG"
+	LevelDebug LogLevel = "DEBUG"
+	LevelInfo  LogLevel = "INFO"
+	LevelWarn  LogLevel = "WARN"
+	LevelError LogLevel = "ERROR"
+	LevelFatal LogLevel = "FATAL"
 )
 
+// rank orders levels from least to most severe.
+func (lv LogLevel) rank() int {
+	switch lv {
+	case LevelDebug:
+		return 0
+	case LevelInfo:
+		return 1
+	case LevelWarn:
+		return 2
+	case LevelError:
+		return 3
+	default:
+		return 4
+	}
+}
+
 var _ contracts.Logger = (*Logger)(nil)
 
 // NewLogger creates a new Logger instance using text format.
@@ -50,71 +99,248 @@ func NewLoggerWithFormat(isProduction bool, format LogFormat) *Logger {
 	return &Logger{isProduction: isProduction, writer: os.Stdout, format: format}
 }
 
-// WithWriter returns a new Logger with a custom writer.
-// Useful for testing — inject a bytes.Buffer to capture output.
+// WithWriter returns a new Logger with a custom primary writer. Outputs added
+// with AddOutput are kept. Useful for testing — inject a bytes.Buffer to
+// capture output.
 func (l *Logger) WithWriter(w io.Writer) *Logger {
-	return &Logger{isProduction: l.isProduction, writer: w, format: l.format}
+	child := l.clone()
+	child.writer = w
+	return child
+}
+
+// Structured reports whether l emits discrete fields rather than one text
+// line, i.e. it writes JSON or forwards to a slog.Handler.
+func (l *Logger) Structured() bool {
+	return l.format == LogFormatJSON || l.slog != nil
+}
+
+// AddOutput makes l also write every entry at minLevel or above to w in the
+// given format. Call it while setting up; child loggers created afterwards
+// inherit the output. Write errors on one output do not affect the others.
+func (l *Logger) AddOutput(w io.Writer, format LogFormat, minLevel LogLevel) {
+	l.outputs = append(l.outputs, output{writer: w, format: format, minLevel: minLevel})
+}
+
+// Redacted replaces sensitive values in log output.
+const Redacted = "[REDACTED]"
+
+// RedactKeys makes l replace the value of any structured field whose name
+// matches one of keys, ignoring case. Child loggers created afterwards
+// inherit the setting.
+func (l *Logger) RedactKeys(keys ...string) {
+	for _, k := range keys {
+		l.redactKeys = append(l.redactKeys, strings.ToLower(k))
+	}
+}
+
+// RedactPatterns makes l replace every match of the given regular expressions
+// in formatted messages. It panics if a pattern does not compile.
+func (l *Logger) RedactPatterns(patterns ...string) {
+	for _, p := range patterns {
+		l.redactRegexp = append(l.redactRegexp, regexp.MustCompile(p))
+	}
+}
+
+// ShouldRedact reports whether key is one of the keys passed to RedactKeys.
+func (l *Logger) ShouldRedact(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range l.redactKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// redact applies the redaction rules to a message and the logger fields.
+func (l *Logger) redact(message string) (string, []field) {
+	for _, re := range l.redactRegexp {
+		message = re.ReplaceAllString(message, Redacted)
+	}
+	if len(l.redactKeys) == 0 {
+		return message, l.fields
+	}
+
+	fields := make([]field, len(l.fields))
+	for i, f := range l.fields {
+		if l.ShouldRedact(f.key) {
+			f.value = Redacted
+		}
+		fields[i] = f
+	}
+	return message, fields
+}
+
+// With returns a child Logger that adds key=value to every entry.
+// The receiver is not modified.
+func (l *Logger) With(key string, value any) *Logger {
+	child := l.clone()
+	child.fields = append(child.fields, field{key: key, value: value})
+	return child
+}
+
+// WithFields returns a child Logger that adds all fields to every entry,
+// in key order. The receiver is not modified.
+func (l *Logger) WithFields(fields map[string]any) *Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	child := l.clone()
+	for _, k := range keys {
+		child.fields = append(child.fields, field{key: k, value: fields[k]})
+	}
+	return child
+}
+
+// clone returns a copy of l that shares no mutable state with it.
+func (l *Logger) clone() *Logger {
+	child := *l
+	child.fields = append([]field(nil), l.fields...)
+	child.outputs = append([]output(nil), l.outputs...)
+	child.redactKeys = append([]string(nil), l.redactKeys...)
+	child.redactRegexp = append([]*regexp.Regexp(nil), l.redactRegexp...)
+	child.hooks = append([]func(LogEntry){}, l.hooks...)
+	return &child
+}
+
+// WithCallerSkip returns a copy of l that skips delta more stack frames when
+// reporting file:line, so entries logged through a wrapper function point at
+// the wrapper's caller. Pass 1 per wrapping function.
+func (l *Logger) WithCallerSkip(delta int) *Logger {
+	child := l.clone()
+	child.callerSkip += delta
+	return child
 }
 
 // caller returns the filename and line number of the calling function.
 func (l *Logger) caller() (string, int) {
-	_, file, line, ok := runtime.Caller(2)
+	_, file, line, ok := runtime.Caller(2 + l.callerSkip)
 	if !ok {
 		return "???", 0
 	}
 	return path.Base(file), line
 }
 
-// log writes a formatted log message at the specified level with file and line information.
+// log writes a formatted log message at the specified level with file and line
+// information to the primary writer and every additional output.
 func (l *Logger) log(level LogLevel, fileName string, line int, format string, args ...interface{}) {
-	message := fmt.Sprintf(format, args...)
-
-	if l.format == LogFormatJSON {
-		entry := map[string]any{
-			"level": string(level),
-			"ts":    time.Now().Format(time.RFC3339),
-			"file":  fileName,
-			"line":  line,
-			"msg":   message,
+	dropped, ok := l.sample(level, format)
+	if !ok {
+		return
+	}
+	message, fields := l.redact(fmt.Sprintf(format, args...))
+	if dropped > 0 {
+		// Cap the slice so appending never writes into the logger's own fields.
+		fields = append(fields[:len(fields):len(fields)],
+			field{key: "sampled", value: true},
+			field{key: "dropped", value: dropped})
+	}
+	now := time.Now()
+
+	if len(l.hooks) > 0 {
+		defer l.runHooks(newLogEntry(level, now, fileName, line, message, fields))
+	}
+
+	if l.slog != nil {
+		l.logSlog(level, fileName, line, message, fields)
+		return
+	}
+
+	rendered := make(map[LogFormat]string, 2)
+	entry := func(f LogFormat) string {
+		if s, ok := rendered[f]; ok {
+			return s
 		}
-		b, _ := json.Marshal(entry)
-		if level == errorLevel {
-			logGolang.Fatalln(string(b))
+		s := render(f, now, level, fileName, line, message, fields)
+		rendered[f] = s
+		return s
+	}
+
+	fmt.Fprintln(l.writer, entry(l.format))
+	for _, o := range l.outputs {
+		if level.rank() < o.minLevel.rank() {
+			continue
 		}
-		fmt.Fprintln(l.writer, string(b))
-		return
+		// A failing sink must not prevent the others from receiving the entry.
+		_, _ = fmt.Fprintln(o.writer, entry(o.format))
 	}
+}
 
-	timeStamp := time.Now().Format("2006-01-02 15:04:05")
-	logLine := fmt.Sprintf("[KEEL] [%s] [%s] [%s:%d] %s", timeStamp, level, fileName, line, message)
-	if level == errorLevel {
-		logGolang.Fatalln(logLine)
+// render formats one entry in the given format.
+func render(format LogFormat, now time.Time, level LogLevel, fileName string, line int, message string, fields []field) string {
+	if format == LogFormatJSON {
+		entry := make(map[string]any, len(fields)+5)
+		for _, f := range fields {
+			entry[f.key] = f.value
+		}
+		// Built-in keys win over fields of the same name.
+		entry["level"] = string(level)
+		entry["ts"] = now.Format(time.RFC3339)
+		entry["file"] = fileName
+		entry["line"] = line
+		entry["msg"] = message
+		b, _ := json.Marshal(entry)
+		return string(b)
+	}
+
+	logLine := fmt.Sprintf("[KEEL] [%s] [%s] [%s:%d] %s", now.Format("2006-01-02 15:04:05"), level, fileName, line, message)
+	for _, f := range fields {
+		logLine += " " + f.key + "=" + formatFieldValue(f.value)
 	}
-	fmt.Fprintln(l.writer, logLine)
+	return logLine
+}
+
+// formatFieldValue renders a field value for text output, quoting it when it
+// would otherwise be ambiguous.
+func formatFieldValue(v any) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
 }
 
 // Info logs an informational message.
 func (l *Logger) Info(format string, args ...interface{}) {
 	f, line := l.caller()
-	l.log(infoLevel, f, line, format, args...)
+	l.log(LevelInfo, f, line, format, args...)
 }
 
 // Warn logs a warning message.
 func (l *Logger) Warn(format string, args ...interface{}) {
 	f, line := l.caller()
-	l.log(warnLevel, f, line, format, args...)
+	l.log(LevelWarn, f, line, format, args...)
 }
 
-// Error logs an error message and exits the application.
+// Error logs an error message.
 func (l *Logger) Error(format string, args ...interface{}) {
 	f, line := l.caller()
-	l.log(errorLevel, f, line, format, args...)
+	l.log(LevelError, f, line, format, args...)
+	l.exitIfErrorExits()
+}
+
+// exitIfErrorExits preserves the deprecated exit-on-Error behavior.
+func (l *Logger) exitIfErrorExits() {
+	if l.ErrorExits {
+		os.Exit(1)
+	}
+}
+
+// Fatal logs an error message and exits the application with status 1.
+// Reserve it for failures the process cannot recover from, such as startup.
+func (l *Logger) Fatal(format string, args ...interface{}) {
+	f, line := l.caller()
+	l.log(LevelFatal, f, line, format, args...)
+	os.Exit(1)
 }
 
 // Debug logs a debug message. Disabled in production.
 func (l *Logger) Debug(format string, args ...interface{}) {
 	if !l.isProduction {
 		f, line := l.caller()
-		l.log(debugLevel, f, line, format, args...)
+		l.log(LevelDebug, f, line, format, args...)
 	}
 }
@@ -2,9 +2,18 @@ package logger
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 // newTestLogger creates a Logger with a buffer for capturing output.
@@ -324,3 +333,673 @@ func TestJSONLogFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestWithFieldsText(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(l *Logger) *Logger
+		wantEnd string
+	}{
+		{
+			name:    "single field",
+			build:   func(l *Logger) *Logger { return l.With("user_id", 42) },
+			wantEnd: "order placed user_id=42",
+		},
+		{
+			name:    "chained fields keep order",
+			build:   func(l *Logger) *Logger { return l.With("user_id", 42).With("order_id", "A-1") },
+			wantEnd: "order placed user_id=42 order_id=A-1",
+		},
+		{
+			name:    "map fields are sorted by key",
+			build:   func(l *Logger) *Logger { return l.WithFields(map[string]any{"b": 2, "a": 1}) },
+			wantEnd: "order placed a=1 b=2",
+		},
+		{
+			name:    "values with spaces are quoted",
+			build:   func(l *Logger) *Logger { return l.With("reason", "out of stock") },
+			wantEnd: `order placed reason="out of stock"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newTestLogger(false)
+			tt.build(log).Info("order placed")
+
+			line := strings.TrimSpace(buf.String())
+			if !strings.HasSuffix(line, tt.wantEnd) {
+				t.Errorf("line = %q, want suffix %q", line, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestWithFieldsJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := NewLoggerWithFormat(false, LogFormatJSON).
+		WithFields(map[string]any{"user_id": 42, "msg": "shadowed"}).
+		With("order_id", "A-1").
+		WithWriter(buf)
+
+	log.Info("order placed")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if entry["user_id"] != float64(42) {
+		t.Errorf("user_id = %v, want 42", entry["user_id"])
+	}
+	if entry["order_id"] != "A-1" {
+		t.Errorf("order_id = %v, want A-1", entry["order_id"])
+	}
+	if entry["msg"] != "order placed" {
+		t.Errorf("msg = %v, built-in keys should not be overridden by fields", entry["msg"])
+	}
+}
+
+func TestWithDoesNotModifyParent(t *testing.T) {
+	parent, buf := newTestLogger(false)
+	parent = parent.With("service", "orders")
+
+	child := parent.With("user_id", 1)
+	sibling := parent.With("user_id", 2)
+
+	parent.Info("parent")
+	child.Info("child")
+	sibling.Info("sibling")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	if strings.Contains(lines[0], "user_id") {
+		t.Errorf("parent should not carry child fields: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "service=orders user_id=1") {
+		t.Errorf("child line = %q, want inherited and own fields", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "service=orders user_id=2") {
+		t.Errorf("sibling line = %q, should not see the other child's field", lines[2])
+	}
+}
+
+func TestErrorLogsAndReturns(t *testing.T) {
+	log, buf := newTestLogger(true)
+	log.Error("payment failed: %s", "timeout")
+
+	output := buf.String()
+	if !strings.Contains(output, "[ERROR]") {
+		t.Errorf("output missing ERROR level, got: %q", output)
+	}
+	if !strings.Contains(output, "payment failed: timeout") {
+		t.Errorf("output missing message, got: %q", output)
+	}
+}
+
+// runExitHelper re-runs the current test binary so exit paths can be
+// observed without terminating the test process.
+func runExitHelper(t *testing.T, mode string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=TestExitHelper")
+	cmd.Env = append(os.Environ(), "KEEL_LOGGER_EXIT_HELPER="+mode)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestExitHelper(t *testing.T) {
+	switch os.Getenv("KEEL_LOGGER_EXIT_HELPER") {
+	case "fatal":
+		NewLogger(false).Fatal("cannot start: %s", "port in use")
+	case "error-exits":
+		log := NewLogger(false)
+		log.ErrorExits = true
+		log.Error("legacy error")
+	}
+}
+
+func TestFatalExits(t *testing.T) {
+	out, err := runExitHelper(t, "fatal")
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("process error = %v, want exit status 1", err)
+	}
+	if !strings.Contains(out, "[FATAL]") || !strings.Contains(out, "cannot start: port in use") {
+		t.Errorf("output = %q, want FATAL entry", out)
+	}
+}
+
+func TestErrorExitsFlag(t *testing.T) {
+	out, err := runExitHelper(t, "error-exits")
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("process error = %v, want exit status 1", err)
+	}
+	if !strings.Contains(out, "legacy error") {
+		t.Errorf("output = %q, want the error entry before exiting", out)
+	}
+}
+
+func TestCtxLogging(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = ContextWithTrace(ctx, "trace-abc", "span-def")
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		logFunc   func(l *Logger, ctx context.Context)
+		wantLevel string
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:      "info with ids",
+			ctx:       ctx,
+			logFunc:   func(l *Logger, ctx context.Context) { l.InfoCtx(ctx, "charged card") },
+			wantLevel: "INFO",
+			want:      []string{"request_id=req-1", "trace_id=trace-abc", "span_id=span-def"},
+		},
+		{
+			name:      "warn with ids",
+			ctx:       ctx,
+			logFunc:   func(l *Logger, ctx context.Context) { l.WarnCtx(ctx, "charged card") },
+			wantLevel: "WARN",
+			want:      []string{"request_id=req-1"},
+		},
+		{
+			name:      "error with ids",
+			ctx:       ctx,
+			logFunc:   func(l *Logger, ctx context.Context) { l.ErrorCtx(ctx, "charged card") },
+			wantLevel: "ERROR",
+			want:      []string{"request_id=req-1"},
+		},
+		{
+			name:      "debug with ids",
+			ctx:       ctx,
+			logFunc:   func(l *Logger, ctx context.Context) { l.DebugCtx(ctx, "charged card") },
+			wantLevel: "DEBUG",
+			want:      []string{"trace_id=trace-abc"},
+		},
+		{
+			name:      "context without ids",
+			ctx:       context.Background(),
+			logFunc:   func(l *Logger, ctx context.Context) { l.InfoCtx(ctx, "charged card") },
+			wantLevel: "INFO",
+			notWant:   []string{"request_id", "trace_id", "span_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, buf := newTestLogger(false)
+			tt.logFunc(log, tt.ctx)
+
+			output := buf.String()
+			if !strings.Contains(output, "["+tt.wantLevel+"]") || !strings.Contains(output, "charged card") {
+				t.Fatalf("output = %q, want %s entry", output, tt.wantLevel)
+			}
+			if !strings.Contains(output, "logger_test.go") {
+				t.Errorf("output = %q, caller should point at the test", output)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(output, w) {
+					t.Errorf("output = %q, missing %q", output, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(output, w) {
+					t.Errorf("output = %q, should not contain %q", output, w)
+				}
+			}
+		})
+	}
+}
+
+func TestContextAccessors(t *testing.T) {
+	ctx := context.Background()
+	if RequestIDFromContext(ctx) != "" {
+		t.Error("empty context should have no request ID")
+	}
+	if traceID, spanID := TraceFromContext(ctx); traceID != "" || spanID != "" {
+		t.Error("empty context should have no trace IDs")
+	}
+
+	ctx = ContextWithRequestID(ctx, "req-1")
+	ctx = ContextWithTrace(ctx, "t", "s")
+	if RequestIDFromContext(ctx) != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want req-1", RequestIDFromContext(ctx))
+	}
+	if traceID, spanID := TraceFromContext(ctx); traceID != "t" || spanID != "s" {
+		t.Errorf("TraceFromContext() = (%q, %q), want (t, s)", traceID, spanID)
+	}
+}
+
+func TestSlogHandlerRoutesIntoLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewLoggerWithFormat(false, LogFormatJSON).WithWriter(buf)
+	sl := slog.New(NewSlogHandler(l)).With("service", "orders").WithGroup("req")
+
+	sl.Info("charged card", "amount", 42, slog.Group("user", "id", 7))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"level":       "INFO",
+		"msg":         "charged card",
+		"service":     "orders",
+		"req.amount":  float64(42),
+		"req.user.id": float64(7),
+		"file":        "logger_test.go",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+}
+
+func TestSlogLevelMapping(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  LogLevel
+	}{
+		{slog.LevelDebug, LevelDebug},
+		{slog.LevelInfo, LevelInfo},
+		{slog.LevelWarn, LevelWarn},
+		{slog.LevelError, LevelError},
+		{slog.LevelError + 4, LevelFatal},
+		{slog.LevelInfo + 2, LevelInfo},
+	}
+	for _, tt := range tests {
+		if got := fromSlogLevel(tt.level); got != tt.want {
+			t.Errorf("fromSlogLevel(%v) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+
+	for _, lvl := range []LogLevel{LevelDebug, LevelInfo, LevelWarn, LevelError, LevelFatal} {
+		if got := fromSlogLevel(toSlogLevel(lvl)); got != lvl {
+			t.Errorf("round trip of %v = %v", lvl, got)
+		}
+	}
+}
+
+func TestSlogHandlerDebugDisabledInProduction(t *testing.T) {
+	h := NewSlogHandler(NewLogger(true))
+	if h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug should be disabled in production")
+	}
+	if !h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info should be enabled in production")
+	}
+}
+
+func TestFromSlog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelWarn})
+	l := FromSlog(h).With("user_id", 42)
+
+	l.Info("filtered by the handler level")
+	l.Warn("low stock: %d", 3)
+	l.Error("payment failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "low stock: 3" || entry["user_id"] != float64(42) {
+		t.Errorf("entry = %v, want WARN with fields", entry)
+	}
+	if entry["file"] != "logger_test.go" {
+		t.Errorf("file = %v, want logger_test.go", entry["file"])
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["level"] != "ERROR" {
+		t.Errorf("level = %v, want ERROR", entry["level"])
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestAddOutput(t *testing.T) {
+	text := &bytes.Buffer{}
+	jsonBuf := &bytes.Buffer{}
+
+	log := NewLogger(false).WithWriter(text)
+	log.AddOutput(failingWriter{}, LogFormatText, LevelDebug)
+	log.AddOutput(jsonBuf, LogFormatJSON, LevelWarn)
+
+	log.Info("server started")
+	log.Warn("disk almost full")
+
+	textLines := strings.Split(strings.TrimSpace(text.String()), "\n")
+	if len(textLines) != 2 {
+		t.Fatalf("primary sink got %d lines, want 2: %q", len(textLines), text.String())
+	}
+	if !strings.HasPrefix(textLines[0], "[KEEL]") {
+		t.Errorf("primary sink should use text format, got %q", textLines[0])
+	}
+
+	jsonLines := strings.Split(strings.TrimSpace(jsonBuf.String()), "\n")
+	if len(jsonLines) != 1 {
+		t.Fatalf("json sink got %d lines, want only the WARN entry: %q", len(jsonLines), jsonBuf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(jsonLines[0]), &entry); err != nil {
+		t.Fatalf("json sink should use JSON format: %v", err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "disk almost full" {
+		t.Errorf("json entry = %v, want the WARN entry", entry)
+	}
+}
+
+func TestAddOutputInheritance(t *testing.T) {
+	primary := &bytes.Buffer{}
+	extra := &bytes.Buffer{}
+
+	log := NewLogger(false)
+	log.AddOutput(extra, LogFormatText, LevelInfo)
+
+	child := log.With("user_id", 1).WithWriter(primary)
+	child.Info("hello")
+
+	if !strings.Contains(primary.String(), "hello") {
+		t.Error("WithWriter should replace the primary sink")
+	}
+	if !strings.Contains(extra.String(), "hello user_id=1") {
+		t.Errorf("child loggers should inherit outputs, got %q", extra.String())
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  LogFormat
+		setup   func(l *Logger)
+		logFunc func(l *Logger)
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "field names in text",
+			format: LogFormatText,
+			setup:  func(l *Logger) { l.RedactKeys("Authorization", "email") },
+			logFunc: func(l *Logger) {
+				l.With("authorization", "Bearer abc").With("EMAIL", "a@b.co").With("user_id", 7).Info("login")
+			},
+			want:    []string{"authorization=[REDACTED]", "EMAIL=[REDACTED]", "user_id=7"},
+			notWant: []string{"Bearer abc", "a@b.co"},
+		},
+		{
+			name:    "field names in json",
+			format:  LogFormatJSON,
+			setup:   func(l *Logger) { l.RedactKeys("token") },
+			logFunc: func(l *Logger) { l.With("Token", "secret-value").Info("login") },
+			want:    []string{`"Token":"[REDACTED]"`},
+			notWant: []string{"secret-value"},
+		},
+		{
+			name:    "patterns in text",
+			format:  LogFormatText,
+			setup:   func(l *Logger) { l.RedactPatterns(`[\w.]+@[\w.]+`, `Bearer \S+`) },
+			logFunc: func(l *Logger) { l.Info("user %s sent Bearer abc.def", "a@b.co") },
+			want:    []string{"user [REDACTED] sent [REDACTED]"},
+			notWant: []string{"a@b.co", "abc.def"},
+		},
+		{
+			name:    "patterns in json",
+			format:  LogFormatJSON,
+			setup:   func(l *Logger) { l.RedactPatterns(`\d{4}-\d{4}-\d{4}-\d{4}`) },
+			logFunc: func(l *Logger) { l.Warn("card 4111-1111-1111-1111 declined") },
+			want:    []string{`"msg":"card [REDACTED] declined"`},
+			notWant: []string{"4111"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			log := NewLoggerWithFormat(false, tt.format).WithWriter(buf)
+			tt.setup(log)
+			tt.logFunc(log)
+
+			output := buf.String()
+			for _, w := range tt.want {
+				if !strings.Contains(output, w) {
+					t.Errorf("output = %q, missing %q", output, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(output, w) {
+					t.Errorf("output = %q, should not contain %q", output, w)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactPatternsPanicsOnInvalidRegexp(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RedactPatterns() should panic for an invalid pattern")
+		}
+	}()
+	NewLogger(false).RedactPatterns("(")
+}
+
+func TestAddHook(t *testing.T) {
+	log, buf := newTestLogger(false)
+	log.RedactKeys("token")
+
+	var entries []LogEntry
+	log.AddHook(func(e LogEntry) {
+		if e.Level == LevelWarn || e.Level == LevelError {
+			entries = append(entries, e)
+		}
+	})
+
+	child := log.With("order_id", "A-1").With("token", "abc")
+	child.Info("ignored by the hook filter")
+	child.Warn("low stock: %d", 3)
+
+	if len(entries) != 1 {
+		t.Fatalf("hook received %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Level != LevelWarn || e.Message != "low stock: 3" {
+		t.Errorf("entry = %+v, want WARN low stock: 3", e)
+	}
+	if e.File != "logger_test.go" || e.Line == 0 || e.Time.IsZero() {
+		t.Errorf("entry location/time not set: %+v", e)
+	}
+	if e.Fields["order_id"] != "A-1" || e.Fields["token"] != Redacted {
+		t.Errorf("fields = %v, want order_id and redacted token", e.Fields)
+	}
+	if !strings.Contains(buf.String(), "low stock: 3") {
+		t.Error("entry should still be written")
+	}
+}
+
+func TestPanickingHookDoesNotBreakLogging(t *testing.T) {
+	log, buf := newTestLogger(false)
+
+	calls := 0
+	log.AddHook(func(LogEntry) { panic("bad hook") })
+	log.AddHook(func(LogEntry) { calls++ })
+
+	log.Info("first")
+	log.Info("second")
+
+	if calls != 2 {
+		t.Errorf("later hook ran %d times, want 2", calls)
+	}
+	if !strings.Contains(buf.String(), "first") || !strings.Contains(buf.String(), "second") {
+		t.Errorf("entries should be written despite the panicking hook, got %q", buf.String())
+	}
+}
+
+func TestAsyncHook(t *testing.T) {
+	received := make(chan LogEntry, 1)
+	hook := AsyncHook(func(e LogEntry) { received <- e }, 4)
+
+	log, _ := newTestLogger(false)
+	log.AddHook(hook)
+	log.Error("payment failed")
+
+	select {
+	case e := <-received:
+		if e.Message != "payment failed" || e.Level != LevelError {
+			t.Errorf("entry = %+v, want ERROR payment failed", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("async hook did not deliver the entry")
+	}
+}
+
+func TestAsyncHookDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	hook := AsyncHook(func(LogEntry) { <-block }, 1)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			hook(LogEntry{Message: "x"})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("AsyncHook should drop entries instead of blocking when full")
+	}
+}
+
+// logf is a wrapper like the ones teams put around the logger.
+func logf(l *Logger, msg string) {
+	l.WithCallerSkip(1).Info("%s", msg)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	log, buf := newTestLogger(false)
+
+	_, _, wantLine, _ := runtime.Caller(0)
+	logf(log, "through wrapper")
+	wantLine++
+
+	output := buf.String()
+	want := "[logger_test.go:" + strconv.Itoa(wantLine) + "]"
+	if !strings.Contains(output, want) {
+		t.Errorf("output = %q, want call site %s", output, want)
+	}
+
+	buf.Reset()
+	log.WithCallerSkip(1).WithCallerSkip(-1).Info("net zero")
+	if strings.Contains(buf.String(), "testing.go") {
+		t.Errorf("skips should add up, got %q", buf.String())
+	}
+	if log.callerSkip != 0 {
+		t.Error("WithCallerSkip should not modify the receiver")
+	}
+}
+
+func TestStructured(t *testing.T) {
+	if NewLogger(false).Structured() {
+		t.Error("text logger should not be structured")
+	}
+	if !NewLoggerWithFormat(false, LogFormatJSON).Structured() {
+		t.Error("JSON logger should be structured")
+	}
+	if !FromSlog(slog.NewTextHandler(&bytes.Buffer{}, nil)).Structured() {
+		t.Error("slog-backed logger should be structured")
+	}
+}
+
+func TestSamplerAllow(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewSampler(SamplingConfig{Initial: 2, Thereafter: 3, Window: time.Minute})
+	s.now = func() time.Time { return now }
+
+	var written []int
+	var drops []int
+	for i := 1; i <= 10; i++ {
+		if ok, dropped := s.Allow("k"); ok {
+			written = append(written, i)
+			drops = append(drops, dropped)
+		}
+	}
+	// First two, then every third past Initial: 5 and 8.
+	if got, want := written, []int{1, 2, 5, 8}; !slices.Equal(got, want) {
+		t.Errorf("written = %v, want %v", got, want)
+	}
+	if got, want := drops, []int{0, 0, 2, 2}; !slices.Equal(got, want) {
+		t.Errorf("dropped = %v, want %v", got, want)
+	}
+
+	// Entries 9 and 10 were dropped; the next window reports them.
+	now = now.Add(time.Minute)
+	if ok, dropped := s.Allow("k"); !ok || dropped != 2 {
+		t.Errorf("first entry of new window = (%v, %d), want (true, 2)", ok, dropped)
+	}
+	if ok, dropped := s.Allow("other"); !ok || dropped != 0 {
+		t.Errorf("keys must be sampled independently, got (%v, %d)", ok, dropped)
+	}
+}
+
+func TestWithSampling(t *testing.T) {
+	base, buf := newTestLogger(false)
+	log := base.WithSampling(SamplingConfig{Initial: 1, Thereafter: 0, Window: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		log.Warn("not found: %d", i)
+	}
+	log.Info("not found: %d", 99)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one WARN and one INFO line, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[0], "not found: 0") || !strings.Contains(lines[1], "[INFO]") {
+		t.Errorf("unexpected lines:\n%s", buf.String())
+	}
+
+	base.Warn("not found: %d", 7)
+	if !strings.Contains(buf.String(), "not found: 7") {
+		t.Error("parent logger must not be sampled")
+	}
+}
+
+func TestWithSamplingReportsDropped(t *testing.T) {
+	log, buf := newTestLogger(false)
+	log = log.With("svc", "api").WithSampling(SamplingConfig{Initial: 1, Thereafter: 4, Window: time.Hour})
+
+	for i := 0; i < 5; i++ {
+		log.Warn("retrying")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got:\n%s", buf.String())
+	}
+	if strings.Contains(lines[0], "sampled=") {
+		t.Errorf("first line should not be marked sampled: %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "svc=api sampled=true dropped=3") {
+		t.Errorf("resumed line should report drops, got %q", lines[1])
+	}
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// maxSampleKeys bounds how many keys a Sampler tracks before it forgets
+// those whose window has expired.
+const maxSampleKeys = 1024
+
+// SamplingConfig limits how often entries sharing a key are written.
+// Within each Window the first Initial entries are written, then every
+// Thereafter-th one; the rest are dropped. A zero Thereafter drops every
+// entry past Initial until the window ends. Window defaults to one second.
+type SamplingConfig struct {
+	Initial    int
+	Thereafter int
+	Window     time.Duration
+}
+
+// Sampler decides which entries of a repeated message are written. It is
+// safe for concurrent use.
+type Sampler struct {
+	cfg      SamplingConfig
+	now      func() time.Time
+	mu       sync.Mutex
+	counters map[string]*sampleCounter
+}
+
+type sampleCounter struct {
+	start   time.Time
+	count   int
+	dropped int // dropped since the last entry written for this key
+}
+
+// NewSampler returns a Sampler applying cfg.
+func NewSampler(cfg SamplingConfig) *Sampler {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Second
+	}
+	return &Sampler{cfg: cfg, now: time.Now, counters: make(map[string]*sampleCounter)}
+}
+
+// Allow reports whether the next entry for key should be written. When it
+// is, dropped is the number of entries for key skipped since the last one
+// written, so callers can record the gap.
+func (s *Sampler) Allow(key string) (ok bool, dropped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	c := s.counters[key]
+	if c == nil {
+		if len(s.counters) >= maxSampleKeys {
+			s.prune(now)
+		}
+		c = &sampleCounter{start: now}
+		s.counters[key] = c
+	}
+	if now.Sub(c.start) >= s.cfg.Window {
+		c.start = now
+		c.count = 0
+	}
+	c.count++
+
+	over := c.count - s.cfg.Initial
+	if over > 0 && (s.cfg.Thereafter <= 0 || over%s.cfg.Thereafter != 0) {
+		c.dropped++
+		return false, 0
+	}
+	dropped, c.dropped = c.dropped, 0
+	return true, dropped
+}
+
+// prune forgets keys whose window has expired and that have no pending
+// drop count.
+func (s *Sampler) prune(now time.Time) {
+	for k, c := range s.counters {
+		if c.dropped == 0 && now.Sub(c.start) >= s.cfg.Window {
+			delete(s.counters, k)
+		}
+	}
+}
+
+// WithSampling returns a child Logger that samples entries per level and
+// message template: see SamplingConfig. The first entry written after some
+// were dropped carries sampled=true and dropped=<count> fields. Fatal entries
+// are never dropped.
+func (l *Logger) WithSampling(cfg SamplingConfig) *Logger {
+	child := l.clone()
+	child.sampler = NewSampler(cfg)
+	return child
+}
+
+// sample applies the logger's sampler before an entry is formatted. It
+// reports whether the entry is written and how many were dropped before it.
+func (l *Logger) sample(level LogLevel, format string) (dropped int, ok bool) {
+	if l.sampler == nil || level == LevelFatal {
+		return 0, true
+	}
+	ok, dropped = l.sampler.Allow(string(level) + " " + format)
+	return dropped, ok
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"runtime"
+	"time"
+)
+
+// slogLevelFatal is the slog level used for Fatal entries.
+const slogLevelFatal = slog.LevelError + 4
+
+// slogHandler routes slog records into a Keel Logger.
+type slogHandler struct {
+	logger *Logger
+	group  string // dotted prefix applied to attributes added later
+}
+
+// NewSlogHandler returns a slog.Handler that writes records through l, so
+// libraries that take a *slog.Logger share the Keel format. Record attributes
+// become structured fields, with groups flattened into dotted keys.
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo || !h.logger.isProduction
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	l := h.logger
+	if r.NumAttrs() > 0 {
+		l = l.clone()
+		r.Attrs(func(a slog.Attr) bool {
+			l.fields = appendAttr(l.fields, h.group, a)
+			return true
+		})
+	}
+
+	fileName, line := "???", 0
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fileName, line = path.Base(frame.File), frame.Line
+	}
+	l.log(fromSlogLevel(r.Level), fileName, line, "%s", r.Message)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	l := h.logger.clone()
+	for _, a := range attrs {
+		l.fields = appendAttr(l.fields, h.group, a)
+	}
+	return &slogHandler{logger: l, group: h.group}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+// appendAttr flattens a, prefixing keys with group, and appends the result.
+func appendAttr(fields []field, group string, a slog.Attr) []field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+	return append(fields, field{key: group + a.Key, value: a.Value.Any()})
+}
+
+// fromSlogLevel maps a slog level onto the closest Keel level.
+func fromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level >= slogLevelFatal:
+		return LevelFatal
+	case level >= slog.LevelError:
+		return LevelError
+	case level >= slog.LevelWarn:
+		return LevelWarn
+	case level >= slog.LevelInfo:
+		return LevelInfo
+	default:
+		return LevelDebug
+	}
+}
+
+// toSlogLevel maps a Keel level onto its slog equivalent.
+func toSlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LevelFatal:
+		return slogLevelFatal
+	case LevelError:
+		return slog.LevelError
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelDebug:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// FromSlog returns a Logger that emits every entry through h instead of
+// writing its own format. Fields become slog attributes and the caller's
+// file and line are added as "file" and "line".
+func FromSlog(h slog.Handler) *Logger {
+	return &Logger{writer: nopWriter{}, format: LogFormatText, slog: h}
+}
+
+// logSlog emits one entry through the configured slog handler.
+func (l *Logger) logSlog(level LogLevel, fileName string, line int, message string, fields []field) {
+	ctx := context.Background()
+	slogLevel := toSlogLevel(level)
+	if !l.slog.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), slogLevel, message, 0)
+	r.AddAttrs(slog.String("file", fileName), slog.Int("line", line))
+	for _, f := range fields {
+		r.AddAttrs(slog.Any(f.key, f.value))
+	}
+	_ = l.slog.Handle(ctx, r)
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) { return len(p), nil }
@@ -2,6 +2,7 @@ package openapi
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
 )
@@ -73,6 +74,13 @@ type QueryParamInput struct {
 	Required    bool
 }
 
+// ResponseInput documents a response besides the success one.
+type ResponseInput struct {
+	StatusCode  int
+	Description string // defaults to the status text
+	Type        any    // body type; nil for no body
+}
+
 // RouteInput is the neutral representation of a route.
 type RouteInput struct {
 	Method      string
@@ -86,6 +94,9 @@ type RouteInput struct {
 	StatusCode  int
 	QueryParams []QueryParamInput
 	Deprecated  bool
+	// ExtraResponses override the automatic error responses with the same
+	// status code.
+	ExtraResponses []ResponseInput
 }
 
 // BuildInput groups the data to build the spec.
@@ -98,6 +109,9 @@ type BuildInput struct {
 	Servers     []ServerInfo
 	Tags        []TagInfo
 	Routes      []RouteInput
+	// SecuritySchemes defines schemes referenced by RouteInput.Secured.
+	// Schemes not listed are inferred from their name.
+	SecuritySchemes map[string]SecurityScheme
 }
 
 // Build constructs the OpenAPI 3.0 specification from the provided input.
@@ -141,7 +155,11 @@ func Build(input BuildInput) Spec {
 			for _, scheme := range route.Secured {
 				security = append(security, map[string][]string{scheme: {}})
 				if _, exists := securitySchemes[scheme]; !exists {
-					securitySchemes[scheme] = inferSecurityScheme(scheme)
+					if defined, ok := input.SecuritySchemes[scheme]; ok {
+						securitySchemes[scheme] = defined
+					} else {
+						securitySchemes[scheme] = inferSecurityScheme(scheme)
+					}
 				}
 			}
 			operation["security"] = security
@@ -182,13 +200,19 @@ func registerStandardSchemas(schemas map[string]any) {
 			"status_code": map[string]any{"type": "integer"},
 			"code":        map[string]any{"type": "string"},
 			"message":     map[string]any{"type": "string"},
+			"request_id":  map[string]any{"type": "string"},
+			"trace_id":    map[string]any{"type": "string", "description": "present when tracing is enabled"},
 		},
 		"required": []string{"status_code", "code", "message"},
 	}
 	schemas["ValidationErrorItem"] = map[string]any{
 		"type": "object",
 		"properties": map[string]any{
-			"field":   map[string]any{"type": "string"},
+			"field": map[string]any{
+				"type":        "string",
+				"description": "JSON name of the invalid field, as sent by the client",
+				"example":     "email",
+			},
 			"message": map[string]any{"type": "string"},
 		},
 		"required": []string{"field", "message"},
@@ -197,7 +221,10 @@ func registerStandardSchemas(schemas map[string]any) {
 		"type": "object",
 		"properties": map[string]any{
 			"status_code": map[string]any{"type": "integer"},
+			"code":        map[string]any{"type": "string"},
 			"message":     map[string]any{"type": "string"},
+			"request_id":  map[string]any{"type": "string"},
+			"trace_id":    map[string]any{"type": "string", "description": "present when tracing is enabled"},
 			"errors": map[string]any{
 				"type":  "array",
 				"items": map[string]any{"$ref": "#/components/schemas/ValidationErrorItem"},
@@ -365,6 +392,22 @@ func buildResponses(route RouteInput, schemas map[string]any) map[string]any {
 		responses[k] = v
 	}
 
+	for _, extra := range route.ExtraResponses {
+		desc := extra.Description
+		if desc == "" {
+			desc = http.StatusText(extra.StatusCode)
+		}
+		resp := map[string]any{"description": desc}
+		if extra.Type != nil {
+			resp["content"] = map[string]any{
+				"application/json": map[string]any{
+					"schema": schemaRef(extra.Type, schemas),
+				},
+			}
+		}
+		responses[fmt.Sprintf("%d", extra.StatusCode)] = resp
+	}
+
 	return responses
 }
 
@@ -430,6 +473,16 @@ func fieldSchema(field reflect.StructField, schemas map[string]any) map[string]a
 		}
 		return prop
 	case reflect.Map:
+		elem := t.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct {
+			return map[string]any{
+				"type":                 "object",
+				"additionalProperties": schemaRef(reflect.New(elem).Interface(), schemas),
+			}
+		}
 		return map[string]any{"type": "object", "additionalProperties": true}
 	default:
 		oaType, oaFormat := goTypeToOA(t.Kind())
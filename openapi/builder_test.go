@@ -319,6 +319,39 @@ func TestReflectSchemaSlice(t *testing.T) {
 	}
 }
 
+func TestReflectSchemaMapOfStructs(t *testing.T) {
+	type CheckDTO struct {
+		Status string `json:"status"`
+	}
+	type ReportDTO struct {
+		Checks map[string]CheckDTO `json:"checks"`
+		Labels map[string]string   `json:"labels"`
+	}
+
+	schemas := map[string]any{}
+	got := reflectSchema(ReportDTO{}, schemas)
+
+	props, ok := got["properties"].(map[string]any)
+	if !ok {
+		t.Fatal("properties should be a map")
+	}
+	checks, ok := props["checks"].(map[string]any)
+	if !ok {
+		t.Fatal("checks property not found")
+	}
+	additional, ok := checks["additionalProperties"].(map[string]any)
+	if !ok {
+		t.Fatalf("checks additionalProperties = %v, want schema ref", checks["additionalProperties"])
+	}
+	if additional["$ref"] != "#/components/schemas/CheckDTO" {
+		t.Errorf("additionalProperties.$ref = %v, want #/components/schemas/CheckDTO", additional["$ref"])
+	}
+	labels, _ := props["labels"].(map[string]any)
+	if labels["additionalProperties"] != true {
+		t.Errorf("labels additionalProperties = %v, want true", labels["additionalProperties"])
+	}
+}
+
 func TestReflectSchemaPointer(t *testing.T) {
 	type DTO struct {
 		Name *string `json:"name"`
@@ -816,3 +849,35 @@ func TestBuildOperationIncludesPathParamsWhenPresent(t *testing.T) {
 		t.Errorf("param required = %v, want true", params[0]["required"])
 	}
 }
+
+func TestBuildExtraResponses(t *testing.T) {
+	type status struct {
+		Status string `json:"status"`
+	}
+	responses := buildResponses(RouteInput{
+		Method:   "GET",
+		Path:     "/ready",
+		Response: status{},
+		ExtraResponses: []ResponseInput{
+			{StatusCode: 503, Type: status{}},
+			{StatusCode: 500, Description: "Custom failure"},
+		},
+	}, map[string]any{})
+
+	down, ok := responses["503"].(map[string]any)
+	if !ok {
+		t.Fatalf("missing 503 response in %v", responses)
+	}
+	if down["description"] != "Service Unavailable" {
+		t.Errorf("503 description = %v, want the status text", down["description"])
+	}
+	schema := down["content"].(map[string]any)["application/json"].(map[string]any)["schema"]
+	if !reflect.DeepEqual(schema, map[string]any{"$ref": "#/components/schemas/status"}) {
+		t.Errorf("503 schema = %v", schema)
+	}
+
+	failure := responses["500"].(map[string]any)
+	if failure["description"] != "Custom failure" || failure["content"] != nil {
+		t.Errorf("500 = %v, want the extra response without a body to replace the automatic one", failure)
+	}
+}
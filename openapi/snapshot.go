@@ -0,0 +1,166 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateSnapshotsEnv names the environment variable that makes SnapshotTest
+// rewrite golden files instead of comparing against them.
+const UpdateSnapshotsEnv = "KEEL_UPDATE_SNAPSHOTS"
+
+// SnapshotTest fails t when spec differs from the golden file at goldenPath,
+// printing a line diff. The spec is rendered as indented JSON with every
+// object's keys sorted, so output is byte-identical across runs.
+//
+// Run the test with KEEL_UPDATE_SNAPSHOTS=1, or with -update when the test
+// binary defines that flag, to write the golden file after an intended
+// change to the API contract.
+//
+//	func TestOpenAPISnapshot(t *testing.T) {
+//		openapi.SnapshotTest(t, app.OpenAPISpec(), "testdata/openapi.json")
+//	}
+func SnapshotTest(t testing.TB, spec Spec, goldenPath string) {
+	t.Helper()
+	got, err := MarshalSnapshot(spec)
+	if err != nil {
+		t.Fatalf("openapi snapshot: %v", err)
+		return
+	}
+
+	if updateSnapshots() {
+		if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+			t.Fatalf("openapi snapshot: %v", err)
+		}
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatalf("openapi snapshot: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("openapi snapshot: %v (run with %s=1 to create it)", err, UpdateSnapshotsEnv)
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("OpenAPI spec differs from %s (run with %s=1 if intended):\n%s",
+			goldenPath, UpdateSnapshotsEnv, lineDiff(string(want), string(got)))
+	}
+}
+
+// MarshalSnapshot renders spec as indented JSON with sorted object keys.
+func MarshalSnapshot(spec Spec) ([]byte, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	// Decoding into generic values and encoding again sorts the keys of
+	// every object, including ones built from Go structs.
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+func updateSnapshots() bool {
+	if v := os.Getenv(UpdateSnapshotsEnv); v != "" && v != "0" && v != "false" {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		return f.Value.String() == "true"
+	}
+	return false
+}
+
+// maxDiffCells bounds the size of the line diff table; larger inputs only
+// report the first differing line.
+const maxDiffCells = 4_000_000
+
+// lineDiff returns a unified-style diff of two texts, showing changed lines
+// with two lines of context.
+func lineDiff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+	if len(a)*len(b) > maxDiffCells {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if a[i] != b[i] {
+				return fmt.Sprintf("first difference at line %d:\n- %s\n+ %s", i+1, a[i], b[i])
+			}
+		}
+		return fmt.Sprintf("line counts differ: %d vs %d", len(a), len(b))
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte // ' ', '-' or '+'
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	const context = 2
+	var out strings.Builder
+	last := -1
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		start := max(k-context, last+1)
+		if last >= 0 && start > last+1 {
+			out.WriteString("...\n")
+		}
+		for c := start; c < k; c++ {
+			fmt.Fprintf(&out, "  %s\n", lines[c].text)
+		}
+		fmt.Fprintf(&out, "%c %s\n", l.op, l.text)
+		last = k
+		for c := k + 1; c < len(lines) && c <= k+context && lines[c].op == ' '; c++ {
+			fmt.Fprintf(&out, "  %s\n", lines[c].text)
+			last = c
+		}
+	}
+	return out.String()
+}
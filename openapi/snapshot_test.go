@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingTB records failures instead of failing the test.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type snapshotUser struct {
+	ID    string `json:"id"`
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age,omitempty"`
+}
+
+func snapshotSpec(routes ...RouteInput) Spec {
+	return Build(BuildInput{Title: "Users", Version: "1.0.0", Routes: routes})
+}
+
+func TestMarshalSnapshotDeterministic(t *testing.T) {
+	routes := []RouteInput{
+		{Method: "GET", Path: "/users", Summary: "List", Response: []snapshotUser{}},
+		{Method: "POST", Path: "/users", Body: snapshotUser{}, Response: snapshotUser{}, StatusCode: 201},
+		{Method: "GET", Path: "/users/:id", Response: snapshotUser{}, Secured: []string{"bearerAuth"}},
+		{Method: "DELETE", Path: "/users/:id"},
+	}
+	first, err := MarshalSnapshot(snapshotSpec(routes...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, _ := MarshalSnapshot(snapshotSpec(routes...))
+		if string(again) != string(first) {
+			t.Fatalf("run %d differs:\n%s", i, lineDiff(string(first), string(again)))
+		}
+	}
+	if strings.Index(string(first), `"components"`) > strings.Index(string(first), `"info"`) {
+		t.Error("top-level keys are not sorted")
+	}
+}
+
+func TestSnapshotTest(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "testdata", "spec.json")
+	spec := snapshotSpec(RouteInput{Method: "GET", Path: "/users/:id", Summary: "Get user"})
+
+	t.Setenv(UpdateSnapshotsEnv, "1")
+	SnapshotTest(t, spec, golden)
+	t.Setenv(UpdateSnapshotsEnv, "")
+	SnapshotTest(t, spec, golden)
+
+	rec := &recordingTB{TB: t}
+	changed := snapshotSpec(RouteInput{Method: "GET", Path: "/users/:id", Summary: "Fetch user"})
+	SnapshotTest(rec, changed, golden)
+	if len(rec.errors) != 1 ||
+		!strings.Contains(rec.errors[0], `"summary": "Get user"`) ||
+		!strings.Contains(rec.errors[0], `"summary": "Fetch user"`) ||
+		!strings.Contains(rec.errors[0], "\n- ") || !strings.Contains(rec.errors[0], "\n+ ") {
+		t.Errorf("failure = %q, want a diff of the summary line", rec.errors)
+	}
+
+	rec = &recordingTB{TB: t}
+	SnapshotTest(rec, spec, filepath.Join(t.TempDir(), "missing.json"))
+	if len(rec.errors) != 1 || !strings.Contains(rec.errors[0], UpdateSnapshotsEnv+"=1 to create it") {
+		t.Errorf("missing golden failure = %q", rec.errors)
+	}
+	if _, err := os.Stat(golden); err != nil {
+		t.Errorf("golden file not written: %v", err)
+	}
+}
+
+func TestLineDiff(t *testing.T) {
+	want := "a\nb\nc\nd\ne\nf\nx\ng\nh"
+	got := "a\nb\nc\nD\ne\nf\nx\ng\nh\ni"
+	diff := lineDiff(want, got)
+	wantDiff := "  b\n  c\n- d\n+ D\n  e\n  f\n...\n  g\n  h\n+ i\n"
+	if diff != wantDiff {
+		t.Errorf("lineDiff = %q, want %q", diff, wantDiff)
+	}
+}
@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ValidateWithLocale validates s like Validate but asks t for each message
+// in locale. A nil translator, or one that returns the key unchanged, yields
+// the English message. Messages from errmsg tags and struct rules are used
+// as written.
+//
+// Message keys and the arguments passed with them:
+//
+//	validation.required   —
+//	validation.email      —
+//	validation.min        limit, e.g. "8"
+//	validation.max        limit
+//	validation.uuid       —
+//	validation.numeric    —
+//	validation.url        —
+//	validation.eqfield    other field name (also eqcsfield)
+//	validation.nefield    other field name (also necsfield)
+//	validation.gtfield    other field name (also gtcsfield)
+//	validation.gtefield   other field name (also gtecsfield)
+//	validation.ltfield    other field name (also ltcsfield)
+//	validation.ltefield   other field name (also ltecsfield)
+//	validation.required_with         other field names, e.g. "email or phone"
+//	validation.required_with_all     other field names, e.g. "email and phone"
+//	validation.required_without      other field names
+//	validation.required_without_all  other field names
+//	validation.required_if           conditions, e.g. "type is 'card'"
+//	validation.required_unless       conditions
+//	validation.excluded_if           conditions
+//	validation.excluded_unless       conditions
+//	validation.excluded_with         other field names
+//	validation.excluded_with_all     other field names
+//	validation.excluded_without      other field names
+//	validation.excluded_without_all  other field names
+//	validation.default    validator tag, e.g. "gte"
+//
+// The gt/lt keys get a ".time" suffix (e.g. "validation.gtfield.time") when
+// the compared fields are times, so they can read "after"/"before".
+func ValidateWithLocale(s any, locale string, t contracts.Translator) []FieldError {
+	if t == nil {
+		return Validate(s)
+	}
+	return validateStruct(s, func(e validator.FieldError, root reflect.Type) string {
+		return translate(describe(e, root), locale, t)
+	})
+}
+
+// translate renders m in locale, falling back to its English text.
+func translate(m message, locale string, t contracts.Translator) string {
+	if m.key == "" {
+		return m.text
+	}
+	if s := t.T(locale, m.key, m.args...); s != "" && s != m.key {
+		return s
+	}
+	return m.text
+}
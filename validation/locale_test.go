@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// mockTranslator knows a few Spanish validation messages.
+type mockTranslator struct{}
+
+func (mockTranslator) T(locale, key string, args ...any) string {
+	es := map[string]string{
+		"validation.required": "este campo es obligatorio",
+		"validation.min":      "mínimo %s caracteres",
+	}
+	if msg, ok := es[key]; ok && locale == "es" {
+		return fmt.Sprintf(msg, args...)
+	}
+	return key
+}
+
+func (mockTranslator) Locales() []string { return []string{"en", "es"} }
+
+func TestValidateWithLocale(t *testing.T) {
+	type signupDTO struct {
+		Name     string `json:"name" validate:"required"`
+		Password string `json:"password" validate:"min=8"`
+		Email    string `json:"email" validate:"omitempty,email"`
+	}
+	input := signupDTO{Password: "short", Email: "nope"}
+
+	tests := []struct {
+		name       string
+		locale     string
+		translator contracts.Translator
+		want       map[string]string
+	}{
+		{
+			name:       "translated and parameterized",
+			locale:     "es",
+			translator: mockTranslator{},
+			want: map[string]string{
+				"name":     "este campo es obligatorio",
+				"password": "mínimo 8 caracteres",
+				"email":    "must be a valid email",
+			},
+		},
+		{
+			name:       "unknown locale falls back to English",
+			locale:     "fr",
+			translator: mockTranslator{},
+			want: map[string]string{
+				"name":     "this field is required",
+				"password": "minimum 8 characters",
+				"email":    "must be a valid email",
+			},
+		},
+		{
+			name:   "nil translator",
+			locale: "es",
+			want: map[string]string{
+				"name":     "this field is required",
+				"password": "minimum 8 characters",
+				"email":    "must be a valid email",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateWithLocale(input, tt.locale, tt.translator)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs)
+			}
+			for _, e := range errs {
+				if e.Message != tt.want[e.Field] {
+					t.Errorf("%s: message = %q, want %q", e.Field, e.Message, tt.want[e.Field])
+				}
+			}
+		})
+	}
+}
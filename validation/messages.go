@@ -0,0 +1,285 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// humanMessage returns a user-friendly error message for a validation error.
+func humanMessage(e validator.FieldError, root reflect.Type) string {
+	return describe(e, root).text
+}
+
+// message is a validation message: its translation key and arguments, and
+// the English text used when no translation exists.
+type message struct {
+	key  string
+	args []any
+	text string
+}
+
+// comparisons holds the English templates of the field comparison rules,
+// keyed by the tag without its "cs" variant.
+var comparisons = map[string][2]string{
+	// tag: {generic, for times}
+	"eqfield":  {"must match %s", "must match %s"},
+	"nefield":  {"must differ from %s", "must differ from %s"},
+	"gtfield":  {"must be greater than %s", "must be after %s"},
+	"gtefield": {"must be greater than or equal to %s", "must be on or after %s"},
+	"ltfield":  {"must be less than %s", "must be before %s"},
+	"ltefield": {"must be less than or equal to %s", "must be on or before %s"},
+}
+
+// describe returns the message for a validation error on a field of a root
+// struct of type root. Keys and arguments are listed on ValidateWithLocale.
+func describe(e validator.FieldError, root reflect.Type) message {
+	tag := e.Tag()
+	switch tag {
+	case "required":
+		return message{"validation.required", nil, "this field is required"}
+	case "email":
+		return message{"validation.email", nil, "must be a valid email"}
+	case "min":
+		return message{"validation.min", []any{e.Param()}, fmt.Sprintf("minimum %s characters", e.Param())}
+	case "max":
+		return message{"validation.max", []any{e.Param()}, fmt.Sprintf("maximum %s characters", e.Param())}
+	case "uuid", "uuid4":
+		return message{"validation.uuid", nil, "must be a valid UUID"}
+	case "numeric":
+		return message{"validation.numeric", nil, "must be a numeric value"}
+	case "url":
+		return message{"validation.url", nil, "must be a valid URL"}
+	case "required_with", "required_with_all", "required_without", "required_without_all":
+		return requiredWithMessage(e, root)
+	case "required_if", "required_unless", "excluded_if", "excluded_unless":
+		return conditionMessage(e, root)
+	case "excluded_with", "excluded_with_all", "excluded_without", "excluded_without_all":
+		return excludedWithMessage(e, root)
+	case structRuleTag:
+		// Struct rules write their own messages, which are not translated.
+		return message{"", nil, e.Param()}
+	}
+
+	base := strings.Replace(tag, "csfield", "field", 1)
+	if tmpl, ok := comparisons[base]; ok {
+		// The cs variants name a field relative to the root struct.
+		var other string
+		if base == tag {
+			other = siblingName(e, root, e.Param())
+		} else {
+			other = referencedName(root, e.Param())
+		}
+		key, text := "validation."+base, tmpl[0]
+		if e.Type() == reflect.TypeOf(time.Time{}) {
+			key, text = key+".time", tmpl[1]
+		}
+		return message{key, []any{other}, fmt.Sprintf(text, other)}
+	}
+
+	return message{"validation.default", []any{tag}, fmt.Sprintf("validation failed: %s", tag)}
+}
+
+// requiredWithMessage renders the required_with(out)(_all) family, whose
+// parameter is a space-separated list of sibling fields.
+func requiredWithMessage(e validator.FieldError, root reflect.Type) message {
+	names, isAre := fieldList(e, root)
+	state := "present"
+	if strings.HasPrefix(e.Tag(), "required_without") {
+		state = "missing"
+	}
+	return message{
+		"validation." + e.Tag(),
+		[]any{names},
+		fmt.Sprintf("required when %s %s %s", names, isAre, state),
+	}
+}
+
+// conditionMessage renders required_if, required_unless, excluded_if and
+// excluded_unless, whose parameter is a list of field/value pairs such as
+// "Type card" or "Type 'credit card' Status active".
+func conditionMessage(e validator.FieldError, root reflect.Type) message {
+	params := splitParams(e.Param())
+	var conds []string
+	for i := 0; i+1 < len(params); i += 2 {
+		conds = append(conds, fmt.Sprintf("%s is '%s'", siblingName(e, root, params[i]), params[i+1]))
+	}
+	cond := strings.Join(conds, " and ")
+
+	lead := "required"
+	if strings.HasPrefix(e.Tag(), "excluded") {
+		lead = "must be empty"
+	}
+	when := "when"
+	if strings.HasSuffix(e.Tag(), "_unless") {
+		when = "unless"
+	}
+	return message{
+		"validation." + e.Tag(),
+		[]any{cond},
+		fmt.Sprintf("%s %s %s", lead, when, cond),
+	}
+}
+
+// excludedWithMessage renders the excluded_with(out)(_all) family.
+func excludedWithMessage(e validator.FieldError, root reflect.Type) message {
+	names, isAre := fieldList(e, root)
+	state := "set"
+	if strings.HasPrefix(e.Tag(), "excluded_without") {
+		state = "missing"
+	}
+	return message{
+		"validation." + e.Tag(),
+		[]any{names},
+		fmt.Sprintf("must be empty when %s %s %s", names, isAre, state),
+	}
+}
+
+// fieldList joins the sibling fields named by a *_with(out) rule: with "or"
+// when any of them counts, with "and" for the _all variants. It also
+// returns the matching verb.
+func fieldList(e validator.FieldError, root reflect.Type) (names, isAre string) {
+	fields := strings.Fields(e.Param())
+	for i, f := range fields {
+		fields[i] = siblingName(e, root, f)
+	}
+	if !strings.HasSuffix(e.Tag(), "_all") {
+		return strings.Join(fields, " or "), "is"
+	}
+	if len(fields) > 1 {
+		return strings.Join(fields, " and "), "are"
+	}
+	return strings.Join(fields, " and "), "is"
+}
+
+// splitParams splits a rule parameter on spaces, keeping single-quoted
+// values together and unquoting them, as the validator does.
+func splitParams(param string) []string {
+	parts := splitParamsRegexp.FindAllString(param, -1)
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(p, "'", "")
+	}
+	return parts
+}
+
+var splitParamsRegexp = regexp.MustCompile(`'[^']*'|\S+`)
+
+// siblingName returns the reported name of field goName declared next to
+// the field that failed.
+func siblingName(e validator.FieldError, root reflect.Type, goName string) string {
+	parent := ""
+	if _, path, ok := strings.Cut(e.StructNamespace(), "."); ok {
+		if i := strings.LastIndexByte(path, '.'); i >= 0 {
+			parent = path[:i+1]
+		}
+	}
+	return referencedName(root, parent+goName)
+}
+
+// referencedName resolves a dotted Go field path from root, e.g.
+// "Period.Start", and returns the reported name of its last field. Paths
+// that cannot be resolved are returned unchanged.
+func referencedName(root reflect.Type, path string) string {
+	field, ok := lookupField(root, path)
+	if !ok {
+		return path
+	}
+	if useJSONNames.Load() {
+		return jsonName(field)
+	}
+	return field.Name
+}
+
+// lookupField returns the struct field at a Go field path from root, as
+// found in a struct namespace without its root segment: "Items[2].Quantity".
+func lookupField(root reflect.Type, path string) (reflect.StructField, bool) {
+	t := root
+	var field reflect.StructField
+	for _, seg := range strings.Split(path, ".") {
+		name, index, _ := strings.Cut(seg, "[")
+		t = indirect(t)
+		if t == nil || t.Kind() != reflect.Struct {
+			return reflect.StructField{}, false
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return reflect.StructField{}, false
+		}
+		field, t = f, f.Type
+		// Each bracketed index steps into a slice, array or map element.
+		for range strings.Count(index, "]") {
+			t = indirect(t)
+			switch t.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				t = t.Elem()
+			default:
+				return reflect.StructField{}, false
+			}
+		}
+	}
+	return field, true
+}
+
+// customMessage returns the message set by the errmsg tag of the field that
+// failed, if any. The tag holds either one message for every rule:
+//
+//	errmsg:"password too weak"
+//
+// or messages per rule, which then cannot contain commas:
+//
+//	errmsg:"min=password too short,required=password is required"
+func customMessage(e validator.FieldError, root reflect.Type) (string, bool) {
+	if e.Tag() == structRuleTag {
+		return "", false
+	}
+	_, path, ok := strings.Cut(e.StructNamespace(), ".")
+	if !ok {
+		return "", false
+	}
+	field, ok := lookupField(root, path)
+	if !ok {
+		return "", false
+	}
+	tag, ok := field.Tag.Lookup("errmsg")
+	if !ok || tag == "" {
+		return "", false
+	}
+
+	rules := make(map[string]string)
+	for _, part := range strings.Split(tag, ",") {
+		rule, msg, ok := strings.Cut(part, "=")
+		if !ok || !isRuleName(rule) {
+			// Not the per-rule form: the whole tag is the message.
+			return tag, true
+		}
+		rules[rule] = msg
+	}
+	msg, ok := rules[e.Tag()]
+	return msg, ok
+}
+
+// isRuleName reports whether s looks like a validator tag such as "min" or
+// "required_with".
+func isRuleName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// indirect dereferences pointer types.
+func indirect(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,267 @@
+package validation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrossFieldMessages(t *testing.T) {
+	type passwordDTO struct {
+		Password string `json:"password"`
+		Confirm  string `json:"confirm_password" validate:"eqfield=Password"`
+		Old      string `json:"old_password" validate:"nefield=Password"`
+	}
+	type rangeDTO struct {
+		Min   int `json:"min"`
+		Max   int `json:"max" validate:"gtfield=Min"`
+		Floor int `json:"floor" validate:"gtefield=Min"`
+		Ceil  int `json:"ceil" validate:"ltfield=Min"`
+		Limit int `json:"limit" validate:"ltefield=Min"`
+	}
+	type periodDTO struct {
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date" validate:"gtefield=StartDate"`
+	}
+	type contactDTO struct {
+		Email string `json:"email"`
+		Phone string `json:"phone"`
+		Name  string `json:"name" validate:"required_with=Email Phone"`
+		Alias string `json:"alias" validate:"required_without=Email"`
+		Nick  string `json:"nick" validate:"required_without_all=Email Phone"`
+	}
+	type inner struct {
+		Code string `json:"code" validate:"eqcsfield=Settings.Code"`
+	}
+	type settings struct {
+		Code string `json:"settings_code"`
+	}
+	type nestedDTO struct {
+		Settings settings `json:"settings"`
+		Inner    inner    `json:"inner"`
+	}
+	type lineDTO struct {
+		Total int `json:"total"`
+		Paid  int `json:"paid" validate:"ltefield=Total"`
+	}
+	type orderDTO struct {
+		Lines []lineDTO `json:"lines" validate:"dive"`
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name  string
+		input any
+		want  map[string]string
+	}{
+		{
+			name:  "eqfield and nefield",
+			input: passwordDTO{Password: "a", Confirm: "b", Old: "a"},
+			want: map[string]string{
+				"confirm_password": "must match password",
+				"old_password":     "must differ from password",
+			},
+		},
+		{
+			name:  "numeric comparisons",
+			input: rangeDTO{Min: 5, Max: 5, Floor: 4, Ceil: 5, Limit: 6},
+			want: map[string]string{
+				"max":   "must be greater than min",
+				"floor": "must be greater than or equal to min",
+				"ceil":  "must be less than min",
+				"limit": "must be less than or equal to min",
+			},
+		},
+		{
+			name:  "time comparison",
+			input: periodDTO{StartDate: now, EndDate: now.Add(-time.Hour)},
+			want:  map[string]string{"end_date": "must be on or after start_date"},
+		},
+		{
+			name:  "required_with and required_without",
+			input: contactDTO{Phone: "555"},
+			want: map[string]string{
+				"name":  "required when email or phone is present",
+				"alias": "required when email is missing",
+			},
+		},
+		{
+			name:  "required_without_all",
+			input: contactDTO{Name: "x", Alias: "y"},
+			want:  map[string]string{"nick": "required when email and phone are missing"},
+		},
+		{
+			name:  "cs field resolved from root",
+			input: nestedDTO{Settings: settings{Code: "a"}, Inner: inner{Code: "b"}},
+			want:  map[string]string{"inner.code": "must match settings_code"},
+		},
+		{
+			name:  "sibling inside slice element",
+			input: orderDTO{Lines: []lineDTO{{Total: 1, Paid: 1}, {Total: 1, Paid: 2}}},
+			want:  map[string]string{"lines[1].paid": "must be less than or equal to total"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(tt.input)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs)
+			}
+			for _, e := range errs {
+				if e.Message != tt.want[e.Field] {
+					t.Errorf("%s: message = %q, want %q", e.Field, e.Message, tt.want[e.Field])
+				}
+			}
+		})
+	}
+}
+
+func TestCustomMessages(t *testing.T) {
+	type item struct {
+		SKU string `json:"sku" validate:"required" errmsg:"every line needs a SKU"`
+	}
+	type dto struct {
+		Password string   `json:"password" validate:"required,min=8" errmsg:"min=password too short,required=password is required"`
+		Pin      string   `json:"pin" validate:"required,numeric" errmsg:"pin must be digits, like 1234"`
+		Code     string   `json:"code" validate:"required,min=3" errmsg:"min=code too short"`
+		Items    []item   `json:"items" validate:"dive"`
+		Tags     []string `json:"tags" validate:"dive,min=2" errmsg:"tags need two letters"`
+	}
+
+	tests := []struct {
+		name  string
+		input dto
+		want  map[string]string
+	}{
+		{
+			name:  "per rule: required",
+			input: dto{Pin: "1", Code: "abc"},
+			want:  map[string]string{"password": "password is required"},
+		},
+		{
+			name:  "per rule: min",
+			input: dto{Password: "short", Pin: "1", Code: "abc"},
+			want:  map[string]string{"password": "password too short"},
+		},
+		{
+			name:  "single message covers every rule",
+			input: dto{Password: "long enough", Pin: "12a", Code: "abc"},
+			want:  map[string]string{"pin": "pin must be digits, like 1234"},
+		},
+		{
+			name:  "rule without a message falls back",
+			input: dto{Password: "long enough", Pin: "1"},
+			want:  map[string]string{"code": "this field is required"},
+		},
+		{
+			name:  "nested and dive fields",
+			input: dto{Password: "long enough", Pin: "1", Code: "abc", Items: []item{{}}, Tags: []string{"x"}},
+			want: map[string]string{
+				"items[0].sku": "every line needs a SKU",
+				"tags[0]":      "tags need two letters",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(tt.input)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs)
+			}
+			for _, e := range errs {
+				if e.Message != tt.want[e.Field] {
+					t.Errorf("%s: message = %q, want %q", e.Field, e.Message, tt.want[e.Field])
+				}
+			}
+		})
+	}
+}
+
+func TestConditionalMessages(t *testing.T) {
+	type paymentDTO struct {
+		Type     string `json:"type"`
+		Status   string `json:"status"`
+		Card     string `json:"card_number" validate:"required_if=Type card"`
+		Holder   string `json:"holder" validate:"required_if=Type 'credit card' Status active"`
+		IBAN     string `json:"iban" validate:"required_unless=Type card"`
+		Cash     string `json:"cash" validate:"excluded_with=Card"`
+		Coupon   string `json:"coupon" validate:"excluded_with_all=Type Status"`
+		Receipt  string `json:"receipt" validate:"excluded_without=Status"`
+		Note     string `json:"note" validate:"excluded_if=Status closed"`
+		Override string `json:"override" validate:"excluded_unless=Status admin"`
+	}
+
+	tests := []struct {
+		name  string
+		input paymentDTO
+		field string
+		want  string
+	}{
+		{
+			name:  "required_if",
+			input: paymentDTO{Type: "card", IBAN: "x"},
+			field: "card_number",
+			want:  "required when type is 'card'",
+		},
+		{
+			name:  "required_if with quoted value and two conditions",
+			input: paymentDTO{Type: "credit card", Status: "active", IBAN: "x"},
+			field: "holder",
+			want:  "required when type is 'credit card' and status is 'active'",
+		},
+		{
+			name:  "required_unless",
+			input: paymentDTO{Type: "bank"},
+			field: "iban",
+			want:  "required unless type is 'card'",
+		},
+		{
+			name:  "excluded_with",
+			input: paymentDTO{Type: "card", Card: "4111", Cash: "10"},
+			field: "cash",
+			want:  "must be empty when card_number is set",
+		},
+		{
+			name:  "excluded_with_all",
+			input: paymentDTO{Type: "bank", Status: "open", IBAN: "x", Coupon: "c"},
+			field: "coupon",
+			want:  "must be empty when type and status are set",
+		},
+		{
+			name:  "excluded_without",
+			input: paymentDTO{IBAN: "x", Receipt: "r"},
+			field: "receipt",
+			want:  "must be empty when status is missing",
+		},
+		{
+			name:  "excluded_if",
+			input: paymentDTO{Status: "closed", IBAN: "x", Note: "n", Override: "o"},
+			field: "note",
+			want:  "must be empty when status is 'closed'",
+		},
+		{
+			name:  "excluded_unless",
+			input: paymentDTO{Status: "open", IBAN: "x", Override: "o"},
+			field: "override",
+			want:  "must be empty unless status is 'admin'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *FieldError
+			for _, e := range Validate(tt.input) {
+				if e.Field == tt.field {
+					got = &e
+				}
+			}
+			if got == nil {
+				t.Fatalf("expected an error on %q, got %v", tt.field, Validate(tt.input))
+			}
+			if got.Message != tt.want {
+				t.Errorf("message = %q, want %q", got.Message, tt.want)
+			}
+		})
+	}
+}
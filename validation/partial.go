@@ -0,0 +1,142 @@
+package validation
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/slice-soft/ss-keel-core/contracts"
+)
+
+// ValidatePartial validates s for PATCH semantics: nil pointer fields count
+// as not provided, so none of their rules run, while every other field is
+// validated as usual. A provided field is checked for `required` by the
+// value it points to, so {"name": ""} fails `required` on Name *string.
+func ValidatePartial(s any) []FieldError {
+	return validatePartial(s, humanMessage)
+}
+
+// ValidatePartialWithLocale is ValidatePartial with messages translated as
+// by ValidateWithLocale.
+func ValidatePartialWithLocale(s any, locale string, t contracts.Translator) []FieldError {
+	if t == nil {
+		return ValidatePartial(s)
+	}
+	return validatePartial(s, func(e validator.FieldError, root reflect.Type) string {
+		return translate(describe(e, root), locale, t)
+	})
+}
+
+func validatePartial(s any, message func(validator.FieldError, reflect.Type) string) []FieldError {
+	root := reflect.TypeOf(s)
+	var empty []validator.FieldError
+	if v := reflect.Indirect(reflect.ValueOf(s)); v.Kind() == reflect.Struct {
+		empty = emptyRequired(v, v.Type().Name(), v.Type().Name())
+	}
+	used := make([]bool, len(empty))
+	var kept validator.ValidationErrors
+	if err := validate.Struct(s); err != nil {
+		for _, e := range err.(validator.ValidationErrors) {
+			// A required error replaces the rules that ran on the empty value.
+			i := slices.IndexFunc(empty, func(req validator.FieldError) bool {
+				return req.StructNamespace() == e.StructNamespace()
+			})
+			switch {
+			case i >= 0 && !used[i]:
+				kept = append(kept, empty[i])
+				used[i] = true
+			case i < 0 && !absent(e, root):
+				kept = append(kept, e)
+			}
+		}
+	}
+	for i, req := range empty {
+		if !used[i] {
+			kept = append(kept, req)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return fieldErrors(kept, root, message)
+}
+
+// emptyRequired returns a required error for every non-nil pointer field of
+// the struct v, or of its nested structs, that has `required` among its
+// rules and points to an empty value; the validator only checks that such a
+// pointer is set. ns and structNs are v's own namespaces.
+func emptyRequired(v reflect.Value, ns, structNs string) []validator.FieldError {
+	var errs []validator.FieldError
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("validate")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		fns, fsns := ns+"."+jsonName(f), structNs+"."+f.Name
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			errs = append(errs, emptyRequired(fv, fns, fsns)...)
+			continue
+		}
+		if f.Type.Kind() != reflect.Pointer || !fv.IsZero() || !hasRequired(tag) {
+			continue
+		}
+		if verrs, ok := validate.Var(fv.Interface(), "required").(validator.ValidationErrors); ok {
+			errs = append(errs, namespacedError{verrs[0], fns, fsns, jsonName(f), f.Name})
+		}
+	}
+	return errs
+}
+
+// hasRequired reports whether the validate tag has a plain required rule
+// for the field itself, rather than for its elements after dive.
+func hasRequired(tag string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		switch rule {
+		case "required":
+			return true
+		case "dive":
+			return false
+		}
+	}
+	return false
+}
+
+// namespacedError is a validator.FieldError from Var placed at a struct
+// field, so it is named and described like the errors of Struct.
+type namespacedError struct {
+	validator.FieldError
+	ns, structNs, field, structField string
+}
+
+func (e namespacedError) Namespace() string       { return e.ns }
+func (e namespacedError) StructNamespace() string { return e.structNs }
+func (e namespacedError) Field() string           { return e.field }
+func (e namespacedError) StructField() string     { return e.structField }
+
+// absent reports whether e is about a pointer field left nil. Errors from
+// struct rules are kept: the rule decides what a missing field means.
+func absent(e validator.FieldError, root reflect.Type) bool {
+	if e.Tag() == structRuleTag {
+		return false
+	}
+	_, path, ok := strings.Cut(e.StructNamespace(), ".")
+	if !ok || strings.HasSuffix(path, "]") {
+		return false
+	}
+	field, ok := lookupField(root, path)
+	if !ok || field.Type.Kind() != reflect.Pointer {
+		return false
+	}
+	v := reflect.ValueOf(e.Value())
+	return !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil())
+}
@@ -0,0 +1,94 @@
+package validation
+
+import "testing"
+
+func TestValidatePartial(t *testing.T) {
+	type address struct {
+		City *string `json:"city" validate:"required,min=2"`
+	}
+	type patchUserDTO struct {
+		Name    *string  `json:"name" validate:"required,min=2"`
+		Email   *string  `json:"email" validate:"required,email"`
+		Website *string  `json:"website" validate:"url"`
+		Age     int      `json:"age" validate:"gte=0"`
+		Address *address `json:"address" validate:"required"`
+	}
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		input patchUserDTO
+		want  []FieldError
+	}{
+		{
+			name:  "nothing provided",
+			input: patchUserDTO{},
+		},
+		{
+			name:  "absent, invalid and valid fields",
+			input: patchUserDTO{Email: str("not-an-email"), Website: str("https://keel.dev")},
+			want:  []FieldError{{Field: "email", Message: "must be a valid email"}},
+		},
+		{
+			name:  "present but empty string fails required",
+			input: patchUserDTO{Name: str("")},
+			want:  []FieldError{{Field: "name", Message: "this field is required"}},
+		},
+		{
+			name:  "present but empty fields keep field order",
+			input: patchUserDTO{Name: str(""), Email: str(""), Website: str("nope")},
+			want: []FieldError{
+				{Field: "name", Message: "this field is required"},
+				{Field: "email", Message: "this field is required"},
+				{Field: "website", Message: "must be a valid URL"},
+			},
+		},
+		{
+			name:  "non-pointer fields keep their rules",
+			input: patchUserDTO{Age: -1},
+			want:  []FieldError{{Field: "age", Message: "validation failed: gte"}},
+		},
+		{
+			name:  "nested struct fields are partial too",
+			input: patchUserDTO{Address: &address{}},
+		},
+		{
+			name:  "nested present field is checked",
+			input: patchUserDTO{Address: &address{City: str("x")}},
+			want:  []FieldError{{Field: "address.city", Message: "minimum 2 characters"}},
+		},
+		{
+			name:  "nested present but empty field fails required",
+			input: patchUserDTO{Address: &address{City: str("")}},
+			want:  []FieldError{{Field: "address.city", Message: "this field is required"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidatePartial(tt.input)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %v, want %v", errs, tt.want)
+			}
+			for i := range tt.want {
+				if errs[i] != tt.want[i] {
+					t.Errorf("errs[%d] = %+v, want %+v", i, errs[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	type renameDTO struct {
+		Name *string `json:"name" validate:"required"`
+	}
+	if errs := ValidatePartial(renameDTO{Name: str("")}); len(errs) != 1 || errs[0] != (FieldError{Field: "name", Message: "this field is required"}) {
+		t.Errorf(`ValidatePartial({"name":""}) = %v, want name required`, errs)
+	}
+	if errs := ValidatePartial(&renameDTO{Name: str("Ana")}); errs != nil {
+		t.Errorf("ValidatePartial of a valid pointer = %v", errs)
+	}
+
+	if errs := Validate(patchUserDTO{}); len(errs) != 4 {
+		t.Errorf("Validate should still check nil pointer fields, got %v", errs)
+	}
+}
@@ -0,0 +1,27 @@
+package validation
+
+import "github.com/go-playground/validator/v10"
+
+// structRuleTag marks errors reported by struct rules; the message travels
+// as the error parameter.
+const structRuleTag = "struct_rule"
+
+// RegisterStructRule adds an invariant over a whole struct of type T, such
+// as "either email or phone must be set". The rule runs whenever a T is
+// validated, including as a nested field, and its errors are merged with
+// the tag-based ones. Field is the path the rule wants to report, relative
+// to T; it is prefixed with T's own path when T is nested. T must be a
+// struct type. Register rules at startup: registration is not safe for
+// concurrent use with validation.
+func RegisterStructRule[T any](fn func(t T) []FieldError) {
+	var zero T
+	validate.RegisterStructValidation(func(sl validator.StructLevel) {
+		v, ok := sl.Current().Interface().(T)
+		if !ok {
+			return
+		}
+		for _, fe := range fn(v) {
+			sl.ReportError(nil, fe.Field, fe.Field, structRuleTag, fe.Message)
+		}
+	}, zero)
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"testing"
+	"time"
+)
+
+type contactRuleDTO struct {
+	Email string      `json:"email"`
+	Phone string      `json:"phone"`
+	Name  string      `json:"name" validate:"required"`
+	Stay  stayRuleDTO `json:"stay"`
+}
+
+type stayRuleDTO struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func init() {
+	RegisterStructRule(func(c contactRuleDTO) []FieldError {
+		if c.Email != "" || c.Phone != "" {
+			return nil
+		}
+		return []FieldError{
+			{Field: "email", Message: "email or phone is required"},
+			{Field: "phone", Message: "email or phone is required"},
+		}
+	})
+	RegisterStructRule(func(s stayRuleDTO) []FieldError {
+		if !s.Start.IsZero() && !s.End.IsZero() && !s.End.After(s.Start) {
+			return []FieldError{{Field: "end", Message: "must be after start"}}
+		}
+		return nil
+	})
+}
+
+func TestRegisterStructRule(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		input contactRuleDTO
+		want  []FieldError
+	}{
+		{
+			name:  "passes",
+			input: contactRuleDTO{Email: "a@b.co", Name: "Ana", Stay: stayRuleDTO{Start: now, End: now.Add(time.Hour)}},
+		},
+		{
+			name:  "rule errors merge with tag errors",
+			input: contactRuleDTO{},
+			want: []FieldError{
+				{Field: "name", Message: "this field is required"},
+				{Field: "email", Message: "email or phone is required"},
+				{Field: "phone", Message: "email or phone is required"},
+			},
+		},
+		{
+			name:  "nested rule reports its path",
+			input: contactRuleDTO{Phone: "555", Name: "Ana", Stay: stayRuleDTO{Start: now, End: now}},
+			want:  []FieldError{{Field: "stay.end", Message: "must be after start"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(tt.input)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %v, want %v", errs, tt.want)
+			}
+			for i := range tt.want {
+				if errs[i] != tt.want[i] {
+					t.Errorf("errs[%d] = %+v, want %+v", i, errs[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStructRuleMessagesAreNotTranslated(t *testing.T) {
+	errs := ValidateWithLocale(contactRuleDTO{Name: "Ana"}, "es", mockTranslator{})
+	if len(errs) != 2 || errs[0].Message != "email or phone is required" {
+		t.Errorf("got %v", errs)
+	}
+}
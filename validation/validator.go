@@ -1,54 +1,181 @@
 package validation
 
 import (
-	"fmt"
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+// useJSONNames selects json tag names over Go field names in FieldError.
+var useJSONNames atomic.Bool
+
+func init() {
+	useJSONNames.Store(true)
+}
+
+// newValidator returns a validator that reports fields by their json name.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonName)
+	return v
+}
+
+// jsonName returns the name a field has in JSON: the first segment of its
+// json tag, or the Go field name when the tag is missing, empty or "-".
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+// UseJSONNames controls whether FieldError.Field is built from the json tag
+// names of the failing field and its parents (the default) or from Go field
+// names.
+//
+// Migration: earlier releases always reported Go names such as "Email".
+// Clients matching on those should switch to the json names they send, or
+// call UseJSONNames(false) at startup to keep the old behavior.
+func UseJSONNames(enabled bool) {
+	useJSONNames.Store(enabled)
+}
 
 // FieldError represents a validation error on a specific field.
+// Field is the path from the validated struct: nested fields are joined with
+// dots and slice or map elements are indexed, e.g. "items[2].quantity" or
+// "attrs[color]".
 type FieldError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
 }
 
 // Validate validates a struct with `validate` tags.
-// Returns nil if there are no errors.
+// Returns nil if there are no errors. A field's `errmsg` tag replaces the
+// generated message, either for every rule or per rule:
+//
+//	Password string `validate:"required,min=8" errmsg:"min=password too short"`
 func Validate(s any) []FieldError {
-	err := validate.Struct(s)
+	return validateStruct(s, humanMessage)
+}
+
+// validateStruct validates s and renders each failure with message, which
+// also receives the type of s to resolve fields referenced by the rule.
+func validateStruct(s any, message func(validator.FieldError, reflect.Type) string) []FieldError {
+	return fieldErrors(validate.Struct(s), reflect.TypeOf(s), message)
+}
+
+// fieldErrors converts a validator error for a root struct of type root.
+// A field's errmsg tag takes precedence over message; see customMessage.
+func fieldErrors(err error, root reflect.Type, message func(validator.FieldError, reflect.Type) string) []FieldError {
 	if err == nil {
 		return nil
 	}
 	var errs []FieldError
 	for _, e := range err.(validator.ValidationErrors) {
-		errs = append(errs, FieldError{
-			Field:   e.Field(),
-			Message: humanMessage(e),
-		})
+		msg, ok := customMessage(e, root)
+		if !ok {
+			msg = message(e, root)
+		}
+		errs = append(errs, FieldError{Field: fieldName(e), Message: msg})
+	}
+	return errs
+}
+
+// ValidateFirst validates s like Validate but stops at the first top-level
+// field that fails, returning its first error, or nil when s is valid. It
+// answers "is this payload valid?" cheaply when many fields fail; for
+// payloads that are usually valid, Validate does less work. Struct rules
+// registered for s's type run with every field checked.
+func ValidateFirst(s any) *FieldError {
+	root := reflect.TypeOf(s)
+	t := indirect(root)
+	if t == nil || t.Kind() != reflect.Struct {
+		return first(Validate(s))
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		err := validate.StructFiltered(s, onlyField(f.Name))
+		if fe := first(fieldErrors(err, root, humanMessage)); fe != nil {
+			return fe
+		}
+	}
+	return nil
+}
+
+// onlyField returns a filter that skips every field outside the top-level
+// field name. Namespaces start with the root type name.
+func onlyField(name string) validator.FilterFunc {
+	return func(ns []byte) bool {
+		_, path, _ := bytes.Cut(ns, []byte("."))
+		top := path
+		if i := bytes.IndexAny(path, ".["); i >= 0 {
+			top = path[:i]
+		}
+		return string(top) != name
+	}
+}
+
+// first returns a pointer to the first error, or nil.
+func first(errs []FieldError) *FieldError {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &errs[0]
+}
+
+// Var validates a single value against a validator tag such as "uuid4" or
+// "required,min=3". It returns nil when the value is valid, otherwise the
+// first failure with an empty Field for the caller to fill in.
+func Var(value any, tag string) *FieldError {
+	err := validate.Var(value, tag)
+	if err == nil {
+		return nil
+	}
+	e := err.(validator.ValidationErrors)[0]
+	return &FieldError{Message: humanMessage(e, nil)}
+}
+
+// Map validates values against per-key rules, e.g. a path parameter and a
+// couple of query values. A key missing from values is validated as nil.
+// Errors are returned in key order with Field set to the key.
+func Map(values map[string]any, rules map[string]string) []FieldError {
+	keys := make([]string, 0, len(rules))
+	for k := range rules {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []FieldError
+	for _, k := range keys {
+		if fe := Var(values[k], rules[k]); fe != nil {
+			fe.Field = k
+			errs = append(errs, *fe)
+		}
 	}
 	return errs
 }
 
-// humanMessage returns a user-friendly error message for a validation error.
-func humanMessage(e validator.FieldError) string {
-	switch e.Tag() {
-	case "required":
-		return "this field is required"
-	case "email":
-		return "must be a valid email"
-	case "min":
-		return fmt.Sprintf("minimum %s characters", e.Param())
-	case "max":
-		return fmt.Sprintf("maximum %s characters", e.Param())
-	case "uuid", "uuid4":
-		return "must be a valid UUID"
-	case "numeric":
-		return "must be a numeric value"
-	case "url":
-		return "must be a valid URL"
-	default:
-		return fmt.Sprintf("validation failed: %s", e.Tag())
+// fieldName returns the reported path of the field that failed, relative
+// to the validated struct. The validator already renders each segment with
+// the registered tag name and brackets for dive elements, so only the root
+// type name has to go.
+func fieldName(e validator.FieldError) string {
+	ns, field := e.Namespace(), e.Field()
+	if !useJSONNames.Load() {
+		ns, field = e.StructNamespace(), e.StructField()
+	}
+	if _, path, ok := strings.Cut(ns, "."); ok {
+		return path
 	}
+	return field
 }
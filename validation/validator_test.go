@@ -6,14 +6,14 @@ import (
 
 func TestValidate(t *testing.T) {
 	type loginDTO struct {
-		Email    string `validate:"required,email"`
-		Password string `validate:"required,min=8"`
+		Email    string `json:"email" validate:"required,email"`
+		Password string `json:"password" validate:"required,min=8"`
 	}
 
 	type profileDTO struct {
-		Name    string `validate:"required,min=2,max=50"`
-		Website string `validate:"omitempty,url"`
-		UserID  string `validate:"required,uuid4"`
+		Name    string `json:"name" validate:"required,min=2,max=50"`
+		Website string `json:"website,omitempty" validate:"omitempty,url"`
+		UserID  string `json:"user_id" validate:"required,uuid4"`
 	}
 
 	tests := []struct {
@@ -39,14 +39,14 @@ func TestValidate(t *testing.T) {
 			input:     loginDTO{Email: "notanemail", Password: "secret123"},
 			wantNil:   false,
 			wantCount: 1,
-			wantField: "Email",
+			wantField: "email",
 		},
 		{
 			name:      "password too short",
 			input:     loginDTO{Email: "juan@test.com", Password: "short"},
 			wantNil:   false,
 			wantCount: 1,
-			wantField: "Password",
+			wantField: "password",
 		},
 		{
 			name:    "valid profile",
@@ -58,14 +58,14 @@ func TestValidate(t *testing.T) {
 			input:     profileDTO{Name: "Juan", UserID: "not-a-uuid"},
 			wantNil:   false,
 			wantCount: 1,
-			wantField: "UserID",
+			wantField: "user_id",
 		},
 		{
 			name:      "name too short",
 			input:     profileDTO{Name: "J", UserID: "550e8400-e29b-41d4-a716-446655440000"},
 			wantNil:   false,
 			wantCount: 1,
-			wantField: "Name",
+			wantField: "name",
 		},
 	}
 
@@ -105,7 +105,7 @@ func TestHumanMessage(t *testing.T) {
 		Name string `validate:"required"`
 	}
 	type emailDTO struct {
-		Email string `validate:"required,email"`
+		Email string `json:"email" validate:"required,email"`
 	}
 	type minDTO struct {
 		Name string `validate:"required,min=8"`
@@ -138,7 +138,7 @@ func TestHumanMessage(t *testing.T) {
 		{
 			name:        "email message",
 			input:       emailDTO{Email: "notanemail"},
-			wantField:   "Email",
+			wantField:   "email",
 			wantMessage: "must be a valid email",
 		},
 		{
@@ -196,3 +196,293 @@ func TestHumanMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestFieldNames(t *testing.T) {
+	type dto struct {
+		Email    string `json:"email" validate:"required"`
+		Nickname string `json:"nick,omitempty" validate:"required"`
+		Internal string `json:"-" validate:"required"`
+		Options  string `json:",omitempty" validate:"required"`
+		Plain    string `validate:"required"`
+	}
+
+	tests := []struct {
+		name     string
+		useJSON  bool
+		wantName []string
+	}{
+		{"json names", true, []string{"email", "nick", "Internal", "Options", "Plain"}},
+		{"go names", false, []string{"Email", "Nickname", "Internal", "Options", "Plain"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			UseJSONNames(tt.useJSON)
+			defer UseJSONNames(true)
+
+			errs := Validate(dto{})
+			if len(errs) != len(tt.wantName) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.wantName), errs)
+			}
+			for i, e := range errs {
+				if e.Field != tt.wantName[i] {
+					t.Errorf("errs[%d].Field = %q, want %q", i, e.Field, tt.wantName[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNestedFieldPaths(t *testing.T) {
+	type geo struct {
+		City string `json:"city" validate:"required"`
+	}
+	type address struct {
+		City    string `json:"city" validate:"required"`
+		Country geo    `json:"country"`
+	}
+	type orderDTO struct {
+		City     string  `json:"city" validate:"required"`
+		Shipping address `json:"shipping"`
+		Billing  address `json:"billing_address"`
+	}
+
+	errs := Validate(orderDTO{})
+
+	want := []string{
+		"city",
+		"shipping.city",
+		"shipping.country.city",
+		"billing_address.city",
+		"billing_address.country.city",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, e := range errs {
+		if e.Field != want[i] {
+			t.Errorf("errs[%d].Field = %q, want %q", i, e.Field, want[i])
+		}
+	}
+
+	UseJSONNames(false)
+	defer UseJSONNames(true)
+	if errs := Validate(orderDTO{}); errs[2].Field != "Shipping.Country.City" {
+		t.Errorf("go-named path = %q, want %q", errs[2].Field, "Shipping.Country.City")
+	}
+}
+
+func TestIndexedFieldPaths(t *testing.T) {
+	type itemDTO struct {
+		SKU      string `json:"sku" validate:"required"`
+		Quantity int    `json:"quantity" validate:"gte=1"`
+	}
+	type cartDTO struct {
+		Items []itemDTO           `json:"items" validate:"dive"`
+		Attrs map[string]string   `json:"attrs" validate:"dive,required"`
+		Lines map[string]*itemDTO `json:"lines" validate:"dive"`
+	}
+
+	tests := []struct {
+		name  string
+		input cartDTO
+		want  []string
+	}{
+		{
+			name: "middle slice element",
+			input: cartDTO{Items: []itemDTO{
+				{SKU: "a", Quantity: 1},
+				{SKU: "b", Quantity: 0},
+				{SKU: "c", Quantity: 3},
+			}},
+			want: []string{"items[1].quantity"},
+		},
+		{
+			name:  "map value",
+			input: cartDTO{Attrs: map[string]string{"color": ""}},
+			want:  []string{"attrs[color]"},
+		},
+		{
+			name:  "struct in map",
+			input: cartDTO{Lines: map[string]*itemDTO{"first": {Quantity: 1}}},
+			want:  []string{"lines[first].sku"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate(tt.input)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs)
+			}
+			for i, e := range errs {
+				if e.Field != tt.want[i] {
+					t.Errorf("errs[%d].Field = %q, want %q", i, e.Field, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVar(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		tag     string
+		wantMsg string
+	}{
+		{"valid uuid4", "550e8400-e29b-41d4-a716-446655440000", "uuid4", ""},
+		{"invalid uuid4", "not-a-uuid", "uuid4", "must be a valid UUID"},
+		{"first failing rule", "", "required,email", "this field is required"},
+		{"parameterized rule", "abc", "min=5", "minimum 5 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fe := Var(tt.value, tt.tag)
+			if tt.wantMsg == "" {
+				if fe != nil {
+					t.Fatalf("expected nil, got %+v", fe)
+				}
+				return
+			}
+			if fe == nil {
+				t.Fatal("expected an error")
+			}
+			if fe.Field != "" || fe.Message != tt.wantMsg {
+				t.Errorf("got %+v, want message %q and empty field", fe, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	errs := Map(
+		map[string]any{
+			"id":    "not-a-uuid",
+			"email": "juan@test.com",
+			"page":  "x1",
+		},
+		map[string]string{
+			"id":    "uuid4",
+			"email": "required,email",
+			"page":  "numeric",
+			"sort":  "required",
+		},
+	)
+
+	want := []FieldError{
+		{Field: "id", Message: "must be a valid UUID"},
+		{Field: "page", Message: "must be a numeric value"},
+		{Field: "sort", Message: "this field is required"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %v, want %v", errs, want)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("errs[%d] = %+v, want %+v", i, errs[i], want[i])
+		}
+	}
+
+	if errs := Map(map[string]any{"id": "550e8400-e29b-41d4-a716-446655440000"}, map[string]string{"id": "uuid4"}); errs != nil {
+		t.Errorf("expected nil for valid values, got %v", errs)
+	}
+}
+
+func TestValidateFirst(t *testing.T) {
+	type address struct {
+		City string `json:"city" validate:"required"`
+	}
+	type dto struct {
+		Name    string   `json:"name" validate:"required"`
+		Address address  `json:"address"`
+		Tags    []string `json:"tags" validate:"dive,min=2"`
+		Confirm string   `json:"confirm" validate:"eqfield=Name"`
+		secret  string
+	}
+
+	tests := []struct {
+		name  string
+		input any
+		want  *FieldError
+	}{
+		{
+			name:  "valid",
+			input: dto{Name: "Ana", Address: address{City: "Lima"}, Tags: []string{"go"}, Confirm: "Ana"},
+		},
+		{
+			name:  "first of many",
+			input: dto{Tags: []string{"x"}, Confirm: "y"},
+			want:  &FieldError{Field: "name", Message: "this field is required"},
+		},
+		{
+			name:  "nested field",
+			input: &dto{Name: "Ana", Confirm: "Ana"},
+			want:  &FieldError{Field: "address.city", Message: "this field is required"},
+		},
+		{
+			name:  "slice element",
+			input: dto{Name: "Ana", Address: address{City: "Lima"}, Tags: []string{"go", "x"}, Confirm: "Ana"},
+			want:  &FieldError{Field: "tags[1]", Message: "minimum 2 characters"},
+		},
+		{
+			name:  "cross-field sees other fields",
+			input: dto{Name: "Ana", Address: address{City: "Lima"}, Confirm: "Bob"},
+			want:  &FieldError{Field: "confirm", Message: "must match name"},
+		},
+		{
+			name:  "struct rule",
+			input: contactRuleDTO{Name: "Ana"},
+			want:  &FieldError{Field: "email", Message: "email or phone is required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateFirst(tt.input)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("expected nil, got %+v", got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// wideDTO has many independent rules so the two validation modes differ.
+type wideDTO struct {
+	F01 string `validate:"required,email"`
+	F02 string `validate:"required,email"`
+	F03 string `validate:"required,email"`
+	F04 string `validate:"required,email"`
+	F05 string `validate:"required,email"`
+	F06 string `validate:"required,email"`
+	F07 string `validate:"required,email"`
+	F08 string `validate:"required,email"`
+	F09 string `validate:"required,email"`
+	F10 string `validate:"required,email"`
+	F11 string `validate:"required,uuid4"`
+	F12 string `validate:"required,uuid4"`
+	F13 string `validate:"required,uuid4"`
+	F14 string `validate:"required,uuid4"`
+	F15 string `validate:"required,uuid4"`
+	F16 string `validate:"required,url"`
+	F17 string `validate:"required,url"`
+	F18 string `validate:"required,url"`
+	F19 string `validate:"required,url"`
+	F20 string `validate:"required,url"`
+}
+
+func BenchmarkValidateManyFailures(b *testing.B) {
+	for b.Loop() {
+		Validate(wideDTO{})
+	}
+}
+
+func BenchmarkValidateFirstManyFailures(b *testing.B) {
+	for b.Loop() {
+		ValidateFirst(wideDTO{})
+	}
+}